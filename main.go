@@ -1,12 +1,18 @@
 package main
 
 import (
+	"cij_api/src/cli"
 	"cij_api/src/config"
 	"cij_api/src/database"
-	"cij_api/src/model"
-	vacancy "cij_api/src/model/vacancy"
+	"cij_api/src/geocoding"
+	"cij_api/src/jobs"
+	"cij_api/src/repo"
 	"cij_api/src/router"
+	"cij_api/src/service"
+	"cij_api/src/storage"
 	"log"
+	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -28,76 +34,30 @@ func main() {
 
 	db := database.ConnectionDB(&loadConfig)
 
-	migrateDb(db)
+	database.Migrate(db)
 
-	startServer(db)
-}
-
-func migrateDb(db *gorm.DB) {
-	db.AutoMigrate(&model.User{})
-	db.AutoMigrate(&model.Address{})
-	db.AutoMigrate(&model.Person{})
-	db.AutoMigrate(&model.Disability{})
-	db.AutoMigrate(&model.PersonDisability{})
-	db.AutoMigrate(&model.Company{})
-	db.AutoMigrate(&model.News{})
-	db.AutoMigrate(&model.Role{})
-	db.AutoMigrate(&model.Activity{})
-
-	db.AutoMigrate(&vacancy.Vacancy{})
-	db.AutoMigrate(&vacancy.VacancyDisability{})
-	db.AutoMigrate(&vacancy.VacancySkill{})
-	db.AutoMigrate(&vacancy.VacancyRequirement{})
-	db.AutoMigrate(&vacancy.VacancyResponsability{})
-	db.AutoMigrate(&vacancy.VacancyApply{})
-
-	createDefaultRoles(db)
-	createDefaultDisabilities(db)
-}
+	fileStorage, err := storage.NewFileStorage(&loadConfig)
+	if err != nil {
+		log.Fatal("cannot create file storage ", err)
+	}
 
-func createDefaultRoles(db *gorm.DB) {
-	db.Exec("INSERT IGNORE INTO roles (name) VALUES ('person')")
-	db.Exec("INSERT IGNORE INTO roles (name) VALUES ('company')")
-	db.Exec("INSERT IGNORE INTO roles (name) VALUES ('admin')")
-}
+	if len(os.Args) > 1 {
+		if err := cli.Run(db, fileStorage, os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
 
-func createDefaultDisabilities(db *gorm.DB) error {
-	disabilities := []model.Disability{
-		{Category: "Visual", Description: "Baixa visão, dificuldade em enxergar a longa distância", Rate: 30},
-		{Category: "Visual", Description: "Cegueira completa", Rate: 80},
-		{Category: "Visual", Description: "Dificuldade em diferenciar cores", Rate: 20},
-		{Category: "Hearing", Description: "Perda auditiva parcial em um ouvido", Rate: 25},
-		{Category: "Hearing", Description: "Surdez total", Rate: 90},
-		{Category: "Hearing", Description: "Sensibilidade a sons altos", Rate: 15},
-		{Category: "Physical", Description: "Paralisia parcial nos membros inferiores", Rate: 60},
-		{Category: "Physical", Description: "Dificuldade de mobilidade devido a esclerose", Rate: 75},
-		{Category: "Physical", Description: "Limitação no movimento das articulações", Rate: 40},
-		{Category: "Intellectual", Description: "Transtorno do espectro autista", Rate: 50},
-		{Category: "Intellectual", Description: "Déficit de atenção e hiperatividade", Rate: 30},
-		{Category: "Intellectual", Description: "Deficiência intelectual leve", Rate: 45},
-		{Category: "Psychosocial", Description: "Transtorno de ansiedade", Rate: 25},
-		{Category: "Psychosocial", Description: "Depressão grave", Rate: 70},
-		{Category: "Psychosocial", Description: "Transtorno bipolar", Rate: 65},
-		{Category: "Psychosocial", Description: "Transtorno de estresse pós-traumático (TEPT)", Rate: 60},
+		return
 	}
 
-	for _, disability := range disabilities {
-		var existing model.Disability
-		if err := db.Where("category = ? AND description = ?", disability.Category, disability.Description).First(&existing).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				if err := db.Create(&disability).Error; err != nil {
-					return err
-				}
-			} else {
-				return err
-			}
-		}
+	geocoder, err := geocoding.NewGeocoder(&loadConfig)
+	if err != nil {
+		log.Fatal("cannot create geocoder ", err)
 	}
 
-	return nil
+	startServer(db, fileStorage, geocoder, &loadConfig)
 }
 
-func startServer(db *gorm.DB) {
+func startServer(db *gorm.DB, fileStorage storage.FileStorage, geocoder geocoding.Geocoder, loadConfig *config.Config) {
 	app := fiber.New()
 
 	app.Use(cors.New())
@@ -107,7 +67,24 @@ func startServer(db *gorm.DB) {
 		AllowHeaders: "Origin, Content-Type, Accept, Access-Control-Allow-Origin",
 	}))
 
-	routes := router.NewRouter(app, db)
+	routes := router.NewRouter(app, db, fileStorage, geocoder, loadConfig)
+
+	userRepo := repo.NewUserRepo(db)
+	auditService := service.NewAuditService(repo.NewAuditRepo(db), userRepo)
+	newsService := service.NewNewsService(repo.NewNewsRepo(db), userRepo, fileStorage, auditService)
+	stopNewsPublisher := jobs.StartNewsPublisher(newsService, time.Minute)
+	defer stopNewsPublisher()
+
+	activityService := service.NewActivityService(repo.NewActivityRepo(db), fileStorage, auditService)
+	stopActivityArchiver := jobs.StartActivityArchiver(activityService, time.Hour)
+	defer stopActivityArchiver()
+
+	reportSnapshotService := service.NewReportSnapshotService(
+		repo.NewReportSnapshotRepo(db), repo.NewPersonDisabilityRepo(db), repo.NewActivityRepo(db),
+		repo.NewEmploymentReportRepo(db), auditService,
+	)
+	stopReportSnapshots := jobs.StartReportSnapshots(reportSnapshotService, 2)
+	defer stopReportSnapshots()
 
 	err := routes.Listen(":3040")
 	if err != nil {
@@ -59,7 +59,15 @@ func (c *AuthController) Authenticate(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	user, err := c.authService.Authenticate(credentials)
+	// The middleware package depends on this one, so the actor is built here.
+	// The user is filled in once authenticated.
+	actor := model.AuditActor{
+		Ip:        ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+		RequestId: ctx.GetRespHeader(fiber.HeaderXRequestID),
+	}
+
+	user, err := c.authService.Authenticate(credentials, actor)
 	if err.Code != "" {
 		response = model.LoginResponse{
 			Message: err.Error(),
@@ -69,19 +77,14 @@ func (c *AuthController) Authenticate(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	var userConfig interface{}
-	userConfig = model.DefaultConfig
-
-	if user.ConfigUrl != "" {
-		userConfig, err = c.configService.GetUserConfig(user.ConfigUrl)
-		if err.Code != "" {
-			response = model.LoginResponse{
-				Message: err.Error(),
-				Code:    err.Code,
-			}
-
-			return ctx.Status(http.StatusInternalServerError).JSON(response)
+	userConfig, err := c.configService.GetUserConfig(user.Id)
+	if err.Code != "" {
+		response = model.LoginResponse{
+			Message: err.Error(),
+			Code:    err.Code,
 		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
 	}
 
 	token, err := c.authService.GenerateToken(user)
@@ -138,19 +141,14 @@ func (c *AuthController) GetUserData(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusInternalServerError).JSON(response)
 	}
 
-	var userConfig interface{}
-	userConfig = model.DefaultConfig
-
-	if user.ConfigUrl != "" {
-		userConfig, err = c.configService.GetUserConfig(user.ConfigUrl)
-		if err.Code != "" {
-			response = model.LoginResponse{
-				Message: err.Error(),
-				Code:    err.Code,
-			}
-
-			return ctx.Status(http.StatusInternalServerError).JSON(response)
+	userConfig, err := c.configService.GetUserConfig(user.Id)
+	if err.Code != "" {
+		response = model.LoginResponse{
+			Message: err.Error(),
+			Code:    err.Code,
 		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
 	}
 
 	if user.RoleId == 2 {
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"cij_api/src/config"
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/repo"
 	"cij_api/src/service"
@@ -14,12 +15,14 @@ import (
 type AuthService struct {
 	userRepo     repo.UserRepo
 	activityRepo repo.ActivityRepo
+	auditService service.AuditService
 }
 
-func NewAuthService(userRepo repo.UserRepo, activityRepo repo.ActivityRepo) *AuthService {
+func NewAuthService(userRepo repo.UserRepo, activityRepo repo.ActivityRepo, auditService service.AuditService) *AuthService {
 	return &AuthService{
 		userRepo:     userRepo,
 		activityRepo: activityRepo,
+		auditService: auditService,
 	}
 }
 
@@ -46,6 +49,7 @@ func (s *AuthService) GenerateToken(user model.User) (string, utils.Error) {
 
 	claims := &jwt.MapClaims{
 		"exp":   jwt.TimeFunc().Add(time.Hour * 24).Unix(),
+		"id":    user.Id,
 		"role":  user.Role.Name,
 		"email": user.Email,
 	}
@@ -71,7 +75,9 @@ func ValidateToken(tokenString string) (*jwt.Token, error) {
 	})
 }
 
-func (s *AuthService) Authenticate(credentials model.Credentials) (model.User, utils.Error) {
+// Authenticate checks the credentials and audits the login of the user, who is
+// the actor of the event.
+func (s *AuthService) Authenticate(credentials model.Credentials, actor model.AuditActor) (model.User, utils.Error) {
 	var user model.User
 
 	user, err := s.userRepo.GetUserByEmail(credentials.Email)
@@ -87,18 +93,23 @@ func (s *AuthService) Authenticate(credentials model.Credentials) (model.User, u
 		return user, authServiceError("invalid password", "04")
 	}
 
-	activityService := service.NewActivityService(s.activityRepo)
 	activity := model.Activity{
 		Type:        "login",
 		Description: "User" + user.Email + "logged in",
 		Actor:       user.Email,
 	}
 
-	activityError := activityService.CreateActivity(&activity)
+	activityError := s.activityRepo.CreateActivity(&activity)
 	if activityError.Code != "" {
 		return user, activityError
 	}
 
+	actor.UserId = user.Id
+	actor.Email = user.Email
+	actor.Role = user.RoleId.Name()
+
+	s.auditService.Record(actor, enum.AuditLogin, enum.AuditUser, user.Id, nil, nil)
+
 	return user, utils.Error{}
 }
 
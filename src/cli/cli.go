@@ -0,0 +1,500 @@
+package cli
+
+import (
+	"cij_api/src/config"
+	"cij_api/src/database"
+	"cij_api/src/enum"
+	"cij_api/src/geocoding"
+	"cij_api/src/model"
+	"cij_api/src/repo"
+	repoVacancy "cij_api/src/repo/vacancy"
+	"cij_api/src/service"
+	"cij_api/src/storage"
+	"cij_api/src/utils"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"gorm.io/gorm"
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(db *gorm.DB, fileStorage storage.FileStorage, args []string) error
+}
+
+var commands = []command{
+	{name: "create-admin", description: "create an admin user", run: createAdmin},
+	{name: "reset-password", description: "set a new password for an existing user", run: resetPassword},
+	{name: "seed", description: "insert the default roles and disabilities", run: seed},
+	{name: "reindex", description: "recreate missing indexes and refresh table statistics", run: reindex},
+	{name: "purge-expired", description: "permanently remove rows soft deleted before a given age", run: purgeExpired},
+	{name: "export-reports", description: "export the reports as JSON", run: exportReports},
+	{name: "user-config", description: "manage the users accessibility configs (repair, import-legacy)", run: userConfig},
+	{name: "migrate-curricula", description: "move the public curricula to the private storage", run: migrateCurricula},
+	{name: "publish-news", description: "publish the scheduled news whose date arrived", run: publishNews},
+	{name: "archive-activities", description: "archive the activities older than their retention", run: archiveActivities},
+	{name: "take-snapshots", description: "take the missing report snapshots, or recompute the days given", run: takeSnapshots},
+	{name: "geocode-addresses", description: "locate the addresses saved without coordinates", run: geocodeAddresses},
+}
+
+// Run executes the subcommand named by the first argument.
+func Run(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(os.Stdout)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(db, fileStorage, args[1:])
+		}
+	}
+
+	printUsage(os.Stderr)
+
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: cij_api <command> [flags]\n\nCommands:\n")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.description)
+	}
+
+	fmt.Fprintf(w, "\nRun without a command to start the API server.\n")
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+func success(format string, a ...interface{}) {
+	color.New(color.FgGreen).Printf(format+"\n", a...)
+}
+
+// newAuditService audits the changes made by the commands as the system.
+func newAuditService(db *gorm.DB) service.AuditService {
+	return service.NewAuditService(repo.NewAuditRepo(db), repo.NewUserRepo(db))
+}
+
+func createAdmin(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	flags := newFlagSet("create-admin")
+	email := flags.String("email", "", "admin email")
+	password := flags.String("password", "", "admin password")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	userService := service.NewUserService(repo.NewUserRepo(db), repo.NewActivityRepo(db), newAuditService(db))
+	request := model.UserRequest{Email: *email, Password: *password}
+
+	if err := userService.CreateAdmin(request, model.SystemActor("create-admin")); err.Code != "" {
+		return describeError(err.Message, err.Fields)
+	}
+
+	success("admin %s created", *email)
+
+	return nil
+}
+
+func resetPassword(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	flags := newFlagSet("reset-password")
+	email := flags.String("email", "", "user email")
+	password := flags.String("password", "", "new password")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	userService := service.NewUserService(repo.NewUserRepo(db), repo.NewActivityRepo(db), newAuditService(db))
+
+	if err := userService.ResetPassword(*email, *password, model.SystemActor("reset-password")); err.Code != "" {
+		return describeError(err.Message, err.Fields)
+	}
+
+	success("password of %s updated", *email)
+
+	return nil
+}
+
+func seed(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	if err := database.Seed(db); err != nil {
+		return err
+	}
+
+	success("default roles and disabilities seeded")
+
+	return nil
+}
+
+func reindex(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	if err := database.Reindex(db); err != nil {
+		return err
+	}
+
+	success("indexes rebuilt")
+
+	return nil
+}
+
+func publishNews(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	newsService := service.NewNewsService(repo.NewNewsRepo(db), repo.NewUserRepo(db), fileStorage, newAuditService(db))
+
+	published, err := newsService.PublishScheduledNews(model.SystemActor("publish-news"))
+	if err.Code != "" {
+		return errors.New(err.Message)
+	}
+
+	success("%d news published", published)
+
+	return nil
+}
+
+func archiveActivities(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	if err := newFlagSet("archive-activities").Parse(args); err != nil {
+		return err
+	}
+
+	activityService := service.NewActivityService(repo.NewActivityRepo(db), fileStorage, newAuditService(db))
+
+	archives, err := activityService.ArchiveExpiredActivities(model.SystemActor("archive-activities"))
+	if err.Code != "" {
+		return errors.New(err.Message)
+	}
+
+	archived := 0
+
+	for _, archive := range archives {
+		fmt.Printf("%-28s %6d %s\n", archive.Type, archive.Count, archive.Key)
+		archived += archive.Count
+	}
+
+	success("%d activities archived", archived)
+
+	return nil
+}
+
+func takeSnapshots(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	flags := newFlagSet("take-snapshots")
+	from := flags.String("from", "", "first day to recompute (YYYY-MM-DD)")
+	to := flags.String("to", "", "last day to recompute (YYYY-MM-DD), default from")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	reportSnapshotService := service.NewReportSnapshotService(
+		repo.NewReportSnapshotRepo(db), repo.NewPersonDisabilityRepo(db), repo.NewActivityRepo(db),
+		repo.NewEmploymentReportRepo(db), newAuditService(db),
+	)
+	actor := model.SystemActor("take-snapshots")
+
+	if *to == "" {
+		*to = *from
+	}
+
+	var result model.SnapshotResult
+	var err utils.Error
+
+	if *from == "" {
+		result, err = reportSnapshotService.TakeMissingSnapshots(actor)
+	} else {
+		result, err = reportSnapshotService.TakeSnapshots(*from, *to, actor)
+	}
+
+	if err.Code != "" {
+		return describeError(err.Message, err.Fields)
+	}
+
+	if result.Days == 0 {
+		success("no missing snapshots")
+		return nil
+	}
+
+	success("%d days snapshot, from %s to %s", result.Days, result.From, result.To)
+
+	return nil
+}
+
+func geocodeAddresses(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	if err := newFlagSet("geocode-addresses").Parse(args); err != nil {
+		return err
+	}
+
+	loadConfig, err := config.LoadConfig(".")
+	if err != nil {
+		return err
+	}
+
+	geocoder, err := geocoding.NewGeocoder(&loadConfig)
+	if err != nil {
+		return err
+	}
+
+	addressService := service.NewAddressService(repo.NewAddressRepo(db), geocoder)
+
+	result, errGeocode := addressService.GeocodeMissingAddresses()
+	if errGeocode.Code != "" {
+		return errors.New(errGeocode.Message)
+	}
+
+	success("%d addresses geocoded, %d not found", result.Geocoded, result.NotFound)
+
+	return nil
+}
+
+func purgeExpired(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	flags := newFlagSet("purge-expired")
+	days := flags.Int("days", 30, "minimum age in days of the soft deleted rows")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *days < 0 {
+		return errors.New("days must be a positive number")
+	}
+
+	before := time.Now().AddDate(0, 0, -*days)
+
+	purged, err := database.PurgeSoftDeleted(db, before)
+	if err != nil {
+		return err
+	}
+
+	tables := make([]string, 0, len(purged))
+	for table := range purged {
+		tables = append(tables, table)
+	}
+
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		fmt.Printf("%-28s %d\n", table, purged[table])
+	}
+
+	success("rows deleted before %s purged", before.Format(time.DateOnly))
+
+	return nil
+}
+
+type reportsExport struct {
+	GeneratedAt              time.Time                             `json:"generated_at"`
+	DisabilityTotals         model.DisabilityTotals                `json:"disability_totals"`
+	DisabilityByNeighborhood *model.DisabilityTotalsByNeighborhood `json:"disability_by_neighborhood,omitempty"`
+	Activities               []model.CountActivitiesByPeriod       `json:"activities"`
+}
+
+func exportReports(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	flags := newFlagSet("export-reports")
+	output := flags.String("output", "", "file to write the reports to (default stdout)")
+	period := flags.String("period", string(enum.LastYear), "activities period: last_three_months, last_six_months or last_year")
+	activityTypes := flags.String("activity-types", "login,register_person,register_company", "comma separated activity types")
+	neighborhood := flags.String("neighborhood", "", "also export the disability totals of this neighborhood")
+	public := flags.Bool("public", false, "suppress the small counts and add the open data noise, for publishing")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	periodFilter := enum.GetPeriodFilterEnum(*period)
+	if periodFilter == "" {
+		return fmt.Errorf("invalid period %q", *period)
+	}
+
+	reportsService := service.NewReportsService(repo.NewPersonDisabilityRepo(db), repo.NewActivityRepo(db), repo.NewEmploymentReportRepo(db))
+
+	privacy := service.ExactReports
+	if *public {
+		loadConfig, err := config.LoadConfig(".")
+		if err != nil {
+			return err
+		}
+
+		privacy = service.PublishedReportsPrivacy(&loadConfig)
+	}
+
+	disabilityTotals, err := reportsService.GetDisabilityTotals()
+	if err.Code != "" {
+		return err
+	}
+
+	export := reportsExport{
+		GeneratedAt:      time.Now(),
+		DisabilityTotals: privacy.DisabilityTotals(disabilityTotals),
+		Activities:       []model.CountActivitiesByPeriod{},
+	}
+
+	if *neighborhood != "" {
+		neighborhoodTotals, err := reportsService.GetDisabilityTotalsByNeighborhood(*neighborhood)
+		if err.Code != "" {
+			return err
+		}
+
+		neighborhoodTotals = privacy.DisabilityTotals(neighborhoodTotals)
+		export.DisabilityByNeighborhood = &neighborhoodTotals
+	}
+
+	for _, activityType := range strings.Split(*activityTypes, ",") {
+		activityType = strings.TrimSpace(activityType)
+		if activityType == "" {
+			continue
+		}
+
+		activities, err := reportsService.CountActivitiesByPeriod(activityType, periodFilter)
+		if err.Code != "" {
+			return err
+		}
+
+		export.Activities = append(export.Activities, privacy.ActivitiesByPeriod(activities))
+	}
+
+	var writer io.Writer = os.Stdout
+
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		writer = file
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(export); err != nil {
+		return err
+	}
+
+	if *output != "" {
+		success("reports exported to %s", *output)
+	}
+
+	return nil
+}
+
+func userConfig(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	if len(args) > 0 && args[0] == "import-legacy" {
+		return importLegacyUserConfigs(db)
+	}
+
+	if len(args) == 0 || args[0] != "repair" {
+		return errors.New("usage: cij_api user-config repair [-dry-run] | import-legacy")
+	}
+
+	flags := newFlagSet("user-config repair")
+	dryRun := flags.Bool("dry-run", false, "only list the users whose config would be reset")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	userRepo := repo.NewUserRepo(db)
+	auditService := newAuditService(db)
+	userService := service.NewUserService(userRepo, repo.NewActivityRepo(db), auditService)
+	configService := service.NewConfigService(userRepo, repo.NewUserConfigRepo(db), repo.NewConfigPresetRepo(db), repo.NewCompanyRepo(db), auditService)
+	actor := model.SystemActor("user-config repair")
+
+	users, err := userService.ListUsers()
+	if err.Code != "" {
+		return err
+	}
+
+	repaired := 0
+
+	for _, user := range users {
+		config, err := configService.GetUserConfig(user.Id)
+		if err.Code != "" {
+			return err
+		}
+
+		if user.ConfigUrl == "" && configService.ValidateUserConfig(config).Code == "" {
+			continue
+		}
+
+		fmt.Printf("%s: legacy or invalid config\n", user.Email)
+
+		if *dryRun {
+			continue
+		}
+
+		if err := configService.SaveUserConfig(user.Id, model.DefaultConfig, actor); err.Code != "" {
+			return err
+		}
+
+		if err := userRepo.UpdateUserConfig("", user.Email); err.Code != "" {
+			return err
+		}
+
+		repaired++
+	}
+
+	success("%d user configs repaired", repaired)
+
+	return nil
+}
+
+// importLegacyUserConfigs copies the configs still kept as files in the
+// storage to the database. The ones that failed for a while can be imported
+// again by running it again.
+func importLegacyUserConfigs(db *gorm.DB) error {
+	imported, failed, err := database.ImportLegacyUserConfigs(db)
+	if err != nil {
+		return err
+	}
+
+	success("%d legacy user configs imported, %d failed", imported, failed)
+
+	return nil
+}
+
+func describeError(message string, fields []model.Field) error {
+	if len(fields) == 0 {
+		return errors.New(message)
+	}
+
+	names := []string{}
+	for _, field := range fields {
+		if field.Value != "" {
+			names = append(names, field.Name+": "+field.Value)
+		} else {
+			names = append(names, field.Name)
+		}
+	}
+
+	return fmt.Errorf("%s (%s)", message, strings.Join(names, ", "))
+}
+
+func migrateCurricula(db *gorm.DB, fileStorage storage.FileStorage, args []string) error {
+	if err := newFlagSet("migrate-curricula").Parse(args); err != nil {
+		return err
+	}
+
+	curriculumService := service.NewCurriculumService(
+		repo.NewPersonRepo(db), repo.NewCompanyRepo(db), repoVacancy.NewVacancyApplyRepo(db),
+		repo.NewActivityRepo(db), fileStorage, newAuditService(db),
+	)
+
+	migrated, err := curriculumService.MigrateLegacyCurricula(model.SystemActor("migrate-curricula"))
+	if err.Code != "" {
+		return err
+	}
+
+	success("%d curricula migrated", migrated)
+
+	return nil
+}
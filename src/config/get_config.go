@@ -3,12 +3,26 @@ package config
 import "github.com/spf13/viper"
 
 type Config struct {
-	DbConnection string `mapstructure:"DSN"`
-	SecretKey    string `mapstructure:"SECRET_KEY"`
-}
-
-type CloudinaryConfig struct {
+	DbDriver      string `mapstructure:"DB_DRIVER"`
+	DbConnection  string `mapstructure:"DSN"`
+	SecretKey     string `mapstructure:"SECRET_KEY"`
+	StorageDriver string `mapstructure:"STORAGE_DRIVER"`
+	StoragePath   string `mapstructure:"STORAGE_PATH"`
+	StorageUrl    string `mapstructure:"STORAGE_URL"`
 	CloudinaryUrl string `mapstructure:"CLOUDINARY_URL"`
+	S3Endpoint    string `mapstructure:"S3_ENDPOINT"`
+	S3Region      string `mapstructure:"S3_REGION"`
+	S3Bucket      string `mapstructure:"S3_BUCKET"`
+	S3AccessKey   string `mapstructure:"S3_ACCESS_KEY"`
+	S3SecretKey   string `mapstructure:"S3_SECRET_KEY"`
+	S3UseSsl      bool   `mapstructure:"S3_USE_SSL"`
+
+	GeocoderDriver    string `mapstructure:"GEOCODER_DRIVER"`
+	GeocoderUrl       string `mapstructure:"GEOCODER_URL"`
+	GeocoderCentroids string `mapstructure:"GEOCODER_CENTROIDS"`
+
+	ReportsMinCellSize int     `mapstructure:"REPORTS_MIN_CELL_SIZE"`
+	OpenDataNoiseScale float64 `mapstructure:"OPEN_DATA_NOISE_SCALE"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -25,19 +39,3 @@ func LoadConfig(path string) (config Config, err error) {
 	err = viper.Unmarshal(&config)
 	return
 }
-
-func LoadCloudinaryConfig(path string) (config CloudinaryConfig, err error) {
-	viper.AddConfigPath(path)
-	viper.SetConfigType("env")
-	viper.SetConfigName("app")
-
-	viper.AutomaticEnv()
-
-	err = viper.ReadInConfig()
-	if err != nil {
-		return
-	}
-
-	err = viper.Unmarshal(&config)
-	return
-}
@@ -1,10 +1,17 @@
 package controller
 
 import (
+	"bufio"
+	"cij_api/src/middleware"
 	"cij_api/src/model"
 	"cij_api/src/service"
+	"cij_api/src/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -42,7 +49,7 @@ func (a *ActivityController) CreateActivity(ctx *fiber.Ctx) error {
 
 	activity := activityRequest.ToModel()
 
-	if err := a.activityService.CreateActivity(activity); err.Code != "" {
+	if err := a.activityService.CreateActivity(activity, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.Code,
@@ -109,3 +116,194 @@ func (a *ActivityController) GetActivitiesByTypeAndPeriod(ctx *fiber.Ctx) error
 
 	return ctx.Status(http.StatusOK).JSON(response)
 }
+
+// ExportActivities
+// @Summary Export the activities of a period.
+// @Description stream the activities created in the period as CSV or as JSON Lines, oldest first.
+// @Tags Activities
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param Authorization header string true "Token"
+// @Param from query string true "Start of the period, RFC 3339"
+// @Param to query string true "End of the period, RFC 3339, exclusive"
+// @Param type query string false "Only export the activities of this type"
+// @Param format query string false "'csv' (default) or 'ndjson'"
+// @Success 200 {string} string
+// @Failure 400 {object} model.Response
+// @Router /activities/export [get]
+func (a *ActivityController) ExportActivities(ctx *fiber.Ctx) error {
+	format := ctx.Query("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "format must be 'csv' or 'ndjson'",
+		})
+	}
+
+	from, fromErr := time.Parse(time.RFC3339, ctx.Query("from"))
+	to, toErr := time.Parse(time.RFC3339, ctx.Query("to"))
+
+	if fromErr != nil || toErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "from and to must be RFC 3339 dates",
+		})
+	}
+
+	if !from.Before(to) {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "the start of the period must be before its end",
+		})
+	}
+
+	filter := model.ActivityExportFilter{Type: ctx.Query("type"), From: from, To: to}
+
+	if format == "csv" {
+		ctx.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	} else {
+		ctx.Set(fiber.HeaderContentType, "application/x-ndjson")
+	}
+
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="activities.%s"`, format))
+
+	// The rows are written while the response is sent, so a failure in the
+	// middle of the export can only cut the body short.
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var err utils.Error
+
+		if format == "csv" {
+			err = a.writeActivitiesCsv(w, filter)
+		} else {
+			err = a.writeActivitiesNdjson(w, filter)
+		}
+
+		if err.Code != "" {
+			fmt.Println("Error:", err.Message)
+		}
+
+		w.Flush()
+	})
+
+	return nil
+}
+
+func (a *ActivityController) writeActivitiesCsv(w *bufio.Writer, filter model.ActivityExportFilter) utils.Error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "type", "description", "actor", "created_at"})
+
+	err := a.activityService.ExportActivities(filter, func(activity model.ActivityResponse) error {
+		return writer.Write([]string{
+			strconv.FormatUint(uint64(activity.ID), 10),
+			activity.Type,
+			activity.Description,
+			activity.Actor,
+			activity.CreatedAt.Format(time.RFC3339),
+		})
+	})
+
+	writer.Flush()
+
+	return err
+}
+
+func (a *ActivityController) writeActivitiesNdjson(w *bufio.Writer, filter model.ActivityExportFilter) utils.Error {
+	encoder := json.NewEncoder(w)
+
+	return a.activityService.ExportActivities(filter, func(activity model.ActivityResponse) error {
+		return encoder.Encode(activity)
+	})
+}
+
+// ListActivityRetentions
+// @Summary List the activity retentions.
+// @Description list how many days the activities of each type are kept before being archived. The types not listed are kept forever.
+// @Tags Activities
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Success 200 {array} model.ActivityRetention
+// @Failure 500 {object} model.Response
+// @Router /activities/retentions [get]
+func (a *ActivityController) ListActivityRetentions(ctx *fiber.Ctx) error {
+	retentions, err := a.activityService.ListActivityRetentions()
+	if err.Code != "" {
+		return activityErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "success",
+		Data:    retentions,
+	})
+}
+
+// SaveActivityRetention
+// @Summary Set the retention of an activity type.
+// @Description set how many days the activities of the type are kept before being archived to the storage and deleted.
+// @Tags Activities
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param type path string true "Activity type"
+// @Param retention body model.ActivityRetentionRequest true "Retention"
+// @Success 200 {object} model.ActivityRetention
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /activities/retentions/{type} [put]
+func (a *ActivityController) SaveActivityRetention(ctx *fiber.Ctx) error {
+	var retentionRequest model.ActivityRetentionRequest
+
+	if err := ctx.BodyParser(&retentionRequest); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: err.Error(),
+		})
+	}
+
+	retention, err := a.activityService.SaveActivityRetention(ctx.Params("type"), retentionRequest, middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return activityErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "Activity retention saved successfully",
+		Data:    retention,
+	})
+}
+
+// DeleteActivityRetention
+// @Summary Remove the retention of an activity type.
+// @Description keep the activities of the type forever.
+// @Tags Activities
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param type path string true "Activity type"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /activities/retentions/{type} [delete]
+func (a *ActivityController) DeleteActivityRetention(ctx *fiber.Ctx) error {
+	retention, err := a.activityService.DeleteActivityRetention(ctx.Params("type"), middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return activityErrorResponse(ctx, err)
+	}
+
+	if retention.Id == 0 {
+		return ctx.Status(http.StatusNotFound).JSON(model.Response{
+			Message: "activity retention not found",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "Activity retention deleted successfully",
+	})
+}
+
+func activityErrorResponse(ctx *fiber.Ctx, err utils.Error) error {
+	response := model.Response{
+		Message: err.Message,
+		Code:    err.Code,
+		Fields:  err.Fields,
+	}
+
+	if err.IsValidation() {
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	return ctx.Status(http.StatusInternalServerError).JSON(response)
+}
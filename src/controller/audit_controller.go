@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxAuditEventsPerPage = 100
+
+type AuditController struct {
+	auditService service.AuditService
+}
+
+func NewAuditController(auditService service.AuditService) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+	}
+}
+
+// ListAuditEvents
+// @Summary List the audit events.
+// @Description list the changes made through the API, newest first. The total of events is sent in the X-Total-Count header.
+// @Tags Audit
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param actor_id query int false "User id of the actor"
+// @Param action query string false "'create', 'update', 'delete' or 'login'"
+// @Param entity_type query string false "Type of the changed entity, like 'person' or 'news'"
+// @Param entity_id query int false "Id of the changed entity"
+// @Param request_id query string false "Id of the request that made the change"
+// @Param from query string false "Start of the period, RFC 3339"
+// @Param to query string false "End of the period, RFC 3339, exclusive"
+// @Param page query int false "Page, from 1"
+// @Param per_page query int false "Events per page, up to 100"
+// @Success 200 {array} model.AuditEventResponse
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /audit-events [get]
+func (a *AuditController) ListAuditEvents(ctx *fiber.Ctx) error {
+	var response model.Response
+
+	filter := model.AuditFilter{
+		ActorId:    ctx.QueryInt("actor_id"),
+		Action:     enum.AuditAction(ctx.Query("action")),
+		EntityType: enum.AuditEntity(ctx.Query("entity_type")),
+		EntityId:   ctx.QueryInt("entity_id"),
+		RequestId:  ctx.Query("request_id"),
+		Page:       ctx.QueryInt("page", 1),
+		PerPage:    ctx.QueryInt("per_page", 20),
+	}
+
+	if filter.Page < 1 || filter.PerPage < 1 || filter.PerPage > maxAuditEventsPerPage {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "page must be positive and per_page between 1 and 100",
+		})
+	}
+
+	if filter.Action != "" && !filter.Action.IsValid() {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "action must be 'create', 'update', 'delete' or 'login'",
+		})
+	}
+
+	for name, date := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if ctx.Query(name) == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, ctx.Query(name))
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+				Message: "from and to must be RFC 3339 dates",
+			})
+		}
+
+		*date = &parsed
+	}
+
+	events, total, err := a.auditService.ListAuditEvents(filter)
+	if err.Code != "" {
+		return ctx.Status(http.StatusInternalServerError).JSON(model.Response{
+			Message: err.Message,
+			Code:    err.Code,
+		})
+	}
+
+	ctx.Set("X-Total-Count", strconv.FormatInt(total, 10))
+
+	response = model.Response{
+		Message: "success",
+		Data:    events,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// GetAuditEvent
+// @Summary Get an audit event.
+// @Description get a change made through the API, with the fields before and after it.
+// @Tags Audit
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param id path int true "Audit event id"
+// @Success 200 {object} model.AuditEventResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /audit-events/{id} [get]
+func (a *AuditController) GetAuditEvent(ctx *fiber.Ctx) error {
+	eventId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: err.Error(),
+		})
+	}
+
+	event, errEvent := a.auditService.GetAuditEventById(eventId)
+	if errEvent.Code != "" {
+		return ctx.Status(http.StatusInternalServerError).JSON(model.Response{
+			Message: errEvent.Message,
+			Code:    errEvent.Code,
+		})
+	}
+
+	if event.Id == 0 {
+		return ctx.Status(http.StatusNotFound).JSON(model.Response{
+			Message: "audit event not found",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "success",
+		Data:    event,
+	})
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"cij_api/src/middleware"
 	"cij_api/src/model"
 	"cij_api/src/service"
 	"cij_api/src/utils"
@@ -90,7 +91,7 @@ func (n *CompanyController) CreateCompany(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	if err := n.companyService.CreateCompany(companyRequest); err.Code != "" {
+	if err := n.companyService.CreateCompany(companyRequest, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.Code,
@@ -172,6 +173,14 @@ func (n *CompanyController) GetCompany(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusInternalServerError).JSON(response)
 	}
 
+	if company.Id == 0 {
+		response = model.Response{
+			Message: "company not found",
+		}
+
+		return ctx.Status(http.StatusNotFound).JSON(response)
+	}
+
 	response = model.Response{
 		Message: "success",
 		Data:    company.ToResponse(*company.User),
@@ -216,7 +225,24 @@ func (n *CompanyController) UpdateCompany(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	if err := n.companyService.UpdateCompany(companyRequest, idInt); err.Code != "" {
+	company, errCompany := n.companyService.GetCompanyById(idInt)
+	if errCompany.Code != "" {
+		response = model.Response{
+			Message: errCompany.Error(),
+		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	}
+
+	if company.Id == 0 {
+		response = model.Response{
+			Message: "company not found",
+		}
+
+		return ctx.Status(http.StatusNotFound).JSON(response)
+	}
+
+	if err := n.companyService.UpdateCompany(companyRequest, idInt, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.Code,
@@ -258,7 +284,24 @@ func (n *CompanyController) DeleteCompany(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	if err := n.companyService.DeleteCompany(idInt); err.Code != "" {
+	company, errCompany := n.companyService.GetCompanyById(idInt)
+	if errCompany.Code != "" {
+		response = model.Response{
+			Message: errCompany.Error(),
+		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	}
+
+	if company.Id == 0 {
+		response = model.Response{
+			Message: "company not found",
+		}
+
+		return ctx.Status(http.StatusNotFound).JSON(response)
+	}
+
+	if err := n.companyService.DeleteCompany(idInt, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 		}
@@ -322,6 +365,17 @@ func (c *CompanyController) validateCompany(companyRequest model.CompanyRequest)
 		return companyControllerError("email already registered", "02", nil)
 	}
 
+	if companyRequest.ConfigPreset != "" {
+		preset, err := c.companyService.GetConfigPresetBySlug(companyRequest.ConfigPreset)
+		if err.Code != "" {
+			return err
+		}
+
+		if preset.Id == 0 {
+			fieldsWithError = append(fieldsWithError, model.Field{Name: "config_preset", Value: "config preset not found"})
+		}
+	}
+
 	if len(fieldsWithError) > 0 {
 		errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.CompanyErrorType, "02")
 
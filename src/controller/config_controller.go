@@ -1,16 +1,20 @@
 package controller
 
 import (
+	"cij_api/src/enum"
+	"cij_api/src/middleware"
 	"cij_api/src/model"
 	"cij_api/src/service"
 	"cij_api/src/utils"
-	"fmt"
 	"net/http"
-	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// ConfigController serves the config of the authenticated user under /me and,
+// for the admins, the config of any user under /users/:id.
 type ConfigController struct {
 	configService service.ConfigService
 }
@@ -23,17 +27,51 @@ func NewConfigController(configService service.ConfigService) *ConfigController
 
 // GetUserConfig godoc
 // @Summary Get user config
-// @Description Get user config
+// @Description Get the current config of the authenticated user, or of the user id for admins
+// @Tags config
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Success 200 {object} model.Config
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/config [get]
+// @Router /users/{id}/config [get]
+func (c *ConfigController) GetUserConfig(ctx *fiber.Ctx) error {
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	config, err := c.configService.GetUserConfig(user.Id)
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	response = model.Response{
+		Message: "success",
+		Data:    config,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// UpdateUserConfig godoc
+// @Summary Update user config
+// @Description Replace the whole user config, saving it as a new version
 // @Tags config
 // @Accept json
 // @Produce json
-// @Param email path string true "User email"
-// @Success 200 {object} interface{}
+// @Param Authorization header string true "Token"
+// @Param config body model.Config true "Config"
+// @Success 200 {object} model.Config
 // @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
 // @Failure 500 {object} model.Response
-// @Router /config/{email} [get]
+// @Router /me/config [put]
+// @Router /users/{id}/config [put]
 func (c *ConfigController) UpdateUserConfig(ctx *fiber.Ctx) error {
-	var configRequest interface{}
+	var configRequest model.Config
 	var response model.Response
 
 	if err := ctx.BodyParser(&configRequest); err != nil {
@@ -44,102 +82,587 @@ func (c *ConfigController) UpdateUserConfig(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	userEmail := ctx.Params("email")
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	if err := c.configService.SaveUserConfig(user.Id, configRequest, middleware.GetAuditActor(ctx)); err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
 
-	// err := validateUserConfig(configRequest)
-	// if err.Message != "" {
-	// 	response = model.Response{
-	// 		Message: err.Message,
-	// 		Fields:  err.Fields,
-	// 	}
+	response = model.Response{
+		Message:  "User config updated successfully",
+		Warnings: c.configService.CheckUserConfig(configRequest),
+		Data:     configRequest,
+	}
 
-	// 	return ctx.Status(http.StatusBadRequest).JSON(response)
-	// }
+	return ctx.Status(http.StatusOK).JSON(response)
+}
 
-	err := c.configService.UploadUserConfig(userEmail, &configRequest)
+// PatchUserConfig godoc
+// @Summary Partially update user config
+// @Description Apply a JSON Merge Patch (RFC 7386) to the user config, saving the result as a new version
+// @Tags config
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param patch body object true "Merge patch"
+// @Success 200 {object} model.Config
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/config [patch]
+// @Router /users/{id}/config [patch]
+func (c *ConfigController) PatchUserConfig(ctx *fiber.Ctx) error {
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	config, err := c.configService.PatchUserConfig(user.Id, ctx.Body(), middleware.GetAuditActor(ctx))
 	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	response = model.Response{
+		Message:  "User config updated successfully",
+		Warnings: c.configService.CheckUserConfig(config),
+		Data:     config,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// ResetUserConfig godoc
+// @Summary Reset user config
+// @Description Save the default config as a new version of the user config
+// @Tags config
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Success 200 {object} model.Config
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/config/reset [post]
+// @Router /users/{id}/config/reset [post]
+func (c *ConfigController) ResetUserConfig(ctx *fiber.Ctx) error {
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	if err := c.configService.ResetUserConfig(user.Id, middleware.GetAuditActor(ctx)); err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	response = model.Response{
+		Message: "User config reset successfully",
+		Data:    model.DefaultConfig,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// ListUserConfigVersions godoc
+// @Summary List user config versions
+// @Description List the saved versions of the user config, newest first
+// @Tags config
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Success 200 {array} model.UserConfigVersionResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/config/versions [get]
+// @Router /users/{id}/config/versions [get]
+func (c *ConfigController) ListUserConfigVersions(ctx *fiber.Ctx) error {
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	versions, err := c.configService.ListUserConfigVersions(user.Id)
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	response = model.Response{
+		Message: "success",
+		Data:    versions,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// RollbackUserConfig godoc
+// @Summary Roll back user config
+// @Description Restore a previous version of the user config as the newest one
+// @Tags config
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param version path int true "Version"
+// @Success 200 {object} model.UserConfigVersionResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/config/versions/{version}/rollback [post]
+// @Router /users/{id}/config/versions/{version}/rollback [post]
+func (c *ConfigController) RollbackUserConfig(ctx *fiber.Ctx) error {
+	version, err := strconv.Atoi(ctx.Params("version"))
+	if err != nil {
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	restored, errRollback := c.configService.RollbackUserConfig(user.Id, version, middleware.GetAuditActor(ctx))
+	if errRollback.Code != "" {
+		return configErrorResponse(ctx, errRollback)
+	}
+
+	if restored.Version == 0 {
 		response = model.Response{
-			Message: err.Message,
+			Message: "user config version not found",
 		}
 
-		return ctx.Status(http.StatusInternalServerError).JSON(response)
+		return ctx.Status(http.StatusNotFound).JSON(response)
 	}
 
 	response = model.Response{
-		Message: "User config updated successfully",
+		Message: "success",
+		Data:    restored,
 	}
 
 	return ctx.Status(http.StatusOK).JSON(response)
 }
 
-func validateUserConfig(config model.Config) utils.Error {
-	errorFields := []model.Field{}
-
-	const MAX_FONT_SIZE = 30
-	const MIN_FONT_SIZE = 14
+// GeneratePalette godoc
+// @Summary Generate accessible palette
+// @Description Generate system colors around a primary color that reach the WCAG contrast level, with chart colors that can be told apart with the color blindness
+// @Tags config
+// @Produce json
+// @Param primary_color query string true "Primary color, with or without #"
+// @Param theme query string false "Theme (light, dark or system)"
+// @Param color_blindness query string false "Color blindness (normal, protanopia, deuteranopia or tritanopia)"
+// @Param level query string false "Contrast level (AA or AAA)"
+// @Success 200 {object} model.PaletteResponse
+// @Failure 400 {object} model.Response
+// @Router /config/palette [get]
+func (c *ConfigController) GeneratePalette(ctx *fiber.Ctx) error {
+	theme := enum.ThemeEnum(ctx.Query("theme", string(enum.Light)))
+	colorBlindness := enum.ColorBlindnessEnum(ctx.Query("color_blindness", string(enum.Normal)))
+	level := enum.ContrastLevelEnum(strings.ToUpper(ctx.Query("level", string(enum.ContrastAA))))
 
-	if !config.Theme.IsValid() {
-		errorFields = append(errorFields, model.Field{Name: "theme", Value: "theme must be 'light', 'dark' or 'system'"})
+	palette, err := c.configService.GeneratePalette(ctx.Query("primary_color"), theme, colorBlindness, level)
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
 	}
 
-	if config.FontSize < MIN_FONT_SIZE || config.FontSize > MAX_FONT_SIZE {
-		errorFields = append(errorFields, model.Field{Name: "font_size", Value: "font_size must be between 14 and 30"})
+	response := model.Response{
+		Message: "success",
+		Data:    palette,
 	}
 
-	if !config.ColorBlindness.IsValid() {
-		errorFields = append(errorFields, model.Field{Name: "color_blindness", Value: "color_blindness must be 'normal', 'protanopia', 'deuteranopia' or 'tritanopia'"})
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// getUser returns the user of the id param on the admin routes and the
+// authenticated user on the /me routes.
+func (c *ConfigController) getUser(ctx *fiber.Ctx) (model.User, int, model.Response) {
+	var user model.User
+	var err utils.Error
+
+	if ctx.Params("id") != "" {
+		userId, convErr := strconv.Atoi(ctx.Params("id"))
+		if convErr != nil {
+			return user, http.StatusBadRequest, model.Response{
+				Message: convErr.Error(),
+			}
+		}
+
+		user, err = c.configService.GetUserById(userId)
+	} else {
+		user, err = c.configService.GetUserByEmail(middleware.GetTokenEmail(ctx))
 	}
 
-	errorColors := validateConfigColors(config)
-	fmt.Print(errorColors)
-	errorFields = append(errorFields, errorColors...)
-	fmt.Print(errorFields)
+	if err.Code != "" {
+		return user, http.StatusInternalServerError, model.Response{
+			Message: err.Message,
+			Code:    err.Code,
+		}
+	}
 
-	if len(errorFields) > 0 {
-		return utils.Error{
-			Message: "Invalid user config",
-			Fields:  errorFields,
+	if user.Id == 0 {
+		return user, http.StatusNotFound, model.Response{
+			Message: "user not found",
 		}
 	}
 
-	return utils.Error{}
+	return user, http.StatusOK, model.Response{}
+}
+
+func configErrorResponse(ctx *fiber.Ctx, err utils.Error) error {
+	response := model.Response{
+		Message: err.Message,
+		Code:    err.Code,
+		Fields:  err.Fields,
+	}
+
+	if err.IsValidation() {
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	return ctx.Status(http.StatusInternalServerError).JSON(response)
 }
 
-func validateConfigColors(config model.Config) []model.Field {
-	errorColors := []model.Field{}
-	configColors := config.SystemColors
+// ListConfigPresets godoc
+// @Summary List config presets
+// @Description List the accessibility presets that users can apply to their config
+// @Tags config
+// @Produce json
+// @Success 200 {array} model.ConfigPresetResponse
+// @Failure 500 {object} model.Response
+// @Router /config/presets [get]
+func (c *ConfigController) ListConfigPresets(ctx *fiber.Ctx) error {
+	presets, err := c.configService.ListConfigPresets()
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
 
-	primaryColors := map[string]string{
-		"primary_color":        configColors.PrimaryColors.PrimaryColor,
-		"secondary_color":      configColors.PrimaryColors.SecondaryColor,
-		"font_color":           configColors.PrimaryColors.FontColor,
-		"secondary_font_color": configColors.PrimaryColors.SecondaryFontColor,
-		"input_color":          configColors.PrimaryColors.InputColor,
-		"background_color":     configColors.PrimaryColors.BackgroundColor,
+	response := model.Response{
+		Message: "success",
+		Data:    presets,
 	}
 
-	for colorCategory, colorValue := range primaryColors {
-		if !isHexString(colorValue) {
-			errorColors = append(errorColors, model.Field{Name: colorCategory, Value: colorValue + " must be a valid hex color"})
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// GetConfigPreset godoc
+// @Summary Get config preset
+// @Description Get an accessibility preset by id
+// @Tags config
+// @Produce json
+// @Param id path int true "Preset id"
+// @Success 200 {object} model.ConfigPresetResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /config/presets/{id} [get]
+func (c *ConfigController) GetConfigPreset(ctx *fiber.Ctx) error {
+	presetId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		response := model.Response{
+			Message: err.Error(),
 		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	preset, errPreset := c.configService.GetConfigPreset(presetId)
+	if errPreset.Code != "" {
+		return configErrorResponse(ctx, errPreset)
+	}
+
+	if preset.Id == 0 {
+		return presetNotFoundResponse(ctx)
+	}
+
+	response := model.Response{
+		Message: "success",
+		Data:    preset,
 	}
 
-	for chartCategory, chartColor := range configColors.ChartColors {
-		if !isHexString(chartColor) {
-			errorColors = append(errorColors, model.Field{Name: string(chartCategory), Value: chartColor + " must be a valid hex color"})
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// CreateConfigPreset godoc
+// @Summary Create config preset
+// @Description Create an accessibility preset, validated with the same rules as the user configs
+// @Tags config
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param preset body model.ConfigPresetRequest true "Preset"
+// @Success 201 {object} model.ConfigPresetResponse
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /config/presets [post]
+func (c *ConfigController) CreateConfigPreset(ctx *fiber.Ctx) error {
+	var presetRequest model.ConfigPresetRequest
+
+	if err := ctx.BodyParser(&presetRequest); err != nil {
+		response := model.Response{
+			Message: err.Error(),
 		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	preset, err := c.configService.CreateConfigPreset(presetRequest, middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	response := model.Response{
+		Message:  "Config preset created successfully",
+		Warnings: c.configService.CheckUserConfig(preset.Config),
+		Data:     preset,
 	}
 
-	return errorColors
+	return ctx.Status(http.StatusCreated).JSON(response)
 }
 
-func isHexString(s string) bool {
-	hexPattern := "^#([a-fA-F0-9]{6}|[a-fA-F0-9]{3})$" // Hex color pattern
+// UpdateConfigPreset godoc
+// @Summary Update config preset
+// @Description Replace an accessibility preset. The users that already applied it keep their config
+// @Tags config
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param id path int true "Preset id"
+// @Param preset body model.ConfigPresetRequest true "Preset"
+// @Success 200 {object} model.ConfigPresetResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /config/presets/{id} [put]
+func (c *ConfigController) UpdateConfigPreset(ctx *fiber.Ctx) error {
+	var presetRequest model.ConfigPresetRequest
+
+	presetId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	if err := ctx.BodyParser(&presetRequest); err != nil {
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	preset, errPreset := c.configService.UpdateConfigPreset(presetRequest, presetId, middleware.GetAuditActor(ctx))
+	if errPreset.Code != "" {
+		return configErrorResponse(ctx, errPreset)
+	}
+
+	if preset.Id == 0 {
+		return presetNotFoundResponse(ctx)
+	}
+
+	response := model.Response{
+		Message:  "Config preset updated successfully",
+		Warnings: c.configService.CheckUserConfig(preset.Config),
+		Data:     preset,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
 
-	ok, err := regexp.MatchString(hexPattern, s)
+// DeleteConfigPreset godoc
+// @Summary Delete config preset
+// @Description Delete an accessibility preset. The users that already applied it keep their config
+// @Tags config
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param id path int true "Preset id"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /config/presets/{id} [delete]
+func (c *ConfigController) DeleteConfigPreset(ctx *fiber.Ctx) error {
+	presetId, err := strconv.Atoi(ctx.Params("id"))
 	if err != nil {
-		return false
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	preset, errPreset := c.configService.GetConfigPreset(presetId)
+	if errPreset.Code != "" {
+		return configErrorResponse(ctx, errPreset)
+	}
+
+	if preset.Id == 0 {
+		return presetNotFoundResponse(ctx)
+	}
+
+	if err := c.configService.DeleteConfigPreset(presetId, middleware.GetAuditActor(ctx)); err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	response := model.Response{
+		Message: "Config preset deleted successfully",
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// ApplyConfigPreset godoc
+// @Summary Apply config preset
+// @Description Save a copy of the preset as a new version of the user config
+// @Tags config
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param slug path string true "Preset slug"
+// @Success 200 {object} model.Config
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/config/presets/{slug}/apply [post]
+// @Router /users/{id}/config/presets/{slug}/apply [post]
+func (c *ConfigController) ApplyConfigPreset(ctx *fiber.Ctx) error {
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	preset, err := c.configService.ApplyConfigPreset(user.Id, ctx.Params("slug"), middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	if preset.Id == 0 {
+		return presetNotFoundResponse(ctx)
+	}
+
+	response = model.Response{
+		Message: "Config preset applied successfully",
+		Data:    preset.Config,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// GetCompanyConfigPreset godoc
+// @Summary Get company default config
+// @Description Get the branded config that the recruiters of the company get until they change their own
+// @Tags config
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Success 200 {object} model.ConfigPresetResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/company/config [get]
+func (c *ConfigController) GetCompanyConfigPreset(ctx *fiber.Ctx) error {
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	preset, err := c.configService.GetCompanyConfigPreset(user.Id)
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	if preset.Id == 0 {
+		return presetNotFoundResponse(ctx)
+	}
+
+	response = model.Response{
+		Message: "success",
+		Data:    preset,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// UpdateCompanyConfigPreset godoc
+// @Summary Update company default config
+// @Description Set the branded config that the recruiters of the company get until they change their own
+// @Tags config
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param config body model.Config true "Config"
+// @Success 200 {object} model.ConfigPresetResponse
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/company/config [put]
+func (c *ConfigController) UpdateCompanyConfigPreset(ctx *fiber.Ctx) error {
+	var configRequest model.Config
+
+	if err := ctx.BodyParser(&configRequest); err != nil {
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	preset, err := c.configService.SaveCompanyConfigPreset(user.Id, configRequest, middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	response = model.Response{
+		Message:  "Company config updated successfully",
+		Warnings: c.configService.CheckUserConfig(preset.Config),
+		Data:     preset,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// DeleteCompanyConfigPreset godoc
+// @Summary Delete company default config
+// @Description Remove the branded config of the company, so its recruiters get the system default
+// @Tags config
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /me/company/config [delete]
+func (c *ConfigController) DeleteCompanyConfigPreset(ctx *fiber.Ctx) error {
+	user, status, response := c.getUser(ctx)
+	if status != http.StatusOK {
+		return ctx.Status(status).JSON(response)
+	}
+
+	deleted, err := c.configService.DeleteCompanyConfigPreset(user.Id, middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return configErrorResponse(ctx, err)
+	}
+
+	if !deleted {
+		return presetNotFoundResponse(ctx)
+	}
+
+	response = model.Response{
+		Message: "Company config deleted successfully",
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+func presetNotFoundResponse(ctx *fiber.Ctx) error {
+	response := model.Response{
+		Message: "config preset not found",
 	}
 
-	return ok
+	return ctx.Status(http.StatusNotFound).JSON(response)
 }
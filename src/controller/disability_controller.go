@@ -1,8 +1,12 @@
 package controller
 
 import (
+	"cij_api/src/middleware"
 	"cij_api/src/model"
 	"cij_api/src/service"
+	"cij_api/src/utils"
+	"net/http"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -21,6 +25,18 @@ func NewDisabilityController(disabilityService service.DisabilityService) *Disab
 	}
 }
 
+// CreateDisability godoc
+// @Summary Create disabilities
+// @Description Add disabilities to the catalogue. A category and description already registered is rejected
+// @Tags disabilities
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param disabilities body DisabilityPostParameters true "Disabilities"
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /disabilities [post]
 func (c *DisabilityController) CreateDisability(ctx *fiber.Ctx) error {
 	var disabilityRequest DisabilityPostParameters
 	var response model.Response
@@ -41,19 +57,273 @@ func (c *DisabilityController) CreateDisability(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(response)
 	}
 
-	err := c.disabilityService.CreateDisability(disabilityRequest.Disabilities)
+	err := c.disabilityService.CreateDisability(disabilityRequest.Disabilities, middleware.GetAuditActor(ctx))
 	if err.Code != "" {
+		return disabilityErrorResponse(ctx, err)
+	}
+
+	response = model.Response{
+		Message: "Disabilities created successfully",
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(response)
+}
+
+// ListDisabilities godoc
+// @Summary List disabilities
+// @Description List the disability catalogue, optionally only the disabilities of a category
+// @Tags disabilities
+// @Produce json
+// @Param category query string false "Category"
+// @Success 200 {array} model.DisabilityResponse
+// @Failure 500 {object} model.Response
+// @Router /disabilities [get]
+func (c *DisabilityController) ListDisabilities(ctx *fiber.Ctx) error {
+	disabilities, err := c.disabilityService.ListDisabilities(ctx.Query("category"), middleware.GetLanguage(ctx))
+	if err.Code != "" {
+		return disabilityErrorResponse(ctx, err)
+	}
+
+	response := model.Response{
+		Message: "success",
+		Data:    disabilities,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// GetDisability godoc
+// @Summary Get disability
+// @Description Get a disability of the catalogue
+// @Tags disabilities
+// @Produce json
+// @Param id path int true "Disability id"
+// @Success 200 {object} model.DisabilityResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /disabilities/{id} [get]
+func (c *DisabilityController) GetDisability(ctx *fiber.Ctx) error {
+	disabilityId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
 		response := model.Response{
 			Message: err.Error(),
-			Code:    err.GetCode(),
 		}
 
-		return ctx.Status(fiber.StatusBadRequest).JSON(response)
+		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	response = model.Response{
-		Message: "Disabilities created successfully",
+	disability, errDisability := c.disabilityService.GetDisabilityById(disabilityId, middleware.GetLanguage(ctx))
+	if errDisability.Code != "" {
+		return disabilityErrorResponse(ctx, errDisability)
 	}
 
-	return ctx.Status(fiber.StatusCreated).JSON(response)
+	if disability.Id == 0 {
+		return disabilityNotFoundResponse(ctx)
+	}
+
+	response := model.Response{
+		Message: "success",
+		Data:    disability,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// UpdateDisability godoc
+// @Summary Update disability
+// @Description Replace a disability of the catalogue
+// @Tags disabilities
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param id path int true "Disability id"
+// @Param disability body model.DisabilityRequest true "Disability"
+// @Success 200 {object} model.DisabilityResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /disabilities/{id} [put]
+func (c *DisabilityController) UpdateDisability(ctx *fiber.Ctx) error {
+	var disabilityRequest model.DisabilityRequest
+
+	disabilityId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	if err := ctx.BodyParser(&disabilityRequest); err != nil {
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	disability, errDisability := c.disabilityService.UpdateDisability(disabilityRequest, disabilityId, middleware.GetAuditActor(ctx))
+	if errDisability.Code != "" {
+		return disabilityErrorResponse(ctx, errDisability)
+	}
+
+	if disability.Id == 0 {
+		return disabilityNotFoundResponse(ctx)
+	}
+
+	response := model.Response{
+		Message: "Disability updated successfully",
+		Data:    disability,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// DeleteDisability godoc
+// @Summary Delete disability
+// @Description Delete a disability of the catalogue. A disability of people or vacancies is only deleted when replace_with is given, moving them to that disability
+// @Tags disabilities
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param id path int true "Disability id"
+// @Param replace_with query int false "Disability that replaces the deleted one"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.DisabilityUsage
+// @Failure 500 {object} model.Response
+// @Router /disabilities/{id} [delete]
+func (c *DisabilityController) DeleteDisability(ctx *fiber.Ctx) error {
+	disabilityId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	replacementId := 0
+
+	if ctx.Query("replace_with") != "" {
+		replacementId, err = strconv.Atoi(ctx.Query("replace_with"))
+		if err != nil {
+			response := model.Response{
+				Message: err.Error(),
+			}
+
+			return ctx.Status(http.StatusBadRequest).JSON(response)
+		}
+	}
+
+	disability, errDisability := c.disabilityService.GetDisabilityById(disabilityId, "")
+	if errDisability.Code != "" {
+		return disabilityErrorResponse(ctx, errDisability)
+	}
+
+	if disability.Id == 0 {
+		return disabilityNotFoundResponse(ctx)
+	}
+
+	usage, errDelete := c.disabilityService.DeleteDisability(disabilityId, replacementId, middleware.GetAuditActor(ctx))
+	if errDelete.Code != "" {
+		return disabilityErrorResponse(ctx, errDelete)
+	}
+
+	if usage.InUse() {
+		response := model.Response{
+			Message: "disability in use, choose a disability to replace it with replace_with",
+			Data:    usage,
+		}
+
+		return ctx.Status(http.StatusConflict).JSON(response)
+	}
+
+	response := model.Response{
+		Message: "Disability deleted successfully",
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// ListDisabilityCategories godoc
+// @Summary List disability categories
+// @Description List the categories used to group the disabilities in the reports
+// @Tags disabilities
+// @Produce json
+// @Success 200 {array} model.DisabilityCategoryResponse
+// @Failure 500 {object} model.Response
+// @Router /disabilities/categories [get]
+func (c *DisabilityController) ListDisabilityCategories(ctx *fiber.Ctx) error {
+	categories, err := c.disabilityService.ListDisabilityCategories(middleware.GetLanguage(ctx))
+	if err.Code != "" {
+		return disabilityErrorResponse(ctx, err)
+	}
+
+	response := model.Response{
+		Message: "success",
+		Data:    categories,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// CreateDisabilityCategory godoc
+// @Summary Create disability category
+// @Description Add a disability category. The slug is derived from the name when not given
+// @Tags disabilities
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param category body model.DisabilityCategoryRequest true "Category"
+// @Success 201 {object} model.DisabilityCategoryResponse
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /disabilities/categories [post]
+func (c *DisabilityController) CreateDisabilityCategory(ctx *fiber.Ctx) error {
+	var categoryRequest model.DisabilityCategoryRequest
+
+	if err := ctx.BodyParser(&categoryRequest); err != nil {
+		response := model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	category, err := c.disabilityService.CreateDisabilityCategory(categoryRequest, middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return disabilityErrorResponse(ctx, err)
+	}
+
+	response := model.Response{
+		Message: "Disability category created successfully",
+		Data:    category,
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(response)
+}
+
+func disabilityNotFoundResponse(ctx *fiber.Ctx) error {
+	response := model.Response{
+		Message: "disability not found",
+	}
+
+	return ctx.Status(http.StatusNotFound).JSON(response)
+}
+
+func disabilityErrorResponse(ctx *fiber.Ctx, err utils.Error) error {
+	response := model.Response{
+		Message: err.Message,
+		Code:    err.Code,
+		Fields:  err.Fields,
+	}
+
+	if err.IsValidation() {
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	return ctx.Status(http.StatusInternalServerError).JSON(response)
 }
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/storage"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FilesController struct {
+	localStorage *storage.LocalStorage
+}
+
+func NewFilesController(localStorage *storage.LocalStorage) *FilesController {
+	return &FilesController{
+		localStorage: localStorage,
+	}
+}
+
+// GetFile
+// @Summary Download a file kept by the local storage.
+// @Description download a file kept by the local storage. Signed URLs are checked before the file is sent and private files require one.
+// @Tags Files
+// @Produce octet-stream
+// @Param key path string true "File key"
+// @Param expires query string false "Signature expiration"
+// @Param signature query string false "Signature"
+// @Success 200 {file} file
+// @Failure 403 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Router /files/{key} [get]
+func (f *FilesController) GetFile(ctx *fiber.Ctx) error {
+	var response model.Response
+
+	key, err := url.PathUnescape(ctx.Params("*"))
+	if err != nil {
+		response = model.Response{
+			Message: "file not found",
+		}
+
+		return ctx.Status(http.StatusNotFound).JSON(response)
+	}
+
+	signature := ctx.Query("signature")
+	if signature != "" || storage.IsPrivate(key) {
+		if err := f.localStorage.Verify(key, ctx.Query("expires"), signature); err != nil {
+			response = model.Response{
+				Message: err.Error(),
+			}
+
+			return ctx.Status(http.StatusForbidden).JSON(response)
+		}
+	}
+
+	file, err := f.localStorage.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidKey) || errors.Is(err, fs.ErrNotExist) {
+			response = model.Response{
+				Message: "file not found",
+			}
+
+			return ctx.Status(http.StatusNotFound).JSON(response)
+		}
+
+		response = model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		ctx.Set(fiber.HeaderContentType, contentType)
+	}
+
+	return ctx.Status(http.StatusOK).SendStream(file)
+}
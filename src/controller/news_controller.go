@@ -1,14 +1,27 @@
 package controller
 
 import (
+	"cij_api/src/enum"
+	"cij_api/src/middleware"
 	"cij_api/src/model"
 	"cij_api/src/service"
+	"cij_api/src/utils"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
 	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+const maxNewsPerPage = 100
+
+// newsFeedMaxAge is how long, in seconds, readers and proxies may cache a feed.
+const newsFeedMaxAge = 300
+
 type NewsController struct {
 	newsService service.NewsService
 }
@@ -20,28 +33,61 @@ func NewNewsController(newsService service.NewsService) *NewsController {
 }
 
 // ListNews
-// @Summary List all registered news.
-// @Description list all registered news.
+// @Summary List the news.
+// @Description list the published news, newest first. Admins may list the news of any status. The total of news is sent in the X-Total-Count header.
 // @Tags News
 // @Accept application/json
 // @Produce json
+// @Param page query int false "Page, from 1"
+// @Param per_page query int false "News per page, up to 100"
+// @Param sort query string false "'-date' for the newest first or 'date' for the oldest first"
+// @Param status query string false "Status, only for admins"
+// @Param category query string false "Category slug"
+// @Param tag query string false "Tag slug"
+// @Param q query string false "Words of the title or description"
 // @Success 200 {array} model.NewsResponse
-// @Failure 404 {object} string "not found"
-// @Failure 500 {object} string "internal server error"
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
 // @Router /news [get]
 func (n *NewsController) ListNews(ctx *fiber.Ctx) error {
 	var response model.Response
 
-	news, err := n.newsService.ListNews()
-	if err.Code != "" {
-		response = model.Response{
-			Message: err.Error(),
-			Code:    err.Code,
-		}
+	filter := model.NewsFilter{
+		Status:   enum.NewsPublished,
+		Category: ctx.Query("category"),
+		Tag:      ctx.Query("tag"),
+		Search:   ctx.Query("q"),
+		Page:     ctx.QueryInt("page", 1),
+		PerPage:  ctx.QueryInt("per_page", 10),
+	}
+
+	if filter.Page < 1 || filter.PerPage < 1 || filter.PerPage > maxNewsPerPage {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "page must be positive and per_page between 1 and 100",
+		})
+	}
 
-		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	switch ctx.Query("sort", "-date") {
+	case "-date":
+	case "date":
+		filter.Oldest = true
+	default:
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "sort must be 'date' or '-date'",
+		})
 	}
 
+	if middleware.GetTokenRole(ctx) == middleware.ADMIN_ROLE {
+		filter.Status = enum.NewsStatus(ctx.Query("status"))
+	}
+
+	news, total, err := n.newsService.ListNews(filter)
+	if err.Code != "" {
+		return newsErrorResponse(ctx, err)
+	}
+
+	ctx.Set("X-Total-Count", strconv.FormatInt(total, 10))
+
 	response = model.Response{
 		Message: "success",
 		Data:    news,
@@ -50,53 +96,414 @@ func (n *NewsController) ListNews(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(response)
 }
 
+// GetNews
+// @Summary Get a news.
+// @Description get a published news. Admins may get the news of any status.
+// @Tags News
+// @Produce json
+// @Param id path int true "News id"
+// @Success 200 {object} model.NewsResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /news/{id} [get]
+func (n *NewsController) GetNews(ctx *fiber.Ctx) error {
+	newsId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: err.Error(),
+		})
+	}
+
+	news, errNews := n.newsService.GetNewsById(newsId)
+	if errNews.Code != "" {
+		return newsErrorResponse(ctx, errNews)
+	}
+
+	return newsResponse(ctx, news)
+}
+
+// GetNewsBySlug
+// @Summary Get a news by its slug.
+// @Description get a published news by the slug of its title. Admins may get the news of any status.
+// @Tags News
+// @Produce json
+// @Param slug path string true "News slug"
+// @Success 200 {object} model.NewsResponse
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /news/by-slug/{slug} [get]
+func (n *NewsController) GetNewsBySlug(ctx *fiber.Ctx) error {
+	news, err := n.newsService.GetNewsBySlug(ctx.Params("slug"))
+	if err.Code != "" {
+		return newsErrorResponse(ctx, err)
+	}
+
+	return newsResponse(ctx, news)
+}
+
 // CreateNews
 // @Summary Create a new news.
-// @Description create a new news.
+// @Description create a news written by the authenticated admin. Without a status it is published, or scheduled when publish_at is in the future. Images need an alternative text to be published.
 // @Tags News
-// @Accept json
+// @Accept multipart/form-data
 // @Produce json
+// @Param Authorization header string true "Token"
 // @Param news formData model.NewsRequest true "news"
-// @Param banner formData file true "banner"
-// @Param authorImage formData file true "author_image"
-// @Success 201 {object} model.Response
-// @Failure 400 {object} string "bad request"
-// @Failure 500 {object} string "internal server error"
+// @Param banner formData file false "banner"
+// @Param authorImage formData file false "author_image"
+// @Param librasVideo formData file false "libras_video, mp4 or webm"
+// @Param audioNarration formData file false "audio_narration, mp3, wav or ogg"
+// @Success 201 {object} model.NewsResponse
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
 // @Router /news [post]
 func (n *NewsController) CreateNews(ctx *fiber.Ctx) error {
-	var response model.Response
+	request, files, err := parseNewsRequest(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: err.Error(),
+		})
+	}
 
-	form, err := ctx.MultipartForm()
+	news, newsError := n.newsService.CreateNews(request, files, middleware.GetAuditActor(ctx))
+	if newsError.Code != "" {
+		return newsErrorResponse(ctx, newsError)
+	}
+
+	response := model.Response{
+		Message: "success",
+		Data:    news,
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(response)
+}
+
+// UpdateNews
+// @Summary Update a news.
+// @Description replace a news. The files not sent are kept. Images need an alternative text to be published.
+// @Tags News
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param id path int true "News id"
+// @Param news formData model.NewsRequest true "news"
+// @Param banner formData file false "banner"
+// @Param authorImage formData file false "author_image"
+// @Param librasVideo formData file false "libras_video, mp4 or webm"
+// @Param audioNarration formData file false "audio_narration, mp3, wav or ogg"
+// @Success 200 {object} model.NewsResponse
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /news/{id} [put]
+func (n *NewsController) UpdateNews(ctx *fiber.Ctx) error {
+	newsId, err := strconv.Atoi(ctx.Params("id"))
 	if err != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
 			Message: err.Error(),
 		})
 	}
 
-	request := model.NewsRequest{
-		Title:       form.Value["title"][0],
-		Description: form.Value["description"][0],
-		Author:      form.Value["author"][0],
-		Date:        form.Value["date"][0],
+	request, files, err := parseNewsRequest(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: err.Error(),
+		})
+	}
+
+	news, newsError := n.newsService.UpdateNews(request, newsId, files, middleware.GetAuditActor(ctx))
+	if newsError.Code != "" {
+		return newsErrorResponse(ctx, newsError)
+	}
+
+	if news.Id == 0 {
+		return newsNotFoundResponse(ctx)
+	}
+
+	response := model.Response{
+		Message: "News updated successfully",
+		Data:    news,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+// DeleteNews
+// @Summary Delete a news.
+// @Description delete a news. Its slug is not reused.
+// @Tags News
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param id path int true "News id"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /news/{id} [delete]
+func (n *NewsController) DeleteNews(ctx *fiber.Ctx) error {
+	newsId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: err.Error(),
+		})
+	}
+
+	news, errNews := n.newsService.GetNewsById(newsId)
+	if errNews.Code != "" {
+		return newsErrorResponse(ctx, errNews)
+	}
+
+	if news.Id == 0 {
+		return newsNotFoundResponse(ctx)
+	}
+
+	if err := n.newsService.DeleteNews(newsId, middleware.GetAuditActor(ctx)); err.Code != "" {
+		return newsErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "News deleted successfully",
+	})
+}
+
+// ListNewsCategories
+// @Summary List the news categories.
+// @Description list the categories of the news, each one with its own feeds.
+// @Tags News
+// @Produce json
+// @Success 200 {array} model.NewsCategoryResponse
+// @Failure 500 {object} model.Response
+// @Router /news/categories [get]
+func (n *NewsController) ListNewsCategories(ctx *fiber.Ctx) error {
+	categories, err := n.newsService.ListNewsCategories()
+	if err.Code != "" {
+		return newsErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "success",
+		Data:    categories,
+	})
+}
+
+// CreateNewsCategory
+// @Summary Create a news category.
+// @Description create a news category. The slug is derived from the name when not given.
+// @Tags News
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param category body model.NewsCategoryRequest true "category"
+// @Success 201 {object} model.NewsCategoryResponse
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /news/categories [post]
+func (n *NewsController) CreateNewsCategory(ctx *fiber.Ctx) error {
+	var request model.NewsCategoryRequest
+
+	if err := ctx.BodyParser(&request); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: err.Error(),
+		})
+	}
+
+	category, err := n.newsService.CreateNewsCategory(request, middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return newsErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(model.Response{
+		Message: "success",
+		Data:    category,
+	})
+}
+
+// ListTags
+// @Summary List the news tags.
+// @Description list the tags used by the news, by name.
+// @Tags News
+// @Produce json
+// @Success 200 {array} model.TagResponse
+// @Failure 500 {object} model.Response
+// @Router /news/tags [get]
+func (n *NewsController) ListTags(ctx *fiber.Ctx) error {
+	tags, err := n.newsService.ListTags()
+	if err.Code != "" {
+		return newsErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "success",
+		Data:    tags,
+	})
+}
+
+// GetNewsRSS
+// @Summary Get the RSS feed of the news.
+// @Description get an RSS 2.0 feed of the latest published news, of every category or of the category in the path.
+// @Tags News
+// @Produce application/rss+xml
+// @Param slug path string false "Category slug"
+// @Success 200 {string} string
+// @Success 304 {string} string
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /news/feed.rss [get]
+// @Router /news/categories/{slug}/feed.rss [get]
+func (n *NewsController) GetNewsRSS(ctx *fiber.Ctx) error {
+	return n.newsFeed(ctx, "application/rss+xml", func(feed model.NewsFeed, links model.FeedLinks) interface{} {
+		return feed.ToRSS(links)
+	})
+}
+
+// GetNewsAtom
+// @Summary Get the Atom feed of the news.
+// @Description get an Atom feed of the latest published news, of every category or of the category in the path.
+// @Tags News
+// @Produce application/atom+xml
+// @Param slug path string false "Category slug"
+// @Success 200 {string} string
+// @Success 304 {string} string
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /news/feed.atom [get]
+// @Router /news/categories/{slug}/feed.atom [get]
+func (n *NewsController) GetNewsAtom(ctx *fiber.Ctx) error {
+	return n.newsFeed(ctx, "application/atom+xml", func(feed model.NewsFeed, links model.FeedLinks) interface{} {
+		return feed.ToAtom(links)
+	})
+}
+
+// newsFeed writes the feed of the category in the path, or of every category,
+// with the caching headers. Readers that already have the feed get a 304.
+func (n *NewsController) newsFeed(ctx *fiber.Ctx, contentType string, build func(model.NewsFeed, model.FeedLinks) interface{}) error {
+	slug := ctx.Params("slug")
+
+	feed, err := n.newsService.GetNewsFeed(slug)
+	if err.Code != "" {
+		return newsErrorResponse(ctx, err)
+	}
+
+	if slug != "" && feed.Category == nil {
+		return ctx.Status(http.StatusNotFound).JSON(model.Response{
+			Message: "news category not found",
+		})
+	}
+
+	baseUrl := ctx.BaseURL()
+	links := model.FeedLinks{
+		Site: baseUrl + "/news",
+		Self: baseUrl + ctx.OriginalURL(),
+		NewsLink: func(news model.NewsResponse) string {
+			return baseUrl + "/news/by-slug/" + news.Slug
+		},
+	}
+
+	body, marshalErr := xml.MarshalIndent(build(feed, links), "", "  ")
+	if marshalErr != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(model.Response{
+			Message: marshalErr.Error(),
+		})
+	}
+
+	body = append([]byte(xml.Header), body...)
+	hash := sha1.Sum(body)
+
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(newsFeedMaxAge))
+	ctx.Set(fiber.HeaderETag, `W/"`+hex.EncodeToString(hash[:])+`"`)
+
+	if updated := feed.Updated(); !updated.IsZero() {
+		ctx.Set(fiber.HeaderLastModified, updated.UTC().Format(http.TimeFormat))
+	}
+
+	if ctx.Fresh() {
+		return ctx.SendStatus(http.StatusNotModified)
 	}
 
+	ctx.Set(fiber.HeaderContentType, contentType+"; charset=utf-8")
+
+	return ctx.Status(http.StatusOK).Send(body)
+}
+
+// parseNewsRequest reads the news from a multipart form, with its images, or
+// from a JSON body.
+func parseNewsRequest(ctx *fiber.Ctx) (model.NewsRequest, map[string]multipart.FileHeader, error) {
+	var request model.NewsRequest
 	files := make(map[string]multipart.FileHeader)
 
+	if !strings.HasPrefix(ctx.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		err := ctx.BodyParser(&request)
+
+		return request, files, err
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return request, files, err
+	}
+
+	request = model.NewsRequest{
+		Title:       ctx.FormValue("title"),
+		Description: ctx.FormValue("description"),
+		Status:      enum.NewsStatus(ctx.FormValue("status")),
+		PublishAt:   ctx.FormValue("publish_at"),
+		Category:    ctx.FormValue("category"),
+
+		BannerAlt:             ctx.FormValue("banner_alt"),
+		BannerCaption:         ctx.FormValue("banner_caption"),
+		BannerLongDescription: ctx.FormValue("banner_long_description"),
+		AuthorImageAlt:        ctx.FormValue("author_image_alt"),
+	}
+
+	if tags := ctx.FormValue("tags"); tags != "" {
+		request.Tags = strings.Split(tags, ",")
+	}
+
 	for filename, file := range form.File {
 		files[filename] = *file[0]
 	}
 
-	newsError := n.newsService.CreateNews(request, files)
-	if newsError.Code != "" {
-		return ctx.Status(http.StatusInternalServerError).JSON(model.Response{
-			Message: newsError.Error(),
-			Code:    newsError.Code,
-		})
+	return request, files, nil
+}
+
+// newsResponse answers with the news, hiding the unpublished ones from the
+// users that are not admins.
+func newsResponse(ctx *fiber.Ctx, news model.NewsResponse) error {
+	if news.Id == 0 {
+		return newsNotFoundResponse(ctx)
 	}
 
-	response = model.Response{
+	if news.Status != enum.NewsPublished && middleware.GetTokenRole(ctx) != middleware.ADMIN_ROLE {
+		return newsNotFoundResponse(ctx)
+	}
+
+	response := model.Response{
 		Message: "success",
+		Data:    news,
 	}
 
-	return ctx.Status(http.StatusCreated).JSON(response)
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
+func newsNotFoundResponse(ctx *fiber.Ctx) error {
+	response := model.Response{
+		Message: "news not found",
+	}
+
+	return ctx.Status(http.StatusNotFound).JSON(response)
+}
+
+func newsErrorResponse(ctx *fiber.Ctx, err utils.Error) error {
+	response := model.Response{
+		Message: err.Message,
+		Code:    err.Code,
+		Fields:  err.Fields,
+	}
+
+	if err.IsValidation() {
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	return ctx.Status(http.StatusInternalServerError).JSON(response)
 }
@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/export"
+	"cij_api/src/i18n"
+	"cij_api/src/middleware"
+	"cij_api/src/model"
+	"cij_api/src/service"
+	"cij_api/src/utils"
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const openDataCatalogueTitle = "Conexão Inclusão Jaraguá open data"
+
+type OpenDataController struct {
+	openDataService service.OpenDataService
+}
+
+func NewOpenDataController(openDataService service.OpenDataService) *OpenDataController {
+	return &OpenDataController{
+		openDataService: openDataService,
+	}
+}
+
+func openDataControllerError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ControllerErrorCode, utils.OpenDataErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+// ListDatasets
+// @Summary List the open datasets
+// @Description the catalogue of the anonymised statistics published as open data, with the data dictionary, license, version, last update and download links of each dataset. The datasets are updated once a day.
+// @Tags Open data
+// @Produce json
+// @Success 200 {object} model.OpenDataCatalogue
+// @Failure 500 {object} model.Response
+// @Router /open-data [get]
+func (c *OpenDataController) ListDatasets(ctx *fiber.Ctx) error {
+	datasets, err := c.openDataService.ListDatasets()
+	if err.Code != "" {
+		return ctx.Status(http.StatusInternalServerError).JSON(model.Response{
+			Message: err.Message,
+			Code:    err.Code,
+		})
+	}
+
+	language := middleware.GetLanguage(ctx)
+
+	for i := range datasets {
+		datasets[i] = translateDataset(datasets[i], language)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "success",
+		Data: model.OpenDataCatalogue{
+			Title:    i18n.Translate(language, openDataCatalogueTitle),
+			License:  service.OpenDataLicense,
+			Datasets: datasets,
+		},
+	})
+}
+
+// GetDataset
+// @Summary Download an open dataset
+// @Description the rows of the dataset as CSV, or as JSON records along with the dataset metadata. The counts small enough to identify someone, and the ones that would reveal them, are suppressed: null in JSON, empty in CSV.
+// @Tags Open data
+// @Produce json,text/csv
+// @Param id path string true "Dataset id"
+// @Param format query string false "'json' (default) or 'csv', or negotiated by the Accept header"
+// @Success 200 {object} model.OpenDataDocument
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /open-data/{id} [get]
+func (c *OpenDataController) GetDataset(ctx *fiber.Ctx) error {
+	format, ok := openDataFormat(ctx)
+	if !ok {
+		return openDataFormatErrorResponse(ctx)
+	}
+
+	data, ok := c.getDataset(ctx)
+	if !ok {
+		return nil
+	}
+
+	if format == export.CSV {
+		table := export.Table{Rows: data.Rows}
+		for _, field := range data.Dataset.Fields {
+			table.Columns = append(table.Columns, field.Name)
+		}
+
+		return sendOpenDataCSV(ctx, data.Dataset, data.Dataset.Id, table)
+	}
+
+	records := []map[string]interface{}{}
+
+	for _, row := range data.Rows {
+		record := map[string]interface{}{}
+		for i, field := range data.Dataset.Fields {
+			record[field.Name] = row[i]
+		}
+
+		records = append(records, record)
+	}
+
+	setOpenDataHeaders(ctx, data.Dataset)
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "success",
+		Data: model.OpenDataDocument{
+			Dataset: translateDataset(data.Dataset, middleware.GetLanguage(ctx)),
+			Records: records,
+		},
+	})
+}
+
+// GetDatasetDictionary
+// @Summary Download the data dictionary of an open dataset
+// @Description the name, type, description and whether each field of the dataset may be empty.
+// @Tags Open data
+// @Produce json,text/csv
+// @Param id path string true "Dataset id"
+// @Param format query string false "'json' (default) or 'csv', or negotiated by the Accept header"
+// @Success 200 {array} model.OpenDataField
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /open-data/{id}/dictionary [get]
+func (c *OpenDataController) GetDatasetDictionary(ctx *fiber.Ctx) error {
+	format, ok := openDataFormat(ctx)
+	if !ok {
+		return openDataFormatErrorResponse(ctx)
+	}
+
+	data, ok := c.getDataset(ctx)
+	if !ok {
+		return nil
+	}
+
+	dataset := translateDataset(data.Dataset, middleware.GetLanguage(ctx))
+
+	if format == export.CSV {
+		table := export.Table{Columns: []string{"name", "type", "description", "nullable"}}
+		for _, field := range dataset.Fields {
+			table.Rows = append(table.Rows, []interface{}{field.Name, field.Type, field.Description, fmt.Sprint(field.Nullable)})
+		}
+
+		return sendOpenDataCSV(ctx, dataset, dataset.Id+"-dictionary", table)
+	}
+
+	setOpenDataHeaders(ctx, dataset)
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "success",
+		Data:    dataset.Fields,
+	})
+}
+
+// getDataset answers the errors itself, returning false when it did.
+func (c *OpenDataController) getDataset(ctx *fiber.Ctx) (model.OpenData, bool) {
+	data, err := c.openDataService.GetDataset(ctx.Params("id"))
+	if err.Code != "" {
+		ctx.Status(http.StatusInternalServerError).JSON(model.Response{
+			Message: err.Message,
+			Code:    err.Code,
+		})
+
+		return data, false
+	}
+
+	if data.Dataset.Id == "" {
+		ctx.Status(http.StatusNotFound).JSON(model.Response{
+			Message: "dataset not found",
+			Code:    openDataControllerError("dataset not found", "02").GetCode(),
+		})
+
+		return data, false
+	}
+
+	return data, true
+}
+
+// openDataFormat picks JSON or CSV from the format query, or else from the
+// Accept header.
+func openDataFormat(ctx *fiber.Ctx) (string, bool) {
+	ctx.Vary(fiber.HeaderAccept)
+
+	if format := ctx.Query("format"); format != "" {
+		return format, format == jsonFormat || format == export.CSV
+	}
+
+	if ctx.Accepts(fiber.MIMEApplicationJSON, export.MIMETypes[export.CSV]) == export.MIMETypes[export.CSV] {
+		return export.CSV, true
+	}
+
+	return jsonFormat, true
+}
+
+func openDataFormatErrorResponse(ctx *fiber.Ctx) error {
+	return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+		Message: "format must be 'json' or 'csv'",
+		Code:    openDataControllerError("invalid format", "01").GetCode(),
+	})
+}
+
+// setOpenDataHeaders tells the version and the last update of the dataset,
+// so the clients can cache it.
+func setOpenDataHeaders(ctx *fiber.Ctx, dataset model.OpenDataset) {
+	ctx.Set(fiber.HeaderLastModified, dataset.UpdatedAt.UTC().Format(http.TimeFormat))
+	ctx.Set(fiber.HeaderETag, fmt.Sprintf(`"%s-%s-%d"`, dataset.Id, dataset.Version, dataset.UpdatedAt.Unix()))
+	ctx.Set(fiber.HeaderLink, fmt.Sprintf(`<%s>; rel="license"`, dataset.License.URL))
+}
+
+func sendOpenDataCSV(ctx *fiber.Ctx, dataset model.OpenDataset, name string, table export.Table) error {
+	setOpenDataHeaders(ctx, dataset)
+	ctx.Set(fiber.HeaderContentType, export.MIMETypes[export.CSV])
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-v%s.csv"`, name, dataset.Version))
+
+	if err := export.WriteCSV(ctx.Response().BodyWriter(), table); err != nil {
+		ctx.Response().ResetBody()
+
+		return ctx.Status(http.StatusInternalServerError).JSON(model.Response{
+			Message: "failed to write the dataset",
+			Code:    openDataControllerError("failed to write the dataset", "03").GetCode(),
+		})
+	}
+
+	ctx.Status(http.StatusOK)
+
+	return nil
+}
+
+// translateDataset translates the title and descriptions of the dataset. The
+// requests without an Accept-Language header get them as they are.
+func translateDataset(dataset model.OpenDataset, language enum.LanguageEnum) model.OpenDataset {
+	if language == "" {
+		return dataset
+	}
+
+	dataset.Title = i18n.Translate(language, dataset.Title)
+	dataset.Description = i18n.Translate(language, dataset.Description)
+
+	fields := make([]model.OpenDataField, len(dataset.Fields))
+	for i, field := range dataset.Fields {
+		field.Description = i18n.Translate(language, field.Description)
+		fields[i] = field
+	}
+
+	dataset.Fields = fields
+
+	return dataset
+}
@@ -1,12 +1,14 @@
 package controller
 
 import (
+	"cij_api/src/middleware"
 	"cij_api/src/model"
 	"cij_api/src/service"
 	"cij_api/src/utils"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,12 +18,14 @@ type MessageResponse struct {
 }
 
 type PersonController struct {
-	personService service.PersonService
+	personService     service.PersonService
+	curriculumService service.CurriculumService
 }
 
-func NewPersonController(personService service.PersonService) *PersonController {
+func NewPersonController(personService service.PersonService, curriculumService service.CurriculumService) *PersonController {
 	return &PersonController{
-		personService: personService,
+		personService:     personService,
+		curriculumService: curriculumService,
 	}
 }
 
@@ -103,7 +107,7 @@ func (n *PersonController) CreatePerson(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	if err := n.personService.CreatePerson(personRequest); err.Code != "" {
+	if err := n.personService.CreatePerson(personRequest, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.GetCode(),
@@ -142,6 +146,10 @@ func (n *PersonController) ListPeople(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusInternalServerError).JSON(response)
 	}
 
+	for i := range people {
+		people[i] = people[i].WithoutPrivateData()
+	}
+
 	response = model.Response{
 		Message: "success",
 		Data:    people,
@@ -194,7 +202,7 @@ func (n *PersonController) GetPerson(ctx *fiber.Ctx) error {
 
 	response = model.Response{
 		Message: "success",
-		Data:    person,
+		Data:    person.WithoutPrivateData(),
 	}
 
 	return ctx.Status(http.StatusOK).JSON(response)
@@ -252,6 +260,21 @@ func (n *PersonController) UpdatePerson(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusNotFound).JSON(response)
 	}
 
+	if !validBirthDate(personRequest.BirthDate) {
+		errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.PersonErrorType, "02")
+		err := utils.NewErrorWithFields("invalid fields", errorCode, []model.Field{
+			{Name: "birth_date", Value: "birth date is not valid"},
+		})
+
+		response = model.Response{
+			Message: err.Error(),
+			Code:    err.GetCode(),
+			Fields:  err.GetFields(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
 	// TODO: Validate only the passed fields
 	// if err := n.validatePerson(personRequest); err.Code != "" {
 	// 	response = model.Response{
@@ -263,7 +286,7 @@ func (n *PersonController) UpdatePerson(ctx *fiber.Ctx) error {
 	// 	return ctx.Status(http.StatusBadRequest).JSON(response)
 	// }
 
-	if err := n.personService.UpdatePerson(personRequest, idInt); err.Code != "" {
+	if err := n.personService.UpdatePerson(personRequest, idInt, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.GetCode(),
@@ -331,7 +354,7 @@ func (n *PersonController) UpdatePersonAddress(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusNotFound).JSON(response)
 	}
 
-	if err := n.personService.UpdatePersonAddress(addressRequest, idInt, nil); err.Code != "" {
+	if err := n.personService.UpdatePersonAddress(addressRequest, idInt, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.GetCode(),
@@ -408,7 +431,7 @@ func (n *PersonController) UpdatePersonDisabilities(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	if err := n.personService.UpdatePersonDisabilities(disabilities, idInt, nil); err.Code != "" {
+	if err := n.personService.UpdatePersonDisabilities(disabilities, idInt, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.GetCode(),
@@ -466,7 +489,7 @@ func (n *PersonController) DeletePerson(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusNotFound).JSON(response)
 	}
 
-	if err := n.personService.DeletePerson(idInt); err.Code != "" {
+	if err := n.personService.DeletePerson(idInt, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.GetCode(),
@@ -484,7 +507,7 @@ func (n *PersonController) DeletePerson(ctx *fiber.Ctx) error {
 
 // UploadCurriculum
 // @Summary Upload a person curriculum.
-// @Description upload a curriculum for a person.
+// @Description upload a curriculum for a person. Allowed for the person and the admins.
 // @Tags People
 // @Accept multipart/form-data
 // @Produce json
@@ -492,6 +515,7 @@ func (n *PersonController) DeletePerson(ctx *fiber.Ctx) error {
 // @Param file formData file true "Curriculum"
 // @Success 200 {object} model.Response
 // @Failure 400 {object} utils.Error
+// @Failure 403 {object} model.Response
 // @Failure 500 {object} model.Response
 // @Router /people/:id/curriculum [post]
 func (n *PersonController) UploadCurriculum(ctx *fiber.Ctx) error {
@@ -534,7 +558,25 @@ func (n *PersonController) UploadCurriculum(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusNotFound).JSON(response)
 	}
 
-	if err := n.personService.UploadCurriculum(*file, idInt); err.Code != "" {
+	requester, errUser := n.personService.GetUserByEmail(middleware.GetTokenEmail(ctx))
+	if errUser.Code != "" {
+		response = model.Response{
+			Message: errUser.Error(),
+			Code:    errUser.Code,
+		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	}
+
+	if !n.curriculumService.CanUploadCurriculum(person, requester) {
+		response = model.Response{
+			Message: "not allowed to upload the curriculum",
+		}
+
+		return ctx.Status(http.StatusForbidden).JSON(response)
+	}
+
+	if err := n.curriculumService.UploadCurriculum(*file, idInt, middleware.GetAuditActor(ctx)); err.Code != "" {
 		response = model.Response{
 			Message: err.Error(),
 			Code:    err.GetCode(),
@@ -550,6 +592,103 @@ func (n *PersonController) UploadCurriculum(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(response)
 }
 
+// DownloadCurriculum
+// @Summary Get the download URL of a person curriculum.
+// @Description get a short-lived URL to download the curriculum. Allowed for the person, the admins and the companies the person applied to.
+// @Tags People
+// @Produce json
+// @Param id path string true "Person ID"
+// @Param Authorization header string true "Token"
+// @Success 200 {object} model.CurriculumUrlResponse
+// @Failure 400 {object} model.Response
+// @Failure 403 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /people/:id/curriculum [get]
+func (n *PersonController) DownloadCurriculum(ctx *fiber.Ctx) error {
+	var response model.Response
+
+	idInt, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		response = model.Response{
+			Message: err.Error(),
+		}
+
+		return ctx.Status(http.StatusBadRequest).JSON(response)
+	}
+
+	person, errPerson := n.personService.GetPersonById(idInt)
+	if errPerson.Code != "" {
+		response = model.Response{
+			Message: errPerson.Error(),
+			Code:    errPerson.Code,
+		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	}
+
+	if person.Id == 0 {
+		response = model.Response{
+			Message: "person not found",
+		}
+
+		return ctx.Status(http.StatusNotFound).JSON(response)
+	}
+
+	requester, errUser := n.personService.GetUserByEmail(middleware.GetTokenEmail(ctx))
+	if errUser.Code != "" {
+		response = model.Response{
+			Message: errUser.Error(),
+			Code:    errUser.Code,
+		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	}
+
+	allowed, errAccess := n.curriculumService.CanDownloadCurriculum(person, requester)
+	if errAccess.Code != "" {
+		response = model.Response{
+			Message: errAccess.Error(),
+			Code:    errAccess.Code,
+		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	}
+
+	if !allowed {
+		response = model.Response{
+			Message: "not allowed to download the curriculum",
+		}
+
+		return ctx.Status(http.StatusForbidden).JSON(response)
+	}
+
+	curriculumUrl, errUrl := n.curriculumService.GetCurriculumUrl(person, requester)
+	if errUrl.Code != "" {
+		response = model.Response{
+			Message: errUrl.Error(),
+			Code:    errUrl.Code,
+		}
+
+		return ctx.Status(http.StatusInternalServerError).JSON(response)
+	}
+
+	if curriculumUrl.Url == "" {
+		response = model.Response{
+			Message: "curriculum not found",
+		}
+
+		return ctx.Status(http.StatusNotFound).JSON(response)
+	}
+
+	response = model.Response{
+		Message: "success",
+		Data:    curriculumUrl,
+	}
+
+	return ctx.Status(http.StatusOK).JSON(response)
+}
+
 func validatePersonRequiredFields(personRequest model.PersonRequest) utils.Error {
 	fieldsWithErrors := []model.Field{}
 
@@ -578,6 +717,18 @@ func validatePersonRequiredFields(personRequest model.PersonRequest) utils.Error
 	return utils.Error{}
 }
 
+// validBirthDate accepts an empty birth date, as it is optional, or a past
+// date in the YYYY-MM-DD format.
+func validBirthDate(birthDate string) bool {
+	if birthDate == "" {
+		return true
+	}
+
+	date, err := time.Parse(time.DateOnly, birthDate)
+
+	return err == nil && date.Year() >= 1900 && date.Before(time.Now())
+}
+
 func (c *PersonController) validatePerson(personRequest model.PersonRequest) utils.Error {
 	fieldsWithErrors := []model.Field{}
 
@@ -602,6 +753,10 @@ func (c *PersonController) validatePerson(personRequest model.PersonRequest) uti
 		fieldsWithErrors = append(fieldsWithErrors, model.Field{Name: "gender", Value: "gender is not valid"})
 	}
 
+	if !validBirthDate(personRequest.BirthDate) {
+		fieldsWithErrors = append(fieldsWithErrors, model.Field{Name: "birth_date", Value: "birth date is not valid"})
+	}
+
 	user, err := c.personService.GetUserByEmail(personRequest.User.Email)
 	if err.Code != "" {
 		return err
@@ -611,6 +766,17 @@ func (c *PersonController) validatePerson(personRequest model.PersonRequest) uti
 		return personControllerError("email already registered", "03", nil)
 	}
 
+	if personRequest.ConfigPreset != "" {
+		preset, err := c.personService.GetConfigPresetBySlug(personRequest.ConfigPreset)
+		if err.Code != "" {
+			return err
+		}
+
+		if preset.Id == 0 {
+			fieldsWithErrors = append(fieldsWithErrors, model.Field{Name: "config_preset", Value: "config preset not found"})
+		}
+	}
+
 	if len(fieldsWithErrors) > 0 {
 		errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.PersonErrorType, "02")
 
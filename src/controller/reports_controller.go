@@ -7,17 +7,31 @@ import (
 	"cij_api/src/utils"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// defaultReportsTimezone aligns the report buckets when no timezone is given.
+// The tzdata import keeps the timezones loadable on images without them.
+const defaultReportsTimezone = "America/Sao_Paulo"
+
+// ReportsController serves the reports with the counts protected by its
+// privacy, the public routes suppressing the small ones and the admin routes
+// leaving them exact.
 type ReportsController struct {
-	reportsService service.ReportsService
+	reportsService        service.ReportsService
+	reportSnapshotService service.ReportSnapshotService
+	privacy               service.ReportsPrivacy
 }
 
-func NewReportsController(reportsService service.ReportsService) *ReportsController {
+func NewReportsController(reportsService service.ReportsService, reportSnapshotService service.ReportSnapshotService, privacy service.ReportsPrivacy) *ReportsController {
 	return &ReportsController{
-		reportsService: reportsService,
+		reportsService:        reportsService,
+		reportSnapshotService: reportSnapshotService,
+		privacy:               privacy,
 	}
 }
 
@@ -32,8 +46,9 @@ func reportsControllerError(message string, code string) utils.Error {
 // @Description Get disability totals
 // @Tags Reports
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
 // @Success 200 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
 // @Router /disabilities [get]
 func (c *ReportsController) GetDisabilityTotals(ctx *fiber.Ctx) error {
 	var response model.Response
@@ -48,12 +63,9 @@ func (c *ReportsController) GetDisabilityTotals(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusInternalServerError).JSON(response)
 	}
 
-	response = model.Response{
-		Message: "Disability totals",
-		Data:    disabilityTotals,
-	}
+	disabilityTotals = c.privacy.DisabilityTotals(disabilityTotals)
 
-	return ctx.Status(http.StatusOK).JSON(response)
+	return c.sendReport(ctx, "disability-totals", "Disability totals", disabilityTotals, disabilityTotalsTable(disabilityTotals, ""))
 }
 
 // GetDisabilityTotalsByNeighborhood
@@ -61,9 +73,10 @@ func (c *ReportsController) GetDisabilityTotals(ctx *fiber.Ctx) error {
 // @Description Get disability totals by neighborhood
 // @Tags Reports
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
 // @Param neighborhood path string true "Neighborhood"
 // @Success 200 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
 // @Router /disabilities/{neighborhood} [get]
 func (c *ReportsController) GetDisabilityTotalsByNeighborhood(ctx *fiber.Ctx) error {
 	var response model.Response
@@ -98,12 +111,9 @@ func (c *ReportsController) GetDisabilityTotalsByNeighborhood(ctx *fiber.Ctx) er
 		return ctx.Status(http.StatusInternalServerError).JSON(response)
 	}
 
-	response = model.Response{
-		Message: "Disability totals by neighborhood: " + neighborhood,
-		Data:    disabilityTotals,
-	}
+	disabilityTotals = c.privacy.DisabilityTotals(disabilityTotals)
 
-	return ctx.Status(http.StatusOK).JSON(response)
+	return c.sendReport(ctx, "disability-totals", neighborhoodTotalsTitle, disabilityTotals, disabilityTotalsTable(disabilityTotals, neighborhood), neighborhood)
 }
 
 // CountActivitiesByPeriod
@@ -111,10 +121,11 @@ func (c *ReportsController) GetDisabilityTotalsByNeighborhood(ctx *fiber.Ctx) er
 // @Description Count activities by period
 // @Tags Reports
 // @Accept json
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
 // @Param type path string true "Type"
 // @Param period path string true "Period"
 // @Success 200 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
 // @Router /activities/{type}/{period} [get]
 func (c *ReportsController) CountActivitiesByPeriod(ctx *fiber.Ctx) error {
 	var response model.Response
@@ -141,10 +152,331 @@ func (c *ReportsController) CountActivitiesByPeriod(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusInternalServerError).JSON(response)
 	}
 
-	response = model.Response{
-		Message: "Activities counted by period",
-		Data:    countActivitiesByPeriod,
+	countActivitiesByPeriod = c.privacy.ActivitiesByPeriod(countActivitiesByPeriod)
+
+	return c.sendReport(ctx, "activities", "Activities counted by period", countActivitiesByPeriod, activitiesByPeriodTable(countActivitiesByPeriod))
+}
+
+// GetActivityReport
+// @Summary Count activities per bucket of time
+// @Description count the activities of one or more types in the period per day, week, month, quarter or year of the timezone, optionally against the previous period with as many buckets.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param types query string true "Comma separated activity types, up to 10"
+// @Param from query string false "Start of the period, a date or RFC 3339 (default one year before to)"
+// @Param to query string false "End of the period, a date (inclusive) or RFC 3339 (exclusive), default now"
+// @Param granularity query string false "'day', 'week', 'month' (default), 'quarter' or 'year'"
+// @Param timezone query string false "IANA timezone the buckets are aligned to (default America/Sao_Paulo)"
+// @Param compare query bool false "Also count the previous equivalent period"
+// @Success 200 {object} model.ActivityReport
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/activities [get]
+func (c *ReportsController) GetActivityReport(ctx *fiber.Ctx) error {
+	location, err := time.LoadLocation(ctx.Query("timezone", defaultReportsTimezone))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "invalid timezone",
+			Code:    reportsControllerError("invalid timezone", "05").GetCode(),
+		})
+	}
+
+	to := time.Now().In(location)
+	if ctx.Query("to") != "" {
+		if to, err = parseReportDate(ctx.Query("to"), location, true); err != nil {
+			return reportDateErrorResponse(ctx)
+		}
+	}
+
+	from := to.AddDate(-1, 0, 0)
+	if ctx.Query("from") != "" {
+		if from, err = parseReportDate(ctx.Query("from"), location, false); err != nil {
+			return reportDateErrorResponse(ctx)
+		}
+	}
+
+	filter := model.ActivityReportFilter{
+		Types:       []string{},
+		From:        from,
+		To:          to,
+		Granularity: enum.ReportGranularity(ctx.Query("granularity", string(enum.Month))),
+		Compare:     ctx.QueryBool("compare"),
+	}
+
+	requested := map[string]bool{}
+
+	for _, activityType := range strings.Split(ctx.Query("types"), ",") {
+		activityType = strings.TrimSpace(activityType)
+		if activityType != "" && !requested[activityType] {
+			requested[activityType] = true
+			filter.Types = append(filter.Types, activityType)
+		}
+	}
+
+	report, reportErr := c.reportsService.GetActivityReport(filter)
+	if reportErr.Code != "" {
+		return reportsErrorResponse(ctx, reportErr)
+	}
+
+	report = c.privacy.ActivityReport(report)
+
+	return c.sendReport(ctx, "activities", "Activities counted by period", report, activityReportTable(report))
+}
+
+// parseReportDate parses an RFC 3339 date, or a day of the location. As the
+// end of the period, a day includes all of it.
+func parseReportDate(value string, location *time.Location, end bool) (time.Time, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, value, location); err == nil {
+		if end {
+			return date.AddDate(0, 0, 1), nil
+		}
+
+		return date, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return date.In(location), nil
+}
+
+func reportDateErrorResponse(ctx *fiber.Ctx) error {
+	return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+		Message: "from and to must be dates or RFC 3339 dates",
+		Code:    reportsControllerError("invalid date", "06").GetCode(),
+	})
+}
+
+// CountVacanciesByCategory
+// @Summary Count the vacancies per disability category
+// @Description count the vacancies open in the period for each disability category, of the companies of the neighborhood. The vacancies are public, so their counts are never suppressed.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param from query string false "Start of the period, a date or RFC 3339"
+// @Param to query string false "End of the period, a date (inclusive) or RFC 3339 (exclusive)"
+// @Param timezone query string false "IANA timezone of the dates (default America/Sao_Paulo)"
+// @Param neighborhood query string false "Neighborhood of the companies"
+// @Success 200 {array} model.VacancyCategoryTotal
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/employment/vacancies [get]
+func (c *ReportsController) CountVacanciesByCategory(ctx *fiber.Ctx) error {
+	filter, ok := parseEmploymentReportFilter(ctx)
+	if !ok {
+		return nil
+	}
+
+	totals, err := c.reportsService.CountVacanciesByCategory(filter)
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	return c.sendReport(ctx, "vacancies", "Vacancies counted by disability category", totals, vacanciesByCategoryTable(totals))
+}
+
+// CountAppliesByVacancy
+// @Summary Count the applies per vacancy
+// @Description count the applies made in the period to each vacancy, with how many were accepted and rejected, by the candidates of the neighborhood.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param from query string false "Start of the period, a date or RFC 3339"
+// @Param to query string false "End of the period, a date (inclusive) or RFC 3339 (exclusive)"
+// @Param timezone query string false "IANA timezone of the dates (default America/Sao_Paulo)"
+// @Param neighborhood query string false "Neighborhood of the candidates"
+// @Success 200 {array} model.VacancyApplyTotals
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/employment/applies [get]
+func (c *ReportsController) CountAppliesByVacancy(ctx *fiber.Ctx) error {
+	filter, ok := parseEmploymentReportFilter(ctx)
+	if !ok {
+		return nil
+	}
+
+	totals, err := c.reportsService.CountAppliesByVacancy(filter)
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	totals = c.privacy.AppliesByVacancy(totals)
+
+	return c.sendReport(ctx, "applies", "Applies counted by vacancy", totals, appliesByVacancyTable(totals))
+}
+
+// GetAcceptanceRates
+// @Summary Get the acceptance rates of the applies
+// @Description get the share of the applies made in the period that were accepted, per disability category of the candidate, company or area of the vacancy.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param group_by query string false "'category' (default), 'company' or 'area'"
+// @Param from query string false "Start of the period, a date or RFC 3339"
+// @Param to query string false "End of the period, a date (inclusive) or RFC 3339 (exclusive)"
+// @Param timezone query string false "IANA timezone of the dates (default America/Sao_Paulo)"
+// @Param neighborhood query string false "Neighborhood of the candidates"
+// @Success 200 {array} model.AcceptanceRate
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/employment/acceptance-rates [get]
+func (c *ReportsController) GetAcceptanceRates(ctx *fiber.Ctx) error {
+	filter, ok := parseEmploymentReportFilter(ctx)
+	if !ok {
+		return nil
+	}
+
+	group := enum.AcceptanceRateGroup(ctx.Query("group_by", string(enum.ByDisabilityCategory)))
+
+	rates, err := c.reportsService.GetAcceptanceRates(filter, group)
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	rates = c.privacy.AcceptanceRates(rates)
+
+	return c.sendReport(ctx, "acceptance-rates", "Acceptance rates", rates, acceptanceRatesTable(rates, group))
+}
+
+// GetTimeToHire
+// @Summary Get the median time to hire
+// @Description get the median of the days between an apply made in the period and its acceptance.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param from query string false "Start of the period, a date or RFC 3339"
+// @Param to query string false "End of the period, a date (inclusive) or RFC 3339 (exclusive)"
+// @Param timezone query string false "IANA timezone of the dates (default America/Sao_Paulo)"
+// @Param neighborhood query string false "Neighborhood of the candidates"
+// @Success 200 {object} model.TimeToHire
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/employment/time-to-hire [get]
+func (c *ReportsController) GetTimeToHire(ctx *fiber.Ctx) error {
+	filter, ok := parseEmploymentReportFilter(ctx)
+	if !ok {
+		return nil
+	}
+
+	timeToHire, err := c.reportsService.GetTimeToHire(filter)
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	timeToHire = c.privacy.TimeToHire(timeToHire)
+
+	return c.sendReport(ctx, "time-to-hire", "Time to hire", timeToHire, timeToHireTable(timeToHire))
+}
+
+// GetApplyFunnel
+// @Summary Get the funnel of the applies
+// @Description count the applies made in the period that were made, reviewed by the company and accepted.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param from query string false "Start of the period, a date or RFC 3339"
+// @Param to query string false "End of the period, a date (inclusive) or RFC 3339 (exclusive)"
+// @Param timezone query string false "IANA timezone of the dates (default America/Sao_Paulo)"
+// @Param neighborhood query string false "Neighborhood of the candidates"
+// @Success 200 {array} model.ApplyFunnelStage
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/employment/funnel [get]
+func (c *ReportsController) GetApplyFunnel(ctx *fiber.Ctx) error {
+	filter, ok := parseEmploymentReportFilter(ctx)
+	if !ok {
+		return nil
+	}
+
+	funnel, err := c.reportsService.GetApplyFunnel(filter)
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	funnel = c.privacy.ApplyFunnel(funnel)
+
+	return c.sendReport(ctx, "funnel", "Apply funnel", funnel, applyFunnelTable(funnel))
+}
+
+// GetDisabilityCrossTab
+// @Summary Cross-tab the people with disabilities
+// @Description count the people with disabilities per pair of values of two dimensions, as a matrix of rows and columns. A person with disabilities of many categories is counted in each of them.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param rows query string false "'category' (default), 'gender', 'neighborhood', 'city' or 'age_band'"
+// @Param columns query string false "'category', 'gender' (default), 'neighborhood', 'city' or 'age_band'"
+// @Param neighborhood query string false "Only count the people of the neighborhood"
+// @Param city query string false "Only count the people of the city"
+// @Success 200 {object} model.CrossTab
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/crosstab [get]
+func (c *ReportsController) GetDisabilityCrossTab(ctx *fiber.Ctx) error {
+	filter := model.CrossTabFilter{
+		Rows:         enum.ReportDimension(ctx.Query("rows", string(enum.DimensionCategory))),
+		Columns:      enum.ReportDimension(ctx.Query("columns", string(enum.DimensionGender))),
+		Neighborhood: strings.TrimSpace(ctx.Query("neighborhood")),
+		City:         strings.TrimSpace(ctx.Query("city")),
+	}
+
+	crossTab, err := c.reportsService.GetDisabilityCrossTab(filter)
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	crossTab = c.privacy.CrossTab(crossTab)
+
+	return c.sendReport(ctx, "crosstab", "Disability cross-tab", crossTab, crossTabTable(crossTab))
+}
+
+// parseEmploymentReportFilter reads the period and the neighborhood of the
+// employment reports. When they are invalid it answers the request and
+// returns false.
+func parseEmploymentReportFilter(ctx *fiber.Ctx) (model.EmploymentReportFilter, bool) {
+	filter := model.EmploymentReportFilter{Neighborhood: strings.TrimSpace(ctx.Query("neighborhood"))}
+
+	location, err := time.LoadLocation(ctx.Query("timezone", defaultReportsTimezone))
+	if err != nil {
+		ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "invalid timezone",
+			Code:    reportsControllerError("invalid timezone", "05").GetCode(),
+		})
+
+		return filter, false
+	}
+
+	for name, date := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if ctx.Query(name) == "" {
+			continue
+		}
+
+		parsed, err := parseReportDate(ctx.Query(name), location, name == "to")
+		if err != nil {
+			reportDateErrorResponse(ctx)
+
+			return filter, false
+		}
+
+		*date = &parsed
+	}
+
+	return filter, true
+}
+
+func reportsErrorResponse(ctx *fiber.Ctx, err utils.Error) error {
+	response := model.Response{
+		Message: err.Message,
+		Code:    err.Code,
+		Fields:  err.Fields,
+	}
+
+	if err.IsValidation() {
+		return ctx.Status(http.StatusBadRequest).JSON(response)
 	}
 
-	return ctx.Status(http.StatusOK).JSON(response)
+	return ctx.Status(http.StatusInternalServerError).JSON(response)
 }
@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"bytes"
+	"cij_api/src/enum"
+	"cij_api/src/export"
+	"cij_api/src/i18n"
+	"cij_api/src/middleware"
+	"cij_api/src/model"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const jsonFormat = "json"
+
+// suppressionNote explains the missing counts of the files of the public
+// reports. It is translated before the minimum cell size is filled in.
+const suppressionNote = "Counts smaller than %d, and the ones that would reveal them, are suppressed and not shown."
+
+// neighborhoodTotalsTitle is translated before the neighborhood is filled in.
+const neighborhoodTotalsTitle = "Disability totals by neighborhood: %s"
+
+// reportFormat picks the format of a report from the format query, or else
+// from the Accept header. The clients that accept none of them get JSON.
+func reportFormat(ctx *fiber.Ctx) (string, bool) {
+	ctx.Vary(fiber.HeaderAccept)
+
+	if format := ctx.Query("format"); format != "" {
+		_, ok := export.MIMETypes[format]
+		return format, ok || format == jsonFormat
+	}
+
+	accepted := ctx.Accepts(fiber.MIMEApplicationJSON, export.MIMETypes[export.CSV], export.MIMETypes[export.XLSX], export.MIMETypes[export.PDF])
+
+	for format, mimeType := range export.MIMETypes {
+		if accepted == mimeType {
+			return format, true
+		}
+	}
+
+	return jsonFormat, true
+}
+
+// sendReport answers the data as JSON, or the table as a file named after
+// the report in the format asked. The arguments are filled in the message
+// after it's translated.
+func (c *ReportsController) sendReport(ctx *fiber.Ctx, name string, message string, data interface{}, table export.Table, args ...interface{}) error {
+	format, ok := reportFormat(ctx)
+	if !ok {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: "format must be 'json', 'csv', 'xlsx' or 'pdf'",
+			Code:    reportsControllerError("invalid format", "07").GetCode(),
+		})
+	}
+
+	if format == jsonFormat {
+		return ctx.Status(http.StatusOK).JSON(model.Response{
+			Message: formatMessage(message, args),
+			Data:    data,
+		})
+	}
+
+	language := middleware.GetLanguage(ctx)
+	if language == "" {
+		language = enum.English
+	}
+
+	table.Title = formatMessage(i18n.Translate(language, message), args)
+	table.Language = string(language)
+
+	if c.privacy.MinCellSize > 1 {
+		table.Notes = append(table.Notes, fmt.Sprintf(i18n.Translate(language, suppressionNote), c.privacy.MinCellSize))
+	}
+
+	for i := range table.Charts {
+		table.Charts[i].Title = i18n.Translate(language, table.Charts[i].Title)
+	}
+
+	// the file is rendered before the headers are set, so a failure is sent
+	// as a plain JSON error
+	var file bytes.Buffer
+	if err := export.Write(&file, format, table); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(model.Response{
+			Message: "failed to write the report",
+			Code:    reportsControllerError("failed to write the report", "08").GetCode(),
+		})
+	}
+
+	ctx.Set(fiber.HeaderContentType, export.MIMETypes[format])
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+
+	return ctx.Status(http.StatusOK).Send(file.Bytes())
+}
+
+func formatMessage(message string, args []interface{}) string {
+	if len(args) == 0 {
+		return message
+	}
+
+	return fmt.Sprintf(message, args...)
+}
+
+// cell is the value of a count in a table, nil when suppressed.
+func cell(count model.Count) interface{} {
+	if count.Suppressed() {
+		return nil
+	}
+
+	return int(count)
+}
+
+func rateCell(rate *float64) interface{} {
+	if rate == nil {
+		return nil
+	}
+
+	return *rate
+}
+
+// chart charts a column of counts per the labels of another.
+func chart(title string, table export.Table, labelColumn int, valueColumn int) export.Chart {
+	chart := export.Chart{Title: title, Labels: []string{}, Values: []interface{}{}}
+
+	for _, row := range table.Rows {
+		chart.Labels = append(chart.Labels, fmt.Sprint(row[labelColumn]))
+		chart.Values = append(chart.Values, row[valueColumn])
+	}
+
+	return chart
+}
+
+func disabilityTotalsTable(totals model.DisabilityTotals, neighborhood string) export.Table {
+	table := export.Table{Columns: []string{"category", "count"}}
+
+	categories := []string{}
+	for category := range totals {
+		categories = append(categories, string(category))
+	}
+
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		table.Rows = append(table.Rows, []interface{}{category, cell(totals[enum.DisabilityCategoryEnum(category)])})
+	}
+
+	table.Charts = []export.Chart{chart("People per disability category", table, 0, 1)}
+
+	if neighborhood != "" {
+		table.Columns = append([]string{"neighborhood"}, table.Columns...)
+
+		for i, row := range table.Rows {
+			table.Rows[i] = append([]interface{}{neighborhood}, row...)
+		}
+	}
+
+	return table
+}
+
+func activitiesByPeriodTable(activities model.CountActivitiesByPeriod) export.Table {
+	table := export.Table{Columns: []string{"activity_type", "bucket", "count"}}
+
+	months := []string{}
+	for month := range activities.MonthsCount {
+		months = append(months, month)
+	}
+
+	sort.Strings(months)
+
+	for _, month := range months {
+		table.Rows = append(table.Rows, []interface{}{activities.ActivityType, month, cell(activities.MonthsCount[month])})
+	}
+
+	table.Charts = []export.Chart{chart("Activities per month", table, 1, 2)}
+
+	return table
+}
+
+func activityReportTable(report model.ActivityReport) export.Table {
+	table := export.Table{Columns: []string{"activity_type", "bucket", "start", "count"}}
+	compare := len(report.Series) > 0 && report.Series[0].Previous != nil
+
+	if compare {
+		table.Columns = append(table.Columns, "previous_count")
+	}
+
+	for _, series := range report.Series {
+		seriesTable := export.Table{}
+
+		for _, bucket := range series.Buckets {
+			row := []interface{}{series.ActivityType, bucket.Label, bucket.Start.Format(time.RFC3339), cell(bucket.Count)}
+
+			if compare {
+				previous := interface{}(nil)
+				if bucket.PreviousCount != nil {
+					previous = cell(*bucket.PreviousCount)
+				}
+
+				row = append(row, previous)
+			}
+
+			seriesTable.Rows = append(seriesTable.Rows, row)
+		}
+
+		table.Rows = append(table.Rows, seriesTable.Rows...)
+		table.Charts = append(table.Charts, chart(series.ActivityType, seriesTable, 1, 3))
+	}
+
+	return table
+}
+
+func vacanciesByCategoryTable(totals []model.VacancyCategoryTotal) export.Table {
+	table := export.Table{Columns: []string{"category", "vacancies"}}
+
+	for _, total := range totals {
+		table.Rows = append(table.Rows, []interface{}{string(total.Category), total.Vacancies})
+	}
+
+	table.Charts = []export.Chart{chart("Vacancies per disability category", table, 0, 1)}
+
+	return table
+}
+
+func appliesByVacancyTable(totals []model.VacancyApplyTotals) export.Table {
+	table := export.Table{Columns: []string{"vacancy_id", "code", "title", "company", "applications", "accepted", "rejected"}}
+
+	for _, total := range totals {
+		table.Rows = append(table.Rows, []interface{}{
+			total.VacancyId, total.Code, total.Title, total.Company,
+			cell(total.Applications), cell(total.Accepted), cell(total.Rejected),
+		})
+	}
+
+	return table
+}
+
+func acceptanceRatesTable(rates []model.AcceptanceRate, group enum.AcceptanceRateGroup) export.Table {
+	table := export.Table{Columns: []string{string(group), "applications", "accepted", "rejected", "rate"}}
+
+	for _, rate := range rates {
+		table.Rows = append(table.Rows, []interface{}{
+			rate.Group, cell(rate.Applications), cell(rate.Accepted), cell(rate.Rejected), rateCell(rate.Rate),
+		})
+	}
+
+	table.Charts = []export.Chart{chart("Acceptance rate", table, 0, 4)}
+
+	return table
+}
+
+func timeToHireTable(timeToHire model.TimeToHire) export.Table {
+	return export.Table{
+		Columns: []string{"hires", "median_days"},
+		Rows:    [][]interface{}{{cell(timeToHire.Hires), rateCell(timeToHire.MedianDays)}},
+	}
+}
+
+func applyFunnelTable(stages []model.ApplyFunnelStage) export.Table {
+	table := export.Table{Columns: []string{"stage", "count", "rate"}}
+
+	for _, stage := range stages {
+		table.Rows = append(table.Rows, []interface{}{stage.Stage, cell(stage.Count), rateCell(stage.Rate)})
+	}
+
+	table.Charts = []export.Chart{chart("Applies per stage", table, 0, 1)}
+
+	return table
+}
+
+// crossTabTable has a column per column label and a last row with the
+// totals of the columns.
+func crossTabTable(crossTab model.CrossTab) export.Table {
+	table := export.Table{Columns: append(append([]string{string(crossTab.Rows.Dimension)}, crossTab.Columns.Labels...), "total")}
+
+	for i, label := range crossTab.Rows.Labels {
+		row := []interface{}{label}
+		for _, value := range crossTab.Values[i] {
+			row = append(row, cell(value))
+		}
+
+		table.Rows = append(table.Rows, append(row, cell(crossTab.RowTotals[i])))
+	}
+
+	table.Charts = []export.Chart{chart("People per "+string(crossTab.Rows.Dimension), table, 0, len(table.Columns)-1)}
+
+	totals := []interface{}{"total"}
+	for _, total := range crossTab.ColumnTotals {
+		totals = append(totals, cell(total))
+	}
+
+	table.Rows = append(table.Rows, append(totals, cell(crossTab.Total)))
+
+	return table
+}
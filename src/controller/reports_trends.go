@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/export"
+	"cij_api/src/middleware"
+	"cij_api/src/model"
+	"cij_api/src/service"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetDisabilityTrend
+// @Summary Trend of the people per disability category
+// @Description the people with disabilities of each category at the end of each bucket, read from the nightly snapshots. The buckets without snapshots are left out.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param from query string false "First day, YYYY-MM-DD (default one year before to)"
+// @Param to query string false "Last day, YYYY-MM-DD (default yesterday)"
+// @Param granularity query string false "'day', 'week', 'month' (default), 'quarter' or 'year'"
+// @Success 200 {object} model.Trend
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/trends/disabilities [get]
+func (c *ReportsController) GetDisabilityTrend(ctx *fiber.Ctx) error {
+	trend, err := c.reportSnapshotService.GetDisabilityTrend(parseTrendFilter(ctx))
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	trend = c.privacy.Trend(trend)
+
+	return c.sendReport(ctx, "disability-trend", "People per disability category over time", trend, trendTable(trend))
+}
+
+// GetActivityTrend
+// @Summary Trend of the activities per type
+// @Description the activities of each type made in each bucket, read from the nightly snapshots, so they include the activities already archived.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param types query string false "Comma separated activity types, up to 10 (default all)"
+// @Param from query string false "First day, YYYY-MM-DD (default one year before to)"
+// @Param to query string false "Last day, YYYY-MM-DD (default yesterday)"
+// @Param granularity query string false "'day', 'week', 'month' (default), 'quarter' or 'year'"
+// @Success 200 {object} model.Trend
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/trends/activities [get]
+func (c *ReportsController) GetActivityTrend(ctx *fiber.Ctx) error {
+	filter := parseTrendFilter(ctx)
+	requested := map[string]bool{}
+
+	for _, activityType := range strings.Split(ctx.Query("types"), ",") {
+		activityType = strings.TrimSpace(activityType)
+		if activityType != "" && !requested[activityType] {
+			requested[activityType] = true
+			filter.Types = append(filter.Types, activityType)
+		}
+	}
+
+	trend, err := c.reportSnapshotService.GetActivityTrend(filter)
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	trend = c.privacy.Trend(trend)
+
+	return c.sendReport(ctx, "activity-trend", "Activities over time", trend, trendTable(trend))
+}
+
+// GetEmploymentTrend
+// @Summary Trend of the vacancies and applies
+// @Description the vacancies open at the end of each bucket and the applies made, accepted and rejected in it, read from the nightly snapshots. The vacancies are public, so their counts are never suppressed.
+// @Tags Reports
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Param from query string false "First day, YYYY-MM-DD (default one year before to)"
+// @Param to query string false "Last day, YYYY-MM-DD (default yesterday)"
+// @Param granularity query string false "'day', 'week', 'month' (default), 'quarter' or 'year'"
+// @Success 200 {object} model.Trend
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Param format query string false "'json' (default), 'csv', 'xlsx' or 'pdf', or negotiated by the Accept header"
+// @Router /reports/trends/employment [get]
+func (c *ReportsController) GetEmploymentTrend(ctx *fiber.Ctx) error {
+	trend, err := c.reportSnapshotService.GetEmploymentTrend(parseTrendFilter(ctx))
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	trend = c.privacy.Trend(trend, "open_vacancies")
+
+	return c.sendReport(ctx, "employment-trend", "Vacancies and applies over time", trend, trendTable(trend))
+}
+
+// TakeSnapshots
+// @Summary Recompute the report snapshots
+// @Description take again the snapshots of the days from from to to, both included and already over, up to 366 days and newer than the shortest activity retention, replacing the ones taken. The people of the days already snapshot are kept.
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Token"
+// @Param period body model.SnapshotRequest true "Days"
+// @Success 200 {object} model.SnapshotResult
+// @Failure 400 {object} model.Response
+// @Failure 500 {object} model.Response
+// @Router /reports/snapshots [post]
+func (c *ReportsController) TakeSnapshots(ctx *fiber.Ctx) error {
+	var snapshotRequest model.SnapshotRequest
+
+	if err := ctx.BodyParser(&snapshotRequest); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(model.Response{
+			Message: err.Error(),
+		})
+	}
+
+	result, err := c.reportSnapshotService.TakeSnapshots(snapshotRequest.From, snapshotRequest.To, middleware.GetAuditActor(ctx))
+	if err.Code != "" {
+		return reportsErrorResponse(ctx, err)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(model.Response{
+		Message: "Report snapshots taken successfully",
+		Data:    result,
+	})
+}
+
+// parseTrendFilter defaults to the year of snapshots until yesterday, per
+// month. The days are validated by the service.
+func parseTrendFilter(ctx *fiber.Ctx) model.TrendFilter {
+	year, month, day := time.Now().In(service.SnapshotsLocation()).Date()
+	yesterday := time.Date(year, month, day-1, 0, 0, 0, 0, time.UTC)
+
+	filter := model.TrendFilter{
+		From:        ctx.Query("from"),
+		To:          ctx.Query("to", yesterday.Format(time.DateOnly)),
+		Granularity: enum.ReportGranularity(ctx.Query("granularity", string(enum.Month))),
+	}
+
+	if filter.From == "" {
+		if to, err := time.Parse(time.DateOnly, filter.To); err == nil {
+			filter.From = to.AddDate(-1, 0, 1).Format(time.DateOnly)
+		}
+	}
+
+	return filter
+}
+
+func trendTable(trend model.Trend) export.Table {
+	table := export.Table{Columns: []string{trend.Dimension, "bucket", "start", "count"}}
+
+	for _, series := range trend.Series {
+		seriesTable := export.Table{}
+
+		for _, point := range series.Points {
+			seriesTable.Rows = append(seriesTable.Rows, []interface{}{series.Name, point.Label, point.Start, cell(point.Count)})
+		}
+
+		table.Rows = append(table.Rows, seriesTable.Rows...)
+		table.Charts = append(table.Charts, chart(series.Name, seriesTable, 1, 3))
+	}
+
+	return table
+}
@@ -2,9 +2,11 @@ package controller
 
 import (
 	"cij_api/src/enum"
+	"cij_api/src/middleware"
 	"cij_api/src/model"
 	vacancy "cij_api/src/model/vacancy"
 	"cij_api/src/service"
+	"cij_api/src/utils"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,6 +24,12 @@ func NewVacancyController(vacancyService service.VacancyService, companyService
 	}
 }
 
+func vacancyControllerError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ControllerErrorCode, utils.VacancyErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
 // CreateVacancy
 // @Summary Create a vacancy
 // @Description Create a vacancy
@@ -51,7 +59,7 @@ func (v *VacancyController) CreateVacancy(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(response)
 	}
 
-	err := v.vacancyService.CreateVacancy(vacancyRequest)
+	err := v.vacancyService.CreateVacancy(vacancyRequest, middleware.GetAuditActor(ctx))
 	if err.Code != "" {
 		response = model.Response{
 			Message: err.Message,
@@ -81,6 +89,10 @@ func (v *VacancyController) CreateVacancy(ctx *fiber.Ctx) error {
 // @Param area query string false "Area"
 // @Param contract_type query string false "Contract Type"
 // @Param search_text query string false "Search Text"
+// @Param near_person_id query string false "Person whose address the vacancies are searched near, the person themself or an admin only"
+// @Param lat query string false "Latitude the vacancies are searched near"
+// @Param lng query string false "Longitude the vacancies are searched near"
+// @Param radius_km query string false "Radius of the search near a person or a point, in km (default 25), the companies without coordinates are left out"
 // @Success 200 {object} model.Response
 // @Router /vacancies [get]
 func (v *VacancyController) ListVacancies(ctx *fiber.Ctx) error {
@@ -98,7 +110,12 @@ func (v *VacancyController) ListVacancies(ctx *fiber.Ctx) error {
 	disabilityIdInt, _ := strconv.Atoi(disabilityId)
 	candidateIdInt, _ := strconv.Atoi(candidateId)
 
-	vacancies, err := v.vacancyService.ListVacancies(perPageInt, companyIdInt, disabilityIdInt, candidateIdInt, area, enum.VacancyContractType(contractType), searchText)
+	distance, ok := parseVacancyDistanceFilter(ctx)
+	if !ok {
+		return nil
+	}
+
+	vacancies, err := v.vacancyService.ListVacancies(perPageInt, companyIdInt, disabilityIdInt, candidateIdInt, area, enum.VacancyContractType(contractType), searchText, distance)
 	if err.Code != "" {
 		response := model.Response{
 			Message: err.Message,
@@ -116,6 +133,68 @@ func (v *VacancyController) ListVacancies(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(response)
 }
 
+// parseVacancyDistanceFilter answers the errors itself, returning false when
+// it did. Only the person themself and the admins may search near a person,
+// as the distances would tell where they live.
+func parseVacancyDistanceFilter(ctx *fiber.Ctx) (vacancy.VacancyDistanceFilter, bool) {
+	distance := vacancy.VacancyDistanceFilter{}
+
+	for _, param := range []struct {
+		name  string
+		value **float64
+	}{
+		{"lat", &distance.Latitude},
+		{"lng", &distance.Longitude},
+		{"radius_km", &distance.RadiusKm},
+	} {
+		if ctx.Query(param.name) == "" {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(ctx.Query(param.name), 64)
+		if err != nil {
+			ctx.Status(fiber.StatusBadRequest).JSON(model.Response{
+				Message: param.name + " must be a number",
+				Code:    vacancyControllerError("invalid distance filter", "01").GetCode(),
+			})
+
+			return distance, false
+		}
+
+		*param.value = &number
+	}
+
+	if ctx.Query("near_person_id") == "" {
+		return distance, true
+	}
+
+	nearPersonId, err := strconv.Atoi(ctx.Query("near_person_id"))
+	if err != nil || nearPersonId <= 0 {
+		ctx.Status(fiber.StatusBadRequest).JSON(model.Response{
+			Message: "near_person_id must be a person id",
+			Code:    vacancyControllerError("invalid near person id", "02").GetCode(),
+		})
+
+		return distance, false
+	}
+
+	distance.NearPersonId = nearPersonId
+
+	switch middleware.GetTokenRole(ctx) {
+	case middleware.ADMIN_ROLE:
+	case middleware.PERSON_ROLE:
+		distance.RequesterEmail = middleware.GetTokenEmail(ctx)
+	default:
+		ctx.Status(fiber.StatusBadRequest).JSON(model.Response{
+			Message: "role don't have permission",
+		})
+
+		return distance, false
+	}
+
+	return distance, true
+}
+
 // GetVacancyById
 // @Summary Get a vacancy by ID
 // @Description Get a vacancy by ID
@@ -191,7 +270,7 @@ func (v *VacancyController) UpdateVacancy(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(response)
 	}
 
-	err := v.vacancyService.UpdateVacancy(vacancyRequest, vacancyIdInt)
+	err := v.vacancyService.UpdateVacancy(vacancyRequest, vacancyIdInt, middleware.GetAuditActor(ctx))
 	if err.Code != "" {
 		response = model.Response{
 			Message: err.Message,
@@ -223,7 +302,7 @@ func (v *VacancyController) DeleteVacancy(ctx *fiber.Ctx) error {
 	vacancyId := ctx.Params("id")
 	vacancyIdInt, _ := strconv.Atoi(vacancyId)
 
-	err := v.vacancyService.DeleteVacancy(vacancyIdInt)
+	err := v.vacancyService.DeleteVacancy(vacancyIdInt, middleware.GetAuditActor(ctx))
 	if err.Code != "" {
 		response = model.Response{
 			Message: err.Message,
@@ -261,7 +340,7 @@ func (v *VacancyController) CandidateApply(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(response)
 	}
 
-	err := v.vacancyService.CandidateApplyVacancy(vacancyApplyRequest.CandidateId, vacancyApplyRequest.VacancyId)
+	err := v.vacancyService.CandidateApplyVacancy(vacancyApplyRequest.CandidateId, vacancyApplyRequest.VacancyId, middleware.GetAuditActor(ctx))
 	if err.Code != "" {
 		response = model.Response{
 			Message: err.Message,
@@ -333,7 +412,7 @@ func (v *VacancyController) UpdateVacancyApplyStatus(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(response)
 	}
 
-	err := v.vacancyService.UpdateVacancyApplyStatus(vacancyApplyId, enum.VacancyApplyStatus(status))
+	err := v.vacancyService.UpdateVacancyApplyStatus(vacancyApplyId, enum.VacancyApplyStatus(status), middleware.GetAuditActor(ctx))
 	if err.Code != "" {
 		response = model.Response{
 			Message: err.Message,
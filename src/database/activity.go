@@ -0,0 +1,21 @@
+package database
+
+import (
+	"cij_api/src/model"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// createActivityIndexes creates the index used by the reports and by the
+// archiver, which filter the activities by type and creation date. The date
+// comes from the embedded gorm.Model, so it can't be declared with tags.
+func createActivityIndexes(db *gorm.DB) {
+	if db.Migrator().HasIndex(&model.Activity{}, "idx_activities_type_created_at") {
+		return
+	}
+
+	if err := db.Exec("CREATE INDEX idx_activities_type_created_at ON activities (type, created_at)").Error; err != nil {
+		fmt.Println("Error:", err)
+	}
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var auditTriggers = map[string]string{
+	"audit_events_no_update": "UPDATE",
+	"audit_events_no_delete": "DELETE",
+}
+
+// createAuditTriggers makes the database reject changes to the audit events,
+// so they stay append-only even for queries that skip the model hooks.
+func createAuditTriggers(db *gorm.DB) {
+	for name, operation := range auditTriggers {
+		exists, err := hasTrigger(db, name)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+
+		if exists {
+			continue
+		}
+
+		statement := fmt.Sprintf(
+			"CREATE TRIGGER %s BEFORE %s ON audit_events FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit events are append-only'",
+			name, operation,
+		)
+
+		if db.Dialector.Name() == SqliteDriver {
+			statement = fmt.Sprintf(
+				"CREATE TRIGGER %s BEFORE %s ON audit_events BEGIN SELECT RAISE(ABORT, 'audit events are append-only'); END",
+				name, operation,
+			)
+		}
+
+		if err := db.Exec(statement).Error; err != nil {
+			fmt.Println("Error: failed to protect the audit events:", err)
+		}
+	}
+}
+
+func hasTrigger(db *gorm.DB, name string) (bool, error) {
+	var count int64
+
+	query := "SELECT COUNT(*) FROM information_schema.triggers WHERE trigger_schema = DATABASE() AND trigger_name = ?"
+	if db.Dialector.Name() == SqliteDriver {
+		query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?"
+	}
+
+	err := db.Raw(query, name).Scan(&count).Error
+
+	return count > 0, err
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"cij_api/src/config"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MysqlDriver  = "mysql"
+	SqliteDriver = "sqlite"
+)
+
+func ConnectionDB(config *config.Config) *gorm.DB {
+	var client *gorm.DB
+
+	switch config.DbDriver {
+	case "", MysqlDriver:
+		client = connectMysql(config.DbConnection)
+	case SqliteDriver:
+		client = connectSqlite(config.DbConnection)
+	default:
+		panic("unsupported database driver " + config.DbDriver)
+	}
+
+	fmt.Print("Database connected\n\n")
+
+	return client
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/utils"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var defaultDisabilityCategories = []model.DisabilityCategory{
+	{Slug: enum.Visual, Name: "Visual", Translations: model.Translations{enum.English: "Visual", enum.Spanish: "Visual"}},
+	{Slug: enum.Hearing, Name: "Auditiva", Translations: model.Translations{enum.English: "Hearing", enum.Spanish: "Auditiva"}},
+	{Slug: enum.Motor, Name: "Física/Motora", Translations: model.Translations{enum.English: "Physical/Motor", enum.Spanish: "Física/Motora"}},
+	{Slug: enum.Intellectual, Name: "Intelectual", Translations: model.Translations{enum.English: "Intellectual", enum.Spanish: "Intelectual"}},
+	{Slug: enum.Psychosocial, Name: "Psicossocial", Translations: model.Translations{enum.English: "Psychosocial", enum.Spanish: "Psicosocial"}},
+	{Slug: enum.Others, Name: "Outras", Translations: model.Translations{enum.English: "Others", enum.Spanish: "Otras"}},
+}
+
+// createDefaultDisabilityCategories creates the missing default categories and
+// translates the ones created before the translations.
+func createDefaultDisabilityCategories(db *gorm.DB) error {
+	for _, category := range defaultDisabilityCategories {
+		translations := category.Translations
+
+		if err := db.Where(model.DisabilityCategory{Slug: category.Slug}).FirstOrCreate(&category).Error; err != nil {
+			return err
+		}
+
+		if len(category.Translations) == 0 {
+			if err := db.Model(&category).Select("translations").Updates(model.DisabilityCategory{Translations: translations}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// normalizeDisabilityCategories moves the disabilities from the free text
+// category column to the categories table, then drops the column. Names like
+// "Physical" or "Física" go to their category and unknown names get a new one.
+func normalizeDisabilityCategories(db *gorm.DB) {
+	if !db.Migrator().HasColumn("disabilities", "category") {
+		return
+	}
+
+	if err := createDefaultDisabilityCategories(db); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	var disabilities []struct {
+		Id       int
+		Category string
+	}
+
+	err := db.Table("disabilities").
+		Select("id", "category").
+		Where("category_id IS NULL OR category_id = 0").
+		Find(&disabilities).Error
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, disability := range disabilities {
+			category := model.DisabilityCategory{
+				Slug: utils.DisabilityCategorySlug(disability.Category),
+				Name: disability.Category,
+			}
+
+			if category.Slug == "" {
+				category.Slug, category.Name = enum.Others, "Outras"
+			}
+
+			if err := tx.Where(model.DisabilityCategory{Slug: category.Slug}).FirstOrCreate(&category).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Table("disabilities").Where("id = ?", disability.Id).Update("category_id", category.Id).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		fmt.Println("Error: failed to normalize the disability categories:", err)
+		return
+	}
+
+	if db.Migrator().HasIndex(&model.Disability{}, "idx_disabilities_category") {
+		if err := db.Migrator().DropIndex(&model.Disability{}, "idx_disabilities_category"); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+
+	if err := db.Migrator().DropColumn(&model.Disability{}, "category"); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
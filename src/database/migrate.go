@@ -0,0 +1,212 @@
+package database
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	vacancy "cij_api/src/model/vacancy"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var models = []interface{}{
+	&model.User{},
+	&model.Address{},
+	&model.Person{},
+	&model.DisabilityCategory{},
+	&model.Disability{},
+	&model.PersonDisability{},
+	&model.Company{},
+	&model.NewsCategory{},
+	&model.Tag{},
+	&model.News{},
+	&model.Role{},
+	&model.Activity{},
+	&model.ActivityRetention{},
+	&model.AuditEvent{},
+	&model.UserConfig{},
+	&model.ConfigPreset{},
+	&model.ReportSnapshot{},
+	&model.DisabilitySnapshot{},
+	&model.ActivitySnapshot{},
+	&model.EmploymentSnapshot{},
+
+	&vacancy.Vacancy{},
+	&vacancy.VacancyDisability{},
+	&vacancy.VacancySkill{},
+	&vacancy.VacancyRequirement{},
+	&vacancy.VacancyResponsability{},
+	&vacancy.VacancyApply{},
+}
+
+func Migrate(db *gorm.DB) {
+	removeDuplicatePersonDisabilities(db)
+
+	for _, m := range models {
+		if err := db.AutoMigrate(m); err != nil {
+			fmt.Println("Error:", err)
+		}
+	}
+
+	normalizeDisabilityCategories(db)
+	normalizeLegacyNews(db)
+	markScheduledNews(db)
+	createNewsSearchIndex(db)
+	createActivityIndexes(db)
+	createAuditTriggers(db)
+
+	if err := Seed(db); err != nil {
+		fmt.Println("Error: failed to seed the database:", err)
+	}
+}
+
+func Seed(db *gorm.DB) error {
+	for _, seed := range []func(db *gorm.DB) error{
+		createDefaultRoles,
+		createDefaultConfigPresets,
+		createDefaultDisabilityCategories,
+		createDefaultNewsCategories,
+		createDefaultDisabilities,
+	} {
+		if err := seed(db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reindex recreates any missing index declared on the models and refreshes
+// the table statistics used by the query planner.
+func Reindex(db *gorm.DB) error {
+	for _, m := range models {
+		if err := db.AutoMigrate(m); err != nil {
+			return err
+		}
+
+		table, err := tableName(db, m)
+		if err != nil {
+			return err
+		}
+
+		if err := db.Exec(analyzeStatement(db) + " " + table).Error; err != nil {
+			return err
+		}
+	}
+
+	createActivityIndexes(db)
+
+	return nil
+}
+
+// PurgeSoftDeleted permanently removes the rows that were soft deleted
+// before the given date. It returns the number of removed rows per table.
+func PurgeSoftDeleted(db *gorm.DB, before time.Time) (map[string]int64, error) {
+	purged := map[string]int64{}
+
+	for _, m := range models {
+		if !db.Migrator().HasColumn(m, "deleted_at") {
+			continue
+		}
+
+		table, err := tableName(db, m)
+		if err != nil {
+			return purged, err
+		}
+
+		result := db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(m)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+
+		purged[table] = result.RowsAffected
+	}
+
+	return purged, nil
+}
+
+func analyzeStatement(db *gorm.DB) string {
+	if db.Dialector.Name() == SqliteDriver {
+		return "ANALYZE"
+	}
+
+	return "ANALYZE TABLE"
+}
+
+func tableName(db *gorm.DB, m interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(m); err != nil {
+		return "", err
+	}
+
+	return stmt.Schema.Table, nil
+}
+
+func createDefaultRoles(db *gorm.DB) error {
+	for _, name := range []string{"person", "company", "admin"} {
+		if err := db.Where(model.Role{Name: name}).FirstOrCreate(&model.Role{}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createDefaultConfigPresets(db *gorm.DB) error {
+	for _, preset := range model.DefaultConfigPresets {
+		if err := db.Where(model.ConfigPreset{Slug: preset.Slug}).FirstOrCreate(&preset).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createDefaultDisabilities(db *gorm.DB) error {
+	disabilities := []model.Disability{
+		{Category: &model.DisabilityCategory{Slug: enum.Visual}, Description: "Baixa visão, dificuldade em enxergar a longa distância", Translations: model.Translations{enum.English: "Low vision, difficulty seeing at long distances", enum.Spanish: "Baja visión, dificultad para ver a larga distancia"}, Rate: 30, Cid10: "H54.2", LegalClassification: enum.LegalVisual},
+		{Category: &model.DisabilityCategory{Slug: enum.Visual}, Description: "Cegueira completa", Translations: model.Translations{enum.English: "Complete blindness", enum.Spanish: "Ceguera total"}, Rate: 80, Cid10: "H54.0", LegalClassification: enum.LegalVisual},
+		{Category: &model.DisabilityCategory{Slug: enum.Visual}, Description: "Dificuldade em diferenciar cores", Translations: model.Translations{enum.English: "Difficulty distinguishing colours", enum.Spanish: "Dificultad para distinguir colores"}, Rate: 20, Cid10: "H53.5"},
+		{Category: &model.DisabilityCategory{Slug: enum.Hearing}, Description: "Perda auditiva parcial em um ouvido", Translations: model.Translations{enum.English: "Partial hearing loss in one ear", enum.Spanish: "Pérdida auditiva parcial en un oído"}, Rate: 25, LegalClassification: enum.LegalHearing},
+		{Category: &model.DisabilityCategory{Slug: enum.Hearing}, Description: "Surdez total", Translations: model.Translations{enum.English: "Total deafness", enum.Spanish: "Sordera total"}, Rate: 90, LegalClassification: enum.LegalHearing},
+		{Category: &model.DisabilityCategory{Slug: enum.Hearing}, Description: "Sensibilidade a sons altos", Translations: model.Translations{enum.English: "Sensitivity to loud sounds", enum.Spanish: "Sensibilidad a los sonidos fuertes"}, Rate: 15},
+		{Category: &model.DisabilityCategory{Slug: enum.Motor}, Description: "Paralisia parcial nos membros inferiores", Translations: model.Translations{enum.English: "Partial paralysis of the lower limbs", enum.Spanish: "Parálisis parcial de los miembros inferiores"}, Rate: 60, LegalClassification: enum.LegalPhysical},
+		{Category: &model.DisabilityCategory{Slug: enum.Motor}, Description: "Dificuldade de mobilidade devido a esclerose", Translations: model.Translations{enum.English: "Reduced mobility due to sclerosis", enum.Spanish: "Dificultad de movilidad debido a esclerosis"}, Rate: 75, LegalClassification: enum.LegalPhysical},
+		{Category: &model.DisabilityCategory{Slug: enum.Motor}, Description: "Limitação no movimento das articulações", Translations: model.Translations{enum.English: "Limited joint movement", enum.Spanish: "Limitación en el movimiento de las articulaciones"}, Rate: 40, LegalClassification: enum.LegalPhysical},
+		{Category: &model.DisabilityCategory{Slug: enum.Intellectual}, Description: "Transtorno do espectro autista", Translations: model.Translations{enum.English: "Autism spectrum disorder", enum.Spanish: "Trastorno del espectro autista"}, Rate: 50, Cid10: "F84.0", Icd11: "6A02", LegalClassification: enum.LegalAutism},
+		{Category: &model.DisabilityCategory{Slug: enum.Intellectual}, Description: "Déficit de atenção e hiperatividade", Translations: model.Translations{enum.English: "Attention deficit hyperactivity disorder", enum.Spanish: "Trastorno por déficit de atención e hiperactividad"}, Rate: 30, Cid10: "F90.0", Icd11: "6A05"},
+		{Category: &model.DisabilityCategory{Slug: enum.Intellectual}, Description: "Deficiência intelectual leve", Translations: model.Translations{enum.English: "Mild intellectual disability", enum.Spanish: "Discapacidad intelectual leve"}, Rate: 45, Cid10: "F70", Icd11: "6A00.0", LegalClassification: enum.LegalIntellectual},
+		{Category: &model.DisabilityCategory{Slug: enum.Psychosocial}, Description: "Transtorno de ansiedade", Translations: model.Translations{enum.English: "Anxiety disorder", enum.Spanish: "Trastorno de ansiedad"}, Rate: 25},
+		{Category: &model.DisabilityCategory{Slug: enum.Psychosocial}, Description: "Depressão grave", Translations: model.Translations{enum.English: "Severe depression", enum.Spanish: "Depresión grave"}, Rate: 70, Cid10: "F32.2", LegalClassification: enum.LegalPsychosocial},
+		{Category: &model.DisabilityCategory{Slug: enum.Psychosocial}, Description: "Transtorno bipolar", Translations: model.Translations{enum.English: "Bipolar disorder", enum.Spanish: "Trastorno bipolar"}, Rate: 65, Cid10: "F31", LegalClassification: enum.LegalPsychosocial},
+		{Category: &model.DisabilityCategory{Slug: enum.Psychosocial}, Description: "Transtorno de estresse pós-traumático (TEPT)", Translations: model.Translations{enum.English: "Post-traumatic stress disorder (PTSD)", enum.Spanish: "Trastorno de estrés postraumático (TEPT)"}, Rate: 60, Cid10: "F43.1", Icd11: "6B40", LegalClassification: enum.LegalPsychosocial},
+	}
+
+	for _, disability := range disabilities {
+		var category model.DisabilityCategory
+		if err := db.Where("slug = ?", disability.Category.Slug).First(&category).Error; err != nil {
+			return err
+		}
+
+		disability.CategoryId = category.Id
+		disability.Category = nil
+
+		var existing model.Disability
+		if err := db.Where("category_id = ? AND description = ?", disability.CategoryId, disability.Description).First(&existing).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				if err := db.Create(&disability).Error; err != nil {
+					return err
+				}
+			} else {
+				return err
+			}
+		} else if len(existing.Translations) == 0 {
+			if err := db.Model(&existing).Select("translations").Updates(model.Disability{Translations: disability.Translations}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
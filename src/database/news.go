@@ -0,0 +1,114 @@
+package database
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/utils"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var defaultNewsCategories = []model.NewsCategory{
+	{Slug: "legislation", Name: "Legislação"},
+	{Slug: "events", Name: "Eventos"},
+	{Slug: "success-stories", Name: "Histórias de sucesso"},
+	{Slug: "general", Name: "Geral"},
+}
+
+func createDefaultNewsCategories(db *gorm.DB) error {
+	for _, category := range defaultNewsCategories {
+		if err := db.Where(model.NewsCategory{Slug: category.Slug}).FirstOrCreate(&category).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createNewsSearchIndex creates the full-text index used by the news search on
+// MySQL. The other databases search with LIKE and don't need it.
+func createNewsSearchIndex(db *gorm.DB) {
+	if db.Dialector.Name() != MysqlDriver || db.Migrator().HasIndex(&model.News{}, "idx_news_search") {
+		return
+	}
+
+	if err := db.Exec("CREATE FULLTEXT INDEX idx_news_search ON news (title, description)").Error; err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// markScheduledNews flags the drafts with a future publish_at, saved before
+// the flag, as scheduled, which is what they were then. It changes nothing on
+// the news saved since, as the drafts with a future publish_at are always
+// scheduled.
+func markScheduledNews(db *gorm.DB) {
+	err := db.Model(&model.News{}).
+		Where("status = ? AND scheduled = ? AND publish_at > ?", enum.NewsDraft, false, time.Now()).
+		Update("scheduled", true).Error
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+// normalizeLegacyNews fills the slugs of the news created before them, and
+// links the news whose free text author is the email of a user to that user.
+// The free text is copied to the author name before its column is dropped,
+// so the names that match no user are kept. Each step skips the news already
+// normalized, so a migration interrupted midway can be run again.
+func normalizeLegacyNews(db *gorm.DB) {
+	var news []model.News
+
+	if err := db.Unscoped().Where("slug IS NULL OR slug = ''").Order("id").Find(&news).Error; err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	for _, item := range news {
+		slug := utils.Slugify(item.Title)
+		if slug == "" {
+			slug = "news"
+		}
+
+		candidate := slug
+
+		for suffix := 2; ; suffix++ {
+			var count int64
+			db.Unscoped().Model(&model.News{}).Where("slug = ?", candidate).Count(&count)
+
+			if count == 0 {
+				break
+			}
+
+			candidate = fmt.Sprintf("%s-%d", slug, suffix)
+		}
+
+		db.Unscoped().Model(&model.News{}).Where("id = ?", item.Id).Update("slug", candidate)
+	}
+
+	if !db.Migrator().HasColumn(&model.News{}, "author") {
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			"UPDATE news SET author_name = author WHERE (author_name IS NULL OR author_name = '') AND author IS NOT NULL",
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			"UPDATE news SET author_id = (SELECT users.id FROM users WHERE users.email = news.author) WHERE author_id IS NULL",
+		).Error
+	})
+	if err != nil {
+		fmt.Println("Error: failed to link the news authors:", err)
+		return
+	}
+
+	if err := db.Migrator().DropColumn(&model.News{}, "author"); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
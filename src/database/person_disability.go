@@ -0,0 +1,56 @@
+package database
+
+import (
+	"cij_api/src/model"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// removeDuplicatePersonDisabilities keeps a single row of each person and
+// disability pair, so the unique index can be created on the databases that
+// stored a disability twice for the same person. A pair is kept as acquired
+// when any of its rows was.
+func removeDuplicatePersonDisabilities(db *gorm.DB) {
+	migrator := db.Migrator()
+
+	if !migrator.HasTable(&model.PersonDisability{}) || migrator.HasIndex(&model.PersonDisability{}, "idx_person_disability") {
+		return
+	}
+
+	var duplicates []model.PersonDisability
+
+	err := db.Table("person_disabilities").
+		Select("person_id, disability_id, MAX(CASE WHEN acquired THEN 1 ELSE 0 END) = 1 AS acquired").
+		Group("person_id, disability_id").
+		Having("COUNT(*) > 1").
+		Scan(&duplicates).Error
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	if len(duplicates) == 0 {
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, duplicate := range duplicates {
+			err := tx.Where("person_id = ? AND disability_id = ?", duplicate.PersonId, duplicate.DisabilityId).
+				Delete(&model.PersonDisability{}).Error
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Create(&duplicate).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// connectSqlite opens a SQLite database. The dsn is a file path or
+// "file::memory:" for a database that only lives while the process runs.
+func connectSqlite(dsn string) *gorm.DB {
+	client, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+
+	if err != nil {
+		panic("failed to connect database")
+	}
+
+	sqlDB, err := client.DB()
+	if err != nil {
+		panic("failed to connect database")
+	}
+
+	// SQLite only allows one writer at a time and every connection to an
+	// in-memory database sees a different database, so a single connection
+	// is shared by the whole application.
+	sqlDB.SetMaxOpenConns(1)
+
+	return client
+}
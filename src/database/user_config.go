@@ -0,0 +1,97 @@
+package database
+
+import (
+	"cij_api/src/model"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportLegacyUserConfigs copies the configs that were kept as JSON files in
+// the file storage to the user_configs table, and returns how many were
+// imported and how many failed. The config url of the user is cleared once
+// imported, and when the file is gone for good, so only the users that failed
+// for a while are retried on the next import.
+func ImportLegacyUserConfigs(db *gorm.DB) (int, int, error) {
+	var users []model.User
+
+	if err := db.Where("config_url <> ''").Find(&users).Error; err != nil {
+		return 0, 0, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	imported, failed := 0, 0
+
+	for _, user := range users {
+		config, status, err := downloadLegacyUserConfig(client, user.ConfigUrl)
+		if err != nil {
+			fmt.Printf("Error: failed to import the config of %s: %v\n", user.Email, err)
+			failed++
+
+			if status >= 400 && status < 500 {
+				if err := db.Model(&model.User{}).Where("id = ?", user.Id).Update("config_url", "").Error; err != nil {
+					fmt.Printf("Error: failed to clear the config url of %s: %v\n", user.Email, err)
+				}
+			}
+
+			continue
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var count int64
+
+			if err := tx.Model(&model.UserConfig{}).Where("user_id = ?", user.Id).Count(&count).Error; err != nil {
+				return err
+			}
+
+			if count == 0 {
+				userConfig := model.UserConfig{UserId: user.Id, Version: 1, Config: config}
+
+				if err := tx.Create(&userConfig).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Model(&model.User{}).Where("id = ?", user.Id).Update("config_url", "").Error
+		})
+		if err != nil {
+			fmt.Printf("Error: failed to import the config of %s: %v\n", user.Email, err)
+			failed++
+			continue
+		}
+
+		imported++
+	}
+
+	return imported, failed, nil
+}
+
+// downloadLegacyUserConfig also returns the status of the answer, zero when
+// there was none.
+func downloadLegacyUserConfig(client *http.Client, url string) (model.Config, int, error) {
+	var config model.Config
+
+	response, err := client.Get(url)
+	if err != nil {
+		return config, 0, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return config, response.StatusCode, fmt.Errorf("unexpected status %s", response.Status)
+	}
+
+	// The legacy files were written with the default config as the base, so
+	// any field missing from them keeps its default value.
+	config = model.DefaultConfig.Clone()
+
+	if err := json.NewDecoder(response.Body).Decode(&config); err != nil {
+		return config, response.StatusCode, err
+	}
+
+	return config, response.StatusCode, nil
+}
@@ -0,0 +1,41 @@
+package enum
+
+// AuditAction is what an audit event did to its entity.
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+	AuditLogin  AuditAction = "login"
+)
+
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditCreate, AuditUpdate, AuditDelete, AuditLogin:
+		return true
+	}
+
+	return false
+}
+
+// AuditEntity is the type of the entity an audit event is about.
+type AuditEntity string
+
+const (
+	AuditUser               AuditEntity = "user"
+	AuditPerson             AuditEntity = "person"
+	AuditCompany            AuditEntity = "company"
+	AuditUserConfig         AuditEntity = "user_config"
+	AuditConfigPreset       AuditEntity = "config_preset"
+	AuditDisability         AuditEntity = "disability"
+	AuditDisabilityCategory AuditEntity = "disability_category"
+	AuditNews               AuditEntity = "news"
+	AuditNewsCategory       AuditEntity = "news_category"
+	AuditVacancy            AuditEntity = "vacancy"
+	AuditVacancyApply       AuditEntity = "vacancy_apply"
+	AuditActivity           AuditEntity = "activity"
+	AuditActivityRetention  AuditEntity = "activity_retention"
+	AuditActivityArchive    AuditEntity = "activity_archive"
+	AuditReportSnapshot     AuditEntity = "report_snapshot"
+)
@@ -0,0 +1,12 @@
+package enum
+
+type ContrastLevelEnum string
+
+const (
+	ContrastAA  ContrastLevelEnum = "AA"
+	ContrastAAA ContrastLevelEnum = "AAA"
+)
+
+func (c ContrastLevelEnum) IsValid() bool {
+	return c == ContrastAA || c == ContrastAAA
+}
@@ -1,5 +1,7 @@
 package enum
 
+// DisabilityCategoryEnum is the slug of a disability category. These are the
+// categories created on the first start; admins may add others.
 type DisabilityCategoryEnum string
 
 const (
@@ -33,3 +33,62 @@ func GetPeriodFilterEnum(value string) PeriodFilterEnum {
 
 	return ""
 }
+
+// ReportGranularity is the size of the buckets the reports count by.
+type ReportGranularity string
+
+const (
+	Day     ReportGranularity = "day"
+	Week    ReportGranularity = "week"
+	Month   ReportGranularity = "month"
+	Quarter ReportGranularity = "quarter"
+	Year    ReportGranularity = "year"
+)
+
+func (e ReportGranularity) IsValid() bool {
+	switch e {
+	case Day, Week, Month, Quarter, Year:
+		return true
+	}
+
+	return false
+}
+
+// AcceptanceRateGroup is what the acceptance rates are grouped by.
+type AcceptanceRateGroup string
+
+const (
+	ByDisabilityCategory AcceptanceRateGroup = "category"
+	ByCompany            AcceptanceRateGroup = "company"
+	ByArea               AcceptanceRateGroup = "area"
+)
+
+func (e AcceptanceRateGroup) IsValid() bool {
+	switch e {
+	case ByDisabilityCategory, ByCompany, ByArea:
+		return true
+	}
+
+	return false
+}
+
+// ReportDimension is a characteristic of the people the cross-tab reports
+// can split them by.
+type ReportDimension string
+
+const (
+	DimensionCategory     ReportDimension = "category"
+	DimensionGender       ReportDimension = "gender"
+	DimensionNeighborhood ReportDimension = "neighborhood"
+	DimensionCity         ReportDimension = "city"
+	DimensionAgeBand      ReportDimension = "age_band"
+)
+
+func (e ReportDimension) IsValid() bool {
+	switch e {
+	case DimensionCategory, DimensionGender, DimensionNeighborhood, DimensionCity, DimensionAgeBand:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,20 @@
+package enum
+
+// LanguageEnum is a language the API answers in. The catalogue data is written
+// in Brazilian Portuguese and the API messages in English.
+type LanguageEnum string
+
+const (
+	PortugueseBR LanguageEnum = "pt-BR"
+	English      LanguageEnum = "en"
+	Spanish      LanguageEnum = "es"
+)
+
+func (l LanguageEnum) IsValid() bool {
+	switch l {
+	case PortugueseBR, English, Spanish:
+		return true
+	}
+
+	return false
+}
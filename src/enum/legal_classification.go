@@ -0,0 +1,26 @@
+package enum
+
+// LegalClassificationEnum is the kind of disability recognised by the
+// Brazilian law: the classes of the Decreto 3.298/1999 (art. 4), the autism
+// spectrum of the Lei 12.764/2012 and the psychosocial impairments covered by
+// the Lei Brasileira de Inclusão (Lei 13.146/2015, art. 2).
+type LegalClassificationEnum string
+
+const (
+	LegalPhysical     LegalClassificationEnum = "physical"
+	LegalHearing      LegalClassificationEnum = "hearing"
+	LegalVisual       LegalClassificationEnum = "visual"
+	LegalIntellectual LegalClassificationEnum = "intellectual"
+	LegalPsychosocial LegalClassificationEnum = "psychosocial"
+	LegalAutism       LegalClassificationEnum = "autism"
+	LegalMultiple     LegalClassificationEnum = "multiple"
+)
+
+func (l LegalClassificationEnum) IsValid() bool {
+	switch l {
+	case LegalPhysical, LegalHearing, LegalVisual, LegalIntellectual, LegalPsychosocial, LegalAutism, LegalMultiple:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,21 @@
+package enum
+
+// NewsStatus is the publication state of a news. A draft saved with a future
+// publication date is scheduled, and published by the scheduler when the date
+// arrives.
+type NewsStatus string
+
+const (
+	NewsDraft     NewsStatus = "draft"
+	NewsPublished NewsStatus = "published"
+	NewsArchived  NewsStatus = "archived"
+)
+
+func (n NewsStatus) IsValid() bool {
+	switch n {
+	case NewsDraft, NewsPublished, NewsArchived:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+// Package export writes the reports as CSV, XLSX and PDF files.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+	PDF  = "pdf"
+)
+
+// MIMETypes are the content types of the formats, as negotiated with the
+// Accept header.
+var MIMETypes = map[string]string{
+	CSV:  "text/csv",
+	XLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	PDF:  "application/pdf",
+}
+
+// Table is a report as rows of cells under named columns. The cells are
+// strings, ints, float64s or nil for the values not shown, like the
+// suppressed counts.
+type Table struct {
+	Title    string
+	Language string
+	Notes    []string
+	Columns  []string
+	Rows     [][]interface{}
+	Charts   []Chart
+}
+
+// Chart is drawn as horizontal bars in the PDF.
+type Chart struct {
+	Title  string
+	Labels []string
+	Values []interface{}
+}
+
+// Write writes the table in the format, which must be one of MIMETypes.
+func Write(w io.Writer, format string, table Table) error {
+	switch format {
+	case CSV:
+		return WriteCSV(w, table)
+	case XLSX:
+		return WriteXLSX(w, table)
+	case PDF:
+		return WritePDF(w, table)
+	}
+
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// formatCell writes the value as text, with the empty text for nil.
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// sheetCell formats the value as formatCell does, for the cells opened by
+// the spreadsheets. The texts they would run as formulas are prefixed with a
+// quote, so they are shown as typed.
+func sheetCell(value interface{}) string {
+	text := formatCell(value)
+
+	switch value.(type) {
+	case int, float64:
+		return text
+	}
+
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+
+	return text
+}
+
+// alt describes the chart for the ones who can't see it, with the title and
+// the label and value of each bar.
+func (c Chart) alt(missing string) string {
+	var alt strings.Builder
+
+	fmt.Fprintf(&alt, "%s.", c.Title)
+
+	for i, label := range c.Labels {
+		value := formatCell(c.Values[i])
+		if value == "" {
+			value = missing
+		}
+
+		fmt.Fprintf(&alt, " %s: %s;", label, value)
+	}
+
+	return strings.TrimSuffix(alt.String(), ";") + "."
+}
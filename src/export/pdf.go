@@ -0,0 +1,560 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// The PDF is tagged for the screen readers: its structure tree has the title
+// as a heading, the notes as paragraphs, the table with header cells scoped
+// to their columns and the charts as figures with a text alternative. What is
+// only decoration, like the grid and the header repeated on each page, is
+// marked as an artifact.
+
+const (
+	pageWidth   = 595.0
+	pageHeight  = 842.0
+	pageMargin  = 50.0
+	contentSize = pageWidth - 2*pageMargin
+
+	titleSize  = 16.0
+	textSize   = 10.0
+	cellSize   = 9.0
+	cellMargin = 3.0
+
+	// charts with more bars than fit a page are left to the table
+	maxChartBars = 40
+	barHeight    = 12.0
+	barGap       = 4.0
+	chartLabels  = 150.0
+	chartValues  = 50.0
+
+	missingText = "-"
+)
+
+// helveticaWidths are the widths of the printable ASCII characters, from the
+// space, in thousandths of the font size.
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+type structElem struct {
+	tag    string
+	parent *structElem
+	kids   []*structElem
+	page   int
+	mcid   int
+	attrs  string
+	alt    string
+	number int
+}
+
+type pdfPage struct {
+	content bytes.Buffer
+	// elements are the leaves of the structure tree drawn in the page, by
+	// marked content id
+	elements []*structElem
+}
+
+type pdfDocument struct {
+	pages    []*pdfPage
+	y        float64
+	document *structElem
+}
+
+// WritePDF writes the table as a tagged PDF in A4 pages.
+func WritePDF(w io.Writer, table Table) error {
+	doc := &pdfDocument{document: &structElem{tag: "Document", mcid: -1}}
+	doc.newPage()
+
+	doc.text(doc.document, "H1", table.Title, titleSize, true)
+
+	for _, note := range table.Notes {
+		doc.text(doc.document, "P", note, textSize, false)
+	}
+
+	doc.table(table)
+
+	for _, chart := range table.Charts {
+		if len(chart.Labels) > 0 && len(chart.Labels) <= maxChartBars {
+			doc.chart(chart)
+		}
+	}
+
+	return doc.write(w, table)
+}
+
+func (d *pdfDocument) newPage() *pdfPage {
+	page := &pdfPage{}
+	d.pages = append(d.pages, page)
+	d.y = pageHeight - pageMargin
+
+	return page
+}
+
+func (d *pdfDocument) page() *pdfPage {
+	return d.pages[len(d.pages)-1]
+}
+
+// ensure starts a new page when the height doesn't fit the current one. It
+// returns whether it did.
+func (d *pdfDocument) ensure(height float64) bool {
+	if d.y-height >= pageMargin || d.y == pageHeight-pageMargin {
+		return false
+	}
+
+	d.newPage()
+
+	return true
+}
+
+// leaf adds a structure element whose content is drawn in the current page,
+// and opens its marked content.
+func (d *pdfDocument) leaf(parent *structElem, tag string) *structElem {
+	page := d.page()
+	elem := &structElem{tag: tag, parent: parent, page: len(d.pages) - 1, mcid: len(page.elements)}
+
+	parent.kids = append(parent.kids, elem)
+	page.elements = append(page.elements, elem)
+
+	fmt.Fprintf(&page.content, "/%s <</MCID %d>> BDC\n", tag, elem.mcid)
+
+	return elem
+}
+
+func (d *pdfDocument) node(parent *structElem, tag string) *structElem {
+	elem := &structElem{tag: tag, parent: parent, mcid: -1}
+	parent.kids = append(parent.kids, elem)
+
+	return elem
+}
+
+func (d *pdfDocument) text(parent *structElem, tag string, text string, size float64, bold bool) {
+	lines := wrap(text, size, contentSize, bold)
+	leading := size * 1.3
+
+	for len(lines) > 0 {
+		d.ensure(leading)
+
+		fitting := int(math.Max(1, math.Floor((d.y-pageMargin)/leading)))
+		if fitting > len(lines) {
+			fitting = len(lines)
+		}
+
+		d.leaf(parent, tag)
+		d.lines(lines[:fitting], pageMargin, d.y-size, size, leading, bold)
+		d.page().content.WriteString("EMC\n")
+
+		d.y -= float64(fitting) * leading
+		lines = lines[fitting:]
+	}
+
+	d.y -= size / 2
+}
+
+func (d *pdfDocument) lines(lines []string, x float64, y float64, size float64, leading float64, bold bool) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+
+	content := &d.page().content
+	fmt.Fprintf(content, "BT /%s %.1f Tf %.1f TL %.2f %.2f Td\n", font, size, leading, x, y)
+
+	for i, line := range lines {
+		if i > 0 {
+			content.WriteString("T* ")
+		}
+
+		fmt.Fprintf(content, "%s Tj\n", pdfString(line))
+	}
+
+	content.WriteString("ET\n")
+}
+
+func (d *pdfDocument) table(table Table) {
+	if len(table.Columns) == 0 {
+		return
+	}
+
+	width := contentSize / float64(len(table.Columns))
+	tableElem := d.node(d.document, "Table")
+
+	header := make([]interface{}, len(table.Columns))
+	for i, column := range table.Columns {
+		header[i] = column
+	}
+
+	d.row(d.node(tableElem, "TR"), header, width, true, false)
+
+	for _, row := range table.Rows {
+		if d.ensure(d.rowHeight(row, width, false)) {
+			// the header of the new page is only for the eyes
+			d.row(nil, header, width, true, true)
+		}
+
+		d.row(d.node(tableElem, "TR"), row, width, false, false)
+	}
+
+	d.y -= textSize
+}
+
+func (d *pdfDocument) rowHeight(row []interface{}, width float64, bold bool) float64 {
+	lines := 1
+
+	for _, value := range row {
+		lines = int(math.Max(float64(lines), float64(len(wrap(cellText(value), cellSize, width-2*cellMargin, bold)))))
+	}
+
+	return float64(lines)*cellSize*1.3 + 2*cellMargin
+}
+
+// row draws the cells of a row, tagged in the row element, or as an artifact
+// when it is nil.
+func (d *pdfDocument) row(rowElem *structElem, row []interface{}, width float64, header bool, artifact bool) {
+	height := d.rowHeight(row, width, header)
+	d.ensure(height)
+
+	content := &d.page().content
+	fmt.Fprintf(content, "/Artifact BMC 0.6 G 0.5 w %.2f %.2f %.2f %.2f re S EMC\n", pageMargin, d.y-height, contentSize, height)
+
+	if artifact {
+		content.WriteString("/Artifact BMC\n")
+	}
+
+	for i, value := range row {
+		x := pageMargin + float64(i)*width
+		lines := wrap(cellText(value), cellSize, width-2*cellMargin, header)
+
+		if !artifact {
+			tag := "TD"
+			if header {
+				tag = "TH"
+			}
+
+			cell := d.leaf(rowElem, tag)
+			if header {
+				cell.attrs = "/A <</O /Table /Scope /Column>>"
+			}
+		}
+
+		d.lines(lines, x+cellMargin, d.y-cellMargin-cellSize, cellSize, cellSize*1.3, header)
+
+		if !artifact {
+			content.WriteString("EMC\n")
+		}
+	}
+
+	if artifact {
+		content.WriteString("EMC\n")
+	}
+
+	d.y -= height
+}
+
+// chart draws the bars of the values, scaled to the largest one, with their
+// labels and values. The missing values get no bar.
+func (d *pdfDocument) chart(chart Chart) {
+	height := titleSize*1.3 + float64(len(chart.Labels))*(barHeight+barGap)
+	d.ensure(height)
+
+	top := d.y
+	figure := d.leaf(d.document, "Figure")
+	figure.alt = chart.alt(missingText)
+	figure.attrs = fmt.Sprintf("/A <</O /Layout /BBox [%.2f %.2f %.2f %.2f]>>", pageMargin, top-height, pageWidth-pageMargin, top)
+
+	d.lines([]string{chart.Title}, pageMargin, d.y-textSize, textSize, textSize, true)
+	d.y -= titleSize * 1.3
+
+	largest := 0.0
+	for _, value := range chart.Values {
+		largest = math.Max(largest, chartValue(value))
+	}
+
+	barsWidth := contentSize - chartLabels - chartValues
+	content := &d.page().content
+
+	for i, label := range chart.Labels {
+		value := chartValue(chart.Values[i])
+		y := d.y - barHeight
+
+		d.lines(wrap(label, cellSize, chartLabels-cellMargin, false)[:1], pageMargin, y+2, cellSize, cellSize, false)
+
+		barWidth := 0.0
+		if largest > 0 {
+			barWidth = value / largest * barsWidth
+		}
+
+		fmt.Fprintf(content, "0.13 0.39 0.67 rg %.2f %.2f %.2f %.2f re f 0 g\n", pageMargin+chartLabels, y, barWidth, barHeight)
+		d.lines([]string{cellText(chart.Values[i])}, pageMargin+chartLabels+barWidth+cellMargin, y+2, cellSize, cellSize, false)
+
+		d.y -= barHeight + barGap
+	}
+
+	content.WriteString("EMC\n")
+	d.y -= textSize
+}
+
+func chartValue(value interface{}) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+
+	return 0
+}
+
+func cellText(value interface{}) string {
+	if text := formatCell(value); text != "" || value != nil {
+		return text
+	}
+
+	return missingText
+}
+
+// write numbers the objects and writes them with their cross-reference
+// table. The structure elements are numbered after the pages.
+func (d *pdfDocument) write(w io.Writer, table Table) error {
+	objects := []string{}
+	add := func(object string) int {
+		objects = append(objects, object)
+		return len(objects)
+	}
+
+	reserve := func() int { return add("") }
+
+	catalog, pages, structRoot, parentTree := reserve(), reserve(), reserve(), reserve()
+	regular := add("<</Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding>>")
+	bold := add("<</Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding>>")
+
+	pageNumbers := []int{}
+	for range d.pages {
+		pageNumbers = append(pageNumbers, reserve())
+	}
+
+	var number func(elem *structElem)
+	number = func(elem *structElem) {
+		elem.number = reserve()
+		for _, kid := range elem.kids {
+			number(kid)
+		}
+	}
+
+	number(d.document)
+
+	var serialize func(elem *structElem, parent int)
+	serialize = func(elem *structElem, parent int) {
+		object := fmt.Sprintf("<</Type /StructElem /S /%s /P %d 0 R", elem.tag, parent)
+
+		if elem.mcid >= 0 {
+			object += fmt.Sprintf(" /Pg %d 0 R /K %d", pageNumbers[elem.page], elem.mcid)
+		} else {
+			kids := []string{}
+			for _, kid := range elem.kids {
+				kids = append(kids, fmt.Sprintf("%d 0 R", kid.number))
+				serialize(kid, elem.number)
+			}
+
+			object += " /K [" + strings.Join(kids, " ") + "]"
+		}
+
+		if elem.attrs != "" {
+			object += " " + elem.attrs
+		}
+
+		if elem.alt != "" {
+			object += " /Alt " + pdfTextString(elem.alt)
+		}
+
+		objects[elem.number-1] = object + ">>"
+	}
+
+	serialize(d.document, structRoot)
+
+	kids, parents := []string{}, []string{}
+
+	for i, page := range d.pages {
+		content, err := deflate(page.content.Bytes())
+		if err != nil {
+			return err
+		}
+
+		stream := add(fmt.Sprintf("<</Length %d /Filter /FlateDecode>>\nstream\n%s\nendstream", len(content), content))
+
+		objects[pageNumbers[i]-1] = fmt.Sprintf(
+			"<</Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources <</Font <</F1 %d 0 R /F2 %d 0 R>>>> /Contents %d 0 R /StructParents %d /Tabs /S>>",
+			pages, pageWidth, pageHeight, regular, bold, stream, i,
+		)
+
+		elements := []string{}
+		for _, elem := range page.elements {
+			elements = append(elements, fmt.Sprintf("%d 0 R", elem.number))
+		}
+
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageNumbers[i]))
+		parents = append(parents, fmt.Sprintf("%d [%s]", i, strings.Join(elements, " ")))
+	}
+
+	language := table.Language
+	if language == "" {
+		language = "en"
+	}
+
+	metadata := fmt.Sprintf(`<?xpacket begin="" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:pdfuaid="http://www.aiim.org/pdfua/ns/id/">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">%s</rdf:li></rdf:Alt></dc:title>
+<dc:language><rdf:Bag><rdf:li>%s</rdf:li></rdf:Bag></dc:language>
+<pdfuaid:part>1</pdfuaid:part>
+</rdf:Description></rdf:RDF></x:xmpmeta>
+<?xpacket end="w"?>`, escapeXML(table.Title), escapeXML(language))
+
+	metadataStream := add(fmt.Sprintf("<</Type /Metadata /Subtype /XML /Length %d>>\nstream\n%s\nendstream", len(metadata), metadata))
+	info := add(fmt.Sprintf("<</Title %s /Producer (CIJ API)>>", pdfTextString(table.Title)))
+
+	objects[catalog-1] = fmt.Sprintf(
+		"<</Type /Catalog /Pages %d 0 R /StructTreeRoot %d 0 R /MarkInfo <</Marked true>> /Lang %s /ViewerPreferences <</DisplayDocTitle true>> /Metadata %d 0 R>>",
+		pages, structRoot, pdfTextString(language), metadataStream,
+	)
+	objects[pages-1] = fmt.Sprintf("<</Type /Pages /Kids [%s] /Count %d>>", strings.Join(kids, " "), len(d.pages))
+	objects[structRoot-1] = fmt.Sprintf("<</Type /StructTreeRoot /K [%d 0 R] /ParentTree %d 0 R /ParentTreeNextKey %d>>", d.document.number, parentTree, len(d.pages))
+	objects[parentTree-1] = fmt.Sprintf("<</Nums [%s]>>", strings.Join(parents, " "))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&out, "trailer\n<</Size %d /Root %d 0 R /Info %d 0 R>>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, info, xref)
+
+	_, err := w.Write(out.Bytes())
+
+	return err
+}
+
+func deflate(content []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return compressed.Bytes(), nil
+}
+
+// pdfString encodes the text shown in a page, in the WinAnsi encoding of the
+// fonts.
+func pdfString(text string) string {
+	encoded, err := encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).String(text)
+	if err != nil {
+		encoded = text
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", "", "\n", " ")
+
+	return "(" + replacer.Replace(encoded) + ")"
+}
+
+// pdfTextString encodes the text of the document structure, in UTF-16.
+func pdfTextString(text string) string {
+	var encoded strings.Builder
+
+	encoded.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&encoded, "%04X", unit)
+	}
+
+	encoded.WriteString(">")
+
+	return encoded.String()
+}
+
+// wrap breaks the text in lines that fit the width, breaking the words longer
+// than a line.
+func wrap(text string, size float64, width float64, bold bool) []string {
+	lines := []string{}
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if textWidth(candidate, size, bold) <= width {
+			line = candidate
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		line = ""
+		for _, r := range word {
+			if line != "" && textWidth(line+string(r), size, bold) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+
+			line += string(r)
+		}
+	}
+
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// textWidth measures the text in the Helvetica widths, with some room for
+// the bold font, which is a bit wider.
+func textWidth(text string, size float64, bold bool) float64 {
+	width := 0
+
+	for _, r := range text {
+		if r >= ' ' && int(r-' ') < len(helveticaWidths) {
+			width += helveticaWidths[r-' ']
+		} else {
+			width += 556
+		}
+	}
+
+	if bold {
+		width = width * 11 / 10
+	}
+
+	return float64(width) * size / 1000
+}
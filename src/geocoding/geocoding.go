@@ -0,0 +1,140 @@
+package geocoding
+
+import (
+	"cij_api/src/config"
+	"cij_api/src/model"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	OfflineDriver   = "offline"
+	NominatimDriver = "nominatim"
+)
+
+const earthRadiusKm = 6371.0
+
+// Point is a latitude and longitude in degrees.
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Geocoder finds the coordinates of the addresses.
+type Geocoder interface {
+	// Geocode returns nil when the address can't be found.
+	Geocode(address model.Address) (*Point, error)
+}
+
+// NewGeocoder creates the geocoder selected by the GEOCODER_DRIVER config.
+// The offline centroids are used when no driver is set, and when the online
+// providers can't find the address.
+func NewGeocoder(config *config.Config) (Geocoder, error) {
+	offline, err := NewOfflineGeocoder(config.GeocoderCentroids)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.GeocoderDriver {
+	case "", OfflineDriver:
+		return offline, nil
+	case NominatimDriver:
+		return Chain(NewNominatimGeocoder(config.GeocoderUrl), offline), nil
+	}
+
+	return nil, fmt.Errorf("unsupported geocoder driver %q", config.GeocoderDriver)
+}
+
+// AddressPoint returns the coordinates stored on the address, or nil when it
+// has none.
+func AddressPoint(address *model.Address) *Point {
+	if address == nil || address.Latitude == nil || address.Longitude == nil {
+		return nil
+	}
+
+	return &Point{Latitude: *address.Latitude, Longitude: *address.Longitude}
+}
+
+func ValidCoordinates(latitude float64, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+// Distance is the great circle distance between the points, in kilometers.
+func Distance(a Point, b Point) float64 {
+	latitudeA, latitudeB := radians(a.Latitude), radians(b.Latitude)
+	deltaLatitude := latitudeB - latitudeA
+	deltaLongitude := radians(b.Longitude - a.Longitude)
+
+	h := math.Pow(math.Sin(deltaLatitude/2), 2) +
+		math.Cos(latitudeA)*math.Cos(latitudeB)*math.Pow(math.Sin(deltaLongitude/2), 2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// BoundingBox holds the latitudes and longitudes, in degrees, of the points
+// between its edges.
+type BoundingBox struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+// BoundingBoxAround returns a box holding every point within radiusKm of the
+// center, and some more, to narrow the points before measuring them. Near the
+// poles and the antimeridian it spans all the longitudes.
+func BoundingBoxAround(center Point, radiusKm float64) BoundingBox {
+	angle := radiusKm / earthRadiusKm
+	deltaLatitude := angle * 180 / math.Pi
+
+	box := BoundingBox{
+		MinLatitude:  math.Max(-90, center.Latitude-deltaLatitude),
+		MaxLatitude:  math.Min(90, center.Latitude+deltaLatitude),
+		MinLongitude: -180,
+		MaxLongitude: 180,
+	}
+
+	if box.MinLatitude <= -90 || box.MaxLatitude >= 90 {
+		return box
+	}
+
+	deltaLongitude := math.Asin(math.Min(1, math.Sin(angle)/math.Cos(radians(center.Latitude)))) * 180 / math.Pi
+
+	if center.Longitude-deltaLongitude >= -180 && center.Longitude+deltaLongitude <= 180 {
+		box.MinLongitude = center.Longitude - deltaLongitude
+		box.MaxLongitude = center.Longitude + deltaLongitude
+	}
+
+	return box
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+type chain []Geocoder
+
+// Chain asks the geocoders in order until one of them finds the address. The
+// errors are only returned when none did.
+func Chain(geocoders ...Geocoder) Geocoder {
+	return chain(geocoders)
+}
+
+func (c chain) Geocode(address model.Address) (*Point, error) {
+	var errs []error
+
+	for _, geocoder := range c {
+		point, err := geocoder.Geocode(address)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if point != nil {
+			return point, nil
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,107 @@
+package geocoding
+
+import (
+	"cij_api/src/model"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNominatimUrl = "https://nominatim.openstreetmap.org"
+	nominatimUserAgent  = "cij_api (Conexão Inclusão Jaraguá)"
+)
+
+// nominatimInterval keeps to the usage policy of the public server, of at
+// most one request per second.
+const nominatimInterval = time.Second
+
+type nominatimGeocoder struct {
+	url    string
+	client *http.Client
+
+	mutex       sync.Mutex
+	lastRequest time.Time
+}
+
+type nominatimPlace struct {
+	Latitude  string `json:"lat"`
+	Longitude string `json:"lon"`
+}
+
+// NewNominatimGeocoder locates the addresses with the OpenStreetMap Nominatim
+// search API. The public server is used when no URL is given.
+func NewNominatimGeocoder(serverUrl string) Geocoder {
+	if serverUrl == "" {
+		serverUrl = defaultNominatimUrl
+	}
+
+	return &nominatimGeocoder{
+		url:    strings.TrimSuffix(serverUrl, "/"),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *nominatimGeocoder) Geocode(address model.Address) (*Point, error) {
+	query := url.Values{}
+	query.Set("format", "jsonv2")
+	query.Set("limit", "1")
+	query.Set("street", strings.TrimSpace(address.Number+" "+address.Street))
+	query.Set("city", address.City)
+	query.Set("state", address.State)
+	query.Set("country", address.Country)
+	query.Set("postalcode", address.ZipCode)
+
+	request, err := http.NewRequest(http.MethodGet, g.url+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("User-Agent", nominatimUserAgent)
+	request.Header.Set("Accept", "application/json")
+
+	g.wait()
+
+	response, err := g.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim answered %s", response.Status)
+	}
+
+	var places []nominatimPlace
+	if err := json.NewDecoder(response.Body).Decode(&places); err != nil {
+		return nil, err
+	}
+
+	if len(places) == 0 {
+		return nil, nil
+	}
+
+	latitude, errLatitude := strconv.ParseFloat(places[0].Latitude, 64)
+	longitude, errLongitude := strconv.ParseFloat(places[0].Longitude, 64)
+	if errLatitude != nil || errLongitude != nil || !ValidCoordinates(latitude, longitude) {
+		return nil, fmt.Errorf("nominatim answered invalid coordinates %q, %q", places[0].Latitude, places[0].Longitude)
+	}
+
+	return &Point{Latitude: latitude, Longitude: longitude}, nil
+}
+
+func (g *nominatimGeocoder) wait() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if elapsed := time.Since(g.lastRequest); elapsed < nominatimInterval {
+		time.Sleep(nominatimInterval - elapsed)
+	}
+
+	g.lastRequest = time.Now()
+}
@@ -0,0 +1,146 @@
+package geocoding
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/utils"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// centroids are the approximate centers of the neighborhoods of Jaraguá do
+// Sul, of the cities around it and of their zip code ranges.
+//
+//go:embed centroids.csv
+var centroids string
+
+const (
+	neighborhoodCentroid = "neighborhood"
+	cityCentroid         = "city"
+	zipCentroid          = "zip"
+)
+
+type offlineGeocoder struct {
+	neighborhoods map[string]Point
+	cities        map[string]Point
+	zipCodes      map[string]Point
+}
+
+// NewOfflineGeocoder locates the addresses by the centroid of their
+// neighborhood, else of their zip code, else of their city, so it never calls
+// out. The centroids of the file, when given, are added to the embedded ones
+// and replace them.
+func NewOfflineGeocoder(centroidsPath string) (Geocoder, error) {
+	geocoder := &offlineGeocoder{
+		neighborhoods: map[string]Point{},
+		cities:        map[string]Point{},
+		zipCodes:      map[string]Point{},
+	}
+
+	if err := geocoder.load(strings.NewReader(centroids)); err != nil {
+		return nil, err
+	}
+
+	if centroidsPath != "" {
+		file, err := os.Open(centroidsPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		if err := geocoder.load(file); err != nil {
+			return nil, fmt.Errorf("%s: %w", centroidsPath, err)
+		}
+	}
+
+	return geocoder, nil
+}
+
+func (g *offlineGeocoder) Geocode(address model.Address) (*Point, error) {
+	city := placeKey(address.City, address.State)
+
+	if point, ok := g.neighborhoods[placeKey(address.Neighborhood, address.City, address.State)]; ok && address.Neighborhood != "" {
+		return &point, nil
+	}
+
+	zipCode := digits(address.ZipCode)
+	for length := len(zipCode); length > 0; length-- {
+		if point, ok := g.zipCodes[zipCode[:length]]; ok {
+			return &point, nil
+		}
+	}
+
+	if point, ok := g.cities[city]; ok {
+		return &point, nil
+	}
+
+	return nil, nil
+}
+
+// load reads the centroids from a CSV with the kind, name, city, state,
+// latitude and longitude columns. The names of the zip centroids are the zip
+// code prefixes.
+func (g *offlineGeocoder) load(reader io.Reader) error {
+	records, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i, record := range records {
+		if i == 0 || len(record) == 0 {
+			continue
+		}
+
+		if len(record) != 6 {
+			return fmt.Errorf("line %d: expected 6 columns, got %d", i+1, len(record))
+		}
+
+		latitude, errLatitude := strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+		longitude, errLongitude := strconv.ParseFloat(strings.TrimSpace(record[5]), 64)
+		if errLatitude != nil || errLongitude != nil || !ValidCoordinates(latitude, longitude) {
+			return fmt.Errorf("line %d: invalid coordinates", i+1)
+		}
+
+		point := Point{Latitude: latitude, Longitude: longitude}
+
+		switch record[0] {
+		case neighborhoodCentroid:
+			g.neighborhoods[placeKey(record[1], record[2], record[3])] = point
+		case cityCentroid:
+			g.cities[placeKey(record[2], record[3])] = point
+		case zipCentroid:
+			g.zipCodes[digits(record[1])] = point
+		default:
+			return fmt.Errorf("line %d: unknown kind %q", i+1, record[0])
+		}
+	}
+
+	return nil
+}
+
+// placeKey ignores the case, accents and spaces of the names, as they are
+// typed freely in the addresses.
+func placeKey(names ...string) string {
+	keys := make([]string, len(names))
+
+	for i, name := range names {
+		keys[i] = utils.NormalizeText(strings.TrimSpace(name))
+	}
+
+	return strings.Join(keys, "|")
+}
+
+func digits(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+
+		return -1
+	}, value)
+}
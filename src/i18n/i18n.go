@@ -0,0 +1,84 @@
+// Package i18n translates the API messages, which are written in English, to
+// the languages asked by the clients.
+package i18n
+
+import (
+	"cij_api/src/enum"
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// supported lists the languages in the order they are preferred when the
+// client asks only for languages the API doesn't have.
+var supported = []enum.LanguageEnum{enum.PortugueseBR, enum.English, enum.Spanish}
+
+var matcher = language.NewMatcher([]language.Tag{
+	language.BrazilianPortuguese,
+	language.English,
+	language.Spanish,
+})
+
+// bundles maps each message to its translation, per language.
+var bundles = loadBundles()
+
+func loadBundles() map[enum.LanguageEnum]map[string]string {
+	loaded := map[enum.LanguageEnum]map[string]string{}
+
+	for _, lang := range supported {
+		content, err := locales.ReadFile(fmt.Sprintf("locales/%s.json", lang))
+		if err != nil {
+			panic(err)
+		}
+
+		bundle := map[string]string{}
+		if err := json.Unmarshal(content, &bundle); err != nil {
+			panic(fmt.Sprintf("invalid %s bundle: %v", lang, err))
+		}
+
+		loaded[lang] = bundle
+	}
+
+	return loaded
+}
+
+// Negotiate picks the language of the answer from an Accept-Language header.
+// It returns false when the header is empty, so the answer is left as it is;
+// languages the API doesn't have fall back to Brazilian Portuguese.
+func Negotiate(acceptLanguage string) (enum.LanguageEnum, bool) {
+	if acceptLanguage == "" {
+		return "", false
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return supported[0], true
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return supported[0], true
+	}
+
+	return supported[index], true
+}
+
+// Translate returns the message in the language. Messages missing from the
+// bundle of the language fall back to the English bundle, and then to the
+// message itself.
+func Translate(lang enum.LanguageEnum, message string) string {
+	if translated, ok := bundles[lang][message]; ok {
+		return translated
+	}
+
+	if translated, ok := bundles[enum.English][message]; ok {
+		return translated
+	}
+
+	return message
+}
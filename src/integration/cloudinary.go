@@ -1,13 +1,15 @@
 package integration
 
-import "github.com/cloudinary/cloudinary-go/v2"
+import (
+	"errors"
 
-func CloudinaryConnect(url string) *cloudinary.Cloudinary {
-	cld, err := cloudinary.NewFromURL(url)
+	"github.com/cloudinary/cloudinary-go/v2"
+)
 
-	if err != nil {
-		panic("failed to connect cloudinary")
+func CloudinaryConnect(url string) (*cloudinary.Cloudinary, error) {
+	if url == "" {
+		return nil, errors.New("CLOUDINARY_URL is required by the cloudinary storage")
 	}
 
-	return cld
-}
\ No newline at end of file
+	return cloudinary.NewFromURL(url)
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/service"
+	"fmt"
+	"time"
+)
+
+// StartActivityArchiver archives the activities older than the retention of
+// their type now and then every interval, until the returned function is
+// called.
+func StartActivityArchiver(activityService service.ActivityService, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	archive := func() {
+		if _, err := activityService.ArchiveExpiredActivities(model.SystemActor("activity-archiver")); err.Code != "" {
+			fmt.Println("Error:", err.Message)
+		}
+	}
+
+	go func() {
+		archive()
+
+		for {
+			select {
+			case <-ticker.C:
+				archive()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
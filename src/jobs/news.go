@@ -0,0 +1,40 @@
+// Package jobs runs the periodic tasks of the API server.
+package jobs
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/service"
+	"fmt"
+	"time"
+)
+
+// StartNewsPublisher publishes the scheduled news now and then every interval,
+// until the returned function is called.
+func StartNewsPublisher(newsService service.NewsService, interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	publish := func() {
+		if _, err := newsService.PublishScheduledNews(model.SystemActor("news-publisher")); err.Code != "" {
+			fmt.Println("Error:", err.Message)
+		}
+	}
+
+	go func() {
+		publish()
+
+		for {
+			select {
+			case <-ticker.C:
+				publish()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
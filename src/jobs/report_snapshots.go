@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/service"
+	"fmt"
+	"time"
+)
+
+// StartReportSnapshots takes the missing report snapshots now and then every
+// night at the hour of the snapshots timezone, until the returned function
+// is called.
+func StartReportSnapshots(reportSnapshotService service.ReportSnapshotService, hour int) (stop func()) {
+	done := make(chan struct{})
+
+	snapshot := func() {
+		if _, err := reportSnapshotService.TakeMissingSnapshots(model.SystemActor("report-snapshots")); err.Code != "" {
+			fmt.Println("Error:", err.Message)
+		}
+	}
+
+	go func() {
+		snapshot()
+
+		for {
+			timer := time.NewTimer(time.Until(nextRun(time.Now().In(service.SnapshotsLocation()), hour)))
+
+			select {
+			case <-timer.C:
+				snapshot()
+			case <-done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+	}
+}
+
+// nextRun is the next time at the hour after now, in its location.
+func nextRun(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"cij_api/src/model"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const requestIdKey = "request_id"
+
+// RequestId tags each request with the id sent in the X-Request-ID header, or
+// a new one, and answers with it so clients can refer to the audit events.
+var RequestId = requestid.New(requestid.Config{
+	Generator:  utils.UUIDv4,
+	ContextKey: requestIdKey,
+})
+
+// GetAuditActor returns who is making the request, for the audit log. The
+// user fields are empty when the route is not authenticated.
+func GetAuditActor(ctx *fiber.Ctx) model.AuditActor {
+	userId, _ := ctx.Locals(tokenIdKey).(int)
+	requestId, _ := ctx.Locals(requestIdKey).(string)
+
+	return model.AuditActor{
+		UserId:    userId,
+		Email:     GetTokenEmail(ctx),
+		Role:      GetTokenRole(ctx),
+		Ip:        ctx.IP(),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+		RequestId: requestId,
+	}
+}
@@ -17,6 +17,33 @@ const PERSON_ROLE = "person"
 const COMPANY_ROLE = "company"
 const ADMIN_ROLE = "admin"
 
+const tokenIdKey = "token_id"
+const tokenEmailKey = "token_email"
+const tokenRoleKey = "token_role"
+
+// AuthAny accepts any valid token, whatever the role.
+func AuthAny(ctx *fiber.Ctx) error {
+	if _, err := Auth(ctx); err.Message != "" {
+		return ctx.Status(http.StatusBadRequest).JSON(err)
+	}
+
+	return ctx.Next()
+}
+
+// AuthOptional authenticates the requests that send a token, so the handler
+// can tell the role with GetTokenRole, and lets anonymous requests through.
+func AuthOptional(ctx *fiber.Ctx) error {
+	if ctx.Get("Authorization") == "" {
+		return ctx.Next()
+	}
+
+	if _, err := Auth(ctx); err.Message != "" {
+		return ctx.Status(http.StatusBadRequest).JSON(err)
+	}
+
+	return ctx.Next()
+}
+
 func AuthUser(ctx *fiber.Ctx) error {
 	var response model.Response
 
@@ -112,5 +139,29 @@ func Auth(ctx *fiber.Ctx) (*jwt.Token, model.Response) {
 		return nil, response
 	}
 
+	claims := token.Claims.(jwt.MapClaims)
+	id, _ := claims["id"].(float64) // missing in the tokens issued before it
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+
+	ctx.Locals(tokenIdKey, int(id))
+	ctx.Locals(tokenEmailKey, email)
+	ctx.Locals(tokenRoleKey, role)
+
 	return token, model.Response{}
 }
+
+// GetTokenEmail returns the email of the authenticated user. It is empty when
+// the route is not protected by one of the auth middlewares.
+func GetTokenEmail(ctx *fiber.Ctx) string {
+	email, _ := ctx.Locals(tokenEmailKey).(string)
+
+	return email
+}
+
+// GetTokenRole returns the role name of the authenticated user.
+func GetTokenRole(ctx *fiber.Ctx) string {
+	role, _ := ctx.Locals(tokenRoleKey).(string)
+
+	return role
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/i18n"
+	"cij_api/src/model"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const languageKey = "language"
+
+// Language answers in the language negotiated from the Accept-Language header,
+// translating the message and the field values of the JSON responses. Requests
+// without the header are answered as they always were.
+func Language(ctx *fiber.Ctx) error {
+	ctx.Vary(fiber.HeaderAcceptLanguage)
+
+	language, ok := i18n.Negotiate(ctx.Get(fiber.HeaderAcceptLanguage))
+	if !ok {
+		return ctx.Next()
+	}
+
+	ctx.Locals(languageKey, language)
+
+	if err := ctx.Next(); err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentLanguage, string(language))
+
+	return translateResponse(ctx, language)
+}
+
+// GetLanguage returns the language negotiated for the request. It is empty
+// when the client didn't send an Accept-Language header.
+func GetLanguage(ctx *fiber.Ctx) enum.LanguageEnum {
+	language, _ := ctx.Locals(languageKey).(enum.LanguageEnum)
+
+	return language
+}
+
+// translateResponse rewrites the message, fields and warnings of a JSON object
+// response. Other responses, like files and lists, are left untouched.
+func translateResponse(ctx *fiber.Ctx, language enum.LanguageEnum) error {
+	if !strings.HasPrefix(string(ctx.Response().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return nil
+	}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(ctx.Response().Body(), &body); err != nil {
+		return nil
+	}
+
+	var message string
+	if err := json.Unmarshal(body["message"], &message); err == nil {
+		body["message"], _ = json.Marshal(i18n.Translate(language, message))
+	}
+
+	for _, key := range []string{"fields", "warnings"} {
+		var fields []model.Field
+		if err := json.Unmarshal(body[key], &fields); err != nil || fields == nil {
+			continue
+		}
+
+		for i := range fields {
+			if fields[i].Value != "" {
+				fields[i].Value = i18n.Translate(language, fields[i].Value)
+			}
+		}
+
+		body[key], _ = json.Marshal(fields)
+	}
+
+	translated, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+
+	ctx.Response().SetBodyRaw(translated)
+
+	return nil
+}
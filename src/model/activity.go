@@ -1,6 +1,10 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Activity struct {
 	*gorm.Model
@@ -11,10 +15,11 @@ type Activity struct {
 }
 
 type ActivityResponse struct {
-	ID          uint   `json:"id"`
-	Type        string `json:"type"`
-	Description string `json:"description"`
-	Actor       string `json:"actor"`
+	ID          uint      `json:"id"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Actor       string    `json:"actor"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type ActivityRequest struct {
@@ -32,10 +37,45 @@ func (a *ActivityRequest) ToModel() *Activity {
 }
 
 func (a *Activity) ToResponse() *ActivityResponse {
-	return &ActivityResponse{
+	response := &ActivityResponse{
 		ID:          a.ID,
 		Type:        a.Type,
 		Description: a.Description,
 		Actor:       a.Actor,
 	}
+
+	if a.Model != nil {
+		response.CreatedAt = a.CreatedAt
+	}
+
+	return response
+}
+
+// ActivityRetention is how many days the activities of a type are kept
+// before being archived. Types without a retention are kept forever.
+type ActivityRetention struct {
+	Id        int       `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	Type      string    `gorm:"type:varchar(100);not null;uniqueIndex" json:"type"`
+	Days      int       `gorm:"type:int;not null" json:"days"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ActivityRetentionRequest struct {
+	Days int `json:"days"`
+}
+
+// ActivityArchive describes a file with the archived activities of a type.
+type ActivityArchive struct {
+	Type   string    `json:"type"`
+	Key    string    `json:"key"`
+	Count  int       `json:"count"`
+	Before time.Time `json:"before"`
+}
+
+// ActivityExportFilter selects the activities streamed by the export. An
+// empty type exports every type.
+type ActivityExportFilter struct {
+	Type string
+	From time.Time
+	To   time.Time
 }
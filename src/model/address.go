@@ -18,29 +18,43 @@ type Address struct {
 	Country      string    `gorm:"type:varchar(200);not null" json:"country"`
 	ZipCode      string    `gorm:"type:char(8);not null" json:"zip_code"`
 	Complement   string    `gorm:"type:varchar(200);" json:"complement"`
+	Latitude     *float64  `gorm:"type:double" json:"latitude"`
+	Longitude    *float64  `gorm:"type:double" json:"longitude"`
 }
 
+// AddressRequest is geocoded when the latitude and longitude are not given.
 type AddressRequest struct {
-	Street       string `json:"street"`
-	Number       string `json:"number"`
-	Neighborhood string `json:"neighborhood"`
-	City         string `json:"city"`
-	State        string `json:"state"`
-	Country      string `json:"country"`
-	ZipCode      string `json:"zip_code"`
-	Complement   string `json:"complement"`
+	Street       string   `json:"street"`
+	Number       string   `json:"number"`
+	Neighborhood string   `json:"neighborhood"`
+	City         string   `json:"city"`
+	State        string   `json:"state"`
+	Country      string   `json:"country"`
+	ZipCode      string   `json:"zip_code"`
+	Complement   string   `json:"complement"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
 }
 
 type AddressResponse struct {
-	Id           int    `json:"id"`
-	Street       string `json:"street"`
-	Number       string `json:"number"`
-	Neighborhood string `json:"neighborhood"`
-	City         string `json:"city"`
-	State        string `json:"state"`
-	Country      string `json:"country"`
-	ZipCode      string `json:"zip_code"`
-	Complement   string `json:"complement"`
+	Id           int      `json:"id"`
+	Street       string   `json:"street"`
+	Number       string   `json:"number"`
+	Neighborhood string   `json:"neighborhood"`
+	City         string   `json:"city"`
+	State        string   `json:"state"`
+	Country      string   `json:"country"`
+	ZipCode      string   `json:"zip_code"`
+	Complement   string   `json:"complement"`
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+}
+
+// GeocodeResult counts the addresses located, and the ones the geocoder
+// couldn't find.
+type GeocodeResult struct {
+	Geocoded int `json:"geocoded"`
+	NotFound int `json:"not_found"`
 }
 
 func (a *Address) ToResponse() AddressResponse {
@@ -54,6 +68,8 @@ func (a *Address) ToResponse() AddressResponse {
 		Country:      a.Country,
 		ZipCode:      a.ZipCode,
 		Complement:   a.Complement,
+		Latitude:     a.Latitude,
+		Longitude:    a.Longitude,
 	}
 }
 
@@ -67,5 +83,7 @@ func (a *AddressRequest) ToModel() Address {
 		Country:      a.Country,
 		ZipCode:      a.ZipCode,
 		Complement:   a.Complement,
+		Latitude:     a.Latitude,
+		Longitude:    a.Longitude,
 	}
 }
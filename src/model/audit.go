@@ -0,0 +1,224 @@
+package model
+
+import (
+	"cij_api/src/enum"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditAppendOnly is returned when changing or removing an audit event.
+var ErrAuditAppendOnly = errors.New("audit events are append-only")
+
+// SystemRole is the role of the audit actor of the scheduled jobs and the
+// command line.
+const SystemRole = "system"
+
+// AuditActor is who made a change and from where. The user id is resolved
+// from the email when the token doesn't carry it.
+type AuditActor struct {
+	UserId    int
+	Email     string
+	Role      string
+	Ip        string
+	UserAgent string
+	RequestId string
+}
+
+// SystemActor is the actor of the changes made by the API itself, named
+// after the job or command that made them.
+func SystemActor(name string) AuditActor {
+	return AuditActor{Role: SystemRole, UserAgent: name}
+}
+
+// OrRegistering returns the actor, or the user being registered when nobody
+// is logged in, since self-registrations are made by the new user.
+func (a AuditActor) OrRegistering(userId int, email string, role string) AuditActor {
+	if a.Email != "" {
+		return a
+	}
+
+	a.UserId = userId
+	a.Email = email
+	a.Role = role
+
+	return a
+}
+
+// AuditEvent records a change made by an actor to an entity. Events are only
+// ever inserted: the hooks below and the database triggers reject changes.
+type AuditEvent struct {
+	Id         int              `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	CreatedAt  time.Time        `gorm:"index" json:"created_at"`
+	ActorId    *int             `gorm:"type:int;index" json:"actor_id"`
+	ActorEmail string           `gorm:"type:varchar(100)" json:"actor_email"`
+	ActorRole  string           `gorm:"type:varchar(20)" json:"actor_role"`
+	Action     enum.AuditAction `gorm:"type:varchar(20);not null;index" json:"action"`
+	EntityType enum.AuditEntity `gorm:"type:varchar(50);not null;index:idx_audit_events_entity" json:"entity_type"`
+	EntityId   int              `gorm:"type:int;index:idx_audit_events_entity" json:"entity_id"`
+	Changes    AuditChanges     `gorm:"type:text;serializer:json" json:"changes"`
+	Ip         string           `gorm:"type:varchar(45)" json:"ip"`
+	UserAgent  string           `gorm:"type:varchar(255)" json:"user_agent"`
+	RequestId  string           `gorm:"type:varchar(64);index" json:"request_id"`
+}
+
+func (e *AuditEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+func (e *AuditEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+// AuditChange is the value of a field before and after a change. Created
+// entities have no before and deleted ones no after.
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type AuditChanges map[string]AuditChange
+
+// auditIgnoredFields change on every save and say nothing about the change.
+var auditIgnoredFields = map[string]bool{
+	"CreatedAt":  true,
+	"UpdatedAt":  true,
+	"DeletedAt":  true,
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+}
+
+// auditRedactedFields are never stored, only whether they changed.
+var auditRedactedFields = map[string]bool{
+	"password": true,
+	"Password": true,
+}
+
+const auditRedacted = "[redacted]"
+
+// NewAuditChanges compares the JSON fields of the entity before and after the
+// change, either of which may be nil, and returns the fields that differ.
+func NewAuditChanges(before interface{}, after interface{}) AuditChanges {
+	beforeFields := auditFields(before)
+	afterFields := auditFields(after)
+	changes := AuditChanges{}
+
+	for name, value := range afterFields {
+		if previous, ok := beforeFields[name]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[name] = AuditChange{Before: redactAuditValue(name, previous), After: redactAuditValue(name, value)}
+		}
+	}
+
+	for name, value := range beforeFields {
+		if _, ok := afterFields[name]; !ok {
+			changes[name] = AuditChange{Before: redactAuditValue(name, value)}
+		}
+	}
+
+	return changes
+}
+
+func auditFields(value interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	if value == nil {
+		return fields
+	}
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fields
+	}
+
+	json.Unmarshal(content, &fields)
+
+	for name := range auditIgnoredFields {
+		delete(fields, name)
+	}
+
+	return fields
+}
+
+// redactAuditValue hides the sensitive fields, at any depth.
+func redactAuditValue(name string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if auditRedactedFields[name] {
+		return auditRedacted
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		redacted := map[string]interface{}{}
+
+		for key, nested := range typed {
+			if !auditIgnoredFields[key] {
+				redacted[key] = redactAuditValue(key, nested)
+			}
+		}
+
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(typed))
+
+		for i, nested := range typed {
+			redacted[i] = redactAuditValue("", nested)
+		}
+
+		return redacted
+	}
+
+	return value
+}
+
+type AuditEventResponse struct {
+	Id         int              `json:"id"`
+	CreatedAt  time.Time        `json:"created_at"`
+	ActorId    *int             `json:"actor_id"`
+	ActorEmail string           `json:"actor_email"`
+	ActorRole  string           `json:"actor_role"`
+	Action     enum.AuditAction `json:"action"`
+	EntityType enum.AuditEntity `json:"entity_type"`
+	EntityId   int              `json:"entity_id"`
+	Changes    AuditChanges     `json:"changes"`
+	Ip         string           `json:"ip"`
+	UserAgent  string           `json:"user_agent"`
+	RequestId  string           `json:"request_id"`
+}
+
+func (e *AuditEvent) ToResponse() AuditEventResponse {
+	return AuditEventResponse{
+		Id:         e.Id,
+		CreatedAt:  e.CreatedAt,
+		ActorId:    e.ActorId,
+		ActorEmail: e.ActorEmail,
+		ActorRole:  e.ActorRole,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityId:   e.EntityId,
+		Changes:    e.Changes,
+		Ip:         e.Ip,
+		UserAgent:  e.UserAgent,
+		RequestId:  e.RequestId,
+	}
+}
+
+// AuditFilter selects the audit events of a list, newest first. Zero values
+// don't filter and pages start at 1.
+type AuditFilter struct {
+	ActorId    int
+	Action     enum.AuditAction
+	EntityType enum.AuditEntity
+	EntityId   int
+	RequestId  string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PerPage    int
+}
@@ -15,11 +15,12 @@ type Company struct {
 }
 
 type CompanyRequest struct {
-	Name    string         `json:"name"`
-	Cnpj    string         `json:"cnpj"`
-	Phone   string         `json:"phone"`
-	User    UserRequest    `json:"user"`
-	Address AddressRequest `json:"address"`
+	Name         string         `json:"name"`
+	Cnpj         string         `json:"cnpj"`
+	Phone        string         `json:"phone"`
+	User         UserRequest    `json:"user"`
+	Address      AddressRequest `json:"address"`
+	ConfigPreset string         `json:"config_preset,omitempty"`
 }
 
 type CompanyResponse struct {
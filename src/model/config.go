@@ -2,6 +2,9 @@ package model
 
 import (
 	"cij_api/src/enum"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type Config struct {
@@ -29,6 +32,21 @@ type SystemPrimaryColors struct {
 
 type SystemChartColors map[enum.DisabilityCategoryEnum]string
 
+// ContrastCheck is the WCAG contrast ratio between a text color and the
+// color behind it.
+type ContrastCheck struct {
+	Foreground string  `json:"foreground"`
+	Background string  `json:"background"`
+	Ratio      float64 `json:"ratio"`
+	AA         bool    `json:"aa"`
+	AAA        bool    `json:"aaa"`
+}
+
+type PaletteResponse struct {
+	SystemColors SystemColors    `json:"system_colors"`
+	Contrast     []ContrastCheck `json:"contrast"`
+}
+
 var DefaultConfig = Config{
 	FontSize:       16,
 	ScreenReader:   false,
@@ -53,3 +71,40 @@ var DefaultConfig = Config{
 		},
 	},
 }
+
+// UserConfig is a version of the accessibility config of a user. Every change
+// creates a new version, so the previous ones can be restored.
+type UserConfig struct {
+	*gorm.Model
+	Id      int    `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	UserId  int    `gorm:"type:int;not null;uniqueIndex:idx_user_config_version" json:"user_id"`
+	Version int    `gorm:"type:int;not null;uniqueIndex:idx_user_config_version" json:"version"`
+	Config  Config `gorm:"type:text;not null;serializer:json" json:"config"`
+	User    *User
+}
+
+type UserConfigVersionResponse struct {
+	Version   int       `json:"version"`
+	Config    Config    `json:"config"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (u *UserConfig) ToVersionResponse() UserConfigVersionResponse {
+	return UserConfigVersionResponse{
+		Version:   u.Version,
+		Config:    u.Config,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+// Clone returns a copy of the config that doesn't share the chart colors.
+func (c Config) Clone() Config {
+	chartColors := SystemChartColors{}
+	for category, color := range c.SystemColors.ChartColors {
+		chartColors[category] = color
+	}
+
+	c.SystemColors.ChartColors = chartColors
+
+	return c
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"cij_api/src/enum"
+
+	"gorm.io/gorm"
+)
+
+// ConfigPreset is a named config that users can apply to their own. A preset
+// with a company is the branded default config of the company recruiters and
+// isn't listed with the public presets.
+type ConfigPreset struct {
+	*gorm.Model
+	Id          int    `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	Slug        string `gorm:"type:varchar(100);not null;unique" json:"slug"`
+	Name        string `gorm:"type:varchar(100);not null" json:"name"`
+	Description string `gorm:"type:varchar(255)" json:"description"`
+	CompanyId   *int   `gorm:"type:int;unique" json:"company_id"`
+	Config      Config `gorm:"type:text;not null;serializer:json" json:"config"`
+}
+
+type ConfigPresetRequest struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Config      Config `json:"config"`
+}
+
+type ConfigPresetResponse struct {
+	Id          int    `json:"id"`
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CompanyId   *int   `json:"company_id,omitempty"`
+	Config      Config `json:"config"`
+}
+
+func (p *ConfigPreset) ToResponse() ConfigPresetResponse {
+	return ConfigPresetResponse{
+		Id:          p.Id,
+		Slug:        p.Slug,
+		Name:        p.Name,
+		Description: p.Description,
+		CompanyId:   p.CompanyId,
+		Config:      p.Config.Clone(),
+	}
+}
+
+func (p *ConfigPresetRequest) ToModel() ConfigPreset {
+	return ConfigPreset{
+		Slug:        p.Slug,
+		Name:        p.Name,
+		Description: p.Description,
+		Config:      p.Config.Clone(),
+	}
+}
+
+// DefaultConfigPresets are created on the first start. Admins can change them
+// afterwards, so they are never overwritten.
+var DefaultConfigPresets = []ConfigPreset{
+	{
+		Slug:        "high-contrast",
+		Name:        "Alto contraste",
+		Description: "Texto claro sobre fundo preto, com contraste acima do nível AAA",
+		Config: Config{
+			FontSize:       18,
+			Theme:          enum.Dark,
+			ColorBlindness: enum.Normal,
+			SystemColors: SystemColors{
+				PrimaryColors: SystemPrimaryColors{
+					PrimaryColor:       "#FFFF00",
+					SecondaryColor:     "#00FFFF",
+					FontColor:          "#FFFFFF",
+					SecondaryFontColor: "#FFFF00",
+					InputColor:         "#1A1A1A",
+					BackgroundColor:    "#000000",
+				},
+				ChartColors: SystemChartColors{
+					enum.Visual:       "#FFFF00",
+					enum.Hearing:      "#0072B2",
+					enum.Motor:        "#D55E00",
+					enum.Intellectual: "#009E73",
+					enum.Psychosocial: "#CC79A7",
+				},
+			},
+		},
+	},
+	{
+		Slug:        "low-vision",
+		Name:        "Baixa visão",
+		Description: "Fonte grande e texto escuro sobre fundo branco",
+		Config: Config{
+			FontSize:       26,
+			ScreenReader:   true,
+			Theme:          enum.Light,
+			ColorBlindness: enum.Normal,
+			SystemColors: SystemColors{
+				PrimaryColors: SystemPrimaryColors{
+					PrimaryColor:       "#003379",
+					SecondaryColor:     "#004AAD",
+					FontColor:          "#000000",
+					SecondaryFontColor: "#4D4D4D",
+					InputColor:         "#F5F5F5",
+					BackgroundColor:    "#FFFFFF",
+				},
+				ChartColors: DefaultConfig.Clone().SystemColors.ChartColors,
+			},
+		},
+	},
+	{
+		Slug:        "dyslexia-friendly",
+		Name:        "Dislexia",
+		Description: "Fundo creme e texto cinza escuro, que reduzem o brilho e o cansaço na leitura",
+		Config: Config{
+			FontSize:       18,
+			Theme:          enum.Light,
+			ColorBlindness: enum.Normal,
+			SystemColors: SystemColors{
+				PrimaryColors: SystemPrimaryColors{
+					PrimaryColor:       "#004AAD",
+					SecondaryColor:     "#003379",
+					FontColor:          "#1E1E1E",
+					SecondaryFontColor: "#4D4D4D",
+					InputColor:         "#FFFFFF",
+					BackgroundColor:    "#FAFAC8",
+				},
+				ChartColors: DefaultConfig.Clone().SystemColors.ChartColors,
+			},
+		},
+	},
+	colorBlindnessPreset("protanopia", "Protanopia", enum.Protanopia, SystemChartColors{
+		enum.Visual:       "#004AAD",
+		enum.Hearing:      "#A1960D",
+		enum.Motor:        "#000000",
+		enum.Intellectual: "#009E73",
+		enum.Psychosocial: "#CC79A7",
+	}),
+	colorBlindnessPreset("deuteranopia", "Deuteranopia", enum.Deuteranopia, SystemChartColors{
+		enum.Visual:       "#004AAD",
+		enum.Hearing:      "#C28600",
+		enum.Motor:        "#000000",
+		enum.Intellectual: "#CC79A7",
+		enum.Psychosocial: "#209CE2",
+	}),
+	colorBlindnessPreset("tritanopia", "Tritanopia", enum.Tritanopia, SystemChartColors{
+		enum.Visual:       "#004AAD",
+		enum.Hearing:      "#D55E00",
+		enum.Motor:        "#A1960D",
+		enum.Intellectual: "#000000",
+		enum.Psychosocial: "#009E73",
+	}),
+}
+
+func colorBlindnessPreset(slug string, name string, colorBlindness enum.ColorBlindnessEnum, chartColors SystemChartColors) ConfigPreset {
+	config := DefaultConfig.Clone()
+	config.ColorBlindness = colorBlindness
+	config.SystemColors.PrimaryColors.SecondaryFontColor = "#595959"
+	config.SystemColors.ChartColors = chartColors
+
+	return ConfigPreset{
+		Slug:        slug,
+		Name:        name,
+		Description: "Cores dos gráficos distinguíveis com " + name,
+		Config:      config,
+	}
+}
@@ -1,22 +1,75 @@
 package model
 
 import (
+	"cij_api/src/enum"
+
 	"gorm.io/gorm"
 )
 
+// DisabilityCategory groups the disabilities in the reports and charts. The
+// name is in Brazilian Portuguese and the translations hold it in the other
+// languages.
+type DisabilityCategory struct {
+	*gorm.Model
+	Id           int                         `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	Slug         enum.DisabilityCategoryEnum `gorm:"type:varchar(50);not null;unique" json:"slug"`
+	Name         string                      `gorm:"type:varchar(100);not null" json:"name"`
+	Translations Translations                `gorm:"type:text;serializer:json" json:"translations"`
+}
+
+type DisabilityCategoryRequest struct {
+	Slug         string       `json:"slug"`
+	Name         string       `json:"name"`
+	Translations Translations `json:"translations,omitempty"`
+}
+
+type DisabilityCategoryResponse struct {
+	Id           int                         `json:"id"`
+	Slug         enum.DisabilityCategoryEnum `json:"slug"`
+	Name         string                      `json:"name"`
+	Translations Translations                `json:"translations,omitempty"`
+}
+
+func (c *DisabilityCategory) ToResponse() DisabilityCategoryResponse {
+	return DisabilityCategoryResponse{
+		Id:           c.Id,
+		Slug:         c.Slug,
+		Name:         c.Name,
+		Translations: c.Translations,
+	}
+}
+
+// ToLocalizedResponse returns the category with the name in the language,
+// falling back to the Brazilian Portuguese one.
+func (c *DisabilityCategory) ToLocalizedResponse(language enum.LanguageEnum) DisabilityCategoryResponse {
+	response := c.ToResponse()
+	response.Name = c.Translations.Get(language, c.Name)
+
+	return response
+}
+
 type Disability struct {
 	*gorm.Model
-	Id          int    `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
-	Category    string `gorm:"type:varchar(200);not null;index" json:"category"`
-	Description string `gorm:"type:varchar(200);not null;index" json:"description"`
-	Rate        int    `gorm:"type:int;not null" json:"rate"`
-	People      []PersonDisability
+	Id                  int                          `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	CategoryId          int                          `gorm:"type:int;index" json:"category_id"`
+	Description         string                       `gorm:"type:varchar(200);not null;index" json:"description"`
+	Rate                int                          `gorm:"type:int;not null" json:"rate"`
+	Cid10               string                       `gorm:"type:varchar(10)" json:"cid10"`
+	Icd11               string                       `gorm:"type:varchar(10)" json:"icd11"`
+	LegalClassification enum.LegalClassificationEnum `gorm:"type:varchar(20)" json:"legal_classification"`
+	Translations        Translations                 `gorm:"type:text;serializer:json" json:"translations"`
+	Category            *DisabilityCategory
+	People              []PersonDisability
 }
 
 type DisabilityRequest struct {
-	Category    string `json:"category"`
-	Description string `json:"description"`
-	Rate        int    `json:"rate"`
+	Category            string                       `json:"category"`
+	Description         string                       `json:"description"`
+	Rate                int                          `json:"rate"`
+	Cid10               string                       `json:"cid10"`
+	Icd11               string                       `json:"icd11"`
+	LegalClassification enum.LegalClassificationEnum `json:"legal_classification"`
+	Translations        Translations                 `json:"translations,omitempty"`
 }
 
 type PersonDisabilityRequest struct {
@@ -25,26 +78,69 @@ type PersonDisabilityRequest struct {
 }
 
 type DisabilityResponse struct {
-	Id          int    `json:"id"`
-	Category    string `json:"category"`
-	Description string `json:"description"`
-	Rate        int    `json:"rate"`
-	Acquired    bool   `json:"acquired"`
+	Id                  int                          `json:"id"`
+	Category            enum.DisabilityCategoryEnum  `json:"category"`
+	CategoryName        string                       `json:"category_name"`
+	Description         string                       `json:"description"`
+	Rate                int                          `json:"rate"`
+	Cid10               string                       `json:"cid10,omitempty"`
+	Icd11               string                       `json:"icd11,omitempty"`
+	LegalClassification enum.LegalClassificationEnum `json:"legal_classification,omitempty"`
+	Translations        Translations                 `json:"translations,omitempty"`
+	Acquired            bool                         `json:"acquired"`
+}
+
+// DisabilityUsage counts the people and vacancies that reference a disability.
+type DisabilityUsage struct {
+	People    int64 `json:"people"`
+	Vacancies int64 `json:"vacancies"`
+}
+
+func (u DisabilityUsage) InUse() bool {
+	return u.People > 0 || u.Vacancies > 0
 }
 
 func (d *Disability) ToResponse() DisabilityResponse {
-	return DisabilityResponse{
-		Id:          d.Id,
-		Category:    d.Category,
-		Description: d.Description,
-		Rate:        d.Rate,
+	response := DisabilityResponse{
+		Id:                  d.Id,
+		Description:         d.Description,
+		Rate:                d.Rate,
+		Cid10:               d.Cid10,
+		Icd11:               d.Icd11,
+		LegalClassification: d.LegalClassification,
+		Translations:        d.Translations,
+	}
+
+	if d.Category != nil {
+		response.Category = d.Category.Slug
+		response.CategoryName = d.Category.Name
 	}
+
+	return response
+}
+
+// ToLocalizedResponse returns the disability with the description and category
+// name in the language, falling back to the Brazilian Portuguese ones.
+func (d *Disability) ToLocalizedResponse(language enum.LanguageEnum) DisabilityResponse {
+	response := d.ToResponse()
+	response.Description = d.Translations.Get(language, d.Description)
+
+	if d.Category != nil {
+		response.CategoryName = d.Category.Translations.Get(language, d.Category.Name)
+	}
+
+	return response
 }
 
-func (dr *DisabilityRequest) ToModel() Disability {
+func (dr *DisabilityRequest) ToModel(category DisabilityCategory) Disability {
 	return Disability{
-		Category:    dr.Category,
-		Description: dr.Description,
-		Rate:        dr.Rate,
+		CategoryId:          category.Id,
+		Category:            &category,
+		Description:         dr.Description,
+		Rate:                dr.Rate,
+		Cid10:               dr.Cid10,
+		Icd11:               dr.Icd11,
+		LegalClassification: dr.LegalClassification,
+		Translations:        dr.Translations,
 	}
 }
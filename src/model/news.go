@@ -1,52 +1,172 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"cij_api/src/enum"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NewsCategory is a topic the readers can follow, with its own feeds.
+type NewsCategory struct {
+	*gorm.Model
+	Id   int    `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	Slug string `gorm:"type:varchar(100);not null;unique" json:"slug"`
+	Name string `gorm:"type:varchar(100);not null" json:"name"`
+}
+
+type NewsCategoryRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type NewsCategoryResponse struct {
+	Id   int    `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+func (c *NewsCategory) ToResponse() NewsCategoryResponse {
+	return NewsCategoryResponse{
+		Id:   c.Id,
+		Slug: c.Slug,
+		Name: c.Name,
+	}
+}
+
+// Tag is a free keyword of the news, created when first used.
+type Tag struct {
+	*gorm.Model
+	Id   int    `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	Slug string `gorm:"type:varchar(100);not null;unique" json:"slug"`
+	Name string `gorm:"type:varchar(100);not null" json:"name"`
+}
+
+type TagResponse struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+func (t *Tag) ToResponse() TagResponse {
+	return TagResponse{
+		Slug: t.Slug,
+		Name: t.Name,
+	}
+}
 
 type News struct {
 	*gorm.Model
-	Id          int    `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
-	Title       string `gorm:"type:varchar(200);not null" json:"title"`
-	Description string `gorm:"type:text;not null" json:"description"`
-	Banner      string `gorm:"type:text;" json:"banner"`
-	Author      string `gorm:"type:varchar(200);not null" json:"author"`
-	AuthorImage string `gorm:"type:text;" json:"author_image"`
-	Date        string `gorm:"type:date;not null" json:"date"`
+	Id          int             `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	Slug        string          `gorm:"type:varchar(255);uniqueIndex" json:"slug"`
+	Title       string          `gorm:"type:varchar(200);not null" json:"title"`
+	Description string          `gorm:"type:text;not null" json:"description"`
+	Banner      string          `gorm:"type:text;" json:"banner"`
+	AuthorId    *int            `gorm:"type:int;index" json:"author_id"`
+	AuthorImage string          `gorm:"type:text;" json:"author_image"`
+	AuthorName  string          `gorm:"type:varchar(200)" json:"author_name"` // free text author of the legacy news, kept when no user matched it
+	Status      enum.NewsStatus `gorm:"type:varchar(20);not null;default:published;index" json:"status"`
+	Date        *time.Time      `gorm:"type:datetime;index" json:"date"` // publication date, empty while draft
+	PublishAt   *time.Time      `gorm:"type:datetime;index" json:"publish_at"`
+	Scheduled   bool            `gorm:"type:boolean;not null;default:false" json:"scheduled"` // draft the scheduler publishes at its publish_at
+	CategoryId  *int            `gorm:"type:int;index" json:"category_id"`
+	Author      *User
+	Category    *NewsCategory
+	Tags        []Tag `gorm:"many2many:news_tags"`
+
+	BannerAlt             string `gorm:"type:varchar(250)" json:"banner_alt"`
+	BannerCaption         string `gorm:"type:text" json:"banner_caption"`
+	BannerLongDescription string `gorm:"type:text" json:"banner_long_description"`
+	AuthorImageAlt        string `gorm:"type:varchar(250)" json:"author_image_alt"`
+	LibrasVideo           string `gorm:"type:text" json:"libras_video"`    // sign language version of the text
+	AudioNarration        string `gorm:"type:text" json:"audio_narration"` // spoken version of the text
 }
 
 type NewsRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Author      string `json:"author"`
-	Date        string `json:"date"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Status      enum.NewsStatus `json:"status"`
+	PublishAt   string          `json:"publish_at"`
+	Category    string          `json:"category"`
+	Tags        []string        `json:"tags"`
+
+	BannerAlt             string `json:"banner_alt"`
+	BannerCaption         string `json:"banner_caption"`
+	BannerLongDescription string `json:"banner_long_description"`
+	AuthorImageAlt        string `json:"author_image_alt"`
 }
 
 type NewsResponse struct {
-	Id          int    `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Author      string `json:"author"`
-	Date        string `json:"date"`
-	Banner      string `json:"banner"`
-	AuthorImage string `json:"author_image"`
+	Id          int                   `json:"id"`
+	Slug        string                `json:"slug"`
+	Title       string                `json:"title"`
+	Description string                `json:"description"`
+	Status      enum.NewsStatus       `json:"status"`
+	Date        *time.Time            `json:"date"`
+	PublishAt   *time.Time            `json:"publish_at,omitempty"`
+	Scheduled   bool                  `json:"scheduled,omitempty"`
+	Banner      string                `json:"banner"`
+	AuthorImage string                `json:"author_image"`
+	Author      *UserResponse         `json:"author,omitempty"`
+	AuthorName  string                `json:"author_name,omitempty"`
+	Category    *NewsCategoryResponse `json:"category,omitempty"`
+	Tags        []TagResponse         `json:"tags"`
+
+	BannerAlt             string `json:"banner_alt"`
+	BannerCaption         string `json:"banner_caption,omitempty"`
+	BannerLongDescription string `json:"banner_long_description,omitempty"`
+	AuthorImageAlt        string `json:"author_image_alt"`
+	LibrasVideo           string `json:"libras_video,omitempty"`
+	AudioNarration        string `json:"audio_narration,omitempty"`
+}
+
+// NewsFilter selects and orders the news of a list. Pages start at 1 and the
+// search matches the words of the title and description.
+type NewsFilter struct {
+	Status   enum.NewsStatus
+	Category string
+	Tag      string
+	Search   string
+	Page     int
+	PerPage  int
+	Oldest   bool
 }
 
 func (n *News) ToResponse() NewsResponse {
-	return NewsResponse{
+	response := NewsResponse{
 		Id:          n.Id,
+		Slug:        n.Slug,
 		Title:       n.Title,
 		Description: n.Description,
-		Author:      n.Author,
+		Status:      n.Status,
 		Date:        n.Date,
+		PublishAt:   n.PublishAt,
+		Scheduled:   n.Scheduled,
 		Banner:      n.Banner,
 		AuthorImage: n.AuthorImage,
+		AuthorName:  n.AuthorName,
+		Tags:        []TagResponse{},
+
+		BannerAlt:             n.BannerAlt,
+		BannerCaption:         n.BannerCaption,
+		BannerLongDescription: n.BannerLongDescription,
+		AuthorImageAlt:        n.AuthorImageAlt,
+		LibrasVideo:           n.LibrasVideo,
+		AudioNarration:        n.AudioNarration,
 	}
-}
 
-func (n *NewsRequest) ToModel() News {
-	return News{
-		Title:       n.Title,
-		Description: n.Description,
-		Author:      n.Author,
-		Date:        n.Date,
+	if n.Author != nil {
+		author := n.Author.ToResponse()
+		response.Author = &author
+	}
+
+	if n.Category != nil {
+		category := n.Category.ToResponse()
+		response.Category = &category
+	}
+
+	for _, tag := range n.Tags {
+		response.Tags = append(response.Tags, tag.ToResponse())
 	}
+
+	return response
 }
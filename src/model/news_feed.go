@@ -0,0 +1,198 @@
+package model
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// NewsFeed is the latest published news, of a category or of every one.
+type NewsFeed struct {
+	Category *NewsCategoryResponse
+	News     []NewsResponse
+}
+
+// FeedLinks are the URLs a feed refers to: the portal, the feed itself and the
+// page of each news.
+type FeedLinks struct {
+	Site     string
+	Self     string
+	NewsLink func(news NewsResponse) string
+}
+
+type RSS struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomSpace string     `xml:"xmlns:atom,attr"`
+	Channel   RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	AtomLink      AtomLink  `xml:"atom:link"`
+	Items         []RSSItem `xml:"item"`
+}
+
+type RSSItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        RSSGuid  `xml:"guid"`
+	Description string   `xml:"description"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type RSSGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type Atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []AtomLink  `xml:"link"`
+	Author  AtomAuthor  `xml:"author"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type AtomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type AtomEntry struct {
+	Title      string         `xml:"title"`
+	Id         string         `xml:"id"`
+	Link       AtomLink       `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Summary    AtomText       `xml:"summary"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+const newsFeedTitle = "Conexão Inclusão - Notícias"
+
+func (f *NewsFeed) title() string {
+	if f.Category != nil {
+		return newsFeedTitle + " - " + f.Category.Name
+	}
+
+	return newsFeedTitle
+}
+
+// Updated returns the date of the newest news of the feed, or the zero time
+// when the feed is empty.
+func (f *NewsFeed) Updated() time.Time {
+	var updated time.Time
+
+	for _, news := range f.News {
+		if news.Date != nil && news.Date.After(updated) {
+			updated = *news.Date
+		}
+	}
+
+	return updated
+}
+
+func (f *NewsFeed) ToRSS(links FeedLinks) RSS {
+	channel := RSSChannel{
+		Title:       f.title(),
+		Link:        links.Site,
+		Description: "Notícias sobre inclusão de pessoas com deficiência",
+		Language:    "pt-BR",
+		AtomLink:    AtomLink{Href: links.Self, Rel: "self", Type: "application/rss+xml"},
+		Items:       []RSSItem{},
+	}
+
+	if updated := f.Updated(); !updated.IsZero() {
+		channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	for _, news := range f.News {
+		link := links.NewsLink(news)
+
+		item := RSSItem{
+			Title:       news.Title,
+			Link:        link,
+			Guid:        RSSGuid{IsPermaLink: true, Value: link},
+			Description: news.Description,
+		}
+
+		if news.Date != nil {
+			item.PubDate = news.Date.Format(time.RFC1123Z)
+		}
+
+		if news.Category != nil {
+			item.Categories = append(item.Categories, news.Category.Name)
+		}
+
+		for _, tag := range news.Tags {
+			item.Categories = append(item.Categories, tag.Name)
+		}
+
+		channel.Items = append(channel.Items, item)
+	}
+
+	return RSS{Version: "2.0", AtomSpace: "http://www.w3.org/2005/Atom", Channel: channel}
+}
+
+func (f *NewsFeed) ToAtom(links FeedLinks) Atom {
+	feed := Atom{
+		Title:   f.title(),
+		Id:      links.Self,
+		Updated: f.Updated().UTC().Format(time.RFC3339),
+		Links: []AtomLink{
+			{Href: links.Site},
+			{Href: links.Self, Rel: "self", Type: "application/atom+xml"},
+		},
+		Author: AtomAuthor{Name: "Conexão Inclusão"},
+	}
+
+	for _, news := range f.News {
+		link := links.NewsLink(news)
+
+		entry := AtomEntry{
+			Title:   news.Title,
+			Id:      link,
+			Link:    AtomLink{Href: link, Rel: "alternate"},
+			Summary: AtomText{Type: "text", Value: news.Description},
+		}
+
+		if news.Date != nil {
+			entry.Published = news.Date.UTC().Format(time.RFC3339)
+			entry.Updated = entry.Published
+		}
+
+		if news.Category != nil {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: news.Category.Slug, Label: news.Category.Name})
+		}
+
+		for _, tag := range news.Tags {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: tag.Slug, Label: tag.Name})
+		}
+
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return feed
+}
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+type OpenDataLicense struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// OpenDataField describes a column of a dataset, for its data dictionary.
+type OpenDataField struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Nullable    bool   `json:"nullable"`
+}
+
+type OpenDataDownload struct {
+	Format string `json:"format"`
+	URL    string `json:"url"`
+}
+
+// OpenDataset is an entry of the open data catalogue. The version changes
+// with the fields, the update time with the data.
+type OpenDataset struct {
+	Id          string             `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Version     string             `json:"version"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	License     OpenDataLicense    `json:"license"`
+	Fields      []OpenDataField    `json:"fields"`
+	Dictionary  string             `json:"dictionary"`
+	Downloads   []OpenDataDownload `json:"downloads"`
+}
+
+// OpenData is a dataset with its rows, a cell per field. The cells are
+// strings, ints, float64s or nil for the suppressed values.
+type OpenData struct {
+	Dataset OpenDataset
+	Rows    [][]interface{}
+}
+
+type OpenDataCatalogue struct {
+	Title    string          `json:"title"`
+	License  OpenDataLicense `json:"license"`
+	Datasets []OpenDataset   `json:"datasets"`
+}
+
+// OpenDataDocument is a dataset as JSON, a record per row.
+type OpenDataDocument struct {
+	Dataset OpenDataset              `json:"dataset"`
+	Records []map[string]interface{} `json:"records"`
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"cij_api/src/enum"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,9 +15,10 @@ type Person struct {
 	Cpf          string          `gorm:"type:char(11);not null;unique" json:"cpf"`
 	Phone        string          `gorm:"type:char(13);not null" json:"phone"`
 	Gender       enum.GenderEnum `gorm:"type:char(6);not null" json:"gender"`
+	BirthDate    *string         `gorm:"type:date" json:"birth_date"`
 	UserId       int             `gorm:"type:int;not null;unique" json:"user_id"`
 	AddressId    *int            `gorm:"type:int;unique" json:"address_id"`
-	Curriculum   string          `gorm:"type:varchar(255)" json:"curriculum"`
+	Curriculum   string          `gorm:"type:varchar(255)" json:"-"`
 	Address      *Address
 	User         *User
 	Disabilities []PersonDisability
@@ -26,9 +29,11 @@ type PersonRequest struct {
 	Cpf          string                    `json:"cpf"`
 	Phone        string                    `json:"phone"`
 	Gender       enum.GenderEnum           `json:"gender"`
+	BirthDate    string                    `json:"birth_date,omitempty"`
 	User         UserRequest               `json:"user"`
 	Address      AddressRequest            `json:"address"`
 	Disabilities []PersonDisabilityRequest `json:"disabilities"`
+	ConfigPreset string                    `json:"config_preset,omitempty"`
 }
 
 type PersonResponse struct {
@@ -37,6 +42,7 @@ type PersonResponse struct {
 	Cpf          string                      `json:"cpf"`
 	Phone        string                      `json:"phone"`
 	Gender       enum.GenderEnum             `json:"gender"`
+	BirthDate    string                      `json:"birth_date,omitempty"`
 	Curriculum   string                      `json:"curriculum,omitempty"`
 	User         UserResponse                `json:"user"`
 	Address      *AddressResponse            `json:"address,omitempty"`
@@ -53,16 +59,45 @@ type CandidateResponse struct {
 	Disabilities []DisabilityResponse `json:"disabilities"`
 }
 
+type CurriculumUrlResponse struct {
+	Url       string `json:"url"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// CurriculumPath is the endpoint that hands out the download URL of the
+// curriculum, which is kept as a private file.
+func (p *Person) CurriculumPath() string {
+	if p.Curriculum == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("/people/%d/curriculum", p.Id)
+}
+
 func (p *Person) ToResponse(user User) PersonResponse {
-	return PersonResponse{
+	response := PersonResponse{
 		Id:         p.Id,
 		Name:       p.Name,
 		Cpf:        p.Cpf,
 		Phone:      p.Phone,
 		Gender:     p.Gender,
-		Curriculum: p.Curriculum,
+		Curriculum: p.CurriculumPath(),
 		User:       user.ToResponse(),
 	}
+
+	if p.BirthDate != nil && len(*p.BirthDate) >= len(time.DateOnly) {
+		response.BirthDate = (*p.BirthDate)[:len(time.DateOnly)]
+	}
+
+	return response
+}
+
+// WithoutPrivateData drops the fields that the public people routes must not
+// show, the birth date is only returned to the person in /get-user-data.
+func (p PersonResponse) WithoutPrivateData() PersonResponse {
+	p.BirthDate = ""
+
+	return p
 }
 
 func (p *Person) ToCandidateResponse(disabilities []DisabilityResponse, address Address) CandidateResponse {
@@ -71,20 +106,26 @@ func (p *Person) ToCandidateResponse(disabilities []DisabilityResponse, address
 		Cpf:          p.Cpf,
 		Phone:        p.Phone,
 		Gender:       p.Gender,
-		Curriculum:   p.Curriculum,
+		Curriculum:   p.CurriculumPath(),
 		Disabilities: disabilities,
 		Address:      address.ToResponse(),
 	}
 }
 
 func (p *PersonRequest) ToModel(user User) Person {
-	return Person{
+	person := Person{
 		Name:   p.Name,
 		Cpf:    p.Cpf,
 		Phone:  p.Phone,
 		Gender: p.Gender,
 		UserId: user.Id,
 	}
+
+	if p.BirthDate != "" {
+		person.BirthDate = &p.BirthDate
+	}
+
+	return person
 }
 
 func (p *PersonRequest) ToUser() User {
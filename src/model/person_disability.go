@@ -2,8 +2,8 @@ package model
 
 type PersonDisability struct {
 	Acquired     bool `gorm:"type:boolean;not null"`
-	PersonId     int  `gorm:"type:int;not null"`
-	DisabilityId int  `gorm:"type:int;not null"`
+	PersonId     int  `gorm:"type:int;not null;uniqueIndex:idx_person_disability"`
+	DisabilityId int  `gorm:"type:int;not null;uniqueIndex:idx_person_disability"`
 	Person       *Person
 	Disability   *Disability
 }
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"cij_api/src/enum"
+	"time"
+)
+
+// ReportSnapshot marks a day whose report metrics were stored, so the days
+// without activities or people are told from the days never snapshot. The
+// days are of the reports timezone.
+type ReportSnapshot struct {
+	Id      int       `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
+	Date    string    `gorm:"type:date;not null;uniqueIndex" json:"date"`
+	TakenAt time.Time `gorm:"not null" json:"taken_at"`
+}
+
+// DisabilitySnapshot is the number of people with disabilities of the
+// category at the end of the day.
+type DisabilitySnapshot struct {
+	Id       int                         `gorm:"type:int;primaryKey;autoIncrement;not null"`
+	Date     string                      `gorm:"type:date;not null;uniqueIndex:idx_disability_snapshots_date_category"`
+	Category enum.DisabilityCategoryEnum `gorm:"type:varchar(100);not null;uniqueIndex:idx_disability_snapshots_date_category"`
+	People   int                         `gorm:"type:int;not null"`
+}
+
+// ActivitySnapshot is the number of activities of the type created in the
+// day. The types without activities in the day have no snapshot.
+type ActivitySnapshot struct {
+	Id    int    `gorm:"type:int;primaryKey;autoIncrement;not null"`
+	Date  string `gorm:"type:date;not null;uniqueIndex:idx_activity_snapshots_date_type"`
+	Type  string `gorm:"type:varchar(100);not null;uniqueIndex:idx_activity_snapshots_date_type"`
+	Count int    `gorm:"type:int;not null"`
+}
+
+// EmploymentSnapshot has the vacancies open in the day and the applies made,
+// accepted and rejected in it.
+type EmploymentSnapshot struct {
+	Id            int    `gorm:"type:int;primaryKey;autoIncrement;not null"`
+	Date          string `gorm:"type:date;not null;uniqueIndex"`
+	OpenVacancies int    `gorm:"type:int;not null"`
+	Applies       int    `gorm:"type:int;not null"`
+	Accepted      int    `gorm:"type:int;not null"`
+	Rejected      int    `gorm:"type:int;not null"`
+}
+
+type SnapshotRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type SnapshotResult struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	Days int    `json:"days"`
+}
+
+// TrendFilter selects the snapshot days from From to To, both included.
+type TrendFilter struct {
+	From        string
+	To          string
+	Granularity enum.ReportGranularity
+	Types       []string
+}
+
+// Trend is a history read from the snapshots, a series per value of the
+// dimension. The buckets without snapshot days are left out.
+type Trend struct {
+	From        string                 `json:"from"`
+	To          string                 `json:"to"`
+	Granularity enum.ReportGranularity `json:"granularity"`
+	Dimension   string                 `json:"dimension"`
+	Series      []TrendSeries          `json:"series"`
+}
+
+type TrendSeries struct {
+	Name   string       `json:"name"`
+	Points []TrendPoint `json:"points"`
+}
+
+// TrendPoint is the count of a bucket: the sum of its days for what happens
+// in a day, like the activities, or its last day for what is counted at the
+// end of a day, like the people.
+type TrendPoint struct {
+	Label string `json:"label"`
+	Start string `json:"start"`
+	Count Count  `json:"count"`
+}
@@ -1,16 +1,196 @@
 package model
 
-type DisabilityTotals struct {
-	Visual       int `json:"visual"`
-	Hearing      int `json:"hearing"`
-	Physical     int `json:"physical"`
-	Intellectual int `json:"intellectual"`
-	Psychosocial int `json:"psychosocial"`
+import (
+	"cij_api/src/enum"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Count is a number of people or events in a report. The public reports
+// replace the counts small enough to identify someone by SuppressedCount,
+// which is sent as null.
+type Count int
+
+const SuppressedCount Count = -1
+
+func (c Count) Suppressed() bool {
+	return c < 0
+}
+
+func (c Count) MarshalJSON() ([]byte, error) {
+	if c.Suppressed() {
+		return []byte("null"), nil
+	}
+
+	return strconv.AppendInt(nil, int64(c), 10), nil
 }
 
+func (c *Count) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*c = SuppressedCount
+		return nil
+	}
+
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*c = Count(value)
+
+	return nil
+}
+
+// DisabilityTotals counts the people per disability category slug, with every
+// category present even when no one has it.
+type DisabilityTotals map[enum.DisabilityCategoryEnum]Count
+
 type DisabilityTotalsByNeighborhood = DisabilityTotals
 
 type CountActivitiesByPeriod struct {
-	ActivityType string         `json:"activityType"`
-	MonthsCount  map[string]int `json:"monthsCount"`
+	ActivityType string           `json:"activityType"`
+	MonthsCount  map[string]Count `json:"monthsCount"`
+}
+
+// ActivityReportFilter selects the activities counted by the activities
+// report. From and To are in the timezone the buckets are aligned to, and To
+// is exclusive.
+type ActivityReportFilter struct {
+	Types       []string
+	From        time.Time
+	To          time.Time
+	Granularity enum.ReportGranularity
+	Compare     bool
+}
+
+// ActivityHourCount is the number of activities of a type created in an hour,
+// as aggregated by the database.
+type ActivityHourCount struct {
+	Type  string
+	Hour  string
+	Count int
+}
+
+type ActivityTypeCount struct {
+	Type  string
+	Count int
+}
+
+type ActivityReport struct {
+	From        time.Time              `json:"from"`
+	To          time.Time              `json:"to"`
+	Granularity enum.ReportGranularity `json:"granularity"`
+	Timezone    string                 `json:"timezone"`
+	Series      []ActivitySeries       `json:"series"`
+}
+
+type ActivitySeries struct {
+	ActivityType string              `json:"activity_type"`
+	Total        Count               `json:"total"`
+	Buckets      []ActivityBucket    `json:"buckets"`
+	Previous     *ActivityComparison `json:"previous,omitempty"`
+}
+
+type ActivityBucket struct {
+	Label         string    `json:"label"`
+	Start         time.Time `json:"start"`
+	Count         Count     `json:"count"`
+	PreviousCount *Count    `json:"previous_count,omitempty"`
+}
+
+// ActivityComparison is the total of the previous equivalent period, that
+// has as many buckets and ends where the report starts. ChangePercent is
+// null when there was no activity in the previous period, or either total is
+// suppressed.
+type ActivityComparison struct {
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Total         Count     `json:"total"`
+	ChangePercent *float64  `json:"change_percent"`
+}
+
+// EmploymentReportFilter narrows the employment reports. The vacancies are
+// in the period when they were open in it, the applies when they were made in
+// it. The neighborhood is the one of the company for the vacancies and of the
+// candidate for the applies.
+type EmploymentReportFilter struct {
+	From         *time.Time
+	To           *time.Time
+	Neighborhood string
+}
+
+type VacancyCategoryTotal struct {
+	Category  enum.DisabilityCategoryEnum `json:"category"`
+	Vacancies int                         `json:"vacancies"`
+}
+
+type VacancyApplyTotals struct {
+	VacancyId    int    `json:"vacancy_id"`
+	Code         string `json:"code"`
+	Title        string `json:"title"`
+	Company      string `json:"company"`
+	Applications Count  `json:"applications"`
+	Accepted     Count  `json:"accepted"`
+	Rejected     Count  `json:"rejected"`
+}
+
+// AcceptanceRate is the share of the applies of a group that were accepted.
+// Rate is null when the counts it comes from are suppressed.
+type AcceptanceRate struct {
+	Group        string   `gorm:"column:group_name" json:"group"`
+	Applications Count    `json:"applications"`
+	Accepted     Count    `json:"accepted"`
+	Rejected     Count    `json:"rejected"`
+	Rate         *float64 `json:"rate"`
+}
+
+// TimeToHire is the median of the days between the apply and its acceptance.
+// MedianDays is null when no one was hired, or the hires are suppressed.
+type TimeToHire struct {
+	Hires      Count    `json:"hires"`
+	MedianDays *float64 `json:"median_days"`
+}
+
+// ApplyFunnelStage counts the applies that reached the stage. Rate is the
+// share of the applies that reached it, null when the count is suppressed.
+type ApplyFunnelStage struct {
+	Stage string   `json:"stage"`
+	Count Count    `json:"count"`
+	Rate  *float64 `json:"rate"`
+}
+
+// CrossTabFilter selects the dimensions of the rows and columns of a
+// cross-tab of the people with disabilities, and narrows it to the people of
+// a neighborhood or city.
+type CrossTabFilter struct {
+	Rows         enum.ReportDimension
+	Columns      enum.ReportDimension
+	Neighborhood string
+	City         string
+}
+
+// CrossTabCount is the number of people with the row and column values, as
+// counted by the database. For the age band the values are birth dates.
+type CrossTabCount struct {
+	Row    string
+	Column string
+	Total  int
+}
+
+type CrossTabAxis struct {
+	Dimension enum.ReportDimension `json:"dimension"`
+	Labels    []string             `json:"labels"`
+}
+
+// CrossTab counts the people with disabilities per pair of row and column
+// labels, Values[row][column]. A person with disabilities of many categories
+// is counted once in each of them, so the totals are the sums of the cells.
+type CrossTab struct {
+	Rows         CrossTabAxis `json:"rows"`
+	Columns      CrossTabAxis `json:"columns"`
+	Values       [][]Count    `json:"values"`
+	RowTotals    []Count      `json:"row_totals"`
+	ColumnTotals []Count      `json:"column_totals"`
+	Total        Count        `json:"total"`
 }
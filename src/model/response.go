@@ -6,10 +6,11 @@ type Field struct {
 }
 
 type Response struct {
-	Message string      `json:"message"`
-	Code    string      `json:"code,omitempty"`
-	Fields  []Field     `json:"fields,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Message  string      `json:"message"`
+	Code     string      `json:"code,omitempty"`
+	Fields   []Field     `json:"fields,omitempty"`
+	Warnings []Field     `json:"warnings,omitempty"`
+	Data     interface{} `json:"data,omitempty"`
 }
 
 type LoginResponse struct {
@@ -12,3 +12,17 @@ const (
 	CompanyRole RoleId = 2
 	AdminRole   RoleId = 3
 )
+
+// Name is the name of the role in the roles table and in the tokens.
+func (r RoleId) Name() string {
+	switch r {
+	case PersonRole:
+		return "person"
+	case CompanyRole:
+		return "company"
+	case AdminRole:
+		return "admin"
+	}
+
+	return ""
+}
@@ -0,0 +1,31 @@
+package model
+
+import "cij_api/src/enum"
+
+// Translations holds a text in the languages other than the one it is stored
+// in, keyed by language.
+type Translations map[enum.LanguageEnum]string
+
+// Get returns the text in the language, or the fallback when it wasn't
+// translated to it.
+func (t Translations) Get(language enum.LanguageEnum, fallback string) string {
+	if text, ok := t[language]; ok && text != "" {
+		return text
+	}
+
+	return fallback
+}
+
+// InvalidLanguages returns the fields of the translations in languages the API
+// doesn't support.
+func (t Translations) InvalidLanguages(name string) []Field {
+	fields := []Field{}
+
+	for language := range t {
+		if !language.IsValid() {
+			fields = append(fields, Field{Name: name, Value: string(language) + " is not a supported language"})
+		}
+	}
+
+	return fields
+}
@@ -10,7 +10,7 @@ type User struct {
 	Id        int    `gorm:"type:int;primaryKey;autoIncrement;not null" json:"id"`
 	Email     string `gorm:"type:varchar(255);not null;unique" json:"email"`
 	Password  string `gorm:"type:varchar(255);not null" json:"password"`
-	ConfigUrl string `gorm:"type:varchar(255);not null" json:"config_url"`
+	ConfigUrl string `gorm:"type:varchar(255);not null" json:"config_url"` // legacy config file, cleared once imported to user_configs
 	RoleId    RoleId `gorm:"type:int;not null" json:"role_id"`
 	Role      *Role
 }
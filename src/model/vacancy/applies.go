@@ -3,6 +3,7 @@ package model
 import (
 	"cij_api/src/enum"
 	"cij_api/src/model"
+	"time"
 )
 
 type VacancyApply struct {
@@ -10,8 +11,10 @@ type VacancyApply struct {
 	VacancyId   int                     `gorm:"type:int;not null" json:"vacancy_id"`
 	CandidateId int                     `gorm:"type:int;not null" json:"candidate_id"`
 	Status      enum.VacancyApplyStatus `gorm:"type:varchar(10);not null" json:"status"`
-	Vacancy     *Vacancy
-	Candidate   *model.Person
+	CreatedAt   *time.Time              `json:"created_at,omitempty"`
+	DecidedAt   *time.Time              `json:"decided_at,omitempty"`
+	Vacancy     *Vacancy                `json:"vacancy,omitempty"`
+	Candidate   *model.Person           `json:"candidate,omitempty"`
 }
 
 type VacancyApplyRequest struct {
@@ -53,6 +53,24 @@ type VacancySimpleResponse struct {
 	Company      string                     `json:"company"`
 	ContractType enum.VacancyContractType   `json:"contract_type"`
 	Disabilities []model.DisabilityResponse `json:"disabilities"`
+	DistanceKm   *float64                   `json:"distance_km,omitempty"`
+}
+
+// VacancyDistanceFilter narrows the vacancies to the ones whose company is
+// within RadiusKm of the address of a person, or of a point, nearest first.
+// The companies whose address has no coordinates are left out.
+// RequesterEmail is the email of the person searching near a person, empty
+// for the admins, as only they may search near somebody else.
+type VacancyDistanceFilter struct {
+	NearPersonId   int
+	Latitude       *float64
+	Longitude      *float64
+	RadiusKm       *float64
+	RequesterEmail string
+}
+
+func (f *VacancyDistanceFilter) IsSet() bool {
+	return f.NearPersonId != 0 || f.Latitude != nil || f.Longitude != nil || f.RadiusKm != nil
 }
 
 type VacancyRequest struct {
@@ -1,8 +1,11 @@
 package repo
 
 import (
+	"cij_api/src/database"
 	"cij_api/src/model"
 	"cij_api/src/utils"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,7 +14,17 @@ type ActivityRepo interface {
 	BaseRepoMethods
 
 	CreateActivity(activity *model.Activity) utils.Error
-	GetActivitiesByTypeAndPeriod(activityType string, startDate string, endDate string) ([]model.Activity, utils.Error)
+	GetActivitiesByTypeAndPeriod(activityType string, startDate time.Time, endDate time.Time) ([]model.Activity, utils.Error)
+	CountActivitiesByHour(activityTypes []string, startDate time.Time, endDate time.Time, shiftSeconds int) ([]model.ActivityHourCount, utils.Error)
+	CountActivitiesByType(startDate time.Time, endDate time.Time) ([]model.ActivityTypeCount, utils.Error)
+	ListActivitiesBefore(activityType string, before time.Time, limit int) ([]model.Activity, utils.Error)
+	DeleteActivitiesBefore(activityType string, before time.Time, lastId uint) utils.Error
+	StreamActivities(filter model.ActivityExportFilter, each func(activity model.Activity) error) utils.Error
+
+	ListActivityRetentions() ([]model.ActivityRetention, utils.Error)
+	GetActivityRetention(activityType string) (model.ActivityRetention, utils.Error)
+	SaveActivityRetention(retention *model.ActivityRetention) utils.Error
+	DeleteActivityRetention(activityType string) utils.Error
 }
 
 type activityRepo struct {
@@ -43,7 +56,7 @@ func (a *activityRepo) CreateActivity(activity *model.Activity) utils.Error {
 	return utils.Error{}
 }
 
-func (a *activityRepo) GetActivitiesByTypeAndPeriod(activityType string, startDate string, endDate string) ([]model.Activity, utils.Error) {
+func (a *activityRepo) GetActivitiesByTypeAndPeriod(activityType string, startDate time.Time, endDate time.Time) ([]model.Activity, utils.Error) {
 	var activities []model.Activity
 
 	if err := a.db.Where("type = ? AND created_at >= ? AND created_at <= ?", activityType, startDate, endDate).Find(&activities).Error; err != nil {
@@ -52,3 +65,138 @@ func (a *activityRepo) GetActivitiesByTypeAndPeriod(activityType string, startDa
 
 	return activities, utils.Error{}
 }
+
+// CountActivitiesByHour counts the activities of the types created in the
+// period, end exclusive, per UTC hour formatted as "2006-01-02 15". The
+// creation dates are shifted by shiftSeconds before being truncated to the
+// hour, so timezones whose offset isn't a whole number of hours can still be
+// bucketed by their local hours. MySQL stores the wall-clock time of the loc
+// of the connection, which must be the time zone of its session, so it's
+// converted to UTC first.
+func (a *activityRepo) CountActivitiesByHour(activityTypes []string, startDate time.Time, endDate time.Time, shiftSeconds int) ([]model.ActivityHourCount, utils.Error) {
+	var counts []model.ActivityHourCount
+
+	hour := fmt.Sprintf("strftime('%%Y-%%m-%%d %%H', created_at, '%+d seconds')", shiftSeconds)
+	if a.db.Dialector.Name() == database.MysqlDriver {
+		hour = fmt.Sprintf("DATE_FORMAT(CONVERT_TZ(created_at, @@session.time_zone, '+00:00') + INTERVAL %d SECOND, '%%Y-%%m-%%d %%H')", shiftSeconds)
+	}
+
+	err := a.db.Model(&model.Activity{}).
+		Select("type, "+hour+" AS hour, COUNT(*) AS count").
+		Where("type IN ? AND created_at >= ? AND created_at < ?", activityTypes, startDate, endDate).
+		Group("type, hour").
+		Scan(&counts).Error
+
+	if err != nil {
+		return nil, activityRepoError("failed to count the activities", "10")
+	}
+
+	return counts, utils.Error{}
+}
+
+// CountActivitiesByType counts the activities of each type created in the
+// period, end exclusive.
+func (a *activityRepo) CountActivitiesByType(startDate time.Time, endDate time.Time) ([]model.ActivityTypeCount, utils.Error) {
+	var counts []model.ActivityTypeCount
+
+	err := a.db.Model(&model.Activity{}).
+		Select("type, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", startDate, endDate).
+		Group("type").
+		Order("type").
+		Scan(&counts).Error
+
+	if err != nil {
+		return nil, activityRepoError("failed to count the activities by type", "11")
+	}
+
+	return counts, utils.Error{}
+}
+
+// ListActivitiesBefore returns the oldest activities of the type created
+// before the date, ordered by id.
+func (a *activityRepo) ListActivitiesBefore(activityType string, before time.Time, limit int) ([]model.Activity, utils.Error) {
+	var activities []model.Activity
+
+	if err := a.db.Where("type = ? AND created_at < ?", activityType, before).Order("id").Limit(limit).Find(&activities).Error; err != nil {
+		return nil, activityRepoError("failed to list the expired activities", "03")
+	}
+
+	return activities, utils.Error{}
+}
+
+// DeleteActivitiesBefore permanently removes the activities of the type
+// created before the date, up to the given id. It removes exactly the rows
+// returned by ListActivitiesBefore with the same type and date.
+func (a *activityRepo) DeleteActivitiesBefore(activityType string, before time.Time, lastId uint) utils.Error {
+	query := a.db.Unscoped().Where("type = ? AND created_at < ? AND id <= ?", activityType, before, lastId)
+
+	if err := query.Delete(&model.Activity{}).Error; err != nil {
+		return activityRepoError("failed to delete the expired activities", "04")
+	}
+
+	return utils.Error{}
+}
+
+// StreamActivities calls each for the activities of the period in batches,
+// so the export doesn't load the whole table in memory.
+func (a *activityRepo) StreamActivities(filter model.ActivityExportFilter, each func(activity model.Activity) error) utils.Error {
+	var batch []model.Activity
+
+	query := a.db.Where("created_at >= ? AND created_at < ?", filter.From, filter.To)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	result := query.FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, activity := range batch {
+			if err := each(activity); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if result.Error != nil {
+		return activityRepoError("failed to export the activities", "05")
+	}
+
+	return utils.Error{}
+}
+
+func (a *activityRepo) ListActivityRetentions() ([]model.ActivityRetention, utils.Error) {
+	var retentions []model.ActivityRetention
+
+	if err := a.db.Order("type").Find(&retentions).Error; err != nil {
+		return nil, activityRepoError("failed to list the activity retentions", "06")
+	}
+
+	return retentions, utils.Error{}
+}
+
+func (a *activityRepo) GetActivityRetention(activityType string) (model.ActivityRetention, utils.Error) {
+	var retention model.ActivityRetention
+
+	if err := a.db.Where("type = ?", activityType).Limit(1).Find(&retention).Error; err != nil {
+		return retention, activityRepoError("failed to get the activity retention", "07")
+	}
+
+	return retention, utils.Error{}
+}
+
+func (a *activityRepo) SaveActivityRetention(retention *model.ActivityRetention) utils.Error {
+	if err := a.db.Save(retention).Error; err != nil {
+		return activityRepoError("failed to save the activity retention", "08")
+	}
+
+	return utils.Error{}
+}
+
+func (a *activityRepo) DeleteActivityRetention(activityType string) utils.Error {
+	if err := a.db.Where("type = ?", activityType).Delete(&model.ActivityRetention{}).Error; err != nil {
+		return activityRepoError("failed to delete the activity retention", "09")
+	}
+
+	return utils.Error{}
+}
@@ -13,6 +13,8 @@ type AddressRepo interface {
 	GetAddressById(id int) (model.Address, utils.Error)
 	UpsertAddress(address model.Address, tx *gorm.DB) (int, utils.Error)
 	DeleteAddress(id int) utils.Error
+	ListAddressesWithoutCoordinates(afterId int, limit int) ([]model.Address, utils.Error)
+	UpdateAddressCoordinates(id int, latitude float64, longitude float64) utils.Error
 }
 
 type addressRepo struct {
@@ -68,3 +70,57 @@ func (n *addressRepo) DeleteAddress(id int) utils.Error {
 
 	return utils.Error{}
 }
+
+// ListAddressesWithoutCoordinates pages the addresses by id, so the ones that
+// can't be located are not listed again.
+func (n *addressRepo) ListAddressesWithoutCoordinates(afterId int, limit int) ([]model.Address, utils.Error) {
+	var addresses []model.Address
+
+	err := n.db.Model(model.Address{}).
+		Where("id > ?", afterId).
+		Where("latitude IS NULL OR longitude IS NULL").
+		Order("id").
+		Limit(limit).
+		Find(&addresses).Error
+
+	if err != nil {
+		return nil, addressRepoError("failed to list the addresses without coordinates", "04")
+	}
+
+	return addresses, utils.Error{}
+}
+
+// UpdateAddressCoordinates only sets the coordinates, leaving the rest of the
+// address as it is.
+func (n *addressRepo) UpdateAddressCoordinates(id int, latitude float64, longitude float64) utils.Error {
+	err := n.db.Model(model.Address{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"latitude": latitude, "longitude": longitude}).Error
+
+	if err != nil {
+		return addressRepoError("failed to update the address coordinates", "05")
+	}
+
+	return utils.Error{}
+}
+
+// matchAddressValues returns the stored spellings of the value of an address
+// column, ignoring case and accents, like "São Francisco" for "sao francisco".
+func matchAddressValues(db *gorm.DB, column string, value string) ([]string, error) {
+	var values []string
+
+	if err := db.Model(model.Address{}).Distinct().Pluck(column, &values).Error; err != nil {
+		return nil, err
+	}
+
+	matchingValues := []string{}
+	normalizedValue := utils.NormalizeText(value)
+
+	for _, storedValue := range values {
+		if utils.NormalizeText(storedValue) == normalizedValue {
+			matchingValues = append(matchingValues, storedValue)
+		}
+	}
+
+	return matchingValues, nil
+}
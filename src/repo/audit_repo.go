@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/utils"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepo only inserts and reads the audit events, which are append-only.
+type AuditRepo interface {
+	BaseRepoMethods
+
+	CreateAuditEvent(event *model.AuditEvent, tx *gorm.DB) utils.Error
+	ListAuditEvents(filter model.AuditFilter) ([]model.AuditEvent, int64, utils.Error)
+	GetAuditEventById(eventId int) (model.AuditEvent, utils.Error)
+}
+
+type auditRepo struct {
+	BaseRepo
+	db *gorm.DB
+}
+
+func NewAuditRepo(db *gorm.DB) AuditRepo {
+	repo := &auditRepo{
+		db: db,
+	}
+
+	repo.SetRepo(repo.db)
+
+	return repo
+}
+
+func auditRepoError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.DatabaseErrorCode, utils.AuditErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+func (r *auditRepo) CreateAuditEvent(event *model.AuditEvent, tx *gorm.DB) utils.Error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.Create(event).Error; err != nil {
+		return auditRepoError("failed to create the audit event", "01")
+	}
+
+	return utils.Error{}
+}
+
+func (r *auditRepo) ListAuditEvents(filter model.AuditFilter) ([]model.AuditEvent, int64, utils.Error) {
+	var events []model.AuditEvent
+	var total int64
+
+	query := r.db.Model(model.AuditEvent{})
+
+	if filter.ActorId != 0 {
+		query = query.Where("actor_id = ?", filter.ActorId)
+	}
+
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+
+	if filter.EntityId != 0 {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+
+	if filter.RequestId != "" {
+		query = query.Where("request_id = ?", filter.RequestId)
+	}
+
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return events, 0, auditRepoError("failed to count the audit events", "02")
+	}
+
+	err := query.Order("id DESC").
+		Offset((filter.Page - 1) * filter.PerPage).
+		Limit(filter.PerPage).
+		Find(&events).Error
+	if err != nil {
+		return events, 0, auditRepoError("failed to list the audit events", "03")
+	}
+
+	return events, total, utils.Error{}
+}
+
+func (r *auditRepo) GetAuditEventById(eventId int) (model.AuditEvent, utils.Error) {
+	var event model.AuditEvent
+
+	if err := r.db.Model(model.AuditEvent{}).Where("id = ?", eventId).Find(&event).Error; err != nil {
+		return event, auditRepoError("failed to get the audit event", "04")
+	}
+
+	return event, utils.Error{}
+}
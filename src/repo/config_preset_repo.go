@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/utils"
+
+	"gorm.io/gorm"
+)
+
+type ConfigPresetRepo interface {
+	CreateConfigPreset(preset model.ConfigPreset) (int, utils.Error)
+	ListConfigPresets() ([]model.ConfigPreset, utils.Error)
+	GetConfigPresetById(presetId int) (model.ConfigPreset, utils.Error)
+	GetConfigPresetBySlug(slug string) (model.ConfigPreset, utils.Error)
+	GetCompanyConfigPreset(companyId int) (model.ConfigPreset, utils.Error)
+	UpdateConfigPreset(preset model.ConfigPreset, presetId int) utils.Error
+	DeleteConfigPreset(presetId int) utils.Error
+}
+
+type configPresetRepo struct {
+	db *gorm.DB
+}
+
+func NewConfigPresetRepo(db *gorm.DB) ConfigPresetRepo {
+	return &configPresetRepo{
+		db: db,
+	}
+}
+
+func configPresetRepoError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.DatabaseErrorCode, utils.ConfigErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+func (n *configPresetRepo) CreateConfigPreset(preset model.ConfigPreset) (int, utils.Error) {
+	if err := n.db.Create(&preset).Error; err != nil {
+		return 0, configPresetRepoError("failed to create the config preset", "05")
+	}
+
+	return preset.Id, utils.Error{}
+}
+
+// ListConfigPresets lists the public presets, leaving out the branded
+// defaults of the companies.
+func (n *configPresetRepo) ListConfigPresets() ([]model.ConfigPreset, utils.Error) {
+	var presets []model.ConfigPreset
+
+	err := n.db.Model(model.ConfigPreset{}).Where("company_id IS NULL").Order("id").Find(&presets).Error
+	if err != nil {
+		return presets, configPresetRepoError("failed to list the config presets", "06")
+	}
+
+	return presets, utils.Error{}
+}
+
+func (n *configPresetRepo) GetConfigPresetById(presetId int) (model.ConfigPreset, utils.Error) {
+	var preset model.ConfigPreset
+
+	err := n.db.Model(model.ConfigPreset{}).Where("id = ?", presetId).Find(&preset).Error
+	if err != nil {
+		return preset, configPresetRepoError("failed to get the config preset", "07")
+	}
+
+	return preset, utils.Error{}
+}
+
+func (n *configPresetRepo) GetConfigPresetBySlug(slug string) (model.ConfigPreset, utils.Error) {
+	var preset model.ConfigPreset
+
+	err := n.db.Model(model.ConfigPreset{}).Where("slug = ?", slug).Find(&preset).Error
+	if err != nil {
+		return preset, configPresetRepoError("failed to get the config preset", "08")
+	}
+
+	return preset, utils.Error{}
+}
+
+func (n *configPresetRepo) GetCompanyConfigPreset(companyId int) (model.ConfigPreset, utils.Error) {
+	var preset model.ConfigPreset
+
+	err := n.db.Model(model.ConfigPreset{}).Where("company_id = ?", companyId).Find(&preset).Error
+	if err != nil {
+		return preset, configPresetRepoError("failed to get the company config preset", "09")
+	}
+
+	return preset, utils.Error{}
+}
+
+func (n *configPresetRepo) UpdateConfigPreset(preset model.ConfigPreset, presetId int) utils.Error {
+	err := n.db.Model(model.ConfigPreset{}).Where("id = ?", presetId).
+		Select("slug", "name", "description", "config").Updates(preset).Error
+	if err != nil {
+		return configPresetRepoError("failed to update the config preset", "10")
+	}
+
+	return utils.Error{}
+}
+
+// DeleteConfigPreset removes the preset for good, so its slug can be used
+// again. The users that applied it keep their own copy of the config.
+func (n *configPresetRepo) DeleteConfigPreset(presetId int) utils.Error {
+	if err := n.db.Unscoped().Where("id = ?", presetId).Delete(&model.ConfigPreset{}).Error; err != nil {
+		return configPresetRepoError("failed to delete the config preset", "11")
+	}
+
+	return utils.Error{}
+}
@@ -1,7 +1,9 @@
 package repo
 
 import (
+	"cij_api/src/enum"
 	"cij_api/src/model"
+	vacancy "cij_api/src/model/vacancy"
 	"cij_api/src/utils"
 	"fmt"
 
@@ -12,6 +14,17 @@ type DisabilityRepo interface {
 	BaseRepoMethods
 
 	BatchInsertDisabilities(disabilities []*model.Disability) utils.Error
+	ListDisabilities(category enum.DisabilityCategoryEnum) ([]model.Disability, utils.Error)
+	GetDisabilityById(disabilityId int) (model.Disability, utils.Error)
+	FindDuplicatedDisability(categoryId int, description string, ignoreId int) (model.Disability, utils.Error)
+	UpdateDisability(disability model.Disability, disabilityId int) utils.Error
+	GetDisabilityUsage(disabilityId int, tx *gorm.DB) (model.DisabilityUsage, utils.Error)
+	RemapDisability(fromId int, toId int, tx *gorm.DB) utils.Error
+	DeleteDisability(disabilityId int, tx *gorm.DB) utils.Error
+
+	ListDisabilityCategories() ([]model.DisabilityCategory, utils.Error)
+	GetDisabilityCategoryBySlug(slug enum.DisabilityCategoryEnum) (model.DisabilityCategory, utils.Error)
+	CreateDisabilityCategory(category model.DisabilityCategory) (int, utils.Error)
 }
 
 type disabilityRepo struct {
@@ -43,3 +56,173 @@ func (d *disabilityRepo) BatchInsertDisabilities(disabilities []*model.Disabilit
 
 	return utils.Error{}
 }
+
+// ListDisabilities lists the catalogue ordered by category. An empty category
+// lists every disability.
+func (d *disabilityRepo) ListDisabilities(category enum.DisabilityCategoryEnum) ([]model.Disability, utils.Error) {
+	var disabilities []model.Disability
+
+	query := d.db.Model(model.Disability{}).Preload("Category")
+
+	if category != "" {
+		query = query.Where("category_id IN (?)", d.db.Model(model.DisabilityCategory{}).Select("id").Where("slug = ?", category))
+	}
+
+	if err := query.Order("category_id, id").Find(&disabilities).Error; err != nil {
+		return disabilities, disabilityRepoError("failed to list the disabilities", "07")
+	}
+
+	return disabilities, utils.Error{}
+}
+
+func (d *disabilityRepo) GetDisabilityById(disabilityId int) (model.Disability, utils.Error) {
+	var disability model.Disability
+
+	err := d.db.Model(model.Disability{}).Preload("Category").Where("id = ?", disabilityId).Find(&disability).Error
+	if err != nil {
+		return disability, disabilityRepoError("failed to get the disability", "08")
+	}
+
+	return disability, utils.Error{}
+}
+
+// FindDuplicatedDisability returns the disability with the same category and
+// description, ignoring case, other than the ignored one.
+func (d *disabilityRepo) FindDuplicatedDisability(categoryId int, description string, ignoreId int) (model.Disability, utils.Error) {
+	var disability model.Disability
+
+	err := d.db.Model(model.Disability{}).
+		Where("category_id = ? AND LOWER(description) = LOWER(?) AND id <> ?", categoryId, description, ignoreId).
+		Limit(1).Find(&disability).Error
+	if err != nil {
+		return disability, disabilityRepoError("failed to find the duplicated disability", "09")
+	}
+
+	return disability, utils.Error{}
+}
+
+func (d *disabilityRepo) UpdateDisability(disability model.Disability, disabilityId int) utils.Error {
+	err := d.db.Model(model.Disability{}).Where("id = ?", disabilityId).
+		Select("category_id", "description", "rate", "cid10", "icd11", "legal_classification", "translations").
+		Updates(disability).Error
+	if err != nil {
+		return disabilityRepoError("failed to update the disability", "10")
+	}
+
+	return utils.Error{}
+}
+
+func (d *disabilityRepo) GetDisabilityUsage(disabilityId int, tx *gorm.DB) (model.DisabilityUsage, utils.Error) {
+	var usage model.DisabilityUsage
+
+	databaseConn := d.db
+
+	if tx != nil {
+		databaseConn = tx
+	}
+
+	err := databaseConn.Model(model.PersonDisability{}).Where("disability_id = ?", disabilityId).Count(&usage.People).Error
+	if err != nil {
+		return usage, disabilityRepoError("failed to count the people with the disability", "11")
+	}
+
+	err = databaseConn.Model(vacancy.VacancyDisability{}).Where("disability_id = ?", disabilityId).Count(&usage.Vacancies).Error
+	if err != nil {
+		return usage, disabilityRepoError("failed to count the vacancies with the disability", "11")
+	}
+
+	return usage, utils.Error{}
+}
+
+// RemapDisability moves the people and vacancies of a disability to another
+// one. Rows that already reference the new disability are dropped instead of
+// duplicated.
+func (d *disabilityRepo) RemapDisability(fromId int, toId int, tx *gorm.DB) utils.Error {
+	databaseConn := d.db
+
+	if tx != nil {
+		databaseConn = tx
+	}
+
+	var peopleIds []int
+
+	err := databaseConn.Model(model.PersonDisability{}).Where("disability_id = ?", toId).Pluck("person_id", &peopleIds).Error
+	if err != nil {
+		return disabilityRepoError("failed to remap the people disabilities", "12")
+	}
+
+	if len(peopleIds) > 0 {
+		err = databaseConn.Where("disability_id = ? AND person_id IN ?", fromId, peopleIds).Delete(&model.PersonDisability{}).Error
+		if err != nil {
+			return disabilityRepoError("failed to remap the people disabilities", "12")
+		}
+	}
+
+	err = databaseConn.Model(model.PersonDisability{}).Where("disability_id = ?", fromId).Update("disability_id", toId).Error
+	if err != nil {
+		return disabilityRepoError("failed to remap the people disabilities", "12")
+	}
+
+	var vacanciesIds []int
+
+	err = databaseConn.Model(vacancy.VacancyDisability{}).Where("disability_id = ?", toId).Pluck("vacancy_id", &vacanciesIds).Error
+	if err != nil {
+		return disabilityRepoError("failed to remap the vacancies disabilities", "13")
+	}
+
+	if len(vacanciesIds) > 0 {
+		err = databaseConn.Where("disability_id = ? AND vacancy_id IN ?", fromId, vacanciesIds).Delete(&vacancy.VacancyDisability{}).Error
+		if err != nil {
+			return disabilityRepoError("failed to remap the vacancies disabilities", "13")
+		}
+	}
+
+	err = databaseConn.Model(vacancy.VacancyDisability{}).Where("disability_id = ?", fromId).Update("disability_id", toId).Error
+	if err != nil {
+		return disabilityRepoError("failed to remap the vacancies disabilities", "13")
+	}
+
+	return utils.Error{}
+}
+
+func (d *disabilityRepo) DeleteDisability(disabilityId int, tx *gorm.DB) utils.Error {
+	databaseConn := d.db
+
+	if tx != nil {
+		databaseConn = tx
+	}
+
+	if err := databaseConn.Where("id = ?", disabilityId).Delete(&model.Disability{}).Error; err != nil {
+		return disabilityRepoError("failed to delete the disability", "14")
+	}
+
+	return utils.Error{}
+}
+
+func (d *disabilityRepo) ListDisabilityCategories() ([]model.DisabilityCategory, utils.Error) {
+	var categories []model.DisabilityCategory
+
+	if err := d.db.Model(model.DisabilityCategory{}).Order("id").Find(&categories).Error; err != nil {
+		return categories, disabilityRepoError("failed to list the disability categories", "15")
+	}
+
+	return categories, utils.Error{}
+}
+
+func (d *disabilityRepo) GetDisabilityCategoryBySlug(slug enum.DisabilityCategoryEnum) (model.DisabilityCategory, utils.Error) {
+	var category model.DisabilityCategory
+
+	if err := d.db.Model(model.DisabilityCategory{}).Where("slug = ?", slug).Find(&category).Error; err != nil {
+		return category, disabilityRepoError("failed to get the disability category", "16")
+	}
+
+	return category, utils.Error{}
+}
+
+func (d *disabilityRepo) CreateDisabilityCategory(category model.DisabilityCategory) (int, utils.Error) {
+	if err := d.db.Create(&category).Error; err != nil {
+		return 0, disabilityRepoError("failed to create the disability category", "17")
+	}
+
+	return category.Id, utils.Error{}
+}
@@ -0,0 +1,293 @@
+package repo
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EmploymentReportRepo interface {
+	BaseRepoMethods
+
+	CountVacanciesByCategory(filter model.EmploymentReportFilter) ([]model.VacancyCategoryTotal, utils.Error)
+	CountAppliesByVacancy(filter model.EmploymentReportFilter) ([]model.VacancyApplyTotals, utils.Error)
+	CountAppliesByGroup(filter model.EmploymentReportFilter, group enum.AcceptanceRateGroup) ([]model.AcceptanceRate, utils.Error)
+	ListHires(filter model.EmploymentReportFilter) ([]Hire, utils.Error)
+	CountApplyFunnel(filter model.EmploymentReportFilter) (ApplyFunnel, utils.Error)
+	CountEmploymentDay(day time.Time) (EmploymentDay, utils.Error)
+}
+
+// Hire is when an accepted apply was made and accepted.
+type Hire struct {
+	CreatedAt time.Time
+	DecidedAt time.Time
+}
+
+type ApplyFunnel struct {
+	Applied  model.Count
+	Reviewed model.Count
+	Accepted model.Count
+}
+
+// EmploymentDay is what the employment snapshot of a day counts.
+type EmploymentDay struct {
+	OpenVacancies int
+	Applies       int
+	Accepted      int
+	Rejected      int
+}
+
+type employmentReportRepo struct {
+	BaseRepo
+	db *gorm.DB
+}
+
+func NewEmploymentReportRepo(db *gorm.DB) EmploymentReportRepo {
+	repo := &employmentReportRepo{
+		db: db,
+	}
+
+	repo.SetRepo(repo.db)
+
+	return repo
+}
+
+func employmentReportRepoError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.DatabaseErrorCode, utils.ReportsErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+const applyCounts = `COUNT(*) AS applications,
+	COALESCE(SUM(CASE WHEN va.status = 'accepted' THEN 1 ELSE 0 END), 0) AS accepted,
+	COALESCE(SUM(CASE WHEN va.status = 'rejected' THEN 1 ELSE 0 END), 0) AS rejected`
+
+// vacancies selects the vacancies open in the period, of the companies of the
+// neighborhood.
+func (r *employmentReportRepo) vacancies(filter model.EmploymentReportFilter) (*gorm.DB, error) {
+	query := r.db.Table("vacancies v").Where("v.deleted_at IS NULL")
+
+	if filter.From != nil {
+		query = query.Where("v.registration_date >= ?", filter.From.Format(time.DateOnly))
+	}
+
+	if filter.To != nil {
+		query = query.Where("v.publish_date < ?", filter.To.Format(time.DateOnly))
+	}
+
+	if filter.Neighborhood != "" {
+		neighborhoods, err := matchAddressValues(r.db, "neighborhood", filter.Neighborhood)
+		if err != nil {
+			return nil, err
+		}
+
+		query = query.
+			Joins("JOIN companies nc ON v.company_id = nc.id").
+			Joins("JOIN addresses na ON nc.address_id = na.id").
+			Where("na.neighborhood IN ?", neighborhoods)
+	}
+
+	return query, nil
+}
+
+// applies selects the applies made in the period to the vacancies not
+// deleted, by the candidates of the neighborhood.
+func (r *employmentReportRepo) applies(filter model.EmploymentReportFilter) (*gorm.DB, error) {
+	query := r.db.Table("vacancy_applies va").
+		Joins("JOIN vacancies v ON va.vacancy_id = v.id AND v.deleted_at IS NULL")
+
+	if filter.From != nil {
+		query = query.Where("va.created_at >= ?", *filter.From)
+	}
+
+	if filter.To != nil {
+		query = query.Where("va.created_at < ?", *filter.To)
+	}
+
+	if filter.Neighborhood != "" {
+		neighborhoods, err := matchAddressValues(r.db, "neighborhood", filter.Neighborhood)
+		if err != nil {
+			return nil, err
+		}
+
+		query = query.
+			Joins("JOIN people np ON va.candidate_id = np.id").
+			Joins("JOIN addresses na ON np.address_id = na.id").
+			Where("na.neighborhood IN ?", neighborhoods)
+	}
+
+	return query, nil
+}
+
+func (r *employmentReportRepo) CountVacanciesByCategory(filter model.EmploymentReportFilter) ([]model.VacancyCategoryTotal, utils.Error) {
+	var rows []model.VacancyCategoryTotal
+	var categories []enum.DisabilityCategoryEnum
+
+	query, err := r.vacancies(filter)
+	if err == nil {
+		err = query.
+			Select("c.slug AS category, COUNT(DISTINCT v.id) AS vacancies").
+			Joins("JOIN vacancy_disabilities vd ON vd.vacancy_id = v.id").
+			Joins("JOIN disabilities d ON vd.disability_id = d.id").
+			Joins("JOIN disability_categories c ON d.category_id = c.id").
+			Group("c.slug").
+			Scan(&rows).Error
+	}
+
+	if err == nil {
+		err = r.db.Model(model.DisabilityCategory{}).Order("id").Pluck("slug", &categories).Error
+	}
+
+	if err != nil {
+		return nil, employmentReportRepoError("failed to count the vacancies by disability category", "01")
+	}
+
+	// every category is listed, even without vacancies
+	vacancies := map[enum.DisabilityCategoryEnum]int{}
+	for _, row := range rows {
+		vacancies[row.Category] = row.Vacancies
+	}
+
+	totals := []model.VacancyCategoryTotal{}
+	for _, category := range categories {
+		totals = append(totals, model.VacancyCategoryTotal{Category: category, Vacancies: vacancies[category]})
+	}
+
+	return totals, utils.Error{}
+}
+
+func (r *employmentReportRepo) CountAppliesByVacancy(filter model.EmploymentReportFilter) ([]model.VacancyApplyTotals, utils.Error) {
+	totals := []model.VacancyApplyTotals{}
+
+	query, err := r.applies(filter)
+	if err == nil {
+		err = query.
+			Select("v.id AS vacancy_id, v.code, v.title, c.name AS company, " + applyCounts).
+			Joins("JOIN companies c ON v.company_id = c.id").
+			Group("v.id, v.code, v.title, c.name").
+			Order("applications DESC, v.id").
+			Scan(&totals).Error
+	}
+
+	if err != nil {
+		return nil, employmentReportRepoError("failed to count the applies by vacancy", "02")
+	}
+
+	return totals, utils.Error{}
+}
+
+// CountAppliesByGroup counts the applies of each group, leaving the rate to
+// the caller. An apply of a candidate with disabilities of many categories is
+// counted once in each of them.
+func (r *employmentReportRepo) CountAppliesByGroup(filter model.EmploymentReportFilter, group enum.AcceptanceRateGroup) ([]model.AcceptanceRate, utils.Error) {
+	rates := []model.AcceptanceRate{}
+
+	query, err := r.applies(filter)
+	if err == nil {
+		switch group {
+		case enum.ByDisabilityCategory:
+			// the candidate has a row for each disability, so each apply is
+			// counted once per category
+			categoryApplies := query.
+				Select("DISTINCT va.id, va.status, c.slug AS group_name").
+				Joins("JOIN person_disabilities pd ON pd.person_id = va.candidate_id").
+				Joins("JOIN disabilities d ON pd.disability_id = d.id").
+				Joins("JOIN disability_categories c ON d.category_id = c.id")
+
+			query = r.db.Table("(?) AS va", categoryApplies).
+				Select("va.group_name, " + applyCounts).
+				Group("va.group_name")
+		case enum.ByCompany:
+			query = query.
+				Select("c.name AS group_name, " + applyCounts).
+				Joins("JOIN companies c ON v.company_id = c.id").
+				Group("c.id, c.name")
+		default:
+			query = query.Select("v.area AS group_name, " + applyCounts).Group("v.area")
+		}
+
+		err = query.Order("applications DESC").Scan(&rates).Error
+	}
+
+	if err != nil {
+		return nil, employmentReportRepoError("failed to count the applies by group", "03")
+	}
+
+	return rates, utils.Error{}
+}
+
+// ListHires returns the accepted applies of the period. The applies made
+// before their dates were stored are left out.
+func (r *employmentReportRepo) ListHires(filter model.EmploymentReportFilter) ([]Hire, utils.Error) {
+	hires := []Hire{}
+
+	query, err := r.applies(filter)
+	if err == nil {
+		err = query.
+			Select("va.created_at, va.decided_at").
+			Where("va.status = ? AND va.created_at IS NOT NULL AND va.decided_at IS NOT NULL", enum.VacancyApplyAccepted).
+			Scan(&hires).Error
+	}
+
+	if err != nil {
+		return nil, employmentReportRepoError("failed to list the hires", "04")
+	}
+
+	return hires, utils.Error{}
+}
+
+func (r *employmentReportRepo) CountApplyFunnel(filter model.EmploymentReportFilter) (ApplyFunnel, utils.Error) {
+	var funnel ApplyFunnel
+
+	query, err := r.applies(filter)
+	if err == nil {
+		err = query.
+			Select(`COUNT(*) AS applied,
+				COALESCE(SUM(CASE WHEN va.status <> 'applied' THEN 1 ELSE 0 END), 0) AS reviewed,
+				COALESCE(SUM(CASE WHEN va.status = 'accepted' THEN 1 ELSE 0 END), 0) AS accepted`).
+			Scan(&funnel).Error
+	}
+
+	if err != nil {
+		return ApplyFunnel{}, employmentReportRepoError("failed to count the apply funnel", "05")
+	}
+
+	return funnel, utils.Error{}
+}
+
+// CountEmploymentDay counts the vacancies open in the day, which starts at
+// the given midnight, and the applies made, accepted and rejected in it.
+func (r *employmentReportRepo) CountEmploymentDay(day time.Time) (EmploymentDay, utils.Error) {
+	var result EmploymentDay
+
+	date := day.Format(time.DateOnly)
+	end := day.AddDate(0, 0, 1)
+
+	var openVacancies int64
+
+	err := r.db.Table("vacancies v").
+		Where("v.deleted_at IS NULL AND v.publish_date <= ? AND v.registration_date >= ?", date, date).
+		Count(&openVacancies).Error
+
+	if err == nil {
+		err = r.db.Table("vacancy_applies va").
+			Joins("JOIN vacancies v ON va.vacancy_id = v.id AND v.deleted_at IS NULL").
+			Select(`COALESCE(SUM(CASE WHEN va.created_at >= ? AND va.created_at < ? THEN 1 ELSE 0 END), 0) AS applies,
+				COALESCE(SUM(CASE WHEN va.status = 'accepted' AND va.decided_at >= ? AND va.decided_at < ? THEN 1 ELSE 0 END), 0) AS accepted,
+				COALESCE(SUM(CASE WHEN va.status = 'rejected' AND va.decided_at >= ? AND va.decided_at < ? THEN 1 ELSE 0 END), 0) AS rejected`,
+				day, end, day, end, day, end).
+			Where("(va.created_at >= ? AND va.created_at < ?) OR (va.decided_at >= ? AND va.decided_at < ?)", day, end, day, end).
+			Scan(&result).Error
+	}
+
+	if err != nil {
+		return EmploymentDay{}, employmentReportRepoError("failed to count the employment of the day", "06")
+	}
+
+	result.OpenVacancies = int(openVacancies)
+
+	return result, utils.Error{}
+}
@@ -1,8 +1,13 @@
 package repo
 
 import (
+	"cij_api/src/database"
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/utils"
+	"strings"
+	"time"
+	"unicode"
 
 	"gorm.io/gorm"
 )
@@ -10,8 +15,20 @@ import (
 type NewsRepo interface {
 	BaseRepoMethods
 
-	ListNews() ([]model.News, utils.Error)
-	CreateNews(news model.News) utils.Error
+	ListNews(filter model.NewsFilter) ([]model.News, int64, utils.Error)
+	GetNewsById(newsId int) (model.News, utils.Error)
+	GetNewsBySlug(slug string) (model.News, utils.Error)
+	SlugExists(slug string) (bool, utils.Error)
+	CreateNews(news model.News) (int, utils.Error)
+	UpdateNews(news model.News, newsId int) utils.Error
+	DeleteNews(newsId int) utils.Error
+	PublishScheduledNews(now time.Time) ([]int, utils.Error)
+
+	ListNewsCategories() ([]model.NewsCategory, utils.Error)
+	GetNewsCategoryBySlug(slug string) (model.NewsCategory, utils.Error)
+	CreateNewsCategory(category model.NewsCategory) (int, utils.Error)
+	ListTags() ([]model.Tag, utils.Error)
+	FindOrCreateTags(tags []model.Tag) ([]model.Tag, utils.Error)
 }
 
 type newsRepo struct {
@@ -35,22 +52,251 @@ func newsRepoError(message string, code string) utils.Error {
 	return utils.NewError(message, errorCode)
 }
 
-func (r *newsRepo) ListNews() ([]model.News, utils.Error) {
+// ListNews returns a page of the news and the total of news of the filter.
+// An empty status lists the news of every status. The search uses the
+// full-text index on MySQL and matches every word with LIKE elsewhere.
+func (r *newsRepo) ListNews(filter model.NewsFilter) ([]model.News, int64, utils.Error) {
 	var news []model.News
+	var total int64
+
+	query := r.db.Model(model.News{})
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if filter.Category != "" {
+		query = query.Where("category_id IN (?)", r.db.Model(model.NewsCategory{}).Select("id").Where("slug = ?", filter.Category))
+	}
+
+	if filter.Tag != "" {
+		query = query.Where(
+			"id IN (?)",
+			r.db.Table("news_tags").Select("news_tags.news_id").Joins("JOIN tags ON tags.id = news_tags.tag_id").Where("tags.slug = ?", filter.Tag),
+		)
+	}
+
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		query = r.searchNews(query, search)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return news, 0, newsRepoError("failed to count the news", "09")
+	}
+
+	order := "COALESCE(date, created_at) DESC, id DESC"
+	if filter.Oldest {
+		order = "COALESCE(date, created_at) ASC, id ASC"
+	}
+
+	err := query.Preload("Author").
+		Preload("Category").
+		Preload("Tags").
+		Order(order).
+		Offset((filter.Page - 1) * filter.PerPage).
+		Limit(filter.PerPage).
+		Find(&news).Error
+	if err != nil {
+		return news, 0, newsRepoError("failed to list the news", "01")
+	}
+
+	return news, total, utils.Error{}
+}
+
+func (r *newsRepo) GetNewsById(newsId int) (model.News, utils.Error) {
+	var news model.News
+
+	err := r.db.Model(model.News{}).Preload("Author").Preload("Category").Preload("Tags").Where("id = ?", newsId).Find(&news).Error
+	if err != nil {
+		return news, newsRepoError("failed to get the news", "03")
+	}
 
-	err := r.db.Model(model.News{}).Find(&news).Error
+	return news, utils.Error{}
+}
+
+func (r *newsRepo) GetNewsBySlug(slug string) (model.News, utils.Error) {
+	var news model.News
+
+	err := r.db.Model(model.News{}).Preload("Author").Preload("Category").Preload("Tags").Where("slug = ?", slug).Find(&news).Error
 	if err != nil {
-		return news, newsRepoError("failed to list the news", "01")
+		return news, newsRepoError("failed to get the news", "04")
 	}
 
 	return news, utils.Error{}
 }
 
-func (r *newsRepo) CreateNews(news model.News) utils.Error {
-	err := r.db.Model(model.News{}).Create(&news).Error
+// SlugExists also checks the deleted news, whose slugs are kept reserved.
+func (r *newsRepo) SlugExists(slug string) (bool, utils.Error) {
+	var count int64
+
+	err := r.db.Unscoped().Model(model.News{}).Where("slug = ?", slug).Count(&count).Error
+	if err != nil {
+		return false, newsRepoError("failed to check the news slug", "05")
+	}
+
+	return count > 0, utils.Error{}
+}
+
+func (r *newsRepo) CreateNews(news model.News) (int, utils.Error) {
+	err := r.db.Model(model.News{}).Omit("Tags.*").Create(&news).Error
 	if err != nil {
-		return newsRepoError("failed to create the news", "02")
+		return 0, newsRepoError("failed to create the news", "02")
+	}
+
+	return news.Id, utils.Error{}
+}
+
+// UpdateNews replaces the fields and the tags of the news.
+func (r *newsRepo) UpdateNews(news model.News, newsId int) utils.Error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(model.News{}).
+			Where("id = ?", newsId).
+			Select(
+				"title", "description", "banner", "author_image", "status", "date", "publish_at", "scheduled", "category_id",
+				"banner_alt", "banner_caption", "banner_long_description", "author_image_alt", "libras_video", "audio_narration",
+			).
+			Updates(news).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.News{Id: newsId}).Association("Tags").Replace(news.Tags)
+	})
+	if err != nil {
+		return newsRepoError("failed to update the news", "06")
 	}
 
 	return utils.Error{}
 }
+
+func (r *newsRepo) DeleteNews(newsId int) utils.Error {
+	if err := r.db.Delete(&model.News{}, newsId).Error; err != nil {
+		return newsRepoError("failed to delete the news", "07")
+	}
+
+	return utils.Error{}
+}
+
+// PublishScheduledNews publishes the scheduled drafts whose publication date
+// arrived, returning the ids of the published news. The other drafts are left
+// alone whatever their publish_at, as they were not validated for publishing.
+func (r *newsRepo) PublishScheduledNews(now time.Time) ([]int, utils.Error) {
+	var newsIds []int
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(model.News{}).
+			Where("status = ? AND scheduled = ? AND publish_at <= ?", enum.NewsDraft, true, now).
+			Pluck("id", &newsIds).Error
+		if err != nil || len(newsIds) == 0 {
+			return err
+		}
+
+		return tx.Model(model.News{}).
+			Where("id IN ?", newsIds).
+			Updates(map[string]interface{}{
+				"status":    enum.NewsPublished,
+				"scheduled": false,
+				"date":      gorm.Expr("publish_at"),
+			}).Error
+	})
+	if err != nil {
+		return nil, newsRepoError("failed to publish the scheduled news", "08")
+	}
+
+	return newsIds, utils.Error{}
+}
+
+func (r *newsRepo) searchNews(query *gorm.DB, search string) *gorm.DB {
+	if r.db.Dialector.Name() == database.MysqlDriver {
+		return query.Where("MATCH (title, description) AGAINST (? IN BOOLEAN MODE)", booleanSearch(search))
+	}
+
+	for _, word := range strings.Fields(strings.ToLower(search)) {
+		pattern := "%" + escapeLike(word) + "%"
+		query = query.Where("(LOWER(title) LIKE ? ESCAPE '\\' OR LOWER(description) LIKE ? ESCAPE '\\')", pattern, pattern)
+	}
+
+	return query
+}
+
+// booleanSearch requires every word of the search, as a prefix, like the LIKE
+// search does. The boolean operators typed in the search are dropped.
+func booleanSearch(search string) string {
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = "+" + word + "*"
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+func (r *newsRepo) ListNewsCategories() ([]model.NewsCategory, utils.Error) {
+	var categories []model.NewsCategory
+
+	if err := r.db.Model(model.NewsCategory{}).Order("id").Find(&categories).Error; err != nil {
+		return categories, newsRepoError("failed to list the news categories", "10")
+	}
+
+	return categories, utils.Error{}
+}
+
+func (r *newsRepo) GetNewsCategoryBySlug(slug string) (model.NewsCategory, utils.Error) {
+	var category model.NewsCategory
+
+	if err := r.db.Model(model.NewsCategory{}).Where("slug = ?", slug).Find(&category).Error; err != nil {
+		return category, newsRepoError("failed to get the news category", "11")
+	}
+
+	return category, utils.Error{}
+}
+
+func (r *newsRepo) CreateNewsCategory(category model.NewsCategory) (int, utils.Error) {
+	if err := r.db.Model(model.NewsCategory{}).Create(&category).Error; err != nil {
+		return 0, newsRepoError("failed to create the news category", "12")
+	}
+
+	return category.Id, utils.Error{}
+}
+
+// ListTags returns the tags used by at least one published news, by name.
+func (r *newsRepo) ListTags() ([]model.Tag, utils.Error) {
+	var tags []model.Tag
+
+	published := r.db.Table("news_tags").
+		Select("news_tags.tag_id").
+		Joins("JOIN news ON news.id = news_tags.news_id").
+		Where("news.status = ?", enum.NewsPublished)
+
+	err := r.db.Model(model.Tag{}).
+		Where("id IN (?)", published).
+		Order("name").
+		Find(&tags).Error
+	if err != nil {
+		return tags, newsRepoError("failed to list the tags", "13")
+	}
+
+	return tags, utils.Error{}
+}
+
+// FindOrCreateTags returns the tags with the slugs, creating the missing ones.
+func (r *newsRepo) FindOrCreateTags(tags []model.Tag) ([]model.Tag, utils.Error) {
+	found := []model.Tag{}
+
+	for _, tag := range tags {
+		if err := r.db.Where(model.Tag{Slug: tag.Slug}).Attrs(model.Tag{Name: tag.Name}).FirstOrCreate(&tag).Error; err != nil {
+			return found, newsRepoError("failed to save the tags", "14")
+		}
+
+		found = append(found, tag)
+	}
+
+	return found, utils.Error{}
+}
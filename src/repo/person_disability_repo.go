@@ -1,8 +1,11 @@
 package repo
 
 import (
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/utils"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -18,7 +21,9 @@ type PersonDisabilityRepo interface {
 
 	// reports
 	CountDisability() (model.DisabilityTotals, utils.Error)
+	CountDisabilityAt(date time.Time) (model.DisabilityTotals, utils.Error)
 	CountDisabilityByNeighborhood(neighborhood string) (model.DisabilityTotalsByNeighborhood, utils.Error)
+	CountPeopleByDimensions(filter model.CrossTabFilter) ([]model.CrossTabCount, utils.Error)
 }
 
 type personDisabilityRepo struct {
@@ -45,7 +50,7 @@ func personDisabilityRepoError(message string, code string) utils.Error {
 func (n *personDisabilityRepo) GetPersonDisabilities(personId int) ([]model.PersonDisability, utils.Error) {
 	var disabilities []model.PersonDisability
 
-	err := n.db.Model(model.PersonDisability{}).Preload("Disability").Where("person_id = ?", personId).Find(&disabilities).Error
+	err := n.db.Model(model.PersonDisability{}).Preload("Disability.Category").Where("person_id = ?", personId).Find(&disabilities).Error
 	if err != nil {
 		return disabilities, personDisabilityRepoError("failed to get the person disabilities", "01")
 	}
@@ -98,76 +103,157 @@ func (n *personDisabilityRepo) ClearPersonDisability(personId int, tx *gorm.DB)
 }
 
 func (n *personDisabilityRepo) CountDisability() (model.DisabilityTotals, utils.Error) {
-	var result []struct {
-		Category string
-		Total    int
-	}
+	var result []disabilityCategoryTotal
 
 	query := `
-		SELECT d.category, COUNT(*) AS total
+		SELECT c.slug AS category, COUNT(*) AS total
 		FROM person_disabilities pd
 		JOIN disabilities d ON pd.disability_id = d.id
-		GROUP BY d.category;
+		JOIN disability_categories c ON d.category_id = c.id
+		GROUP BY c.slug
 	`
 
 	if err := n.db.Raw(query).Scan(&result).Error; err != nil {
 		return model.DisabilityTotals{}, personDisabilityRepoError("failed to count the disabilities", "05")
 	}
 
-	totals := model.DisabilityTotals{}
-	for _, row := range result {
-		switch row.Category {
-		case "Visual":
-			totals.Visual = row.Total
-		case "Hearing":
-			totals.Hearing = row.Total
-		case "Physical":
-			totals.Physical = row.Total
-		case "Intellectual":
-			totals.Intellectual = row.Total
-		case "Psychosocial":
-			totals.Psychosocial = row.Total
-		}
+	totals, err := n.disabilityTotalsFromRows(result)
+	if err != nil {
+		return model.DisabilityTotals{}, personDisabilityRepoError("failed to count the disabilities", "05")
 	}
 
 	return totals, utils.Error{}
 }
 
 func (n *personDisabilityRepo) CountDisabilityByNeighborhood(neighborhood string) (model.DisabilityTotalsByNeighborhood, utils.Error) {
-	var result []struct {
-		Category string
-		Total    int
+	var result []disabilityCategoryTotal
+
+	matchingNeighborhoods, err := matchAddressValues(n.db, "neighborhood", neighborhood)
+	if err != nil {
+		return model.DisabilityTotalsByNeighborhood{}, personDisabilityRepoError("failed to count the disabilities by neighborhood", "06")
+	}
+
+	if len(matchingNeighborhoods) > 0 {
+		query := `
+			SELECT c.slug AS category, COUNT(*) AS total
+			FROM person_disabilities pd
+			JOIN disabilities d ON pd.disability_id = d.id
+			JOIN disability_categories c ON d.category_id = c.id
+			JOIN people p ON pd.person_id = p.id
+			JOIN addresses a ON p.address_id = a.id
+			WHERE a.neighborhood IN ?
+			GROUP BY c.slug
+		`
+
+		if err := n.db.Raw(query, matchingNeighborhoods).Scan(&result).Error; err != nil {
+			return model.DisabilityTotalsByNeighborhood{}, personDisabilityRepoError("failed to count the disabilities by neighborhood", "06")
+		}
 	}
 
+	totals, err := n.disabilityTotalsFromRows(result)
+	if err != nil {
+		return model.DisabilityTotalsByNeighborhood{}, personDisabilityRepoError("failed to count the disabilities by neighborhood", "06")
+	}
+
+	return totals, utils.Error{}
+}
+
+// dimensionColumns are the columns of the cross-tab dimensions in the query
+// of CountPeopleByDimensions.
+var dimensionColumns = map[enum.ReportDimension]string{
+	enum.DimensionCategory:     "c.slug",
+	enum.DimensionGender:       "p.gender",
+	enum.DimensionNeighborhood: "COALESCE(a.neighborhood, '')",
+	enum.DimensionCity:         "COALESCE(a.city, '')",
+	enum.DimensionAgeBand:      "COALESCE(p.birth_date, '')",
+}
+
+// CountPeopleByDimensions counts the distinct people with disabilities per
+// value of the row and column dimensions. People without an address have an
+// empty neighborhood and city.
+func (n *personDisabilityRepo) CountPeopleByDimensions(filter model.CrossTabFilter) ([]model.CrossTabCount, utils.Error) {
+	counts := []model.CrossTabCount{}
+
+	rowColumn, columnColumn := dimensionColumns[filter.Rows], dimensionColumns[filter.Columns]
+
+	query := n.db.Table("person_disabilities pd").
+		Select(fmt.Sprintf("%s AS `row`, %s AS `column`, COUNT(DISTINCT p.id) AS total", rowColumn, columnColumn)).
+		Joins("JOIN people p ON pd.person_id = p.id AND p.deleted_at IS NULL").
+		Joins("JOIN disabilities d ON pd.disability_id = d.id").
+		Joins("JOIN disability_categories c ON d.category_id = c.id").
+		Joins("LEFT JOIN addresses a ON p.address_id = a.id").
+		Group(rowColumn + ", " + columnColumn)
+
+	for column, value := range map[string]string{"neighborhood": filter.Neighborhood, "city": filter.City} {
+		if value == "" {
+			continue
+		}
+
+		values, err := matchAddressValues(n.db, column, value)
+		if err != nil {
+			return nil, personDisabilityRepoError("failed to count the people by dimensions", "07")
+		}
+
+		query = query.Where("a."+column+" IN ?", values)
+	}
+
+	if err := query.Scan(&counts).Error; err != nil {
+		return nil, personDisabilityRepoError("failed to count the people by dimensions", "07")
+	}
+
+	return counts, utils.Error{}
+}
+
+// CountDisabilityAt counts the disabilities of the people registered before
+// the date and not deleted by then, as the totals were at that moment.
+func (n *personDisabilityRepo) CountDisabilityAt(date time.Time) (model.DisabilityTotals, utils.Error) {
+	var result []disabilityCategoryTotal
+
 	query := `
-		SELECT d.category, COUNT(*) AS total
+		SELECT c.slug AS category, COUNT(*) AS total
 		FROM person_disabilities pd
-		JOIN disabilities d ON pd.disability_id = d.id
 		JOIN people p ON pd.person_id = p.id
-		JOIN addresses a ON p.address_id = a.id
-		WHERE REPLACE(LOWER(NormalizeText(a.neighborhood)), ' ', '') = REPLACE(LOWER(NormalizeText(?)), ' ', '')
-		GROUP BY d.category;
+		JOIN disabilities d ON pd.disability_id = d.id
+		JOIN disability_categories c ON d.category_id = c.id
+		WHERE p.created_at < ? AND (p.deleted_at IS NULL OR p.deleted_at >= ?)
+		GROUP BY c.slug
 	`
 
-	if err := n.db.Raw(query, neighborhood).Scan(&result).Error; err != nil {
-		return model.DisabilityTotalsByNeighborhood{}, personDisabilityRepoError("failed to count the disabilities by neighborhood", "06")
+	if err := n.db.Raw(query, date, date).Scan(&result).Error; err != nil {
+		return model.DisabilityTotals{}, personDisabilityRepoError("failed to count the disabilities", "08")
 	}
 
-	totals := model.DisabilityTotalsByNeighborhood{}
-	for _, row := range result {
-		switch row.Category {
-		case "Visual":
-			totals.Visual = row.Total
-		case "Hearing":
-			totals.Hearing = row.Total
-		case "Physical":
-			totals.Physical = row.Total
-		case "Intellectual":
-			totals.Intellectual = row.Total
-		case "Psychosocial":
-			totals.Psychosocial = row.Total
-		}
+	totals, err := n.disabilityTotalsFromRows(result)
+	if err != nil {
+		return model.DisabilityTotals{}, personDisabilityRepoError("failed to count the disabilities", "08")
 	}
 
 	return totals, utils.Error{}
 }
+
+type disabilityCategoryTotal struct {
+	Category enum.DisabilityCategoryEnum
+	Total    int
+}
+
+// disabilityTotalsFromRows starts every category at zero, so categories that
+// no one has still appear in the reports.
+func (n *personDisabilityRepo) disabilityTotalsFromRows(rows []disabilityCategoryTotal) (model.DisabilityTotals, error) {
+	var categories []enum.DisabilityCategoryEnum
+
+	if err := n.db.Model(model.DisabilityCategory{}).Pluck("slug", &categories).Error; err != nil {
+		return model.DisabilityTotals{}, err
+	}
+
+	totals := model.DisabilityTotals{}
+
+	for _, category := range categories {
+		totals[category] = 0
+	}
+
+	for _, row := range rows {
+		totals[row.Category] = model.Count(row.Total)
+	}
+
+	return totals, nil
+}
@@ -17,7 +17,7 @@ type PersonRepo interface {
 	GetPersonByCpf(cpf string) (model.Person, utils.Error)
 	UpdatePerson(person model.Person, personId int, tx *gorm.DB) utils.Error
 	DeletePerson(personId int) utils.Error
-	UploadCurriculum(personId int, fileUrl string) utils.Error
+	UploadCurriculum(personId int, fileKey string) utils.Error
 }
 
 type personRepo struct {
@@ -126,8 +126,8 @@ func (n *personRepo) DeletePerson(personId int) utils.Error {
 	return utils.Error{}
 }
 
-func (n *personRepo) UploadCurriculum(personId int, fileUrl string) utils.Error {
-	if err := n.db.Model(model.Person{}).Where("id = ?", personId).Update("curriculum", fileUrl).Error; err != nil {
+func (n *personRepo) UploadCurriculum(personId int, fileKey string) utils.Error {
+	if err := n.db.Model(model.Person{}).Where("id = ?", personId).Update("curriculum", fileKey).Error; err != nil {
 		return personRepoError("failed to upload the curriculum", "08")
 	}
 
@@ -0,0 +1,170 @@
+package repo
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/utils"
+
+	"gorm.io/gorm"
+)
+
+type ReportSnapshotRepo interface {
+	BaseRepoMethods
+
+	SaveSnapshot(snapshot model.ReportSnapshot, disabilities []model.DisabilitySnapshot, activities []model.ActivitySnapshot, employment model.EmploymentSnapshot) utils.Error
+	GetLastSnapshotDate() (string, utils.Error)
+	ListSnapshotDates(from string, to string) ([]string, utils.Error)
+	ListDisabilitySnapshots(from string, to string) ([]model.DisabilitySnapshot, utils.Error)
+	ListActivitySnapshots(types []string, from string, to string) ([]model.ActivitySnapshot, utils.Error)
+	ListEmploymentSnapshots(from string, to string) ([]model.EmploymentSnapshot, utils.Error)
+}
+
+type reportSnapshotRepo struct {
+	BaseRepo
+	db *gorm.DB
+}
+
+func NewReportSnapshotRepo(db *gorm.DB) ReportSnapshotRepo {
+	repo := &reportSnapshotRepo{
+		db: db,
+	}
+
+	repo.SetRepo(repo.db)
+
+	return repo
+}
+
+func reportSnapshotRepoError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.DatabaseErrorCode, utils.ReportsErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+// snapshotDate drops the time MySQL may add to the dates.
+func snapshotDate(date string) string {
+	if len(date) > 10 {
+		return date[:10]
+	}
+
+	return date
+}
+
+// SaveSnapshot replaces the snapshot of the day, so a day taken again is
+// recomputed instead of counted twice.
+func (r *reportSnapshotRepo) SaveSnapshot(snapshot model.ReportSnapshot, disabilities []model.DisabilitySnapshot, activities []model.ActivitySnapshot, employment model.EmploymentSnapshot) utils.Error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []interface{}{&model.ReportSnapshot{}, &model.DisabilitySnapshot{}, &model.ActivitySnapshot{}, &model.EmploymentSnapshot{}} {
+			if err := tx.Where("date = ?", snapshot.Date).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(disabilities) > 0 {
+			if err := tx.Create(&disabilities).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(activities) > 0 {
+			if err := tx.Create(&activities).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&employment).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&snapshot).Error
+	})
+
+	if err != nil {
+		return reportSnapshotRepoError("failed to save the report snapshot", "07")
+	}
+
+	return utils.Error{}
+}
+
+// GetLastSnapshotDate returns the latest day snapshot, or the empty string
+// when there is none.
+func (r *reportSnapshotRepo) GetLastSnapshotDate() (string, utils.Error) {
+	var snapshots []model.ReportSnapshot
+
+	if err := r.db.Order("date DESC").Limit(1).Find(&snapshots).Error; err != nil {
+		return "", reportSnapshotRepoError("failed to get the last report snapshot", "08")
+	}
+
+	if len(snapshots) == 0 {
+		return "", utils.Error{}
+	}
+
+	return snapshotDate(snapshots[0].Date), utils.Error{}
+}
+
+func (r *reportSnapshotRepo) ListSnapshotDates(from string, to string) ([]string, utils.Error) {
+	var dates []string
+
+	err := r.db.Model(&model.ReportSnapshot{}).
+		Where("date >= ? AND date <= ?", from, to).
+		Order("date").
+		Pluck("date", &dates).Error
+
+	if err != nil {
+		return nil, reportSnapshotRepoError("failed to list the report snapshots", "09")
+	}
+
+	for i := range dates {
+		dates[i] = snapshotDate(dates[i])
+	}
+
+	return dates, utils.Error{}
+}
+
+func (r *reportSnapshotRepo) ListDisabilitySnapshots(from string, to string) ([]model.DisabilitySnapshot, utils.Error) {
+	var snapshots []model.DisabilitySnapshot
+
+	if err := r.db.Where("date >= ? AND date <= ?", from, to).Order("date, category").Find(&snapshots).Error; err != nil {
+		return nil, reportSnapshotRepoError("failed to list the disability snapshots", "10")
+	}
+
+	for i := range snapshots {
+		snapshots[i].Date = snapshotDate(snapshots[i].Date)
+	}
+
+	return snapshots, utils.Error{}
+}
+
+// ListActivitySnapshots lists the snapshots of the activity types, or of all
+// of them when no type is given.
+func (r *reportSnapshotRepo) ListActivitySnapshots(types []string, from string, to string) ([]model.ActivitySnapshot, utils.Error) {
+	var snapshots []model.ActivitySnapshot
+
+	query := r.db.Where("date >= ? AND date <= ?", from, to)
+
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+
+	if err := query.Order("date, type").Find(&snapshots).Error; err != nil {
+		return nil, reportSnapshotRepoError("failed to list the activity snapshots", "11")
+	}
+
+	for i := range snapshots {
+		snapshots[i].Date = snapshotDate(snapshots[i].Date)
+	}
+
+	return snapshots, utils.Error{}
+}
+
+func (r *reportSnapshotRepo) ListEmploymentSnapshots(from string, to string) ([]model.EmploymentSnapshot, utils.Error) {
+	var snapshots []model.EmploymentSnapshot
+
+	if err := r.db.Where("date >= ? AND date <= ?", from, to).Order("date").Find(&snapshots).Error; err != nil {
+		return nil, reportSnapshotRepoError("failed to list the employment snapshots", "12")
+	}
+
+	for i := range snapshots {
+		snapshots[i].Date = snapshotDate(snapshots[i].Date)
+	}
+
+	return snapshots, utils.Error{}
+}
@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"cij_api/src/model"
+	"cij_api/src/utils"
+
+	"gorm.io/gorm"
+)
+
+type UserConfigRepo interface {
+	BaseRepoMethods
+
+	CreateUserConfig(userConfig model.UserConfig, tx *gorm.DB) utils.Error
+	GetLatestUserConfig(userId int, tx *gorm.DB) (model.UserConfig, utils.Error)
+	GetUserConfigVersion(userId int, version int) (model.UserConfig, utils.Error)
+	ListUserConfigVersions(userId int) ([]model.UserConfig, utils.Error)
+}
+
+type userConfigRepo struct {
+	BaseRepo
+	db *gorm.DB
+}
+
+func NewUserConfigRepo(db *gorm.DB) UserConfigRepo {
+	repo := &userConfigRepo{
+		db: db,
+	}
+
+	repo.SetRepo(repo.db)
+
+	return repo
+}
+
+func userConfigRepoError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.DatabaseErrorCode, utils.ConfigErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+func (n *userConfigRepo) CreateUserConfig(userConfig model.UserConfig, tx *gorm.DB) utils.Error {
+	databaseConn := n.db
+
+	if tx != nil {
+		databaseConn = tx
+	}
+
+	if err := databaseConn.Create(&userConfig).Error; err != nil {
+		return userConfigRepoError("failed to create the user config", "01")
+	}
+
+	return utils.Error{}
+}
+
+func (n *userConfigRepo) GetLatestUserConfig(userId int, tx *gorm.DB) (model.UserConfig, utils.Error) {
+	var userConfig model.UserConfig
+
+	databaseConn := n.db
+
+	if tx != nil {
+		databaseConn = tx
+	}
+
+	err := databaseConn.Model(model.UserConfig{}).Where("user_id = ?", userId).Order("version DESC").Limit(1).Find(&userConfig).Error
+	if err != nil {
+		return userConfig, userConfigRepoError("failed to get the user config", "02")
+	}
+
+	return userConfig, utils.Error{}
+}
+
+func (n *userConfigRepo) GetUserConfigVersion(userId int, version int) (model.UserConfig, utils.Error) {
+	var userConfig model.UserConfig
+
+	err := n.db.Model(model.UserConfig{}).Where("user_id = ? AND version = ?", userId, version).Find(&userConfig).Error
+	if err != nil {
+		return userConfig, userConfigRepoError("failed to get the user config version", "03")
+	}
+
+	return userConfig, utils.Error{}
+}
+
+func (n *userConfigRepo) ListUserConfigVersions(userId int) ([]model.UserConfig, utils.Error) {
+	var userConfigs []model.UserConfig
+
+	err := n.db.Model(model.UserConfig{}).Where("user_id = ?", userId).Order("version DESC").Find(&userConfigs).Error
+	if err != nil {
+		return userConfigs, userConfigRepoError("failed to list the user config versions", "04")
+	}
+
+	return userConfigs, utils.Error{}
+}
@@ -5,6 +5,7 @@ import (
 	model "cij_api/src/model/vacancy"
 	"cij_api/src/repo"
 	"cij_api/src/utils"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -14,10 +15,12 @@ type VacancyApplyRepo interface {
 
 	CreateVacancyApply(createVacancyApply model.VacancyApply) (int, utils.Error)
 	GetVacancyApply(vacancyId int, candidateId int) (model.VacancyApply, utils.Error)
+	GetVacancyApplyById(vacancyApplyId int) (model.VacancyApply, utils.Error)
 	ListVacancyAppliesByVacancyId(vacancyId int) ([]model.VacancyApply, utils.Error)
 	ListVacancyAppliesByVacancyIdAndCandidateId(vacancyId int, candidateId int) ([]model.VacancyApply, utils.Error)
 	UpdateVacancyApplyStatus(vacancyApplyId int, status enum.VacancyApplyStatus) utils.Error
 	DeleteVacancyAppliesByVacancyId(vacancyId int, tx *gorm.DB) utils.Error
+	HasCompanyApply(companyId int, candidateId int) (bool, utils.Error)
 }
 
 type vacancyApplyRepo struct {
@@ -59,6 +62,16 @@ func (v *vacancyApplyRepo) GetVacancyApply(vacancyId int, candidateId int) (mode
 	return vacancyApply, utils.Error{}
 }
 
+func (v *vacancyApplyRepo) GetVacancyApplyById(vacancyApplyId int) (model.VacancyApply, utils.Error) {
+	var vacancyApply model.VacancyApply
+
+	if err := v.db.Where("id = ?", vacancyApplyId).Find(&vacancyApply).Error; err != nil {
+		return model.VacancyApply{}, vacancyApplyRepoError("failed to get the vacancy apply", "06")
+	}
+
+	return vacancyApply, utils.Error{}
+}
+
 func (v *vacancyApplyRepo) ListVacancyAppliesByVacancyId(vacancyId int) ([]model.VacancyApply, utils.Error) {
 	var vacancyApplies []model.VacancyApply
 
@@ -79,8 +92,19 @@ func (v *vacancyApplyRepo) ListVacancyAppliesByVacancyIdAndCandidateId(vacancyId
 	return vacancyApplies, utils.Error{}
 }
 
+// UpdateVacancyApplyStatus also stores when the apply was accepted or
+// rejected, which the reports use as the hiring date.
 func (v *vacancyApplyRepo) UpdateVacancyApplyStatus(vacancyApplyId int, status enum.VacancyApplyStatus) utils.Error {
-	if err := v.db.Model(model.VacancyApply{}).Where("id = ?", vacancyApplyId).Update("status", status).Error; err != nil {
+	var decidedAt *time.Time
+
+	if status != enum.VacancyApplyApplied {
+		now := time.Now()
+		decidedAt = &now
+	}
+
+	updates := map[string]interface{}{"status": status, "decided_at": decidedAt}
+
+	if err := v.db.Model(model.VacancyApply{}).Where("id = ?", vacancyApplyId).Updates(updates).Error; err != nil {
 		return vacancyApplyRepoError("failed to update the vacancy apply status", "03")
 	}
 
@@ -100,3 +124,19 @@ func (v *vacancyApplyRepo) DeleteVacancyAppliesByVacancyId(vacancyId int, tx *go
 
 	return utils.Error{}
 }
+
+// HasCompanyApply reports whether the candidate applied to any vacancy of the
+// company.
+func (v *vacancyApplyRepo) HasCompanyApply(companyId int, candidateId int) (bool, utils.Error) {
+	var count int64
+
+	err := v.db.Model(model.VacancyApply{}).
+		Joins("JOIN vacancies ON vacancies.id = vacancy_applies.vacancy_id").
+		Where("vacancies.company_id = ? AND vacancy_applies.candidate_id = ?", companyId, candidateId).
+		Count(&count).Error
+	if err != nil {
+		return false, vacancyApplyRepoError("failed to check the company applies", "05")
+	}
+
+	return count > 0, utils.Error{}
+}
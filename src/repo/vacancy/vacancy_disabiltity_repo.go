@@ -41,7 +41,7 @@ func vacancyDisabilityRepoError(message string, code string) utils.Error {
 func (v *vacancyDisabilityRepo) GetVacancyDisabilities(vacancyId int) ([]model.VacancyDisability, utils.Error) {
 	var disabilities []model.VacancyDisability
 
-	err := v.db.Model(model.VacancyDisability{}).Preload("Disability").Where("vacancy_id = ?", vacancyId).Find(&disabilities).Error
+	err := v.db.Model(model.VacancyDisability{}).Preload("Disability.Category").Where("vacancy_id = ?", vacancyId).Find(&disabilities).Error
 	if err != nil {
 		return disabilities, vacancyDisabilityRepoError("failed to get the vacancy disabilities", "01")
 	}
@@ -2,6 +2,7 @@ package repo
 
 import (
 	"cij_api/src/enum"
+	"cij_api/src/geocoding"
 	model "cij_api/src/model/vacancy"
 	"cij_api/src/repo"
 	"cij_api/src/utils"
@@ -18,10 +19,11 @@ type VacancyRepo interface {
 		area string,
 		contractType enum.VacancyContractType,
 		searchText string,
+		companiesWithin *geocoding.BoundingBox,
 	) ([]model.Vacancy, utils.Error)
 	UpsertVacancy(vacancy model.Vacancy, tx *gorm.DB) (int, utils.Error)
 	UpdateVacancy(vacancy model.Vacancy, tx *gorm.DB) utils.Error
-	DeleteVacancy(id int) utils.Error
+	DeleteVacancy(id int, tx *gorm.DB) utils.Error
 }
 
 type vacancyRepo struct {
@@ -60,12 +62,13 @@ func (v *vacancyRepo) ListVacancies(
 	area string,
 	contractType enum.VacancyContractType,
 	searchText string,
+	companiesWithin *geocoding.BoundingBox,
 ) ([]model.Vacancy, utils.Error) {
 	var vacancies []model.Vacancy
 
 	query := v.db.Model(&model.Vacancy{}).
 		Preload("Disabilities").
-		Preload("Company")
+		Preload("Company.Address")
 
 	if area != "" {
 		query = query.Where("vacancies.area = ?", area)
@@ -83,6 +86,16 @@ func (v *vacancyRepo) ListVacancies(
 		query = query.Where("(vacancies.code LIKE ? OR vacancies.title LIKE ?)", "%"+searchText+"%", "%"+searchText+"%")
 	}
 
+	// the companies without coordinates are left out, as they can't be
+	// measured
+	if companiesWithin != nil {
+		query = query.
+			Joins("JOIN companies ON companies.id = vacancies.company_id").
+			Joins("JOIN addresses ON addresses.id = companies.address_id").
+			Where("addresses.latitude BETWEEN ? AND ?", companiesWithin.MinLatitude, companiesWithin.MaxLatitude).
+			Where("addresses.longitude BETWEEN ? AND ?", companiesWithin.MinLongitude, companiesWithin.MaxLongitude)
+	}
+
 	err := query.Find(&vacancies).Error
 	if err != nil {
 		return vacancies, vacancyRepoError("failed to list the vacancies", "02")
@@ -119,8 +132,14 @@ func (v *vacancyRepo) UpdateVacancy(vacancy model.Vacancy, tx *gorm.DB) utils.Er
 	return utils.Error{}
 }
 
-func (v *vacancyRepo) DeleteVacancy(id int) utils.Error {
-	if err := v.db.Where("id = ?", id).Delete(&model.Vacancy{}).Error; err != nil {
+func (v *vacancyRepo) DeleteVacancy(id int, tx *gorm.DB) utils.Error {
+	databaseConn := v.db
+
+	if tx != nil {
+		databaseConn = tx
+	}
+
+	if err := databaseConn.Where("id = ?", id).Delete(&model.Vacancy{}).Error; err != nil {
 		return vacancyRepoError("failed to delete the vacancy", "04")
 	}
 
@@ -0,0 +1,209 @@
+package router_test
+
+import (
+	"bytes"
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/repo"
+	"cij_api/src/service"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateActivity(t *testing.T) {
+	h := newHarness(t)
+
+	request := model.ActivityRequest{Type: "visit", Description: "Page visited", Actor: "anonymous"}
+
+	h.request(http.MethodPost, "/activities", request, h.adminToken()).
+		expect(http.StatusCreated).
+		expectMessage("Activity created successfully")
+
+	var count int64
+	h.db.Model(&model.Activity{}).Where("type = ?", "visit").Count(&count)
+
+	if count != 1 {
+		t.Fatalf("expected the activity to be created, got %d", count)
+	}
+}
+
+func TestCreateActivityErrors(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	h.request(http.MethodPost, "/activities", "not an object", token).expect(http.StatusBadRequest)
+	h.request(http.MethodPost, "/activities", nil, "").expect(http.StatusBadRequest).expectMessage("token not found")
+	h.request(http.MethodPost, "/activities", nil, h.companyToken(h.createCompany())).
+		expect(http.StatusBadRequest).
+		expectMessage("role don't have permission")
+}
+
+func TestGetActivitiesByTypeAndPeriod(t *testing.T) {
+	h := newHarness(t)
+	h.create(&model.Activity{Type: "visit", Description: "Page visited", Actor: "anonymous"})
+	h.create(&model.Activity{Type: "other", Description: "Other activity", Actor: "anonymous"})
+
+	start := time.Now().Add(-time.Hour).Unix()
+	end := time.Now().Add(time.Hour).Unix()
+
+	var body struct {
+		Data []model.ActivityResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/activities?type=visit&start_date=%d&end_date=%d", start, end), nil, "").
+		expect(http.StatusOK).
+		decode(&body)
+
+	if len(body.Data) != 1 || body.Data[0].Type != "visit" {
+		t.Fatalf("unexpected activities: %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/activities?type=visit&start_date=abc&end_date=%d", end), nil, "").
+		expect(http.StatusBadRequest).
+		expectMessage("Invalid start date")
+
+	h.request(http.MethodGet, fmt.Sprintf("/activities?type=visit&start_date=%d", start), nil, "").
+		expect(http.StatusBadRequest).
+		expectMessage("Invalid end date")
+}
+
+func (h *harness) createActivityAt(activityType string, createdAt time.Time) model.Activity {
+	activity := model.Activity{Type: activityType, Description: "Page visited", Actor: "anonymous"}
+	h.create(&activity)
+	h.db.Model(&model.Activity{}).Where("id = ?", activity.ID).UpdateColumn("created_at", createdAt)
+
+	return activity
+}
+
+func TestActivityRetentions(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	h.request(http.MethodPut, "/activities/retentions/visit", model.ActivityRetentionRequest{Days: 30}, token).
+		expect(http.StatusOK)
+	h.request(http.MethodPut, "/activities/retentions/visit", model.ActivityRetentionRequest{Days: 90}, token).
+		expect(http.StatusOK)
+
+	var body struct {
+		Data []model.ActivityRetention `json:"data"`
+	}
+	h.request(http.MethodGet, "/activities/retentions", nil, token).expect(http.StatusOK).decode(&body)
+
+	if len(body.Data) != 1 || body.Data[0].Type != "visit" || body.Data[0].Days != 90 {
+		t.Fatalf("unexpected retentions: %+v", body.Data)
+	}
+
+	h.request(http.MethodPut, "/activities/retentions/visit", model.ActivityRetentionRequest{Days: 0}, token).
+		expect(http.StatusBadRequest).
+		expectMessage("the retention must be of at least one day")
+	h.request(http.MethodPut, "/activities/retentions/Page%20visit", model.ActivityRetentionRequest{Days: 10}, token).
+		expect(http.StatusBadRequest).
+		expectMessage("invalid activity type")
+	h.request(http.MethodGet, "/activities/retentions", nil, h.personToken(h.createPerson())).
+		expect(http.StatusBadRequest).
+		expectMessage("role don't have permission")
+
+	h.request(http.MethodDelete, "/activities/retentions/visit", nil, token).expect(http.StatusOK)
+	h.request(http.MethodDelete, "/activities/retentions/visit", nil, token).expect(http.StatusNotFound)
+
+	var count int64
+	h.db.Model(&model.AuditEvent{}).Where("entity_type = ?", enum.AuditActivityRetention).Count(&count)
+
+	if count != 3 {
+		t.Fatalf("expected the retention changes to be audited, got %d", count)
+	}
+}
+
+func TestArchiveExpiredActivities(t *testing.T) {
+	h := newHarness(t)
+	h.create(&model.ActivityRetention{Type: "visit", Days: 30})
+
+	if !h.db.Migrator().HasIndex(&model.Activity{}, "idx_activities_type_created_at") {
+		t.Fatal("expected the activities to be indexed by type and date")
+	}
+
+	expired := h.createActivityAt("visit", time.Now().AddDate(0, 0, -40))
+	recent := h.createActivityAt("visit", time.Now().AddDate(0, 0, -10))
+	kept := h.createActivityAt("login", time.Now().AddDate(-1, 0, 0))
+
+	activityService := service.NewActivityService(repo.NewActivityRepo(h.db), fileStorage, h.auditService())
+
+	archives, err := activityService.ArchiveExpiredActivities(model.SystemActor("test"))
+	if err.Code != "" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(archives) != 1 || archives[0].Count != 1 || !strings.HasPrefix(archives[0].Key, "private/archive/activities/visit/") {
+		t.Fatalf("unexpected archives: %+v", archives)
+	}
+
+	content, ok := fileStorage.file(archives[0].Key)
+	if !ok {
+		t.Fatal("expected the archive to be stored")
+	}
+
+	reader, gzipErr := gzip.NewReader(bytes.NewReader(content))
+	if gzipErr != nil {
+		t.Fatalf("expected a gzipped archive: %v", gzipErr)
+	}
+
+	var archived model.ActivityResponse
+	if decodeErr := json.NewDecoder(reader).Decode(&archived); decodeErr != nil || archived.ID != expired.ID || archived.CreatedAt.IsZero() {
+		t.Fatalf("unexpected archived activity: %+v (%v)", archived, decodeErr)
+	}
+
+	var ids []uint
+	h.db.Unscoped().Model(&model.Activity{}).Order("id").Pluck("id", &ids)
+
+	if len(ids) != 2 || ids[0] != recent.ID || ids[1] != kept.ID {
+		t.Fatalf("expected only the expired activity to be deleted, got %v", ids)
+	}
+
+	if archives, _ := activityService.ArchiveExpiredActivities(model.SystemActor("test")); len(archives) != 0 {
+		t.Fatalf("expected nothing left to archive, got %+v", archives)
+	}
+}
+
+func TestExportActivities(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+	now := time.Now().UTC()
+
+	visit := h.createActivityAt("visit", now.Add(-2*time.Hour))
+	h.createActivityAt("login", now.Add(-time.Hour))
+	h.createActivityAt("visit", now.AddDate(0, 0, -3))
+
+	period := fmt.Sprintf("from=%s&to=%s", now.Add(-24*time.Hour).Format(time.RFC3339), now.Format(time.RFC3339))
+
+	res := h.request(http.MethodGet, "/activities/export?"+period, nil, token).expect(http.StatusOK)
+
+	rows, csvErr := csv.NewReader(bytes.NewReader(res.body)).ReadAll()
+	if csvErr != nil || len(rows) != 3 || rows[0][0] != "id" || rows[1][1] != "visit" || rows[2][1] != "login" {
+		t.Fatalf("unexpected csv export: %q (%v)", res.body, csvErr)
+	}
+
+	if res.header.Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("unexpected content type %q", res.header.Get("Content-Type"))
+	}
+
+	res = h.request(http.MethodGet, "/activities/export?format=ndjson&type=visit&"+period, nil, token).expect(http.StatusOK)
+
+	var exported model.ActivityResponse
+	lines := strings.Split(strings.TrimSpace(string(res.body)), "\n")
+
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &exported) != nil || exported.ID != visit.ID {
+		t.Fatalf("unexpected ndjson export: %q", res.body)
+	}
+
+	h.request(http.MethodGet, "/activities/export?format=xml&"+period, nil, token).expect(http.StatusBadRequest)
+	h.request(http.MethodGet, "/activities/export?from=yesterday", nil, token).expect(http.StatusBadRequest)
+	h.request(http.MethodGet, fmt.Sprintf("/activities/export?from=%s&to=%s", now.Format(time.RFC3339), now.Add(-time.Hour).Format(time.RFC3339)), nil, token).
+		expect(http.StatusBadRequest).
+		expectMessage("the start of the period must be before its end")
+}
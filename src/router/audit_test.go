@@ -0,0 +1,157 @@
+package router_test
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type auditEventsBody struct {
+	Data []model.AuditEventResponse `json:"data"`
+}
+
+func TestAuditEvents(t *testing.T) {
+	h := newHarness(t)
+	admin := h.createUser(model.AdminRole)
+	token := h.token(admin)
+	disability := h.disability()
+	headers := map[string]string{"X-Request-ID": "request-1", "User-Agent": "audit-test"}
+
+	update := model.DisabilityRequest{
+		Category:            string(disability.Category.Slug),
+		Description:         disability.Description,
+		Rate:                70,
+		Cid10:               disability.Cid10,
+		Icd11:               disability.Icd11,
+		LegalClassification: disability.LegalClassification,
+	}
+	path := fmt.Sprintf("/disabilities/%d", disability.Id)
+
+	res := h.requestWithHeaders(http.MethodPut, path, update, token, headers).expect(http.StatusOK)
+	if res.header.Get("X-Request-ID") != "request-1" {
+		t.Fatalf("expected the request id to be answered, got %q", res.header.Get("X-Request-ID"))
+	}
+
+	h.request(http.MethodDelete, path, nil, token).expect(http.StatusOK)
+
+	var body auditEventsBody
+	res = h.request(http.MethodGet, fmt.Sprintf("/audit-events?entity_type=disability&entity_id=%d", disability.Id), nil, token).
+		expect(http.StatusOK)
+	res.decode(&body)
+
+	if len(body.Data) != 2 || res.header.Get("X-Total-Count") != "2" {
+		t.Fatalf("expected the update and the delete, got %s", res.body)
+	}
+
+	deleted, updated := body.Data[0], body.Data[1]
+
+	if deleted.Action != enum.AuditDelete || deleted.Changes["rate"].After != nil || deleted.Changes["rate"].Before != float64(70) {
+		t.Fatalf("unexpected delete event: %+v", deleted)
+	}
+
+	if updated.Action != enum.AuditUpdate || updated.ActorId == nil || *updated.ActorId != admin.Id ||
+		updated.ActorEmail != admin.Email || updated.ActorRole != "admin" ||
+		updated.RequestId != "request-1" || updated.UserAgent != "audit-test" || updated.Ip == "" {
+		t.Fatalf("unexpected update event: %+v", updated)
+	}
+
+	if change := updated.Changes["rate"]; change.Before != float64(disability.Rate) || change.After != float64(70) {
+		t.Fatalf("expected the rate change, got %+v", updated.Changes)
+	}
+
+	if _, ok := updated.Changes["description"]; ok {
+		t.Fatalf("expected only the changed fields, got %+v", updated.Changes)
+	}
+
+	var filtered auditEventsBody
+	h.request(http.MethodGet, "/audit-events?request_id=request-1&action=update", nil, token).expect(http.StatusOK).decode(&filtered)
+
+	if len(filtered.Data) != 1 || filtered.Data[0].Id != updated.Id {
+		t.Fatalf("expected the update event, got %+v", filtered.Data)
+	}
+
+	var event struct {
+		Data model.AuditEventResponse `json:"data"`
+	}
+	h.request(http.MethodGet, fmt.Sprintf("/audit-events/%d", updated.Id), nil, token).expect(http.StatusOK).decode(&event)
+
+	if event.Data.Id != updated.Id || event.Data.EntityId != disability.Id {
+		t.Fatalf("unexpected event: %+v", event.Data)
+	}
+
+	h.request(http.MethodGet, "/audit-events/999999", nil, token).expect(http.StatusNotFound)
+	h.request(http.MethodGet, "/audit-events?action=publish", nil, token).expect(http.StatusBadRequest)
+	h.request(http.MethodGet, "/audit-events?from=yesterday", nil, token).expect(http.StatusBadRequest)
+	h.request(http.MethodGet, "/audit-events", nil, h.personToken(h.createPerson())).
+		expect(http.StatusBadRequest).
+		expectMessage("role don't have permission")
+}
+
+func TestAuditLogin(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+
+	h.requestWithHeaders(http.MethodPost, "/login", model.Credentials{Email: person.User.Email, Password: testPassword}, "", map[string]string{"User-Agent": "audit-test"}).
+		expect(http.StatusOK)
+
+	var event model.AuditEvent
+	if err := h.db.Where("action = ?", enum.AuditLogin).First(&event).Error; err != nil {
+		t.Fatalf("expected the login to be audited: %v", err)
+	}
+
+	if event.ActorId == nil || *event.ActorId != person.UserId || event.ActorRole != "person" ||
+		event.EntityType != enum.AuditUser || event.EntityId != person.UserId || event.UserAgent != "audit-test" || event.RequestId == "" {
+		t.Fatalf("unexpected login event: %+v", event)
+	}
+}
+
+func TestAuditEventsAppendOnly(t *testing.T) {
+	h := newHarness(t)
+
+	event := model.AuditEvent{Action: enum.AuditCreate, EntityType: enum.AuditNews, EntityId: 1}
+	h.create(&event)
+
+	if err := h.db.Model(&event).Update("action", enum.AuditDelete).Error; err == nil {
+		t.Fatal("expected the update of an audit event to fail")
+	}
+
+	if err := h.db.Delete(&event).Error; err == nil {
+		t.Fatal("expected the deletion of an audit event to fail")
+	}
+
+	if err := h.db.Exec("UPDATE audit_events SET action = 'delete'").Error; err == nil {
+		t.Fatal("expected the database to reject the update")
+	}
+
+	if err := h.db.Exec("DELETE FROM audit_events").Error; err == nil {
+		t.Fatal("expected the database to reject the deletion")
+	}
+
+	var stored model.AuditEvent
+	h.db.First(&stored, event.Id)
+
+	if stored.Action != enum.AuditCreate {
+		t.Fatalf("expected the event to be kept, got %+v", stored)
+	}
+}
+
+func TestAuditFailureKeepsTheChange(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	if err := h.db.Migrator().DropTable(&model.AuditEvent{}); err != nil {
+		t.Fatal(err)
+	}
+
+	request := model.NewsRequest{Title: "Feira", Description: "Descrição"}
+	h.request(http.MethodPost, "/news", request, token).expect(http.StatusCreated)
+
+	var count int64
+	h.db.Model(&model.News{}).Count(&count)
+
+	if count != 1 {
+		t.Fatalf("expected the news to be created without the audit event, got %d news", count)
+	}
+}
@@ -0,0 +1,89 @@
+package router_test
+
+import (
+	"cij_api/src/model"
+	"net/http"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+
+	var body model.LoginResponse
+	h.request(http.MethodPost, "/login", model.Credentials{Email: person.User.Email, Password: testPassword}, "").
+		expect(http.StatusOK).
+		decode(&body)
+
+	if body.Token == "" {
+		t.Fatal("expected a token")
+	}
+
+	var activities int64
+	h.db.Model(&model.Activity{}).Where("type = ? AND actor = ?", "login", person.User.Email).Count(&activities)
+
+	if activities != 1 {
+		t.Fatalf("expected one login activity, got %d", activities)
+	}
+}
+
+func TestLoginErrors(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+
+	h.request(http.MethodPost, "/login", model.Credentials{Email: "missing@test.com", Password: testPassword}, "").
+		expect(http.StatusBadRequest).
+		expectCode("3103")
+
+	h.request(http.MethodPost, "/login", model.Credentials{Email: person.User.Email, Password: "wrong"}, "").
+		expect(http.StatusBadRequest).
+		expectCode("3104")
+
+	h.request(http.MethodPost, "/login", "not an object", "").expect(http.StatusBadRequest)
+}
+
+func TestGetUserData(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	company := h.createCompany()
+
+	var body struct {
+		UserInfo model.PersonResponse `json:"user_info"`
+	}
+
+	h.request(http.MethodPost, "/get-user-data", map[string]string{"token": h.personToken(person)}, "").
+		expect(http.StatusOK).
+		decode(&body)
+
+	if body.UserInfo.Cpf != person.Cpf || body.UserInfo.Address == nil {
+		t.Fatalf("unexpected person data: %+v", body.UserInfo)
+	}
+
+	var companyBody struct {
+		UserInfo model.CompanyResponse `json:"user_info"`
+	}
+
+	h.request(http.MethodPost, "/get-user-data", map[string]string{"token": h.companyToken(company)}, "").
+		expect(http.StatusOK).
+		decode(&companyBody)
+
+	if companyBody.UserInfo.Cnpj != company.Cnpj {
+		t.Fatalf("unexpected company data: %+v", companyBody.UserInfo)
+	}
+}
+
+func TestGetUserDataErrors(t *testing.T) {
+	h := newHarness(t)
+
+	h.request(http.MethodPost, "/get-user-data", map[string]string{"token": "invalid"}, "").
+		expect(http.StatusInternalServerError).
+		expectCode("3105")
+
+	user := h.createUser(model.PersonRole)
+	token := h.token(user)
+	h.db.Unscoped().Delete(&user)
+
+	h.request(http.MethodPost, "/get-user-data", map[string]string{"token": token}, "").
+		expect(http.StatusInternalServerError).
+		expectCode("3106")
+}
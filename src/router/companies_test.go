@@ -0,0 +1,186 @@
+package router_test
+
+import (
+	"cij_api/src/model"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func companyRequest() model.CompanyRequest {
+	return model.CompanyRequest{
+		Name:  "Empresa Acessível",
+		Cnpj:  fmt.Sprintf("%014d", next()),
+		Phone: "5541966666666",
+		User: model.UserRequest{
+			Email:    fmt.Sprintf("company%d@test.com", next()),
+			Password: testPassword,
+		},
+		Address: addressRequest(),
+	}
+}
+
+func TestCreateCompany(t *testing.T) {
+	h := newHarness(t)
+	request := companyRequest()
+
+	h.request(http.MethodPost, "/companies", request, h.adminToken()).expect(http.StatusOK).expectMessage("success")
+
+	var company model.Company
+	if err := h.db.Preload("User").Where("cnpj = ?", request.Cnpj).First(&company).Error; err != nil {
+		t.Fatalf("company not created: %v", err)
+	}
+
+	if company.User.RoleId != model.CompanyRole || company.User.Email != request.User.Email {
+		t.Fatalf("unexpected company user: %+v", company.User)
+	}
+}
+
+func TestCreateCompanyErrors(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+	existing := h.createCompany()
+
+	missingFields := companyRequest()
+	missingFields.Cnpj = ""
+
+	duplicatedCnpj := companyRequest()
+	duplicatedCnpj.Cnpj = existing.Cnpj
+
+	duplicatedEmail := companyRequest()
+	duplicatedEmail.User.Email = existing.User.Email
+
+	invalidFields := companyRequest()
+	invalidFields.Cnpj = "123"
+
+	missingUser := companyRequest()
+	missingUser.User.Email = ""
+
+	invalidAddress := companyRequest()
+	invalidAddress.Address.State = "Paraná"
+
+	tests := []struct {
+		name    string
+		request model.CompanyRequest
+		code    string
+	}{
+		{"missing fields", missingFields, "1501"},
+		{"duplicated cnpj", duplicatedCnpj, "4501"},
+		{"duplicated email", duplicatedEmail, "4502"},
+		{"invalid fields", invalidFields, "1502"},
+		{"missing user fields", missingUser, "1101"},
+		{"invalid address", invalidAddress, "1301"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h.t = t
+
+			h.request(http.MethodPost, "/companies", test.request, token).
+				expect(http.StatusBadRequest).
+				expectCode(test.code)
+		})
+	}
+}
+
+func TestListCompanies(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+
+	var body struct {
+		Data []model.CompanyResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/companies", nil, "").expect(http.StatusOK).decode(&body)
+
+	if len(body.Data) != 1 || body.Data[0].Cnpj != company.Cnpj || body.Data[0].Address.Id != company.Address.Id {
+		t.Fatalf("unexpected companies: %+v", body.Data)
+	}
+}
+
+func TestGetCompany(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+
+	var body struct {
+		Data model.CompanyResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/companies/%d", company.Id), nil, "").expect(http.StatusOK).decode(&body)
+
+	if body.Data.Id != company.Id || body.Data.User.Email != company.User.Email {
+		t.Fatalf("unexpected company: %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, "/companies/abc", nil, "").expect(http.StatusBadRequest)
+	h.request(http.MethodGet, "/companies/9999", nil, "").expect(http.StatusNotFound).expectMessage("company not found")
+}
+
+func TestUpdateCompany(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	token := h.adminToken()
+
+	request := model.CompanyRequest{
+		Name:    "Empresa Renomeada",
+		Address: addressRequest(),
+		User:    model.UserRequest{Password: "new-password"},
+	}
+
+	h.request(http.MethodPut, fmt.Sprintf("/companies/%d", company.Id), request, token).expect(http.StatusOK)
+
+	var updated model.Company
+	h.db.Preload("User").Preload("Address").First(&updated, company.Id)
+
+	if updated.Name != "Empresa Renomeada" || updated.Address.Street != request.Address.Street {
+		t.Fatalf("unexpected company: %+v", updated)
+	}
+
+	if !updated.User.ValidatePassword("new-password") {
+		t.Fatal("expected the company password to be updated")
+	}
+
+	h.request(http.MethodPut, "/companies/abc", request, token).expect(http.StatusBadRequest)
+	h.request(http.MethodPut, "/companies/9999", request, token).expect(http.StatusNotFound)
+}
+
+func TestDeleteCompany(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	token := h.adminToken()
+
+	h.request(http.MethodDelete, fmt.Sprintf("/companies/%d", company.Id), nil, token).expect(http.StatusOK)
+
+	var count int64
+	h.db.Model(&model.Company{}).Where("id = ?", company.Id).Count(&count)
+
+	if count != 0 {
+		t.Fatal("expected the company to be deleted")
+	}
+
+	h.request(http.MethodDelete, "/companies/abc", nil, token).expect(http.StatusBadRequest)
+	h.request(http.MethodDelete, fmt.Sprintf("/companies/%d", company.Id), nil, token).expect(http.StatusNotFound)
+}
+
+func TestCompaniesAuthorization(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	companyToken := h.companyToken(company)
+	personToken := h.personToken(h.createPerson())
+	path := fmt.Sprintf("/companies/%d", company.Id)
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/companies"},
+		{http.MethodPut, path},
+		{http.MethodDelete, path},
+	}
+
+	for _, route := range routes {
+		h.request(route.method, route.path, nil, "").expect(http.StatusBadRequest).expectMessage("token not found")
+		h.request(route.method, route.path, nil, companyToken).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+		h.request(route.method, route.path, nil, personToken).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+	}
+}
@@ -0,0 +1,526 @@
+package router_test
+
+import (
+	"bytes"
+	"cij_api/src/database"
+	"cij_api/src/model"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpdateUserConfig(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+
+	config := model.DefaultConfig.Clone()
+	config.FontSize = 20
+
+	h.request(http.MethodPut, "/me/config", config, h.personToken(person)).
+		expect(http.StatusOK).
+		expectMessage("User config updated successfully")
+
+	var stored model.UserConfig
+	if err := h.db.Where("user_id = ?", person.User.Id).First(&stored).Error; err != nil {
+		t.Fatalf("config not stored: %v", err)
+	}
+
+	if stored.Version != 1 || stored.Config.FontSize != 20 {
+		t.Fatalf("unexpected stored config: %+v", stored)
+	}
+
+	var body struct {
+		UserInfo model.PersonResponse `json:"user_info"`
+	}
+
+	h.request(http.MethodPost, "/get-user-data", map[string]string{"token": h.personToken(person)}, "").
+		expect(http.StatusOK).
+		decode(&body)
+
+	if config, ok := body.UserInfo.User.Config.(map[string]interface{}); !ok || config["font_size"] != float64(20) {
+		t.Fatalf("expected the saved config, got %v", body.UserInfo.User.Config)
+	}
+}
+
+func TestUpdateUserConfigErrors(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+
+	h.request(http.MethodPut, "/me/config", []byte("{"), token).expect(http.StatusBadRequest)
+	h.request(http.MethodPut, "/me/config", model.DefaultConfig, "").expect(http.StatusBadRequest).expectMessage("token not found")
+
+	config := model.DefaultConfig.Clone()
+	config.FontSize = 50
+	config.Theme = "blue"
+	config.SystemColors.PrimaryColors.FontColor = "black"
+
+	response := h.request(http.MethodPut, "/me/config", config, token).
+		expect(http.StatusBadRequest).
+		expectCode("1701").
+		response()
+
+	if len(response.Fields) != 3 {
+		t.Fatalf("expected 3 invalid fields, got %+v", response.Fields)
+	}
+}
+
+func TestUserConfigVersions(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+	path := "/me/config"
+
+	for _, fontSize := range []int{18, 22, 26} {
+		config := model.DefaultConfig.Clone()
+		config.FontSize = fontSize
+
+		h.request(http.MethodPut, path, config, token).expect(http.StatusOK)
+	}
+
+	var versions struct {
+		Data []model.UserConfigVersionResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, path+"/versions", nil, token).expect(http.StatusOK).decode(&versions)
+
+	if len(versions.Data) != 3 || versions.Data[0].Version != 3 || versions.Data[2].Config.FontSize != 18 {
+		t.Fatalf("unexpected versions: %+v", versions.Data)
+	}
+
+	h.request(http.MethodPost, path+"/versions/1/rollback", nil, token).expect(http.StatusOK)
+
+	var body struct {
+		Data []model.PersonResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/people", nil, "").expect(http.StatusOK).decode(&body)
+
+	if config, ok := body.Data[0].User.Config.(map[string]interface{}); !ok || config["font_size"] != float64(18) {
+		t.Fatalf("expected the restored config, got %v", body.Data[0].User.Config)
+	}
+
+	var latest model.UserConfig
+	h.db.Where("user_id = ?", person.User.Id).Order("version DESC").First(&latest)
+
+	if latest.Version != 4 {
+		t.Fatalf("expected the rollback to create version 4, got %d", latest.Version)
+	}
+
+	h.request(http.MethodPost, path+"/versions/99/rollback", nil, token).expect(http.StatusNotFound)
+	h.request(http.MethodPost, path+"/versions/abc/rollback", nil, token).expect(http.StatusBadRequest)
+
+	h.request(http.MethodGet, path+"/versions", nil, "").expect(http.StatusBadRequest).expectMessage("token not found")
+	h.request(http.MethodPost, path+"/versions/1/rollback", nil, "").expect(http.StatusBadRequest).expectMessage("token not found")
+
+	other := h.personToken(h.createPerson())
+	usersPath := fmt.Sprintf("/users/%d/config", person.User.Id)
+
+	h.request(http.MethodGet, usersPath+"/versions", nil, other).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+	h.request(http.MethodPost, usersPath+"/versions/1/rollback", nil, other).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+
+	// the versions were once reachable by email, without a token
+	h.request(http.MethodGet, "/config/"+person.User.Email+"/versions", nil, "").expect(http.StatusBadRequest).expectMessage("token not found")
+	h.request(http.MethodPost, "/config/"+person.User.Email+"/versions/1/rollback", nil, other).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+}
+
+func TestGetUserConfig(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+
+	var body struct {
+		Data model.Config `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/me/config", nil, h.companyToken(company)).expect(http.StatusOK).decode(&body)
+
+	if body.Data.FontSize != model.DefaultConfig.FontSize || body.Data.Theme != model.DefaultConfig.Theme {
+		t.Fatalf("expected the default config, got %+v", body.Data)
+	}
+}
+
+func TestPatchUserConfig(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+
+	patch := []byte(`{"font_size": 24, "theme": "dark", "system_colors": {"primary_colors": {"primary_color": "#112233"}, "chart_colors": {"visual": null}}}`)
+
+	var body struct {
+		Data model.Config `json:"data"`
+	}
+
+	h.request(http.MethodPatch, "/me/config", patch, token).expect(http.StatusOK).decode(&body)
+
+	config := body.Data
+	if config.FontSize != 24 || config.Theme != "dark" || config.SystemColors.PrimaryColors.PrimaryColor != "#112233" {
+		t.Fatalf("patch not applied: %+v", config)
+	}
+
+	if config.SystemColors.PrimaryColors.BackgroundColor != model.DefaultConfig.SystemColors.PrimaryColors.BackgroundColor {
+		t.Fatalf("expected the fields missing from the patch to be kept: %+v", config)
+	}
+
+	if _, ok := config.SystemColors.ChartColors["visual"]; ok || len(config.SystemColors.ChartColors) != len(model.DefaultConfig.SystemColors.ChartColors)-1 {
+		t.Fatalf("expected the null member to be removed: %+v", config.SystemColors.ChartColors)
+	}
+
+	h.request(http.MethodPatch, "/me/config", []byte(`{"font_size": 99}`), token).expect(http.StatusBadRequest).expectCode("1701")
+	h.request(http.MethodPatch, "/me/config", []byte(`{"font_size": "big"}`), token).expect(http.StatusBadRequest).expectCode("1702")
+	h.request(http.MethodPatch, "/me/config", []byte(`{`), token).expect(http.StatusBadRequest).expectCode("1702")
+
+	h.request(http.MethodPost, "/me/config/reset", nil, token).expect(http.StatusOK)
+	h.request(http.MethodGet, "/me/config", nil, token).expect(http.StatusOK).decode(&body)
+
+	if body.Data.FontSize != model.DefaultConfig.FontSize || body.Data.Theme != model.DefaultConfig.Theme {
+		t.Fatalf("expected the default config after the reset, got %+v", body.Data)
+	}
+
+	var versions int64
+	h.db.Model(&model.UserConfig{}).Where("user_id = ?", person.User.Id).Count(&versions)
+
+	if versions != 2 {
+		t.Fatalf("expected the patch and the reset to create 2 versions, got %d", versions)
+	}
+}
+
+func TestAdminUserConfig(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	path := fmt.Sprintf("/users/%d/config", person.User.Id)
+	adminToken := h.adminToken()
+
+	h.request(http.MethodPatch, path, []byte(`{"screen_reader": true}`), adminToken).expect(http.StatusOK)
+
+	var body struct {
+		Data model.Config `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/me/config", nil, h.personToken(person)).expect(http.StatusOK).decode(&body)
+
+	if !body.Data.ScreenReader {
+		t.Fatalf("expected the admin change to be applied, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, path, nil, h.personToken(person)).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+	h.request(http.MethodGet, "/users/9999/config", nil, adminToken).expect(http.StatusNotFound)
+	h.request(http.MethodGet, "/users/abc/config", nil, adminToken).expect(http.StatusBadRequest)
+}
+
+func TestImportLegacyUserConfigs(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+
+	configUrl := func(userId int) string {
+		var user model.User
+		h.db.First(&user, userId)
+
+		return user.ConfigUrl
+	}
+
+	config := model.DefaultConfig.Clone()
+	config.Theme = "dark"
+
+	content, _ := json.Marshal(config)
+	legacyUrl, _ := fileStorage.Put(fmt.Sprintf("cij/user_config/%s", person.User.Email), bytes.NewReader(content))
+
+	h.db.Model(&model.User{}).Where("id = ?", person.User.Id).Update("config_url", legacyUrl)
+
+	missing := h.createUser(model.PersonRole)
+	h.db.Model(&model.User{}).Where("id = ?", missing.Id).Update("config_url", fileStorage.server.URL+"/files/missing")
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	retried := h.createUser(model.PersonRole)
+	h.db.Model(&model.User{}).Where("id = ?", retried.Id).Update("config_url", unavailable.URL)
+
+	// the migrations no longer download the legacy configs
+	database.Migrate(h.db)
+
+	if configUrl(person.User.Id) == "" {
+		t.Fatal("expected the migrations to leave the legacy configs")
+	}
+
+	if imported, failed, err := database.ImportLegacyUserConfigs(h.db); err != nil || imported != 1 || failed != 2 {
+		t.Fatalf("expected one config imported and two failed, got %d %d %v", imported, failed, err)
+	}
+
+	var stored model.UserConfig
+	if err := h.db.Where("user_id = ?", person.User.Id).First(&stored).Error; err != nil {
+		t.Fatalf("legacy config not imported: %v", err)
+	}
+
+	if stored.Config.Theme != "dark" {
+		t.Fatalf("unexpected imported config: %+v", stored.Config)
+	}
+
+	if configUrl(person.User.Id) != "" {
+		t.Fatal("expected the legacy config url to be cleared")
+	}
+
+	if configUrl(missing.Id) != "" {
+		t.Fatal("expected the config url of the missing file to be cleared")
+	}
+
+	if configUrl(retried.Id) == "" {
+		t.Fatal("expected the unavailable config to be retried later")
+	}
+}
+
+func TestUserConfigContrast(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+
+	config := model.DefaultConfig.Clone()
+	config.SystemColors.PrimaryColors.FontColor = "#AAAAAA"
+
+	response := h.request(http.MethodPut, "/me/config", config, token).
+		expect(http.StatusBadRequest).
+		expectCode("1701").
+		response()
+
+	if len(response.Fields) != 2 || response.Fields[0].Name != "font_color" {
+		t.Fatalf("expected the font color to fail on the background and the input, got %+v", response.Fields)
+	}
+
+	config = model.DefaultConfig.Clone()
+	config.SystemColors.PrimaryColors.FontColor = "#666666"
+	config.ColorBlindness = "deuteranopia"
+	config.SystemColors.ChartColors["visual"] = "#D62728"
+	config.SystemColors.ChartColors["hearing"] = "#2CA02C"
+
+	response = h.request(http.MethodPut, "/me/config", config, token).
+		expect(http.StatusOK).
+		response()
+
+	warned := map[string]bool{}
+	for _, warning := range response.Warnings {
+		warned[warning.Name] = true
+	}
+
+	if !warned["font_color"] || !warned["secondary_font_color"] || !warned["chart_colors"] {
+		t.Fatalf("expected AAA, secondary font and chart warnings, got %+v", response.Warnings)
+	}
+
+	config.FontSize = 24
+
+	response = h.request(http.MethodPut, "/me/config", config, token).
+		expect(http.StatusOK).
+		response()
+
+	for _, warning := range response.Warnings {
+		if warning.Name == "font_color" {
+			t.Fatalf("expected large text to reach AAA, got %+v", warning)
+		}
+	}
+}
+
+func TestGeneratePalette(t *testing.T) {
+	h := newHarness(t)
+
+	for _, query := range []string{
+		"primary_color=FFD700&theme=light&level=AAA",
+		"primary_color=%23004AAD&theme=dark&color_blindness=protanopia",
+	} {
+		var body struct {
+			Data model.PaletteResponse `json:"data"`
+		}
+
+		h.request(http.MethodGet, "/config/palette?"+query, nil, "").expect(http.StatusOK).decode(&body)
+
+		for _, check := range body.Data.Contrast {
+			if !check.AA {
+				t.Fatalf("%s: expected every pair to reach AA, got %+v", query, check)
+			}
+		}
+
+		if len(body.Data.SystemColors.ChartColors) != 5 {
+			t.Fatalf("%s: expected 5 chart colors, got %+v", query, body.Data.SystemColors.ChartColors)
+		}
+
+		config := model.DefaultConfig.Clone()
+		config.SystemColors = body.Data.SystemColors
+
+		h.request(http.MethodPut, "/me/config", config, h.personToken(h.createPerson())).expect(http.StatusOK)
+	}
+
+	response := h.request(http.MethodGet, "/config/palette?primary_color=blue&level=AAAA", nil, "").
+		expect(http.StatusBadRequest).
+		expectCode("1703").
+		response()
+
+	if len(response.Fields) != 2 {
+		t.Fatalf("expected 2 invalid fields, got %+v", response.Fields)
+	}
+}
+
+func TestConfigPresets(t *testing.T) {
+	h := newHarness(t)
+	adminToken := h.adminToken()
+
+	var presets struct {
+		Data []model.ConfigPresetResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/config/presets", nil, "").expect(http.StatusOK).decode(&presets)
+
+	if len(presets.Data) != len(model.DefaultConfigPresets) {
+		t.Fatalf("expected the default presets, got %+v", presets.Data)
+	}
+
+	for _, preset := range presets.Data {
+		response := h.request(http.MethodPut, "/me/config", preset.Config, h.personToken(h.createPerson())).
+			expect(http.StatusOK).
+			response()
+
+		for _, warning := range response.Warnings {
+			if warning.Name == "chart_colors" || warning.Name == "font_color" && preset.Slug == "high-contrast" {
+				t.Fatalf("%s: unexpected warning %+v", preset.Slug, warning)
+			}
+		}
+	}
+
+	request := model.ConfigPresetRequest{Slug: "large-dark", Name: "Escuro com fonte grande", Config: model.DefaultConfig.Clone()}
+	request.Config.FontSize = 28
+
+	var created struct {
+		Data model.ConfigPresetResponse `json:"data"`
+	}
+
+	h.request(http.MethodPost, "/config/presets", request, "").expect(http.StatusBadRequest).expectMessage("token not found")
+	h.request(http.MethodPost, "/config/presets", request, adminToken).expect(http.StatusCreated).decode(&created)
+	h.request(http.MethodPost, "/config/presets", request, adminToken).expect(http.StatusBadRequest).expectCode("1705")
+
+	invalid := request
+	invalid.Slug = "Large Dark"
+	invalid.Name = ""
+	invalid.Config.SystemColors.PrimaryColors.FontColor = "#EEEEEE"
+
+	response := h.request(http.MethodPost, "/config/presets", invalid, adminToken).
+		expect(http.StatusBadRequest).
+		expectCode("1704").
+		response()
+
+	if len(response.Fields) != 4 {
+		t.Fatalf("expected the slug, the name and the contrast to be invalid, got %+v", response.Fields)
+	}
+
+	reserved := request
+	reserved.Slug = "company-1"
+
+	response = h.requestWithHeaders(http.MethodPost, "/config/presets", reserved, adminToken, map[string]string{"Accept-Language": "es"}).
+		expect(http.StatusBadRequest).
+		expectCode("1704").
+		response()
+
+	if len(response.Fields) != 1 || response.Fields[0].Value != "el slug está reservado para los preajustes de las empresas" {
+		t.Fatalf("expected the reserved slug to be rejected, got %+v", response.Fields)
+	}
+
+	path := fmt.Sprintf("/config/presets/%d", created.Data.Id)
+	request.Config.Theme = "dark"
+
+	h.request(http.MethodPut, path, request, adminToken).expect(http.StatusOK)
+	h.request(http.MethodPut, "/config/presets/9999", request, adminToken).expect(http.StatusNotFound)
+
+	person := h.createPerson()
+	token := h.personToken(person)
+
+	var applied struct {
+		Data model.Config `json:"data"`
+	}
+
+	h.request(http.MethodPost, "/me/config/presets/large-dark/apply", nil, token).expect(http.StatusOK).decode(&applied)
+
+	if applied.Data.FontSize != 28 || applied.Data.Theme != "dark" {
+		t.Fatalf("expected the updated preset to be applied, got %+v", applied.Data)
+	}
+
+	h.request(http.MethodDelete, path, nil, adminToken).expect(http.StatusOK)
+	h.request(http.MethodGet, path, nil, "").expect(http.StatusNotFound)
+	h.request(http.MethodPost, "/me/config/presets/large-dark/apply", nil, token).expect(http.StatusNotFound)
+
+	h.request(http.MethodGet, "/me/config", nil, token).expect(http.StatusOK).decode(&applied)
+
+	if applied.Data.FontSize != 28 {
+		t.Fatalf("expected the applied config to be kept after the preset is deleted, got %+v", applied.Data)
+	}
+
+	h.request(http.MethodPost, fmt.Sprintf("/users/%d/config/presets/high-contrast/apply", person.User.Id), nil, adminToken).expect(http.StatusOK)
+}
+
+func TestRegisterWithConfigPreset(t *testing.T) {
+	h := newHarness(t)
+
+	request := personRequest()
+	request.ConfigPreset = "low-vision"
+
+	h.request(http.MethodPost, "/people", request, "").expect(http.StatusOK)
+
+	var user model.User
+	h.db.Where("email = ?", request.User.Email).First(&user)
+
+	var stored model.UserConfig
+	if err := h.db.Where("user_id = ?", user.Id).First(&stored).Error; err != nil {
+		t.Fatalf("preset not applied: %v", err)
+	}
+
+	if stored.Config.FontSize != 26 || !stored.Config.ScreenReader {
+		t.Fatalf("expected the low vision preset, got %+v", stored.Config)
+	}
+}
+
+func TestCompanyConfigPreset(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	token := h.companyToken(company)
+
+	h.request(http.MethodGet, "/me/company/config", nil, token).expect(http.StatusNotFound)
+
+	branded := model.DefaultConfig.Clone()
+	branded.SystemColors.PrimaryColors.PrimaryColor = "#7A1F5C"
+
+	h.request(http.MethodPut, "/me/company/config", branded, h.personToken(h.createPerson())).
+		expect(http.StatusBadRequest).
+		expectMessage("role don't have permission")
+
+	h.request(http.MethodPut, "/me/company/config", branded, token).expect(http.StatusOK)
+
+	var body struct {
+		Data model.Config `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/me/config", nil, token).expect(http.StatusOK).decode(&body)
+
+	if body.Data.SystemColors.PrimaryColors.PrimaryColor != "#7A1F5C" {
+		t.Fatalf("expected the branded default, got %+v", body.Data)
+	}
+
+	var presets struct {
+		Data []model.ConfigPresetResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/config/presets", nil, "").expect(http.StatusOK).decode(&presets)
+
+	for _, preset := range presets.Data {
+		if preset.CompanyId != nil {
+			t.Fatalf("expected the company preset to be private, got %+v", preset)
+		}
+	}
+
+	h.request(http.MethodPost, fmt.Sprintf("/me/config/presets/company-%d/apply", company.Id), nil, h.personToken(h.createPerson())).
+		expect(http.StatusNotFound)
+
+	h.request(http.MethodDelete, "/me/company/config", nil, token).expect(http.StatusOK)
+	h.request(http.MethodGet, "/me/config", nil, token).expect(http.StatusOK).decode(&body)
+
+	if body.Data.SystemColors.PrimaryColors.PrimaryColor != model.DefaultConfig.SystemColors.PrimaryColors.PrimaryColor {
+		t.Fatalf("expected the system default after the branded default is deleted, got %+v", body.Data)
+	}
+}
@@ -0,0 +1,313 @@
+package router_test
+
+import (
+	"cij_api/src/controller"
+	"cij_api/src/database"
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	vacancy "cij_api/src/model/vacancy"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateDisability(t *testing.T) {
+	h := newHarness(t)
+
+	request := controller.DisabilityPostParameters{
+		Disabilities: []model.DisabilityRequest{
+			{Category: "Visual", Description: "Visão monocular", Rate: 35, Cid10: "h54.4", Icd11: "9D90.2", LegalClassification: "visual"},
+		},
+	}
+
+	h.request(http.MethodPost, "/disabilities", request, "").expect(http.StatusBadRequest).expectMessage("token not found")
+
+	h.request(http.MethodPost, "/disabilities", request, h.adminToken()).
+		expect(http.StatusCreated).
+		expectMessage("Disabilities created successfully")
+
+	var disability model.Disability
+	if err := h.db.Where("description = ?", "Visão monocular").First(&disability).Error; err != nil {
+		t.Fatalf("expected the disability to be created: %v", err)
+	}
+
+	if disability.Cid10 != "H54.4" || disability.LegalClassification != "visual" {
+		t.Fatalf("unexpected disability: %+v", disability)
+	}
+}
+
+func TestCreateDisabilityErrors(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+	existing := h.disability()
+
+	h.request(http.MethodPost, "/disabilities", controller.DisabilityPostParameters{}, token).
+		expect(http.StatusBadRequest).
+		expectMessage("No disabilities to create")
+
+	h.request(http.MethodPost, "/disabilities", "not an object", token).expect(http.StatusBadRequest)
+
+	invalid := controller.DisabilityPostParameters{
+		Disabilities: []model.DisabilityRequest{
+			{Category: "Visual", Rate: 120, Cid10: "54.0", Icd11: "IO00", LegalClassification: "blind"},
+		},
+	}
+
+	response := h.request(http.MethodPost, "/disabilities", invalid, token).
+		expect(http.StatusBadRequest).
+		expectCode("1401").
+		response()
+
+	if len(response.Fields) != 5 {
+		t.Fatalf("expected 5 invalid fields, got %+v", response.Fields)
+	}
+
+	duplicated := controller.DisabilityPostParameters{
+		Disabilities: []model.DisabilityRequest{
+			{Category: "visual ", Description: existing.Description, Rate: 10},
+		},
+	}
+
+	h.request(http.MethodPost, "/disabilities", duplicated, token).expect(http.StatusBadRequest).expectCode("1402")
+
+	repeated := controller.DisabilityPostParameters{
+		Disabilities: []model.DisabilityRequest{
+			{Category: "Visual", Description: "Visão monocular", Rate: 35},
+			{Category: "Visual", Description: "visão monocular", Rate: 35},
+		},
+	}
+
+	h.request(http.MethodPost, "/disabilities", repeated, token).expect(http.StatusBadRequest).expectCode("1402")
+}
+
+func TestListDisabilities(t *testing.T) {
+	h := newHarness(t)
+
+	var body struct {
+		Data []model.DisabilityResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/disabilities", nil, "").expect(http.StatusOK).decode(&body)
+
+	total := len(body.Data)
+	if total == 0 {
+		t.Fatal("expected the seeded disabilities")
+	}
+
+	h.request(http.MethodGet, "/disabilities?category=hearing", nil, "").expect(http.StatusOK).decode(&body)
+
+	if len(body.Data) == 0 || len(body.Data) == total {
+		t.Fatalf("expected only the hearing disabilities, got %+v", body.Data)
+	}
+
+	for _, disability := range body.Data {
+		if disability.Category != enum.Hearing || disability.CategoryName != "Auditiva" {
+			t.Fatalf("unexpected category: %+v", disability)
+		}
+	}
+
+	var single struct {
+		Data model.DisabilityResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/disabilities/%d", body.Data[0].Id), nil, "").expect(http.StatusOK).decode(&single)
+
+	if single.Data.Description != body.Data[0].Description {
+		t.Fatalf("unexpected disability: %+v", single.Data)
+	}
+
+	h.request(http.MethodGet, "/disabilities/9999", nil, "").expect(http.StatusNotFound)
+	h.request(http.MethodGet, "/disabilities/abc", nil, "").expect(http.StatusBadRequest)
+}
+
+func TestUpdateDisability(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+	disability := h.disability()
+	path := fmt.Sprintf("/disabilities/%d", disability.Id)
+
+	request := model.DisabilityRequest{
+		Category:            string(disability.Category.Slug),
+		Description:         disability.Description,
+		Rate:                disability.Rate,
+		Cid10:               "H54.2",
+		LegalClassification: "visual",
+	}
+
+	h.request(http.MethodPut, path, request, h.personToken(h.createPerson())).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+	h.request(http.MethodPut, path, request, token).expect(http.StatusOK)
+
+	var updated model.Disability
+	h.db.First(&updated, disability.Id)
+
+	if updated.Cid10 != "H54.2" {
+		t.Fatalf("expected the update to be stored, got %+v", updated)
+	}
+
+	var other model.Disability
+	h.db.Preload("Category").Where("id <> ?", disability.Id).First(&other)
+
+	request.Description = other.Description
+	request.Category = string(other.Category.Slug)
+
+	h.request(http.MethodPut, path, request, token).expect(http.StatusBadRequest).expectCode("1402")
+	h.request(http.MethodPut, "/disabilities/9999", request, token).expect(http.StatusNotFound)
+}
+
+func TestDeleteDisability(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	person := h.createPerson()
+	company := h.createCompany()
+	newVacancy := h.createVacancy(company)
+
+	used := h.disability()
+	path := fmt.Sprintf("/disabilities/%d", used.Id)
+
+	var replacement model.Disability
+	h.db.Where("id <> ?", used.Id).Order("id").First(&replacement)
+	h.create(&model.PersonDisability{PersonId: person.Id, DisabilityId: replacement.Id})
+
+	var conflict struct {
+		Data model.DisabilityUsage `json:"data"`
+	}
+
+	h.request(http.MethodDelete, path, nil, token).expect(http.StatusConflict).decode(&conflict)
+
+	if conflict.Data.People != 1 || conflict.Data.Vacancies != 1 {
+		t.Fatalf("unexpected usage: %+v", conflict.Data)
+	}
+
+	h.request(http.MethodDelete, path+"?replace_with="+fmt.Sprint(used.Id), nil, token).expect(http.StatusBadRequest).expectCode("1403")
+	h.request(http.MethodDelete, path+"?replace_with=9999", nil, token).expect(http.StatusBadRequest).expectCode("1403")
+	h.request(http.MethodDelete, path+fmt.Sprintf("?replace_with=%d", replacement.Id), nil, token).expect(http.StatusOK)
+
+	var personDisabilities []model.PersonDisability
+	h.db.Where("person_id = ?", person.Id).Find(&personDisabilities)
+
+	if len(personDisabilities) != 1 || personDisabilities[0].DisabilityId != replacement.Id {
+		t.Fatalf("expected the person to keep a single replacement disability, got %+v", personDisabilities)
+	}
+
+	var vacancyDisabilities []vacancy.VacancyDisability
+	h.db.Where("vacancy_id = ?", newVacancy.Id).Find(&vacancyDisabilities)
+
+	if len(vacancyDisabilities) != 1 || vacancyDisabilities[0].DisabilityId != replacement.Id {
+		t.Fatalf("expected the vacancy to be remapped, got %+v", vacancyDisabilities)
+	}
+
+	h.request(http.MethodGet, path, nil, "").expect(http.StatusNotFound)
+
+	unused := model.Disability{CategoryId: used.CategoryId, Description: "Sem uso", Rate: 10}
+	h.create(&unused)
+
+	h.request(http.MethodDelete, fmt.Sprintf("/disabilities/%d", unused.Id), nil, token).expect(http.StatusOK)
+	h.request(http.MethodDelete, "/disabilities/9999", nil, token).expect(http.StatusNotFound)
+}
+
+func TestDisabilityCategories(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	var body struct {
+		Data []model.DisabilityCategoryResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/disabilities/categories", nil, "").expect(http.StatusOK).decode(&body)
+
+	if len(body.Data) != 6 || body.Data[0].Slug != enum.Visual {
+		t.Fatalf("expected the default categories, got %+v", body.Data)
+	}
+
+	request := model.DisabilityCategoryRequest{Name: "Múltipla"}
+
+	h.request(http.MethodPost, "/disabilities/categories", request, h.personToken(h.createPerson())).
+		expect(http.StatusBadRequest).
+		expectMessage("role don't have permission")
+
+	var created struct {
+		Data model.DisabilityCategoryResponse `json:"data"`
+	}
+
+	h.request(http.MethodPost, "/disabilities/categories", request, token).expect(http.StatusCreated).decode(&created)
+
+	if created.Data.Slug != "multipla" {
+		t.Fatalf("expected the slug from the name, got %+v", created.Data)
+	}
+
+	h.request(http.MethodPost, "/disabilities/categories", request, token).expect(http.StatusBadRequest).expectCode("1405")
+	h.request(http.MethodPost, "/disabilities/categories", model.DisabilityCategoryRequest{Slug: "Not A Slug"}, token).
+		expect(http.StatusBadRequest).
+		expectCode("1404")
+
+	disabilities := controller.DisabilityPostParameters{
+		Disabilities: []model.DisabilityRequest{
+			{Category: "multipla", Description: "Surdocegueira", Rate: 90},
+		},
+	}
+
+	h.request(http.MethodPost, "/disabilities", disabilities, token).expect(http.StatusCreated)
+
+	disabilities.Disabilities[0].Category = "unknown"
+	h.request(http.MethodPost, "/disabilities", disabilities, token).expect(http.StatusBadRequest).expectCode("1401")
+
+	var totals struct {
+		Data model.DisabilityTotals `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/reports/disabilities", nil, "").expect(http.StatusOK).decode(&totals)
+
+	if count, ok := totals.Data["multipla"]; !ok || count != 0 {
+		t.Fatalf("expected the new category in the report, got %+v", totals.Data)
+	}
+}
+
+func TestNormalizeLegacyDisabilityCategories(t *testing.T) {
+	h := newHarness(t)
+
+	h.db.Exec("ALTER TABLE `disabilities` ADD COLUMN `category` varchar(200)")
+	h.db.Exec("CREATE INDEX idx_disabilities_category ON disabilities (category)")
+	h.db.Exec("INSERT INTO disabilities (category, description, rate) VALUES ('Física', 'Amputação', 50), ('Neurológica', 'Epilepsia', 30)")
+
+	database.Migrate(h.db)
+
+	if h.db.Migrator().HasColumn("disabilities", "category") {
+		t.Fatal("expected the legacy category column to be dropped")
+	}
+
+	var amputation, epilepsy model.Disability
+	h.db.Preload("Category").Where("description = ?", "Amputação").First(&amputation)
+	h.db.Preload("Category").Where("description = ?", "Epilepsia").First(&epilepsy)
+
+	if amputation.Category == nil || amputation.Category.Slug != enum.Motor {
+		t.Fatalf("expected the legacy name to be mapped, got %+v", amputation)
+	}
+
+	if epilepsy.Category == nil || epilepsy.Category.Slug != "neurologica" || epilepsy.Category.Name != "Neurológica" {
+		t.Fatalf("expected a category for the unknown name, got %+v", epilepsy)
+	}
+}
+
+func TestRemoveDuplicatePersonDisabilities(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	disability := h.disability()
+
+	h.db.Migrator().DropIndex(&model.PersonDisability{}, "idx_person_disability")
+	h.db.Exec("INSERT INTO person_disabilities (person_id, disability_id, acquired) VALUES (?, ?, false), (?, ?, true)",
+		person.Id, disability.Id, person.Id, disability.Id)
+
+	database.Migrate(h.db)
+
+	var personDisabilities []model.PersonDisability
+	h.db.Where("person_id = ? AND disability_id = ?", person.Id, disability.Id).Find(&personDisabilities)
+
+	if len(personDisabilities) != 1 || !personDisabilities[0].Acquired {
+		t.Fatalf("expected a single acquired disability, got %+v", personDisabilities)
+	}
+
+	if !h.db.Migrator().HasIndex(&model.PersonDisability{}, "idx_person_disability") {
+		t.Fatal("expected the unique index to be created")
+	}
+}
@@ -0,0 +1,207 @@
+package router_test
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	vacancy "cij_api/src/model/vacancy"
+	"cij_api/src/utils"
+	"fmt"
+	"sync/atomic"
+)
+
+const testPassword = "secret123"
+
+var sequence int64
+
+// next returns a number that is unique inside the test binary, used to keep
+// emails, cpfs and cnpjs from colliding.
+func next() int64 {
+	return atomic.AddInt64(&sequence, 1)
+}
+
+func (h *harness) create(value interface{}) {
+	h.t.Helper()
+
+	if err := h.db.Create(value).Error; err != nil {
+		h.t.Fatalf("failed to create %T: %v", value, err)
+	}
+}
+
+func (h *harness) createUser(role model.RoleId) model.User {
+	h.t.Helper()
+
+	password, err := utils.EncryptPassword(testPassword)
+	if err != nil {
+		h.t.Fatalf("failed to encrypt the password: %v", err)
+	}
+
+	user := model.User{
+		Email:    fmt.Sprintf("user%d@test.com", next()),
+		Password: password,
+		RoleId:   role,
+	}
+
+	h.create(&user)
+
+	return user
+}
+
+func (h *harness) createAddress(neighborhood string) model.Address {
+	h.t.Helper()
+
+	address := model.Address{
+		Street:       "Rua das Flores",
+		Number:       "100",
+		Neighborhood: neighborhood,
+		City:         "Curitiba",
+		State:        "PR",
+		Country:      "Brasil",
+		ZipCode:      "80000000",
+	}
+
+	h.create(&address)
+
+	return address
+}
+
+func (h *harness) locate(addressId int, latitude float64, longitude float64) {
+	h.t.Helper()
+
+	err := h.db.Model(&model.Address{}).Where("id = ?", addressId).
+		Updates(map[string]interface{}{"latitude": latitude, "longitude": longitude}).Error
+	if err != nil {
+		h.t.Fatalf("failed to locate the address: %v", err)
+	}
+}
+
+func (h *harness) disability() model.Disability {
+	h.t.Helper()
+
+	var disability model.Disability
+	if err := h.db.Preload("Category").Order("id").First(&disability).Error; err != nil {
+		h.t.Fatalf("failed to get a seeded disability: %v", err)
+	}
+
+	return disability
+}
+
+func (h *harness) createPerson() model.Person {
+	h.t.Helper()
+
+	user := h.createUser(model.PersonRole)
+	address := h.createAddress("Centro")
+
+	person := model.Person{
+		Name:      "Maria da Silva",
+		Cpf:       fmt.Sprintf("%011d", next()),
+		Phone:     "5541999999999",
+		Gender:    enum.Female,
+		UserId:    user.Id,
+		AddressId: &address.Id,
+	}
+
+	h.create(&person)
+	h.create(&model.PersonDisability{PersonId: person.Id, DisabilityId: h.disability().Id})
+
+	person.User = &user
+	person.Address = &address
+
+	return person
+}
+
+func (h *harness) createCompany() model.Company {
+	h.t.Helper()
+
+	user := h.createUser(model.CompanyRole)
+	address := h.createAddress("Batel")
+
+	company := model.Company{
+		Name:      "Empresa Inclusiva",
+		Cnpj:      fmt.Sprintf("%014d", next()),
+		Phone:     "5541988888888",
+		UserId:    user.Id,
+		AddressId: &address.Id,
+	}
+
+	h.create(&company)
+
+	company.User = &user
+	company.Address = &address
+
+	return company
+}
+
+func (h *harness) createVacancy(company model.Company) vacancy.Vacancy {
+	h.t.Helper()
+
+	newVacancy := vacancy.Vacancy{
+		Code:             fmt.Sprintf("VAGA-%d", next()),
+		Title:            "Desenvolvedor",
+		Description:      "Desenvolvimento de sistemas",
+		Department:       "Tecnologia",
+		Section:          "Sistemas",
+		Turn:             "Integral",
+		PublishDate:      "2024-01-10",
+		RegistrationDate: "2024-02-10",
+		Area:             "ti",
+		CompanyId:        company.Id,
+		ContractType:     enum.CLT,
+	}
+
+	h.create(&newVacancy)
+	h.create(&vacancy.VacancySkill{Skill: "Go", VacancyId: newVacancy.Id})
+	h.create(&vacancy.VacancyResponsability{Responsability: "Manter a API", VacancyId: newVacancy.Id})
+	h.create(&vacancy.VacancyRequirement{Requirement: "Ensino superior", Type: enum.Obligatory, VacancyId: newVacancy.Id})
+	h.create(&vacancy.VacancyDisability{VacancyId: newVacancy.Id, DisabilityId: h.disability().Id})
+
+	return newVacancy
+}
+
+func (h *harness) createApply(vacancyId int, person model.Person) vacancy.VacancyApply {
+	h.t.Helper()
+
+	apply := vacancy.VacancyApply{
+		VacancyId:   vacancyId,
+		CandidateId: person.Id,
+		Status:      enum.VacancyApplyApplied,
+	}
+
+	h.create(&apply)
+
+	return apply
+}
+
+// vacancyRequest builds a valid request to create or update a vacancy of the company.
+func (h *harness) vacancyRequest(company model.Company) vacancy.VacancyRequest {
+	return vacancy.VacancyRequest{
+		Code:             fmt.Sprintf("VAGA-%d", next()),
+		Title:            "Analista de dados",
+		Description:      "Análise de dados",
+		Department:       "Dados",
+		Section:          "BI",
+		Turn:             "Manhã",
+		PublishDate:      "2024-03-01",
+		RegistrationDate: "2024-04-01",
+		Area:             "dados",
+		CompanyId:        company.Id,
+		ContractType:     enum.PJ,
+		Disabilities:     []vacancy.VacancyDisabilityRequest{vacancy.VacancyDisabilityRequest(h.disability().Id)},
+		Skills:           []vacancy.VacancySkillRequest{"SQL"},
+		Responsabilities: []vacancy.VacancyResponsabilityRequest{"Criar relatórios"},
+		Requirements: []vacancy.VacancyRequirementRequest{
+			{Requirement: "Experiência com SQL", Type: enum.Desirable},
+		},
+	}
+}
+
+func addressRequest() model.AddressRequest {
+	return model.AddressRequest{
+		Street:       "Rua XV de Novembro",
+		Number:       "200",
+		Neighborhood: "Centro",
+		City:         "Curitiba",
+		State:        "PR",
+		Country:      "Brasil",
+		ZipCode:      "80020310",
+	}
+}
@@ -0,0 +1,79 @@
+package router_test
+
+import (
+	"cij_api/src/storage"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newLocalStorage(t *testing.T) *storage.LocalStorage {
+	t.Helper()
+
+	localStorage, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:3040/files", testSecretKey)
+	if err != nil {
+		t.Fatalf("cannot create local storage: %v", err)
+	}
+
+	return localStorage
+}
+
+func TestLocalStorageGetFile(t *testing.T) {
+	localStorage := newLocalStorage(t)
+	h := newHarnessWithStorage(t, localStorage)
+
+	fileUrl, err := localStorage.Put("cij/news/banner/banner.txt", strings.NewReader("banner"))
+	if err != nil {
+		t.Fatalf("cannot put file: %v", err)
+	}
+
+	if fileUrl != "http://localhost:3040/files/cij/news/banner/banner.txt" {
+		t.Fatalf("unexpected file url %q", fileUrl)
+	}
+
+	response := h.request(http.MethodGet, "/files/cij/news/banner/banner.txt", nil, "").expect(http.StatusOK)
+
+	if string(response.body) != "banner" {
+		t.Fatalf("unexpected file content %q", response.body)
+	}
+
+	if contentType := response.header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+}
+
+func TestLocalStorageGetFileNotFound(t *testing.T) {
+	h := newHarnessWithStorage(t, newLocalStorage(t))
+
+	h.request(http.MethodGet, "/files/cij/missing", nil, "").expect(http.StatusNotFound).expectMessage("file not found")
+	h.request(http.MethodGet, "/files/cij/%2E%2E/%2E%2E/etc/passwd", nil, "").expect(http.StatusNotFound).expectMessage("file not found")
+}
+
+func TestLocalStorageSignedURL(t *testing.T) {
+	localStorage := newLocalStorage(t)
+	h := newHarnessWithStorage(t, localStorage)
+
+	if _, err := localStorage.Put("private/curriculum/file", strings.NewReader("curriculum")); err != nil {
+		t.Fatalf("cannot put file: %v", err)
+	}
+
+	signedUrl, err := localStorage.SignedURL("private/curriculum/file", time.Minute)
+	if err != nil {
+		t.Fatalf("cannot sign url: %v", err)
+	}
+
+	parsed, _ := url.Parse(signedUrl)
+
+	h.request(http.MethodGet, parsed.RequestURI(), nil, "").expect(http.StatusOK)
+	h.request(http.MethodGet, parsed.Path, nil, "").expect(http.StatusForbidden)
+
+	query := parsed.Query()
+	query.Set("signature", strings.Repeat("0", 64))
+	h.request(http.MethodGet, parsed.Path+"?"+query.Encode(), nil, "").expect(http.StatusForbidden)
+
+	expired, _ := localStorage.SignedURL("private/curriculum/file", -time.Minute)
+	parsed, _ = url.Parse(expired)
+	h.request(http.MethodGet, parsed.RequestURI(), nil, "").expect(http.StatusForbidden)
+}
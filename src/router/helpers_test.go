@@ -0,0 +1,207 @@
+package router_test
+
+import (
+	"bytes"
+	"cij_api/src/auth"
+	"cij_api/src/model"
+	"cij_api/src/repo"
+	"cij_api/src/service"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// response is a recorded answer of the application.
+type response struct {
+	t      *testing.T
+	status int
+	header http.Header
+	body   []byte
+}
+
+// request sends a JSON request. A []byte body is sent as it is, a nil body
+// sends no body and an empty token sends no Authorization header.
+func (h *harness) request(method string, path string, body interface{}, token string) *response {
+	h.t.Helper()
+
+	return h.requestWithHeaders(method, path, body, token, nil)
+}
+
+// requestWithHeaders sends a JSON request like request, with extra headers.
+func (h *harness) requestWithHeaders(method string, path string, body interface{}, token string, headers map[string]string) *response {
+	h.t.Helper()
+
+	var reader io.Reader
+	switch content := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(content)
+	default:
+		encoded, err := json.Marshal(content)
+		if err != nil {
+			h.t.Fatalf("failed to marshal the request body: %v", err)
+		}
+
+		reader = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, "/", reader)
+	// the request line is sent as given, so malformed escapes reach the app
+	req.RequestURI = path
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	return h.do(req, token)
+}
+
+// multipart sends a multipart form with the given fields and files.
+func (h *harness) multipart(method string, path string, fields map[string]string, files map[string][]byte, token string) *response {
+	h.t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+
+	for name, content := range files {
+		part, err := writer.CreateFormFile(name, name+".txt")
+		if err != nil {
+			h.t.Fatalf("failed to create the form file: %v", err)
+		}
+
+		part.Write(content)
+	}
+
+	writer.Close()
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return h.do(req, token)
+}
+
+func (h *harness) do(req *http.Request, token string) *response {
+	h.t.Helper()
+
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	res, err := h.app.Test(req, -1)
+	if err != nil {
+		h.t.Fatalf("%s %s failed: %v", req.Method, req.URL, err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		h.t.Fatalf("failed to read the response body: %v", err)
+	}
+
+	return &response{
+		t:      h.t,
+		status: res.StatusCode,
+		header: res.Header,
+		body:   body,
+	}
+}
+
+// expect fails the test when the status code is not the expected one.
+func (r *response) expect(status int) *response {
+	r.t.Helper()
+
+	if r.status != status {
+		r.t.Fatalf("expected status %d, got %d: %s", status, r.status, r.body)
+	}
+
+	return r
+}
+
+// decode unmarshals the response body into v.
+func (r *response) decode(v interface{}) {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.body, v); err != nil {
+		r.t.Fatalf("failed to decode the response body %s: %v", r.body, err)
+	}
+}
+
+// response decodes the body as the default response of the API.
+func (r *response) response() model.Response {
+	r.t.Helper()
+
+	var response model.Response
+	r.decode(&response)
+
+	return response
+}
+
+// expectCode fails the test when the error code of the body is not the expected one.
+func (r *response) expectCode(code string) *response {
+	r.t.Helper()
+
+	if response := r.response(); response.Code != code {
+		r.t.Fatalf("expected code %q, got %q: %s", code, response.Code, r.body)
+	}
+
+	return r
+}
+
+// expectMessage fails the test when the message of the body is not the expected one.
+func (r *response) expectMessage(message string) *response {
+	r.t.Helper()
+
+	if response := r.response(); response.Message != message {
+		r.t.Fatalf("expected message %q, got %q", message, response.Message)
+	}
+
+	return r
+}
+
+// token generates a token for the user the same way the login does.
+func (h *harness) token(user model.User) string {
+	h.t.Helper()
+
+	var role model.Role
+	if err := h.db.First(&role, user.RoleId).Error; err != nil {
+		h.t.Fatalf("failed to get the role %d: %v", user.RoleId, err)
+	}
+
+	user.Role = &role
+
+	authService := auth.NewAuthService(repo.NewUserRepo(h.db), repo.NewActivityRepo(h.db), h.auditService())
+
+	token, err := authService.GenerateToken(user)
+	if err.Code != "" {
+		h.t.Fatalf("failed to generate the token: %v", err)
+	}
+
+	return token
+}
+
+func (h *harness) auditService() service.AuditService {
+	return service.NewAuditService(repo.NewAuditRepo(h.db), repo.NewUserRepo(h.db))
+}
+
+func (h *harness) adminToken() string {
+	return h.token(h.createUser(model.AdminRole))
+}
+
+func (h *harness) personToken(person model.Person) string {
+	return h.token(*person.User)
+}
+
+func (h *harness) companyToken(company model.Company) string {
+	return h.token(*company.User)
+}
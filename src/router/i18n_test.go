@@ -0,0 +1,132 @@
+package router_test
+
+import (
+	"cij_api/src/controller"
+	"cij_api/src/database"
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAcceptLanguageMessages(t *testing.T) {
+	h := newHarness(t)
+
+	cases := []struct {
+		acceptLanguage string
+		message        string
+		language       string
+	}{
+		{"", "token not found", ""},
+		{"pt-BR,pt;q=0.9", "token não encontrado", "pt-BR"},
+		{"es-MX", "token no encontrado", "es"},
+		{"en-US", "token not found", "en"},
+		{"fr-FR", "token não encontrado", "pt-BR"},
+	}
+
+	for _, c := range cases {
+		res := h.requestWithHeaders(http.MethodGet, "/me/config", nil, "", map[string]string{"Accept-Language": c.acceptLanguage}).
+			expect(http.StatusBadRequest).
+			expectMessage(c.message)
+
+		if language := res.header.Get("Content-Language"); language != c.language {
+			t.Fatalf("expected Content-Language %q for %q, got %q", c.language, c.acceptLanguage, language)
+		}
+	}
+
+	h.requestWithHeaders(http.MethodGet, "/me/company/config", nil, h.personToken(h.createPerson()), map[string]string{"Accept-Language": "en"}).
+		expect(http.StatusBadRequest).
+		expectMessage("role doesn't have permission")
+
+	invalid := controller.DisabilityPostParameters{
+		Disabilities: []model.DisabilityRequest{{Category: "visual", Description: "Visão monocular", Rate: 120}},
+	}
+
+	response := h.requestWithHeaders(http.MethodPost, "/disabilities", invalid, h.adminToken(), map[string]string{"Accept-Language": "es"}).
+		expect(http.StatusBadRequest).
+		expectCode("1401").
+		response()
+
+	if response.Message != "discapacidad no válida" || response.Fields[0].Value != "rate debe estar entre 0 y 100" {
+		t.Fatalf("expected the spanish validation messages, got %+v", response)
+	}
+}
+
+func TestLocalizedDisabilities(t *testing.T) {
+	h := newHarness(t)
+	disability := h.disability()
+	path := fmt.Sprintf("/disabilities/%d", disability.Id)
+
+	var body struct {
+		Data model.DisabilityResponse `json:"data"`
+	}
+
+	h.requestWithHeaders(http.MethodGet, path, nil, "", map[string]string{"Accept-Language": "en"}).expect(http.StatusOK).decode(&body)
+
+	if body.Data.Description != disability.Translations[enum.English] || body.Data.CategoryName != "Visual" {
+		t.Fatalf("expected the english description, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, path, nil, "").expect(http.StatusOK).decode(&body)
+
+	if body.Data.Description != disability.Description || body.Data.Translations[enum.Spanish] == "" {
+		t.Fatalf("expected the stored description and its translations, got %+v", body.Data)
+	}
+
+	var categories struct {
+		Data []model.DisabilityCategoryResponse `json:"data"`
+	}
+
+	h.requestWithHeaders(http.MethodGet, "/disabilities/categories", nil, "", map[string]string{"Accept-Language": "en"}).
+		expect(http.StatusOK).
+		decode(&categories)
+
+	if categories.Data[1].Name != "Hearing" {
+		t.Fatalf("expected the english category names, got %+v", categories.Data)
+	}
+
+	request := model.DisabilityRequest{
+		Category:     "visual",
+		Description:  "Visão monocular",
+		Rate:         35,
+		Translations: model.Translations{"fr": "Vision monoculaire"},
+	}
+
+	h.request(http.MethodPut, path, request, h.adminToken()).expect(http.StatusBadRequest).expectCode("1401")
+
+	request.Translations = model.Translations{enum.Spanish: "Visión monocular"}
+	h.request(http.MethodPut, path, request, h.adminToken()).expect(http.StatusOK)
+
+	h.requestWithHeaders(http.MethodGet, path, nil, "", map[string]string{"Accept-Language": "es"}).expect(http.StatusOK).decode(&body)
+
+	if body.Data.Description != "Visión monocular" {
+		t.Fatalf("expected the updated translation, got %+v", body.Data)
+	}
+
+	h.requestWithHeaders(http.MethodGet, path, nil, "", map[string]string{"Accept-Language": "en"}).expect(http.StatusOK).decode(&body)
+
+	if body.Data.Description != "Visão monocular" {
+		t.Fatalf("expected the fallback to the stored description, got %+v", body.Data)
+	}
+}
+
+func TestTranslateSeededDisabilities(t *testing.T) {
+	h := newHarness(t)
+	disability := h.disability()
+
+	h.db.Exec("UPDATE disabilities SET translations = NULL WHERE id = ?", disability.Id)
+	h.db.Exec("UPDATE disability_categories SET translations = NULL")
+
+	database.Migrate(h.db)
+
+	translated := h.disability()
+
+	if translated.Translations[enum.English] != disability.Translations[enum.English] {
+		t.Fatalf("expected the seed to translate the disability, got %+v", translated.Translations)
+	}
+
+	if translated.Category.Translations[enum.Spanish] != "Visual" {
+		t.Fatalf("expected the seed to translate the category, got %+v", translated.Category.Translations)
+	}
+}
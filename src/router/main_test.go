@@ -0,0 +1,101 @@
+package router_test
+
+import (
+	"cij_api/src/config"
+	"cij_api/src/database"
+	"cij_api/src/geocoding"
+	"cij_api/src/router"
+	"cij_api/src/storage"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const testSecretKey = "integration-test-secret"
+
+var fileStorage *fakeStorage
+
+var testConfig config.Config
+
+// TestMain starts the fake file storage and writes an app.env, so the services
+// that load their configuration through viper find it.
+func TestMain(m *testing.M) {
+	fileStorage = newFakeStorage()
+
+	configDir, err := os.MkdirTemp("", "cij_api_test")
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	env := fmt.Sprintf(
+		"DB_DRIVER=%s\nDSN=file::memory:\nSECRET_KEY=%s\n",
+		database.SqliteDriver, testSecretKey,
+	)
+
+	if err := os.WriteFile(filepath.Join(configDir, "app.env"), []byte(env), 0644); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	testConfig, err = config.LoadConfig(configDir)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	fileStorage.server.Close()
+	os.RemoveAll(configDir)
+
+	os.Exit(code)
+}
+
+// harness is an application booted against its own in-memory database.
+type harness struct {
+	t   *testing.T
+	app *fiber.App
+	db  *gorm.DB
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	return newHarnessWithStorage(t, fileStorage)
+}
+
+func newHarnessWithStorage(t *testing.T, fileStorage storage.FileStorage) *harness {
+	t.Helper()
+
+	db := database.ConnectionDB(&config.Config{
+		DbDriver:     database.SqliteDriver,
+		DbConnection: "file::memory:",
+	})
+
+	database.Migrate(db)
+
+	t.Cleanup(func() {
+		sqlDB, err := db.DB()
+		if err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	geocoder, err := geocoding.NewOfflineGeocoder("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := router.NewRouter(fiber.New(), db, fileStorage, geocoder, &testConfig)
+
+	return &harness{
+		t:   t,
+		app: app,
+		db:  db,
+	}
+}
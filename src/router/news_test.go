@@ -0,0 +1,630 @@
+package router_test
+
+import (
+	"cij_api/src/database"
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/repo"
+	"cij_api/src/service"
+	"cij_api/src/utils"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newsFields() map[string]string {
+	return map[string]string{
+		"title":       "Feira de empregabilidade",
+		"description": "Feira com vagas para pessoas com deficiência",
+	}
+}
+
+func (h *harness) createNews(title string, status enum.NewsStatus, date time.Time) model.News {
+	h.t.Helper()
+
+	news := model.News{Slug: utils.Slugify(title), Title: title, Description: "Descrição", Status: status}
+	if status == enum.NewsPublished {
+		news.Date = &date
+	}
+
+	h.create(&news)
+
+	return news
+}
+
+func TestCreateNews(t *testing.T) {
+	h := newHarness(t)
+	admin := h.createUser(model.AdminRole)
+	token := h.token(admin)
+
+	files := map[string][]byte{
+		"banner":       []byte("banner"),
+		"author_image": []byte("author"),
+	}
+
+	h.multipart(http.MethodPost, "/news", newsFields(), files, "").expect(http.StatusBadRequest).expectMessage("token not found")
+
+	var body struct {
+		Data model.NewsResponse `json:"data"`
+	}
+
+	fields := newsFields()
+	fields["banner_alt"] = "Pessoas em uma feira de empregos"
+	fields["author_image_alt"] = "Foto da autora"
+
+	h.multipart(http.MethodPost, "/news", fields, files, token).expect(http.StatusCreated).decode(&body)
+
+	if body.Data.Slug != "feira-de-empregabilidade" || body.Data.Status != enum.NewsPublished || body.Data.Date == nil {
+		t.Fatalf("expected a published news with a slug, got %+v", body.Data)
+	}
+
+	if body.Data.Author == nil || body.Data.Author.Id != admin.Id {
+		t.Fatalf("expected the admin as the author, got %+v", body.Data.Author)
+	}
+
+	if body.Data.Banner == "" || body.Data.AuthorImage == "" {
+		t.Fatalf("expected the images to be uploaded: %+v", body.Data)
+	}
+
+	banner := strings.TrimPrefix(body.Data.Banner, fileStorage.server.URL+"/files/")
+
+	if content, _ := fileStorage.file(banner); !strings.HasPrefix(banner, "cij/news/banner/") || !strings.HasSuffix(banner, ".txt") || string(content) != "banner" {
+		t.Fatalf("unexpected banner %q in the storage: %q", banner, content)
+	}
+
+	// another banner uploaded with the same name doesn't replace the first
+	h.multipart(http.MethodPost, "/news", fields, map[string][]byte{"banner": []byte("other")}, token).expect(http.StatusCreated)
+
+	if content, _ := fileStorage.file(banner); string(content) != "banner" {
+		t.Fatalf("expected the first banner kept, got %q", content)
+	}
+
+	h.request(http.MethodPost, "/news", newsFields(), token).expect(http.StatusCreated).decode(&body)
+
+	if body.Data.Slug != "feira-de-empregabilidade-3" {
+		t.Fatalf("expected a numbered slug for the repeated title, got %q", body.Data.Slug)
+	}
+}
+
+func TestCreateNewsErrors(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	h.multipart(http.MethodPost, "/news", newsFields(), map[string][]byte{"other": []byte("other")}, token).
+		expect(http.StatusInternalServerError).
+		expectCode("3604")
+
+	response := h.request(http.MethodPost, "/news", map[string]string{"status": "hidden", "publish_at": "tomorrow"}, token).
+		expect(http.StatusBadRequest).
+		expectCode("1601").
+		response()
+
+	if len(response.Fields) != 4 {
+		t.Fatalf("expected 4 invalid fields, got %+v", response.Fields)
+	}
+
+	scheduled := newsFields()
+	scheduled["status"] = "published"
+	scheduled["publish_at"] = time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	h.request(http.MethodPost, "/news", scheduled, token).expect(http.StatusBadRequest).expectCode("1601")
+}
+
+func TestListNews(t *testing.T) {
+	h := newHarness(t)
+	now := time.Now()
+
+	for i := 1; i <= 3; i++ {
+		h.createNews(fmt.Sprintf("Notícia %d", i), enum.NewsPublished, now.AddDate(0, 0, -i))
+	}
+
+	h.createNews("Rascunho", enum.NewsDraft, now)
+
+	var body struct {
+		Data []model.NewsResponse `json:"data"`
+	}
+
+	res := h.request(http.MethodGet, "/news?per_page=2", nil, "").expect(http.StatusOK)
+	res.decode(&body)
+
+	if len(body.Data) != 2 || body.Data[0].Title != "Notícia 1" || res.header.Get("X-Total-Count") != "3" {
+		t.Fatalf("expected the first page of the published news, got %+v (%s)", body.Data, res.header.Get("X-Total-Count"))
+	}
+
+	h.request(http.MethodGet, "/news?per_page=2&page=2", nil, "").expect(http.StatusOK).decode(&body)
+
+	if len(body.Data) != 1 || body.Data[0].Title != "Notícia 3" {
+		t.Fatalf("expected the last page, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, "/news?sort=date", nil, "").expect(http.StatusOK).decode(&body)
+
+	if len(body.Data) != 3 || body.Data[0].Title != "Notícia 3" {
+		t.Fatalf("expected the oldest first, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, "/news?status=draft", nil, "").expect(http.StatusOK).decode(&body)
+
+	if len(body.Data) != 3 {
+		t.Fatalf("expected the drafts to be hidden, got %+v", body.Data)
+	}
+
+	res = h.request(http.MethodGet, "/news?status=draft", nil, h.adminToken()).expect(http.StatusOK)
+	res.decode(&body)
+
+	if len(body.Data) != 1 || body.Data[0].Title != "Rascunho" || res.header.Get("X-Total-Count") != strconv.Itoa(1) {
+		t.Fatalf("expected the admin to list the drafts, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, "/news?sort=title", nil, "").expect(http.StatusBadRequest)
+	h.request(http.MethodGet, "/news?per_page=500", nil, "").expect(http.StatusBadRequest)
+}
+
+func TestGetNews(t *testing.T) {
+	h := newHarness(t)
+	published := h.createNews("Publicada", enum.NewsPublished, time.Now())
+	draft := h.createNews("Rascunho", enum.NewsDraft, time.Now())
+
+	var body struct {
+		Data model.NewsResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/news/%d", published.Id), nil, "").expect(http.StatusOK).decode(&body)
+
+	if body.Data.Slug != published.Slug {
+		t.Fatalf("unexpected news: %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, "/news/by-slug/"+published.Slug, nil, "").expect(http.StatusOK).decode(&body)
+
+	if body.Data.Id != published.Id {
+		t.Fatalf("unexpected news: %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/news/%d", draft.Id), nil, "").expect(http.StatusNotFound)
+	h.request(http.MethodGet, "/news/by-slug/"+draft.Slug, nil, h.personToken(h.createPerson())).expect(http.StatusNotFound)
+	h.request(http.MethodGet, "/news/by-slug/"+draft.Slug, nil, h.adminToken()).expect(http.StatusOK)
+	h.request(http.MethodGet, "/news/9999", nil, "").expect(http.StatusNotFound)
+	h.request(http.MethodGet, "/news/abc", nil, "").expect(http.StatusBadRequest)
+	h.request(http.MethodGet, "/news/1", nil, "invalid").expect(http.StatusBadRequest)
+}
+
+func TestUpdateNews(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+	news := h.createNews("Rascunho", enum.NewsDraft, time.Now())
+	path := fmt.Sprintf("/news/%d", news.Id)
+
+	request := model.NewsRequest{Title: "Notícia revisada", Description: "Descrição", Status: enum.NewsPublished}
+
+	h.request(http.MethodPut, path, request, h.personToken(h.createPerson())).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+
+	var body struct {
+		Data model.NewsResponse `json:"data"`
+	}
+
+	h.request(http.MethodPut, path, request, token).expect(http.StatusOK).decode(&body)
+
+	if body.Data.Title != "Notícia revisada" || body.Data.Status != enum.NewsPublished || body.Data.Date == nil || body.Data.Slug != news.Slug {
+		t.Fatalf("expected the news published with the same slug, got %+v", body.Data)
+	}
+
+	published := *body.Data.Date
+
+	request.Status = enum.NewsArchived
+	h.request(http.MethodPut, path, request, token).expect(http.StatusOK).decode(&body)
+
+	if body.Data.Status != enum.NewsArchived || body.Data.Date == nil || !body.Data.Date.Equal(published) {
+		t.Fatalf("expected the archived news to keep its date, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, path, nil, "").expect(http.StatusNotFound)
+	h.request(http.MethodPut, "/news/9999", request, token).expect(http.StatusNotFound)
+}
+
+func TestDeleteNews(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+	news := h.createNews("Notícia", enum.NewsPublished, time.Now())
+	path := fmt.Sprintf("/news/%d", news.Id)
+
+	h.request(http.MethodDelete, path, nil, "").expect(http.StatusBadRequest)
+	h.request(http.MethodDelete, path, nil, token).expect(http.StatusOK).expectMessage("News deleted successfully")
+	h.request(http.MethodGet, path, nil, "").expect(http.StatusNotFound)
+	h.request(http.MethodDelete, path, nil, token).expect(http.StatusNotFound)
+}
+
+func TestPublishScheduledNews(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	scheduled := newsFields()
+	scheduled["publish_at"] = time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	var body struct {
+		Data model.NewsResponse `json:"data"`
+	}
+
+	h.request(http.MethodPost, "/news", scheduled, token).expect(http.StatusCreated).decode(&body)
+
+	if body.Data.Status != enum.NewsDraft || !body.Data.Scheduled || body.Data.Date != nil || body.Data.PublishAt == nil {
+		t.Fatalf("expected a scheduled draft, got %+v", body.Data)
+	}
+
+	past := newsFields()
+	past["status"] = string(enum.NewsDraft)
+	past["publish_at"] = time.Now().Add(-time.Hour).Format(time.RFC3339)
+
+	response := h.requestWithHeaders(http.MethodPost, "/news", past, token, map[string]string{"Accept-Language": "pt-BR"}).
+		expect(http.StatusBadRequest).
+		expectCode("1601").
+		response()
+
+	if len(response.Fields) != 1 || !strings.HasPrefix(response.Fields[0].Value, "um rascunho não pode ter publish_at no passado") {
+		t.Fatalf("expected the translated past publish_at of the draft, got %+v", response.Fields)
+	}
+
+	// a draft is only published when scheduled, whatever its publish_at
+	draft := h.createNews("Rascunho", enum.NewsDraft, time.Now())
+	h.db.Model(&model.News{}).Where("id = ?", draft.Id).Update("publish_at", time.Now().Add(-time.Hour))
+
+	path := fmt.Sprintf("/news/%d", body.Data.Id)
+	h.request(http.MethodGet, path, nil, "").expect(http.StatusNotFound)
+
+	newsService := service.NewNewsService(repo.NewNewsRepo(h.db), repo.NewUserRepo(h.db), fileStorage, h.auditService())
+
+	if published, err := newsService.PublishScheduledNews(model.SystemActor("test")); err.Code != "" || published != 0 {
+		t.Fatalf("expected nothing to publish yet, got %d %v", published, err)
+	}
+
+	publishAt := time.Now().Add(-time.Minute)
+	h.db.Model(&model.News{}).Where("id = ?", body.Data.Id).Update("publish_at", publishAt)
+
+	if published, err := newsService.PublishScheduledNews(model.SystemActor("test")); err.Code != "" || published != 1 {
+		t.Fatalf("expected the scheduled news to be published, got %d %v", published, err)
+	}
+
+	body.Data = model.NewsResponse{}
+	h.request(http.MethodGet, path, nil, "").expect(http.StatusOK).decode(&body)
+
+	if body.Data.Status != enum.NewsPublished || body.Data.Scheduled || body.Data.Date == nil || !body.Data.Date.Equal(*body.Data.PublishAt) {
+		t.Fatalf("expected the news dated at its publish_at, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/news/%d", draft.Id), nil, "").expect(http.StatusNotFound)
+}
+
+func TestNormalizeLegacyNews(t *testing.T) {
+	h := newHarness(t)
+	admin := h.createUser(model.AdminRole)
+
+	h.db.Exec("ALTER TABLE `news` ADD COLUMN `author` varchar(200)")
+	h.db.Exec("INSERT INTO news (title, description, author, status) VALUES ('Notícia antiga', 'Descrição', ?, 'published'), ('Notícia antiga', 'Descrição', 'Redação', 'published')", admin.Email)
+
+	database.Migrate(h.db)
+
+	if h.db.Migrator().HasColumn(&model.News{}, "author") {
+		t.Fatal("expected the free text author column to be dropped")
+	}
+
+	var news []model.News
+	h.db.Order("id").Find(&news)
+
+	if len(news) != 2 || news[0].Slug != "noticia-antiga" || news[1].Slug != "noticia-antiga-2" {
+		t.Fatalf("expected the slugs to be filled, got %+v", news)
+	}
+
+	if news[0].AuthorId == nil || *news[0].AuthorId != admin.Id || news[1].AuthorId != nil {
+		t.Fatalf("expected only the email author to be linked, got %+v", news)
+	}
+
+	if news[1].AuthorName != "Redação" {
+		t.Fatalf("expected the unmatched author name to be kept, got %q", news[1].AuthorName)
+	}
+
+	database.Migrate(h.db)
+
+	var again []model.News
+	h.db.Order("id").Find(&again)
+
+	if len(again) != 2 || again[1].Slug != news[1].Slug || again[1].AuthorName != "Redação" || *again[0].AuthorId != admin.Id {
+		t.Fatalf("expected the migration to change nothing when run again, got %+v", again)
+	}
+}
+
+func TestNewsCategoriesAndTags(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	var categories struct {
+		Data []model.NewsCategoryResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/news/categories", nil, "").expect(http.StatusOK).decode(&categories)
+
+	if len(categories.Data) != 4 || categories.Data[0].Slug != "legislation" {
+		t.Fatalf("expected the default categories, got %+v", categories.Data)
+	}
+
+	request := model.NewsCategoryRequest{Name: "Acessibilidade Digital"}
+
+	h.request(http.MethodPost, "/news/categories", request, h.personToken(h.createPerson())).expect(http.StatusBadRequest)
+	h.request(http.MethodPost, "/news/categories", request, token).expect(http.StatusCreated)
+	h.request(http.MethodPost, "/news/categories", request, token).expect(http.StatusBadRequest).expectCode("1603")
+	h.request(http.MethodPost, "/news/categories", model.NewsCategoryRequest{Slug: "Not A Slug"}, token).
+		expect(http.StatusBadRequest).
+		expectCode("1602")
+
+	fields := newsFields()
+	fields["category"] = "acessibilidade-digital"
+	fields["tags"] = "Libras, Inclusão,libras"
+
+	var body struct {
+		Data model.NewsResponse `json:"data"`
+	}
+
+	h.multipart(http.MethodPost, "/news", fields, nil, token).expect(http.StatusCreated).decode(&body)
+
+	if body.Data.Category == nil || body.Data.Category.Name != "Acessibilidade Digital" || len(body.Data.Tags) != 2 {
+		t.Fatalf("expected the category and two tags, got %+v", body.Data)
+	}
+
+	var updated struct {
+		Data model.NewsResponse `json:"data"`
+	}
+
+	update := model.NewsRequest{Title: "Feira", Description: "Descrição", Tags: []string{"Inclusão", "Eventos"}}
+	h.request(http.MethodPut, fmt.Sprintf("/news/%d", body.Data.Id), update, token).expect(http.StatusOK).decode(&updated)
+
+	if updated.Data.Category != nil || len(updated.Data.Tags) != 2 || updated.Data.Tags[1].Slug != "eventos" {
+		t.Fatalf("expected the category removed and the tags replaced, got %+v", updated.Data)
+	}
+
+	draft := model.NewsRequest{Title: "Rascunho", Description: "Descrição", Status: enum.NewsDraft, Tags: []string{"Acessibilidade"}}
+	h.request(http.MethodPost, "/news", draft, token).expect(http.StatusCreated)
+
+	var tags struct {
+		Data []model.TagResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/news/tags", nil, "").expect(http.StatusOK).decode(&tags)
+
+	if len(tags.Data) != 2 || tags.Data[0].Name != "Eventos" {
+		t.Fatalf("expected only the tags of the published news, got %+v", tags.Data)
+	}
+
+	invalid := model.NewsRequest{Title: "Feira", Description: "Descrição", Category: "unknown", Tags: []string{strings.Repeat("a", 51)}}
+
+	response := h.request(http.MethodPost, "/news", invalid, token).expect(http.StatusBadRequest).expectCode("1601").response()
+
+	if len(response.Fields) != 2 {
+		t.Fatalf("expected the category and the tags to be invalid, got %+v", response.Fields)
+	}
+}
+
+func TestFilterNews(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	requests := []model.NewsRequest{
+		{Title: "Nova lei de cotas", Description: "Empresas devem contratar 5% de pessoas com deficiência", Category: "legislation", Tags: []string{"Cotas"}},
+		{Title: "Feira de empregos", Description: "Vagas para pessoas surdas", Category: "events", Tags: []string{"Libras"}},
+		{Title: "Curso de Libras", Description: "Inscrições abertas", Category: "events"},
+	}
+
+	for _, request := range requests {
+		h.request(http.MethodPost, "/news", request, token).expect(http.StatusCreated)
+	}
+
+	cases := map[string][]string{
+		"/news?category=events":                      {"Curso de Libras", "Feira de empregos"},
+		"/news?tag=libras":                           {"Feira de empregos"},
+		"/news?q=libras":                             {"Curso de Libras"},
+		"/news?q=" + url.QueryEscape("VAGAS surdas"): {"Feira de empregos"},
+		"/news?q=" + url.QueryEscape("5%"):           {"Nova lei de cotas"},
+		"/news?q=" + url.QueryEscape("%"):            {"Nova lei de cotas"},
+		"/news?category=events&q=feira":              {"Feira de empregos"},
+		"/news?category=unknown":                     {},
+	}
+
+	for path, titles := range cases {
+		var body struct {
+			Data []model.NewsResponse `json:"data"`
+		}
+
+		h.request(http.MethodGet, path, nil, "").expect(http.StatusOK).decode(&body)
+
+		found := []string{}
+		for _, news := range body.Data {
+			found = append(found, news.Title)
+		}
+
+		sort.Strings(found)
+
+		if strings.Join(found, "|") != strings.Join(titles, "|") {
+			t.Fatalf("%s: expected %v, got %v", path, titles, found)
+		}
+	}
+}
+
+func TestNewsFeeds(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	request := model.NewsRequest{
+		Title:       "Cotas <novas> & ampliadas",
+		Description: "Lei \"nova\" <script>alert(1)</script>",
+		Category:    "legislation",
+		Tags:        []string{"Cotas"},
+		PublishAt:   "2024-05-10T09:00:00-03:00",
+	}
+
+	h.request(http.MethodPost, "/news", request, token).expect(http.StatusCreated)
+	h.createNews("Evento", enum.NewsPublished, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	h.createNews("Rascunho", enum.NewsDraft, time.Now())
+
+	res := h.request(http.MethodGet, "/news/feed.rss", nil, "").expect(http.StatusOK)
+
+	if !strings.HasPrefix(res.header.Get("Content-Type"), "application/rss+xml") {
+		t.Fatalf("unexpected content type %q", res.header.Get("Content-Type"))
+	}
+
+	if res.header.Get("Cache-Control") != "public, max-age=300" || res.header.Get("Last-Modified") != "Fri, 10 May 2024 12:00:00 GMT" {
+		t.Fatalf("unexpected caching headers: %v", res.header)
+	}
+
+	var rss model.RSS
+	if err := xml.Unmarshal(res.body, &rss); err != nil {
+		t.Fatalf("expected a valid RSS feed: %v\n%s", err, res.body)
+	}
+
+	if len(rss.Channel.Items) != 2 || rss.Channel.Items[0].Title != request.Title || rss.Channel.Items[0].Description != request.Description {
+		t.Fatalf("expected the published news, newest first, got %+v", rss.Channel.Items)
+	}
+
+	if strings.Contains(string(res.body), "<script>") || !strings.Contains(string(res.body), "&lt;novas&gt; &amp; ampliadas") {
+		t.Fatalf("expected the content to be escaped:\n%s", res.body)
+	}
+
+	if !strings.HasSuffix(rss.Channel.Items[0].Link, "/news/by-slug/cotas-novas-ampliadas") {
+		t.Fatalf("unexpected link %q", rss.Channel.Items[0].Link)
+	}
+
+	h.requestWithHeaders(http.MethodGet, "/news/feed.rss", nil, "", map[string]string{"If-None-Match": res.header.Get("ETag")}).
+		expect(http.StatusNotModified)
+
+	res = h.request(http.MethodGet, "/news/categories/legislation/feed.atom", nil, "").expect(http.StatusOK)
+
+	if !strings.HasPrefix(res.header.Get("Content-Type"), "application/atom+xml") {
+		t.Fatalf("unexpected content type %q", res.header.Get("Content-Type"))
+	}
+
+	var atom model.Atom
+	if err := xml.Unmarshal(res.body, &atom); err != nil {
+		t.Fatalf("expected a valid Atom feed: %v\n%s", err, res.body)
+	}
+
+	if len(atom.Entries) != 1 || atom.Entries[0].Title != request.Title || atom.Updated != "2024-05-10T12:00:00Z" {
+		t.Fatalf("expected only the legislation news, got %+v", atom)
+	}
+
+	h.request(http.MethodGet, "/news/categories/events/feed.rss", nil, "").expect(http.StatusOK)
+	h.request(http.MethodGet, "/news/categories/unknown/feed.atom", nil, "").expect(http.StatusNotFound)
+}
+
+func TestNewsAccessibleMedia(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+
+	files := map[string][]byte{"banner": []byte("banner")}
+
+	response := h.multipart(http.MethodPost, "/news", newsFields(), files, token).
+		expect(http.StatusBadRequest).
+		expectCode("1601").
+		response()
+
+	if len(response.Fields) != 1 || response.Fields[0].Name != "banner_alt" {
+		t.Fatalf("expected the banner alternative text to be required, got %+v", response.Fields)
+	}
+
+	draft := newsFields()
+	draft["status"] = "draft"
+
+	var body struct {
+		Data model.NewsResponse `json:"data"`
+	}
+
+	h.multipart(http.MethodPost, "/news", draft, files, token).expect(http.StatusCreated).decode(&body)
+
+	path := fmt.Sprintf("/news/%d", body.Data.Id)
+	publish := model.NewsRequest{Title: "Feira", Description: "Descrição", Status: enum.NewsPublished}
+
+	h.request(http.MethodPut, path, publish, token).expect(http.StatusBadRequest).expectCode("1601")
+
+	publish.Status = enum.NewsDraft
+	publish.PublishAt = time.Now().Add(time.Hour).Format(time.RFC3339)
+	h.request(http.MethodPut, path, publish, token).expect(http.StatusBadRequest).expectCode("1601")
+
+	publish.PublishAt = ""
+	publish.BannerAlt = "banner.jpg"
+	h.request(http.MethodPut, path, publish, token).expect(http.StatusBadRequest).expectCode("1601")
+
+	media := newsFields()
+	media["banner_alt"] = "Pessoas em uma feira de empregos"
+	media["banner_caption"] = "Feira de 2024"
+	media["banner_long_description"] = "Dezenas de pessoas visitam os estandes das empresas."
+
+	mediaFiles := map[string][]byte{
+		"libras_video":    {0x1A, 0x45, 0xDF, 0xA3, 0x01, 0x00, 0x00, 0x00},
+		"audio_narration": []byte("ID3\x03\x00\x00\x00\x00\x00\x00"),
+	}
+
+	h.multipart(http.MethodPut, path, media, mediaFiles, token).expect(http.StatusOK).decode(&body)
+
+	if body.Data.Status != enum.NewsPublished || body.Data.Banner == "" || body.Data.BannerAlt != "Pessoas em uma feira de empregos" {
+		t.Fatalf("expected the news published with its banner described, got %+v", body.Data)
+	}
+
+	if body.Data.BannerCaption != "Feira de 2024" || body.Data.LibrasVideo == "" || body.Data.AudioNarration == "" {
+		t.Fatalf("expected the caption, the video and the audio, got %+v", body.Data)
+	}
+
+	invalid := h.multipart(http.MethodPut, path, media, map[string][]byte{"audio_narration": []byte("not audio")}, token).
+		expect(http.StatusBadRequest).
+		expectCode("1604").
+		response()
+
+	if len(invalid.Fields) != 1 || invalid.Fields[0].Name != "audio_narration" || invalid.Fields[0].Value != "unsupported file type, send a mp3, wav or ogg audio" {
+		t.Fatalf("expected the audio to be rejected, got %+v", invalid.Fields)
+	}
+
+	audio := strings.TrimPrefix(body.Data.AudioNarration, fileStorage.server.URL+"/files/")
+
+	if content, _ := fileStorage.file(audio); !strings.HasPrefix(string(content), "ID3") {
+		t.Fatalf("expected the rejected audio not to be stored, got %q", content)
+	}
+}
+
+func TestNewsMediaRemovedWhenNotSaved(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+	news := h.createNews("Feira", enum.NewsPublished, time.Now())
+
+	storedBanners := func() int {
+		fileStorage.mutex.Lock()
+		defer fileStorage.mutex.Unlock()
+
+		count := 0
+		for key := range fileStorage.files {
+			if strings.HasPrefix(key, "cij/news/banner/") {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	before := storedBanners()
+
+	// the news can't be written, so the update fails after the upload
+	if err := h.db.Exec("CREATE TRIGGER news_read_only BEFORE UPDATE ON news BEGIN SELECT RAISE(ABORT, 'read only'); END").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	fields := newsFields()
+	fields["banner_alt"] = "Pessoas em uma feira de empregos"
+
+	h.multipart(http.MethodPut, fmt.Sprintf("/news/%d", news.Id), fields, map[string][]byte{"banner": []byte("banner")}, token).
+		expect(http.StatusInternalServerError).
+		expectCode("2606")
+
+	if after := storedBanners(); after != before {
+		t.Fatalf("expected the banner of the failed update removed, got %d banners instead of %d", after, before)
+	}
+}
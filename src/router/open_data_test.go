@@ -0,0 +1,88 @@
+package router_test
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOpenData(t *testing.T) {
+	h := newHarness(t)
+	vacancyId := h.createVacancy(h.createCompany()).Id
+
+	for i := 0; i < 6; i++ {
+		person := h.createPerson()
+
+		if i < 2 {
+			h.createApply(vacancyId, person)
+		}
+	}
+
+	var catalogue struct {
+		Data model.OpenDataCatalogue `json:"data"`
+	}
+	h.request(http.MethodGet, "/open-data", nil, "").expect(http.StatusOK).decode(&catalogue)
+
+	if len(catalogue.Data.Datasets) != 8 || catalogue.Data.License.Id != "CC-BY-4.0" {
+		t.Fatalf("unexpected catalogue: %+v", catalogue.Data)
+	}
+
+	for _, dataset := range catalogue.Data.Datasets {
+		if dataset.Version == "" || dataset.UpdatedAt.IsZero() || len(dataset.Fields) == 0 || len(dataset.Downloads) != 2 || dataset.License.URL == "" {
+			t.Fatalf("expected the metadata of the dataset, got %+v", dataset)
+		}
+	}
+
+	var translated struct {
+		Data model.OpenDataCatalogue `json:"data"`
+	}
+	h.requestWithHeaders(http.MethodGet, "/open-data", nil, "", map[string]string{"Accept-Language": "pt-BR"}).expect(http.StatusOK).decode(&translated)
+
+	for i, dataset := range translated.Data.Datasets {
+		english := catalogue.Data.Datasets[i]
+
+		if dataset.Title == english.Title || dataset.Description == english.Description {
+			t.Fatalf("expected the dataset %s translated, got %+v", dataset.Id, dataset)
+		}
+
+		for j, field := range dataset.Fields {
+			if field.Description == english.Fields[j].Description {
+				t.Fatalf("expected the field %s of %s translated", field.Name, dataset.Id)
+			}
+		}
+	}
+
+	res := h.request(http.MethodGet, "/open-data/disability-totals?format=csv", nil, "").expect(http.StatusOK)
+
+	if !strings.HasPrefix(string(res.body), "category,people\n") || !strings.Contains(string(res.body), string(enum.Visual)+",6\n") {
+		t.Fatalf("unexpected dataset CSV: %s", res.body)
+	}
+
+	if res.header.Get("Last-Modified") == "" || !strings.Contains(res.header.Get("Content-Disposition"), "disability-totals-v1.0.csv") {
+		t.Fatalf("expected the version and update of the dataset in the headers, got %v", res.header)
+	}
+
+	var rates struct {
+		Data model.OpenDataDocument `json:"data"`
+	}
+	h.request(http.MethodGet, "/open-data/acceptance-rates-by-category", nil, "").expect(http.StatusOK).decode(&rates)
+
+	if len(rates.Data.Records) != 1 || rates.Data.Records[0]["applications"] != nil || rates.Data.Records[0]["rate"] != nil {
+		t.Fatalf("expected the 2 applications suppressed, got %+v", rates.Data.Records)
+	}
+
+	if !rates.Data.Dataset.UpdatedAt.Equal(catalogue.Data.Datasets[4].UpdatedAt) {
+		t.Fatalf("expected the dataset published once a day, got %v and %v", rates.Data.Dataset.UpdatedAt, catalogue.Data.Datasets[4].UpdatedAt)
+	}
+
+	dictionary := h.requestWithHeaders(http.MethodGet, "/open-data/apply-funnel/dictionary", nil, "", map[string]string{"Accept": "text/csv"}).expect(http.StatusOK)
+
+	if !strings.HasPrefix(string(dictionary.body), "name,type,description,nullable\nstage,string,") {
+		t.Fatalf("unexpected data dictionary: %s", dictionary.body)
+	}
+
+	h.request(http.MethodGet, "/open-data/unknown", nil, "").expect(http.StatusNotFound).expectCode("41202")
+	h.request(http.MethodGet, "/open-data/apply-funnel?format=xml", nil, "").expect(http.StatusBadRequest).expectCode("41201")
+}
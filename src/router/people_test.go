@@ -0,0 +1,455 @@
+package router_test
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func personRequest() model.PersonRequest {
+	return model.PersonRequest{
+		Name:   "João Souza",
+		Cpf:    fmt.Sprintf("%011d", next()),
+		Phone:  "5541977777777",
+		Gender: enum.Male,
+		User: model.UserRequest{
+			Email:    fmt.Sprintf("person%d@test.com", next()),
+			Password: testPassword,
+		},
+		Address: addressRequest(),
+	}
+}
+
+func TestCreatePerson(t *testing.T) {
+	h := newHarness(t)
+	disability := h.disability()
+
+	request := personRequest()
+	request.BirthDate = "1990-05-20"
+	request.Disabilities = []model.PersonDisabilityRequest{{Id: disability.Id, Acquired: true}}
+
+	h.request(http.MethodPost, "/people", request, "").expect(http.StatusOK).expectMessage("success")
+
+	var person model.Person
+	if err := h.db.Preload("User").Where("cpf = ?", request.Cpf).First(&person).Error; err != nil {
+		t.Fatalf("person not created: %v", err)
+	}
+
+	if person.User.RoleId != model.PersonRole || person.AddressId == nil {
+		t.Fatalf("unexpected person: %+v", person)
+	}
+
+	var body struct {
+		Data model.PersonResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/people/%d", person.Id), nil, "").expect(http.StatusOK).decode(&body)
+
+	if config, ok := body.Data.User.Config.(map[string]interface{}); !ok || config["font_size"] != float64(model.DefaultConfig.FontSize) {
+		t.Fatalf("expected the default config, got %v", body.Data.User.Config)
+	}
+
+	if body.Data.BirthDate != "" {
+		t.Fatalf("expected the birth date out of the public response, got %q", body.Data.BirthDate)
+	}
+
+	var people struct {
+		Data []model.PersonResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/people", nil, "").expect(http.StatusOK).decode(&people)
+
+	for _, listed := range people.Data {
+		if listed.BirthDate != "" {
+			t.Fatalf("expected the birth date out of the people list, got %q", listed.BirthDate)
+		}
+	}
+
+	var userData struct {
+		UserInfo model.PersonResponse `json:"user_info"`
+	}
+
+	h.request(http.MethodPost, "/get-user-data", map[string]string{"token": h.personToken(person)}, "").
+		expect(http.StatusOK).
+		decode(&userData)
+
+	if userData.UserInfo.BirthDate != "1990-05-20" {
+		t.Fatalf("expected the birth date for the person, got %q", userData.UserInfo.BirthDate)
+	}
+}
+
+func TestCreatePersonErrors(t *testing.T) {
+	h := newHarness(t)
+	existing := h.createPerson()
+
+	missingFields := personRequest()
+	missingFields.Name = ""
+
+	missingUser := personRequest()
+	missingUser.User.Password = ""
+
+	duplicatedCpf := personRequest()
+	duplicatedCpf.Cpf = existing.Cpf
+
+	duplicatedEmail := personRequest()
+	duplicatedEmail.User.Email = existing.User.Email
+
+	invalidFields := personRequest()
+	invalidFields.Phone = "123"
+
+	invalidAddress := personRequest()
+	invalidAddress.Address.ZipCode = ""
+
+	unknownDisability := personRequest()
+	unknownDisability.Disabilities = []model.PersonDisabilityRequest{{Id: 9999}}
+
+	unknownPreset := personRequest()
+	unknownPreset.ConfigPreset = "unknown"
+
+	futureBirthDate := personRequest()
+	futureBirthDate.BirthDate = time.Now().AddDate(1, 0, 0).Format(time.DateOnly)
+
+	tests := []struct {
+		name    string
+		request model.PersonRequest
+		code    string
+	}{
+		{"missing fields", missingFields, "1201"},
+		{"missing user fields", missingUser, "1101"},
+		{"duplicated cpf", duplicatedCpf, "4202"},
+		{"duplicated email", duplicatedEmail, "4203"},
+		{"invalid fields", invalidFields, "1202"},
+		{"invalid address", invalidAddress, "1301"},
+		{"unknown disability", unknownDisability, "4206"},
+		{"unknown config preset", unknownPreset, "1202"},
+		{"future birth date", futureBirthDate, "1202"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h.t = t
+
+			h.request(http.MethodPost, "/people", test.request, "").
+				expect(http.StatusBadRequest).
+				expectCode(test.code)
+		})
+	}
+}
+
+func TestListPeople(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+
+	var body struct {
+		Data []model.PersonResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/people", nil, "").expect(http.StatusOK).decode(&body)
+
+	if len(body.Data) != 1 || body.Data[0].Cpf != person.Cpf {
+		t.Fatalf("unexpected people: %+v", body.Data)
+	}
+
+	if body.Data[0].Disabilities == nil || len(*body.Data[0].Disabilities) != 1 {
+		t.Fatalf("expected the person disabilities: %+v", body.Data[0])
+	}
+}
+
+func TestGetPerson(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+
+	var body struct {
+		Data model.PersonResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/people/%d", person.Id), nil, "").expect(http.StatusOK).decode(&body)
+
+	if body.Data.Id != person.Id || body.Data.User.Email != person.User.Email {
+		t.Fatalf("unexpected person: %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, "/people/abc", nil, "").expect(http.StatusBadRequest)
+	h.request(http.MethodGet, "/people/9999", nil, "").expect(http.StatusNotFound).expectMessage("person not found")
+}
+
+func TestUpdatePerson(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+
+	request := model.PersonRequest{Name: "Maria Souza"}
+
+	h.request(http.MethodPut, fmt.Sprintf("/people/%d", person.Id), request, token).expect(http.StatusOK)
+
+	var updated model.Person
+	h.db.First(&updated, person.Id)
+
+	if updated.Name != "Maria Souza" {
+		t.Fatalf("expected the name to be updated, got %q", updated.Name)
+	}
+
+	h.request(http.MethodPut, "/people/abc", request, token).expect(http.StatusBadRequest)
+	h.request(http.MethodPut, "/people/9999", request, token).expect(http.StatusNotFound)
+	h.request(http.MethodPut, fmt.Sprintf("/people/%d", person.Id), model.PersonRequest{BirthDate: "20/05/1990"}, token).
+		expect(http.StatusBadRequest).
+		expectCode("1202")
+}
+
+func TestUpdatePersonAddress(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+
+	request := addressRequest()
+	request.Neighborhood = "Água Verde"
+
+	h.request(http.MethodPut, fmt.Sprintf("/people/%d/address", person.Id), request, token).expect(http.StatusOK)
+
+	var address model.Address
+	h.db.First(&address, *person.AddressId)
+
+	if address.Neighborhood != "Água Verde" {
+		t.Fatalf("expected the address to be updated, got %q", address.Neighborhood)
+	}
+
+	h.request(http.MethodPut, "/people/abc/address", request, token).expect(http.StatusBadRequest)
+	h.request(http.MethodPut, "/people/9999/address", request, token).expect(http.StatusNotFound)
+}
+
+func TestGeocodePersonAddress(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+	path := fmt.Sprintf("/people/%d/address", person.Id)
+
+	latitude, longitude := -26.47, -49.1
+
+	tests := []struct {
+		name    string
+		request func(request *model.AddressRequest)
+		located bool
+		point   [2]float64
+	}{
+		{"neighborhood", func(request *model.AddressRequest) {
+			request.Neighborhood, request.City, request.State, request.ZipCode = "vila  nova", "Jaragua do Sul", "sc", "89259000"
+		}, true, [2]float64{-26.4703, -49.0978}},
+		{"zip code", func(request *model.AddressRequest) {
+			request.Neighborhood, request.City, request.ZipCode = "Desconhecido", "Guaramirim", "89270000"
+		}, true, [2]float64{-26.4731, -49.0021}},
+		{"city", func(request *model.AddressRequest) {
+			request.Neighborhood, request.City, request.State, request.ZipCode = "Desconhecido", "Corupá", "SC", "00000000"
+		}, true, [2]float64{-26.4251, -49.2429}},
+		{"given coordinates", func(request *model.AddressRequest) {
+			request.Latitude, request.Longitude = &latitude, &longitude
+		}, true, [2]float64{latitude, longitude}},
+		{"unknown", func(request *model.AddressRequest) {
+			request.City, request.ZipCode = "Atlântida", "00000000"
+		}, false, [2]float64{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h.t = t
+
+			request := addressRequest()
+			test.request(&request)
+
+			h.request(http.MethodPut, path, request, token).expect(http.StatusOK)
+
+			var address model.Address
+			h.db.First(&address, *person.AddressId)
+
+			if !test.located {
+				if address.Latitude != nil || address.Longitude != nil {
+					t.Fatalf("expected no coordinates, got %v, %v", *address.Latitude, *address.Longitude)
+				}
+
+				return
+			}
+
+			if address.Latitude == nil || address.Longitude == nil || *address.Latitude != test.point[0] || *address.Longitude != test.point[1] {
+				t.Fatalf("expected the coordinates %v, got %v, %v", test.point, address.Latitude, address.Longitude)
+			}
+		})
+	}
+
+	h.t = t
+
+	invalid := personRequest()
+	invalid.Address.Latitude = &latitude
+
+	h.request(http.MethodPost, "/people", invalid, "").expect(http.StatusBadRequest).expectCode("1301")
+}
+
+func TestUpdatePersonDisabilities(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+
+	var disabilities []model.Disability
+	h.db.Order("id").Limit(2).Find(&disabilities)
+
+	request := []model.PersonDisabilityRequest{{Id: disabilities[0].Id}, {Id: disabilities[1].Id, Acquired: true}}
+
+	h.request(http.MethodPut, fmt.Sprintf("/people/%d/disabilities", person.Id), request, token).expect(http.StatusOK)
+
+	var count int64
+	h.db.Model(&model.PersonDisability{}).Where("person_id = ?", person.Id).Count(&count)
+
+	if count != 2 {
+		t.Fatalf("expected 2 disabilities, got %d", count)
+	}
+
+	h.request(http.MethodPut, fmt.Sprintf("/people/%d/disabilities", person.Id), []model.PersonDisabilityRequest{{Id: 9999}}, token).
+		expect(http.StatusBadRequest).
+		expectCode("4206")
+	h.request(http.MethodPut, "/people/abc/disabilities", request, token).expect(http.StatusBadRequest)
+	h.request(http.MethodPut, "/people/9999/disabilities", request, token).expect(http.StatusNotFound)
+}
+
+func TestDeletePerson(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.adminToken()
+
+	h.request(http.MethodDelete, fmt.Sprintf("/people/%d", person.Id), nil, token).expect(http.StatusOK)
+
+	var count int64
+	h.db.Model(&model.Person{}).Where("id = ?", person.Id).Count(&count)
+
+	if count != 0 {
+		t.Fatal("expected the person to be deleted")
+	}
+
+	h.request(http.MethodDelete, "/people/abc", nil, token).expect(http.StatusBadRequest)
+	h.request(http.MethodDelete, fmt.Sprintf("/people/%d", person.Id), nil, token).expect(http.StatusNotFound)
+}
+
+func TestUploadCurriculum(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	token := h.personToken(person)
+	path := fmt.Sprintf("/people/%d/curriculum", person.Id)
+
+	h.multipart(http.MethodPost, path, nil, map[string][]byte{"file": []byte("curriculum")}, token).expect(http.StatusOK)
+
+	var updated model.Person
+	h.db.First(&updated, person.Id)
+
+	if !strings.HasPrefix(updated.Curriculum, "private/curriculum/") || strings.Contains(updated.Curriculum, person.Cpf) {
+		t.Fatalf("expected the curriculum to be kept under an opaque private key, got %q", updated.Curriculum)
+	}
+
+	if content, _ := fileStorage.file(updated.Curriculum); string(content) != "curriculum" {
+		t.Fatalf("unexpected curriculum in the storage: %q", content)
+	}
+
+	h.multipart(http.MethodPost, path, nil, map[string][]byte{"file": []byte("new curriculum")}, token).expect(http.StatusOK)
+
+	if _, ok := fileStorage.file(updated.Curriculum); ok {
+		t.Fatal("expected the previous curriculum to be deleted")
+	}
+
+	h.multipart(http.MethodPost, path, nil, nil, token).expect(http.StatusBadRequest)
+	h.multipart(http.MethodPost, "/people/abc/curriculum", nil, map[string][]byte{"file": []byte("curriculum")}, token).
+		expect(http.StatusBadRequest)
+	h.multipart(http.MethodPost, "/people/9999/curriculum", nil, map[string][]byte{"file": []byte("curriculum")}, token).
+		expect(http.StatusNotFound)
+
+	h.db.First(&updated, person.Id)
+
+	h.multipart(http.MethodPost, path, nil, map[string][]byte{"file": []byte("other curriculum")}, h.personToken(h.createPerson())).
+		expect(http.StatusForbidden).
+		expectMessage("not allowed to upload the curriculum")
+
+	if content, _ := fileStorage.file(updated.Curriculum); string(content) != "new curriculum" {
+		t.Fatalf("expected another person to leave the curriculum unchanged, got %q", content)
+	}
+
+	h.multipart(http.MethodPost, path, nil, map[string][]byte{"file": []byte("admin curriculum")}, h.adminToken()).expect(http.StatusOK)
+}
+
+func TestDownloadCurriculum(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	path := fmt.Sprintf("/people/%d/curriculum", person.Id)
+
+	h.request(http.MethodGet, path, nil, h.personToken(person)).expect(http.StatusNotFound).expectMessage("curriculum not found")
+
+	h.multipart(http.MethodPost, path, nil, map[string][]byte{"file": []byte("curriculum")}, h.personToken(person)).
+		expect(http.StatusOK)
+
+	var personBody struct {
+		Data model.PersonResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/people/%d", person.Id), nil, "").expect(http.StatusOK).decode(&personBody)
+
+	if personBody.Data.Curriculum != path {
+		t.Fatalf("expected the curriculum to point to the download endpoint, got %q", personBody.Data.Curriculum)
+	}
+
+	company := h.createCompany()
+	h.createApply(h.createVacancy(company).Id, person)
+
+	allowed := map[string]string{
+		"person":  h.personToken(person),
+		"admin":   h.adminToken(),
+		"company": h.companyToken(company),
+	}
+
+	for name, token := range allowed {
+		var body struct {
+			Data model.CurriculumUrlResponse `json:"data"`
+		}
+
+		h.request(http.MethodGet, path, nil, token).expect(http.StatusOK).decode(&body)
+
+		if body.Data.Url == "" || body.Data.ExpiresAt <= time.Now().Unix() {
+			t.Fatalf("%s: unexpected curriculum url %+v", name, body.Data)
+		}
+	}
+
+	var downloads int64
+	h.db.Model(&model.Activity{}).Where("type = ?", "download_curriculum").Count(&downloads)
+
+	if downloads != int64(len(allowed)) {
+		t.Fatalf("expected %d logged downloads, got %d", len(allowed), downloads)
+	}
+
+	h.request(http.MethodGet, path, nil, h.personToken(h.createPerson())).expect(http.StatusForbidden)
+	h.request(http.MethodGet, path, nil, h.companyToken(h.createCompany())).expect(http.StatusForbidden)
+
+	h.request(http.MethodGet, path, nil, "").expect(http.StatusBadRequest).expectMessage("token not found")
+	h.request(http.MethodGet, "/people/abc/curriculum", nil, h.adminToken()).expect(http.StatusBadRequest)
+	h.request(http.MethodGet, "/people/9999/curriculum", nil, h.adminToken()).expect(http.StatusNotFound)
+}
+
+func TestPeopleAuthorization(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	companyToken := h.companyToken(h.createCompany())
+	path := fmt.Sprintf("/people/%d", person.Id)
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPut, path},
+		{http.MethodPut, path + "/address"},
+		{http.MethodPut, path + "/disabilities"},
+		{http.MethodDelete, path},
+		{http.MethodPost, path + "/curriculum"},
+	}
+
+	for _, route := range routes {
+		h.request(route.method, route.path, nil, "").expect(http.StatusBadRequest).expectMessage("token not found")
+		h.request(route.method, route.path, nil, "invalid").expect(http.StatusBadRequest).expectMessage("token invalid or expired")
+		h.request(route.method, route.path, nil, companyToken).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+	}
+}
@@ -0,0 +1,600 @@
+package router_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetDisabilityTotals(t *testing.T) {
+	h := newHarness(t)
+	h.createPerson()
+	h.createPerson()
+
+	var body struct {
+		Data model.DisabilityTotals `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/reports/exact/disabilities", nil, h.adminToken()).expect(http.StatusOK).decode(&body)
+
+	if body.Data[enum.Visual] != 2 {
+		t.Fatalf("expected 2 people with visual disabilities, got %+v", body.Data)
+	}
+}
+
+func TestGetDisabilityTotalsByNeighborhood(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+
+	address := person.Address
+	address.Neighborhood = "São Francisco"
+	h.db.Save(address)
+
+	var body struct {
+		Data model.DisabilityTotalsByNeighborhood `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/reports/exact/disabilities/"+url.PathEscape("sao francisco"), nil, h.adminToken()).
+		expect(http.StatusOK).
+		decode(&body)
+
+	if body.Data[enum.Visual] != 1 {
+		t.Fatalf("expected one person in the neighborhood, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, "/reports/disabilities/%ZZ", nil, "").expect(http.StatusBadRequest)
+}
+
+func TestCountActivitiesByPeriod(t *testing.T) {
+	h := newHarness(t)
+	h.create(&model.Activity{Type: "login", Description: "User logged in", Actor: "user@test.com"})
+
+	var body struct {
+		Data model.CountActivitiesByPeriod `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/reports/exact/activities/login/last_three_months", nil, h.adminToken()).
+		expect(http.StatusOK).
+		decode(&body)
+
+	if count := body.Data.MonthsCount[time.Now().Format("2006-01")]; count != 1 {
+		t.Fatalf("expected one login this month, got %+v", body.Data)
+	}
+
+	h.request(http.MethodGet, "/reports/activities/login/forever", nil, "").
+		expect(http.StatusBadRequest).
+		expectCode("4904")
+}
+
+func TestGetActivityReport(t *testing.T) {
+	h := newHarness(t)
+	h.createActivityAt("login", time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC))
+	h.createActivityAt("login", time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))
+	h.createActivityAt("login", time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	h.createActivityAt("register_person", time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC))
+
+	var body struct {
+		Data model.ActivityReport `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/reports/exact/activities?types=login,register_person,login&from=2024-02-01&to=2024-03-31&timezone=America/Sao_Paulo&compare=true", nil, h.adminToken()).
+		expect(http.StatusOK).
+		decode(&body)
+
+	if len(body.Data.Series) != 2 || body.Data.Timezone != "America/Sao_Paulo" || body.Data.Granularity != enum.Month {
+		t.Fatalf("unexpected report: %+v", body.Data)
+	}
+
+	login := body.Data.Series[0]
+	if login.ActivityType != "login" || login.Total != 2 || len(login.Buckets) != 2 ||
+		login.Buckets[0].Label != "2024-02" || login.Buckets[0].Count != 1 || login.Buckets[1].Count != 1 {
+		t.Fatalf("expected the logins of february and march in São Paulo, got %+v", login)
+	}
+
+	if login.Previous == nil || login.Previous.Total != 1 || login.Previous.ChangePercent == nil || *login.Previous.ChangePercent != 100 ||
+		*login.Buckets[0].PreviousCount != 0 || *login.Buckets[1].PreviousCount != 1 {
+		t.Fatalf("expected the january login in the previous period, got %+v", login)
+	}
+
+	if register := body.Data.Series[1]; register.Total != 1 || register.Previous.ChangePercent != nil {
+		t.Fatalf("unexpected register series: %+v", register)
+	}
+
+	var utc struct {
+		Data model.ActivityReport `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/activities?types=login&from=2024-02-01&to=2024-03-31&timezone=UTC", nil, h.adminToken()).
+		expect(http.StatusOK).
+		decode(&utc)
+
+	if buckets := utc.Data.Series[0].Buckets; buckets[0].Count != 0 || buckets[1].Count != 2 || utc.Data.Series[0].Previous != nil {
+		t.Fatalf("expected both logins in march in UTC, got %+v", utc.Data.Series[0])
+	}
+}
+
+func TestGetActivityReportOffsetTimes(t *testing.T) {
+	h := newHarness(t)
+	brasilia := time.FixedZone("BRT", -3*60*60)
+	h.createActivityAt("login", time.Date(2024, 2, 29, 22, 30, 0, 0, brasilia))
+
+	for timezone, day := range map[string]string{"UTC": "2024-03-01", "America/Sao_Paulo": "2024-02-29"} {
+		var body struct {
+			Data model.ActivityReport `json:"data"`
+		}
+
+		h.request(http.MethodGet, "/reports/exact/activities?types=login&from=2024-02-28&to=2024-03-02&granularity=day&timezone="+timezone, nil, h.adminToken()).
+			expect(http.StatusOK).
+			decode(&body)
+
+		for _, bucket := range body.Data.Series[0].Buckets {
+			if (bucket.Label == day) != (bucket.Count == 1) {
+				t.Fatalf("expected the login stored at -03:00 on %s in %s, got %+v", day, timezone, body.Data.Series[0].Buckets)
+			}
+		}
+	}
+}
+
+func TestGetActivityReportByWeek(t *testing.T) {
+	h := newHarness(t)
+	h.createActivityAt("login", time.Date(2024, 3, 3, 18, 15, 0, 0, time.UTC))
+	h.createActivityAt("login", time.Date(2024, 3, 3, 18, 45, 0, 0, time.UTC))
+
+	var body struct {
+		Data model.ActivityReport `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/reports/exact/activities?types=login&from=2024-02-26&to=2024-03-10&granularity=week&timezone=Asia/Kolkata", nil, h.adminToken()).
+		expect(http.StatusOK).
+		decode(&body)
+
+	buckets := body.Data.Series[0].Buckets
+	if len(buckets) != 2 || buckets[0].Label != "2024-W09" || buckets[0].Count != 1 || buckets[1].Label != "2024-W10" || buckets[1].Count != 1 {
+		t.Fatalf("expected one login on each side of the monday midnight in India, got %+v", buckets)
+	}
+}
+
+func TestGetActivityReportErrors(t *testing.T) {
+	h := newHarness(t)
+
+	h.request(http.MethodGet, "/reports/activities", nil, "").expect(http.StatusBadRequest).expectCode("1901")
+	h.request(http.MethodGet, "/reports/activities?types=login&granularity=hour", nil, "").expect(http.StatusBadRequest).expectCode("1902")
+	h.request(http.MethodGet, "/reports/activities?types=login&from=2024-03-01&to=2024-02-01", nil, "").expect(http.StatusBadRequest).expectCode("1903")
+	h.request(http.MethodGet, "/reports/activities?types=login&from=2000-01-01&to=2024-01-01&granularity=day", nil, "").expect(http.StatusBadRequest).expectCode("1904")
+	h.request(http.MethodGet, "/reports/activities?types=login&timezone=Mars/Base", nil, "").expect(http.StatusBadRequest).expectCode("4905")
+	h.request(http.MethodGet, "/reports/activities?types=login&from=yesterday", nil, "").expect(http.StatusBadRequest).expectCode("4906")
+}
+
+func TestEmploymentReports(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	vacancyId := h.createVacancy(company).Id
+
+	other := h.createPerson()
+	h.db.Model(other.Address).Update("neighborhood", "Boqueirão")
+
+	applied := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	applies := []struct {
+		person    model.Person
+		status    enum.VacancyApplyStatus
+		decidedIn int
+	}{
+		{h.createPerson(), enum.VacancyApplyAccepted, 4},
+		{h.createPerson(), enum.VacancyApplyRejected, 2},
+		{other, enum.VacancyApplyApplied, 0},
+	}
+
+	for _, apply := range applies {
+		created := h.createApply(vacancyId, apply.person)
+		updates := map[string]interface{}{"status": apply.status, "created_at": applied, "decided_at": nil}
+
+		if apply.decidedIn > 0 {
+			updates["decided_at"] = applied.AddDate(0, 0, apply.decidedIn)
+		}
+
+		h.db.Model(&created).Updates(updates)
+	}
+
+	var vacancies struct {
+		Data []model.VacancyCategoryTotal `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/employment/vacancies?from=2024-02-01&to=2024-02-28&neighborhood=batel", nil, h.adminToken()).
+		expect(http.StatusOK).
+		decode(&vacancies)
+
+	for _, total := range vacancies.Data {
+		if (total.Category == enum.Visual) != (total.Vacancies == 1) {
+			t.Fatalf("expected one visual vacancy open in february, got %+v", vacancies.Data)
+		}
+	}
+
+	var closed struct {
+		Data []model.VacancyCategoryTotal `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/employment/vacancies?from=2024-03-01", nil, h.adminToken()).expect(http.StatusOK).decode(&closed)
+
+	if len(closed.Data) == 0 || closed.Data[0].Vacancies != 0 {
+		t.Fatalf("expected no vacancy open in march, got %+v", closed.Data)
+	}
+
+	var byVacancy struct {
+		Data []model.VacancyApplyTotals `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/employment/applies?from=2024-01-01&to=2024-01-31", nil, h.adminToken()).expect(http.StatusOK).decode(&byVacancy)
+
+	if len(byVacancy.Data) != 1 || byVacancy.Data[0].VacancyId != vacancyId || byVacancy.Data[0].Company != company.Name ||
+		byVacancy.Data[0].Applications != 3 || byVacancy.Data[0].Accepted != 1 || byVacancy.Data[0].Rejected != 1 {
+		t.Fatalf("unexpected applies by vacancy: %+v", byVacancy.Data)
+	}
+
+	var neighborhood struct {
+		Data []model.VacancyApplyTotals `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/employment/applies?neighborhood="+url.QueryEscape("boqueirao"), nil, h.adminToken()).expect(http.StatusOK).decode(&neighborhood)
+
+	if len(neighborhood.Data) != 1 || neighborhood.Data[0].Applications != 1 {
+		t.Fatalf("expected the applies of the candidates of the neighborhood, got %+v", neighborhood.Data)
+	}
+
+	// a second disability of the same category doesn't count the apply twice
+	lowVision := model.Disability{CategoryId: h.disability().CategoryId, Description: "Baixa visão", Rate: 10}
+	h.create(&lowVision)
+	h.create(&model.PersonDisability{PersonId: applies[0].person.Id, DisabilityId: lowVision.Id})
+
+	for group, expected := range map[string]string{"category": string(enum.Visual), "company": company.Name, "area": "ti"} {
+		var rates struct {
+			Data []model.AcceptanceRate `json:"data"`
+		}
+		h.request(http.MethodGet, "/reports/exact/employment/acceptance-rates?group_by="+group, nil, h.adminToken()).expect(http.StatusOK).decode(&rates)
+
+		if len(rates.Data) != 1 || rates.Data[0].Group != expected || rates.Data[0].Applications != 3 || *rates.Data[0].Rate != 0.3333 {
+			t.Fatalf("unexpected acceptance rates by %s: %+v", group, rates.Data)
+		}
+	}
+
+	var timeToHire struct {
+		Data model.TimeToHire `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/employment/time-to-hire", nil, h.adminToken()).expect(http.StatusOK).decode(&timeToHire)
+
+	if timeToHire.Data.Hires != 1 || timeToHire.Data.MedianDays == nil || *timeToHire.Data.MedianDays != 4 {
+		t.Fatalf("unexpected time to hire: %+v", timeToHire.Data)
+	}
+
+	var funnel struct {
+		Data []model.ApplyFunnelStage `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/employment/funnel", nil, h.adminToken()).expect(http.StatusOK).decode(&funnel)
+
+	if len(funnel.Data) != 3 || funnel.Data[0].Count != 3 || funnel.Data[1].Count != 2 || funnel.Data[2].Count != 1 || *funnel.Data[2].Rate != 0.3333 {
+		t.Fatalf("unexpected funnel: %+v", funnel.Data)
+	}
+
+	var empty struct {
+		Data model.TimeToHire `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/employment/time-to-hire?from=2025-01-01", nil, h.adminToken()).expect(http.StatusOK).decode(&empty)
+
+	if empty.Data.Hires != 0 || empty.Data.MedianDays != nil {
+		t.Fatalf("expected no hires in 2025, got %+v", empty.Data)
+	}
+
+	h.request(http.MethodGet, "/reports/employment/acceptance-rates?group_by=gender", nil, "").expect(http.StatusBadRequest).expectCode("1906")
+	h.request(http.MethodGet, "/reports/employment/funnel?from=2024-02-01&to=2024-01-01", nil, "").expect(http.StatusBadRequest).expectCode("1905")
+	h.request(http.MethodGet, "/reports/employment/funnel?to=someday", nil, "").expect(http.StatusBadRequest).expectCode("4906")
+}
+
+func TestGetDisabilityCrossTab(t *testing.T) {
+	h := newHarness(t)
+	birthDate := time.Now().AddDate(-30, 0, 0).Format(time.DateOnly)
+
+	young := h.createPerson()
+	h.db.Model(&young).Update("birth_date", time.Now().AddDate(-20, 0, 0).Format(time.DateOnly))
+
+	adult := h.createPerson()
+	h.db.Model(&adult).Updates(map[string]interface{}{"gender": enum.Male, "birth_date": birthDate})
+	h.db.Model(adult.Address).Update("neighborhood", "São Francisco")
+
+	other := h.createPerson()
+	h.db.Model(other.Address).Update("neighborhood", "sao francisco")
+
+	var body struct {
+		Data model.CrossTab `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/crosstab?rows=neighborhood&columns=gender", nil, h.adminToken()).expect(http.StatusOK).decode(&body)
+
+	crossTab := body.Data
+	if len(crossTab.Rows.Labels) != 2 || crossTab.Rows.Labels[0] != "Centro" || crossTab.Rows.Labels[1] != "São Francisco" {
+		t.Fatalf("expected the neighborhoods merged, got %+v", crossTab.Rows)
+	}
+
+	if len(crossTab.Columns.Labels) != 3 || crossTab.Columns.Labels[0] != string(enum.Male) {
+		t.Fatalf("expected every gender, got %+v", crossTab.Columns)
+	}
+
+	if crossTab.Values[0][1] != 1 || crossTab.Values[1][0] != 1 || crossTab.Values[1][1] != 1 ||
+		crossTab.RowTotals[1] != 2 || crossTab.ColumnTotals[1] != 2 || crossTab.Total != 3 {
+		t.Fatalf("unexpected cross-tab: %+v", crossTab)
+	}
+
+	var ages struct {
+		Data model.CrossTab `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/crosstab?rows=age_band&columns=category&neighborhood=sao%20francisco", nil, h.adminToken()).
+		expect(http.StatusOK).
+		decode(&ages)
+
+	bands := map[string]model.Count{}
+	for i, label := range ages.Data.Rows.Labels {
+		bands[label] = ages.Data.RowTotals[i]
+	}
+
+	if bands["25-34"] != 1 || bands["unknown"] != 1 || bands["18-24"] != 0 || ages.Data.Total != 2 {
+		t.Fatalf("unexpected age bands: %+v", ages.Data)
+	}
+
+	h.request(http.MethodGet, "/reports/crosstab?rows=income", nil, "").expect(http.StatusBadRequest).expectCode("1907")
+	h.request(http.MethodGet, "/reports/crosstab?rows=gender&columns=gender", nil, "").expect(http.StatusBadRequest).expectCode("1908")
+}
+
+func TestReportsSuppression(t *testing.T) {
+	h := newHarness(t)
+
+	var people []model.Person
+	for i := 0; i < 11; i++ {
+		people = append(people, h.createPerson())
+	}
+
+	for _, person := range people[:5] {
+		address := person.Address
+		address.Neighborhood = "Batel"
+		h.db.Save(address)
+	}
+
+	people[5].Gender = enum.Male
+	h.db.Save(&people[5])
+
+	var totals struct {
+		Data model.DisabilityTotals `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/disabilities/batel", nil, "").expect(http.StatusOK).decode(&totals)
+
+	if totals.Data[enum.Visual] != 5 || totals.Data[enum.Hearing] != 0 {
+		t.Fatalf("expected the counts of at least 5 to be shown, got %+v", totals.Data)
+	}
+
+	var body struct {
+		Data model.CrossTab `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/crosstab?rows=neighborhood&columns=gender", nil, "").expect(http.StatusOK).decode(&body)
+
+	// centro has 1 man and 5 women, hiding the man hides the women, then
+	// what the column and row totals would reveal
+	crossTab := body.Data
+	if crossTab.Rows.Labels[1] != "Centro" || !crossTab.Values[1][0].Suppressed() || !crossTab.Values[1][1].Suppressed() ||
+		!crossTab.Values[0][1].Suppressed() || crossTab.Values[0][0] != 0 || !crossTab.ColumnTotals[0].Suppressed() ||
+		!crossTab.RowTotals[0].Suppressed() || !crossTab.RowTotals[1].Suppressed() || crossTab.Total != 11 {
+		t.Fatalf("unexpected suppressed cross-tab: %+v", crossTab)
+	}
+
+	if !strings.Contains(string(h.request(http.MethodGet, "/reports/crosstab?rows=neighborhood&columns=gender", nil, "").body), `"values":[[0,null,0],[null,null,0]]`) {
+		t.Fatal("expected the suppressed counts to be sent as null")
+	}
+
+	h.request(http.MethodGet, "/reports/exact/crosstab?rows=neighborhood&columns=gender", nil, h.adminToken()).expect(http.StatusOK).decode(&body)
+
+	if body.Data.Values[1][0] != 1 || body.Data.Values[1][1] != 5 || body.Data.RowTotals[1] != 6 {
+		t.Fatalf("expected the exact counts for the admins, got %+v", body.Data)
+	}
+
+	company := h.createCompany()
+	h.createApply(h.createVacancy(company).Id, people[0])
+
+	var funnel struct {
+		Data []model.ApplyFunnelStage `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/employment/funnel", nil, "").expect(http.StatusOK).decode(&funnel)
+
+	if !funnel.Data[0].Count.Suppressed() || funnel.Data[0].Rate != nil || funnel.Data[1].Count != 0 {
+		t.Fatalf("expected the single apply to be suppressed, got %+v", funnel.Data)
+	}
+
+	h.request(http.MethodGet, "/reports/exact/employment/funnel", nil, h.personToken(people[0])).
+		expect(http.StatusBadRequest).
+		expectMessage("role don't have permission")
+}
+
+func TestReportFormats(t *testing.T) {
+	h := newHarness(t)
+
+	for i := 0; i < 6; i++ {
+		h.createPerson()
+	}
+
+	csv := h.request(http.MethodGet, "/reports/disabilities?format=csv", nil, "").expect(http.StatusOK)
+
+	if csv.header.Get("Content-Type") != "text/csv" || !strings.Contains(csv.header.Get("Content-Disposition"), `filename="disability-totals.csv"`) ||
+		!strings.HasPrefix(string(csv.body), "category,count\n") || !strings.Contains(string(csv.body), "\nvisual,6\n") {
+		t.Fatalf("unexpected csv report: %v %s", csv.header, csv.body)
+	}
+
+	// the suppressed counts are left empty
+	crossTab := h.requestWithHeaders(http.MethodGet, "/reports/crosstab", nil, "", map[string]string{"Accept": "text/csv"}).expect(http.StatusOK)
+
+	if lines := strings.Split(string(crossTab.body), "\n"); lines[0] != "category,male,female,other,total" || lines[1] != "visual,0,6,0,6" {
+		t.Fatalf("unexpected negotiated csv report: %s", crossTab.body)
+	}
+
+	xlsx := h.request(http.MethodGet, "/reports/employment/funnel?format=xlsx", nil, "").expect(http.StatusOK)
+
+	archive, err := zip.NewReader(bytes.NewReader(xlsx.body), int64(len(xlsx.body)))
+	if err != nil {
+		t.Fatalf("invalid xlsx report: %v", err)
+	}
+
+	for _, file := range archive.File {
+		if file.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+
+		sheet, _ := file.Open()
+		content, _ := io.ReadAll(sheet)
+
+		if !strings.Contains(string(content), `<t xml:space="preserve">stage</t>`) || !strings.Contains(string(content), `<c r="B2"><v>0</v></c>`) {
+			t.Fatalf("unexpected sheet: %s", content)
+		}
+	}
+
+	pdf := h.requestWithHeaders(http.MethodGet, "/reports/disabilities", nil, "", map[string]string{"Accept": "application/pdf", "Accept-Language": "pt-BR"}).
+		expect(http.StatusOK)
+
+	for _, tag := range []string{"%PDF-1.7", "/StructTreeRoot", "/MarkInfo <</Marked true>>", "/Lang <FEFF00700074002D00420052>", "/S /H1", "/S /TH", "/Scope /Column", "/S /Figure", "/Alt "} {
+		if !bytes.Contains(pdf.body, []byte(tag)) {
+			t.Fatalf("expected %q in the pdf report", tag)
+		}
+	}
+
+	h.request(http.MethodGet, "/reports/disabilities/centro", nil, "").expect(http.StatusOK).expectMessage("Disability totals by neighborhood: centro")
+
+	// the texts the spreadsheets would run as formulas are quoted
+	company := h.createCompany()
+	vacancy := h.createVacancy(company)
+	h.db.Model(&vacancy).Update("title", "=HYPERLINK(\"http://example.com\")")
+	h.createApply(vacancy.Id, h.createPerson())
+
+	applies := h.request(http.MethodGet, "/reports/exact/employment/applies?format=csv", nil, h.adminToken()).expect(http.StatusOK)
+
+	if !strings.Contains(string(applies.body), `,"'=HYPERLINK(""http://example.com"")",`) {
+		t.Fatalf("expected the formula quoted, got %s", applies.body)
+	}
+
+	h.request(http.MethodGet, "/reports/disabilities?format=docx", nil, "").expect(http.StatusBadRequest).expectCode("4907")
+}
+
+func TestReportTrends(t *testing.T) {
+	h := newHarness(t)
+	token := h.adminToken()
+	vacancyId := h.createVacancy(h.createCompany()).Id
+
+	registered := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	deleted := h.createPerson()
+	candidate := h.createPerson()
+
+	h.db.Model(&model.Person{}).Where("id IN ?", []int{deleted.Id, candidate.Id}).UpdateColumn("created_at", registered)
+	h.db.Model(&model.Person{}).Where("id = ?", deleted.Id).UpdateColumn("deleted_at", time.Date(2024, 1, 16, 12, 0, 0, 0, time.UTC))
+
+	h.createActivityAt("login", time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	h.createActivityAt("login", time.Date(2024, 1, 15, 13, 0, 0, 0, time.UTC))
+	h.createActivityAt("login", time.Date(2024, 1, 16, 12, 0, 0, 0, time.UTC))
+
+	apply := h.createApply(vacancyId, candidate)
+	h.db.Model(&apply).Updates(map[string]interface{}{
+		"status":     enum.VacancyApplyAccepted,
+		"created_at": time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+		"decided_at": time.Date(2024, 1, 16, 12, 0, 0, 0, time.UTC),
+	})
+
+	period := model.SnapshotRequest{From: "2024-01-14", To: "2024-01-16"}
+
+	h.request(http.MethodPost, "/reports/snapshots", period, h.personToken(candidate)).
+		expect(http.StatusBadRequest).
+		expectMessage("role don't have permission")
+
+	// taking the days again replaces their snapshots
+	for i := 0; i < 2; i++ {
+		var taken struct {
+			Data model.SnapshotResult `json:"data"`
+		}
+		h.request(http.MethodPost, "/reports/snapshots", period, token).expect(http.StatusOK).decode(&taken)
+
+		if taken.Data.Days != 3 {
+			t.Fatalf("expected 3 days snapshot, got %+v", taken.Data)
+		}
+	}
+
+	// the people deleted for good are kept in the days already snapshot
+	h.db.Unscoped().Delete(&model.PersonDisability{}, "person_id = ?", deleted.Id)
+	h.db.Unscoped().Delete(&model.Person{}, deleted.Id)
+	h.request(http.MethodPost, "/reports/snapshots", period, token).expect(http.StatusOK)
+
+	series := func(trend model.Trend, name string) []model.TrendPoint {
+		for _, s := range trend.Series {
+			if s.Name == name {
+				return s.Points
+			}
+		}
+
+		t.Fatalf("expected a %s series, got %+v", name, trend.Series)
+		return nil
+	}
+
+	var daily struct {
+		Data model.Trend `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/trends/disabilities?from=2024-01-01&to=2024-01-31&granularity=day", nil, token).expect(http.StatusOK).decode(&daily)
+
+	if points := series(daily.Data, string(enum.Visual)); len(points) != 3 || points[0].Label != "2024-01-14" ||
+		points[0].Count != 2 || points[1].Count != 2 || points[2].Count != 1 {
+		t.Fatalf("unexpected daily disability trend: %+v", points)
+	}
+
+	var monthly struct {
+		Data model.Trend `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/trends/disabilities?from=2024-01-01&to=2024-01-31", nil, token).expect(http.StatusOK).decode(&monthly)
+
+	if points := series(monthly.Data, string(enum.Visual)); len(points) != 1 || points[0].Label != "2024-01" || points[0].Count != 1 {
+		t.Fatalf("expected the people at the end of the month, got %+v", points)
+	}
+
+	var activities struct {
+		Data model.Trend `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/trends/activities?types=login&from=2024-01-01&to=2024-01-31", nil, token).expect(http.StatusOK).decode(&activities)
+
+	if points := series(activities.Data, "login"); len(points) != 1 || points[0].Count != 3 {
+		t.Fatalf("expected the activities of the month summed, got %+v", points)
+	}
+
+	var employment struct {
+		Data model.Trend `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/exact/trends/employment?from=2024-01-01&to=2024-01-31", nil, token).expect(http.StatusOK).decode(&employment)
+
+	for name, expected := range map[string]model.Count{"open_vacancies": 1, "applies": 1, "accepted": 1, "rejected": 0} {
+		if points := series(employment.Data, name); len(points) != 1 || points[0].Count != expected {
+			t.Fatalf("expected %d %s, got %+v", expected, name, points)
+		}
+	}
+
+	var public struct {
+		Data model.Trend `json:"data"`
+	}
+	h.request(http.MethodGet, "/reports/trends/employment?from=2024-01-01&to=2024-01-31", nil, "").expect(http.StatusOK).decode(&public)
+
+	if series(public.Data, "applies")[0].Count != model.SuppressedCount || series(public.Data, "open_vacancies")[0].Count != 1 {
+		t.Fatalf("expected the applies suppressed but not the open vacancies, got %+v", public.Data.Series)
+	}
+
+	res := h.request(http.MethodGet, "/reports/exact/trends/activities?types=login&from=2024-01-01&to=2024-01-31&format=csv", nil, token).expect(http.StatusOK)
+
+	if !strings.Contains(string(res.body), "activity_type,bucket,start,count\nlogin,2024-01,2024-01-01,3\n") {
+		t.Fatalf("unexpected trend CSV: %s", res.body)
+	}
+
+	today := time.Now().Format(time.DateOnly)
+	h.request(http.MethodPost, "/reports/snapshots", model.SnapshotRequest{From: "2024-01-16", To: "2024-01-14"}, token).expect(http.StatusBadRequest).expectCode("1909")
+	h.request(http.MethodPost, "/reports/snapshots", model.SnapshotRequest{From: "2022-01-01", To: "2024-01-01"}, token).expect(http.StatusBadRequest).expectCode("1910")
+	h.request(http.MethodPost, "/reports/snapshots", model.SnapshotRequest{From: today, To: today}, token).expect(http.StatusBadRequest).expectCode("1911")
+
+	// the activities older than the retention may be archived
+	h.create(&model.ActivityRetention{Type: "login", Days: 30})
+	h.request(http.MethodPost, "/reports/snapshots", period, token).expect(http.StatusBadRequest).expectCode("1912")
+	h.request(http.MethodGet, "/reports/trends/disabilities?granularity=hour", nil, "").expect(http.StatusBadRequest).expectCode("1902")
+}
@@ -2,11 +2,14 @@ package router
 
 import (
 	"cij_api/src/auth"
+	"cij_api/src/config"
 	"cij_api/src/controller"
+	"cij_api/src/geocoding"
 	"cij_api/src/middleware"
 	"cij_api/src/repo"
 	vacancy "cij_api/src/repo/vacancy"
 	"cij_api/src/service"
+	"cij_api/src/storage"
 	"fmt"
 
 	_ "cij_api/docs"
@@ -17,38 +20,46 @@ import (
 	"gorm.io/gorm"
 )
 
-func NewRouter(router *fiber.App, db *gorm.DB) *fiber.App {
+func NewRouter(router *fiber.App, db *gorm.DB, fileStorage storage.FileStorage, geocoder geocoding.Geocoder, loadConfig *config.Config) *fiber.App {
 	userRepo := repo.NewUserRepo(db)
 	activityRepo := repo.NewActivityRepo(db)
 
+	auditService := service.NewAuditService(repo.NewAuditRepo(db), userRepo)
+	auditController := controller.NewAuditController(auditService)
+
+	companyRepo := repo.NewCompanyRepo(db)
+
+	userConfigRepo := repo.NewUserConfigRepo(db)
+	configPresetRepo := repo.NewConfigPresetRepo(db)
+	configService := service.NewConfigService(userRepo, userConfigRepo, configPresetRepo, companyRepo, auditService)
+	configController := controller.NewConfigController(configService)
+
 	addressRepo := repo.NewAddressRepo(db)
-	addressService := service.NewAddressService(addressRepo)
+	addressService := service.NewAddressService(addressRepo, geocoder)
 
 	personDisabilityRepo := repo.NewPersonDisabilityRepo(db)
+	vacancyApplyRepo := vacancy.NewVacancyApplyRepo(db)
 
 	personRepo := repo.NewPersonRepo(db)
-	personService := service.NewPersonService(personRepo, userRepo, addressRepo, personDisabilityRepo, activityRepo)
-	personController := controller.NewPersonController(personService)
+	personService := service.NewPersonService(personRepo, userRepo, addressRepo, personDisabilityRepo, activityRepo, addressService, configService, auditService)
+	curriculumService := service.NewCurriculumService(personRepo, companyRepo, vacancyApplyRepo, activityRepo, fileStorage, auditService)
+	personController := controller.NewPersonController(personService, curriculumService)
 
-	companyRepo := repo.NewCompanyRepo(db)
-	companyService := service.NewCompanyService(companyRepo, userRepo, addressRepo, activityRepo)
+	companyService := service.NewCompanyService(companyRepo, userRepo, addressRepo, activityRepo, addressService, configService, auditService)
 	companyController := controller.NewCompanyController(companyService)
 
 	newsRepo := repo.NewNewsRepo(db)
-	newsService := service.NewNewsService(newsRepo)
+	newsService := service.NewNewsService(newsRepo, userRepo, fileStorage, auditService)
 	newsController := controller.NewNewsController(newsService)
 
 	disabilityRepo := repo.NewDisabilityRepo(db)
-	disabilityService := service.NewDisabilityService(disabilityRepo)
+	disabilityService := service.NewDisabilityService(disabilityRepo, auditService)
 	disabilityController := controller.NewDisabilityController(disabilityService)
 
-	configService := service.NewConfigService(userRepo)
-	configController := controller.NewConfigController(configService)
-
-	authService := auth.NewAuthService(userRepo, activityRepo)
+	authService := auth.NewAuthService(userRepo, activityRepo, auditService)
 	authController := auth.NewAuthController(*authService, personService, companyService, addressService, configService)
 
-	activityService := service.NewActivityService(activityRepo)
+	activityService := service.NewActivityService(activityRepo, fileStorage, auditService)
 	activityController := controller.NewActivityController(activityService)
 
 	vacancyRepo := vacancy.NewVacancyRepo(db)
@@ -56,22 +67,35 @@ func NewRouter(router *fiber.App, db *gorm.DB) *fiber.App {
 	vacancyRequirementsRepo := vacancy.NewRequirementsRepo(db)
 	vacancyResponsabilitiesRepo := vacancy.NewResponsabilitiesRepo(db)
 	vacancyDisabilitiesRepo := vacancy.NewVacancyDisabilityRepo(db)
-	vacancyApplyRepo := vacancy.NewVacancyApplyRepo(db)
 
 	vacancyService := service.NewVacancyService(
 		vacancyRepo, vacancySkillsRepo, vacancyRequirementsRepo,
 		vacancyResponsabilitiesRepo, vacancyDisabilitiesRepo, vacancyApplyRepo, personRepo,
-		personDisabilityRepo,
+		personDisabilityRepo, auditService,
 	)
 	vacancyController := controller.NewVacancyController(vacancyService, companyService)
 
-	reportsService := service.NewReportsService(personDisabilityRepo, activityRepo)
-	reportsController := controller.NewReportsController(reportsService)
+	employmentReportRepo := repo.NewEmploymentReportRepo(db)
+	reportsService := service.NewReportsService(personDisabilityRepo, activityRepo, employmentReportRepo)
+	reportSnapshotService := service.NewReportSnapshotService(repo.NewReportSnapshotRepo(db), personDisabilityRepo, activityRepo, employmentReportRepo, auditService)
+	reportsController := controller.NewReportsController(reportsService, reportSnapshotService, service.PublicReportsPrivacy(loadConfig))
+	exactReportsController := controller.NewReportsController(reportsService, reportSnapshotService, service.ExactReports)
+
+	openDataService := service.NewOpenDataService(reportsService, reportSnapshotService, service.PublishedReportsPrivacy(loadConfig))
+	openDataController := controller.NewOpenDataController(openDataService)
 
 	router.Get("/health", HealthCheck)
 
 	router.Get("/swagger/*", swagger.HandlerDefault)
 
+	if localStorage, ok := fileStorage.(*storage.LocalStorage); ok {
+		filesController := controller.NewFilesController(localStorage)
+		router.Get("/files/*", filesController.GetFile)
+	}
+
+	router.Use(middleware.RequestId)
+	router.Use(middleware.Language)
+
 	router.Post("/login", authController.Authenticate)
 	router.Post("/get-user-data", authController.GetUserData)
 
@@ -80,6 +104,7 @@ func NewRouter(router *fiber.App, db *gorm.DB) *fiber.App {
 		api.Get("/", personController.ListPeople)
 		api.Get("/:id", personController.GetPerson)
 		api.Post("/", personController.CreatePerson)
+		api.Get("/:id/curriculum", middleware.AuthAny, personController.DownloadCurriculum)
 
 		api.Use(middleware.AuthUser)
 		api.Put("/:id", personController.UpdatePerson)
@@ -102,18 +127,73 @@ func NewRouter(router *fiber.App, db *gorm.DB) *fiber.App {
 
 	api = router.Group("/news")
 	{
-		api.Get("/", newsController.ListNews)
+		api.Get("/", middleware.AuthOptional, newsController.ListNews)
+		api.Get("/feed.rss", newsController.GetNewsRSS)
+		api.Get("/feed.atom", newsController.GetNewsAtom)
+		api.Get("/categories", newsController.ListNewsCategories)
+		api.Get("/categories/:slug/feed.rss", newsController.GetNewsRSS)
+		api.Get("/categories/:slug/feed.atom", newsController.GetNewsAtom)
+		api.Get("/tags", newsController.ListTags)
+		api.Get("/by-slug/:slug", middleware.AuthOptional, newsController.GetNewsBySlug)
+		api.Get("/:id", middleware.AuthOptional, newsController.GetNews)
+
+		api.Use(middleware.AuthAdmin)
 		api.Post("/", newsController.CreateNews)
+		api.Post("/categories", newsController.CreateNewsCategory)
+		api.Put("/:id", newsController.UpdateNews)
+		api.Delete("/:id", newsController.DeleteNews)
 	}
 
 	api = router.Group("/config")
 	{
-		api.Put("/:email", configController.UpdateUserConfig)
+		api.Get("/palette", configController.GeneratePalette)
+		api.Get("/presets", configController.ListConfigPresets)
+		api.Get("/presets/:id", configController.GetConfigPreset)
+
+		api.Use(middleware.AuthAdmin)
+		api.Post("/presets", configController.CreateConfigPreset)
+		api.Put("/presets/:id", configController.UpdateConfigPreset)
+		api.Delete("/presets/:id", configController.DeleteConfigPreset)
+	}
+
+	api = router.Group("/me")
+	{
+		api.Use(middleware.AuthAny)
+		api.Get("/config", configController.GetUserConfig)
+		api.Put("/config", configController.UpdateUserConfig)
+		api.Patch("/config", configController.PatchUserConfig)
+		api.Post("/config/reset", configController.ResetUserConfig)
+		api.Get("/config/versions", configController.ListUserConfigVersions)
+		api.Post("/config/versions/:version/rollback", configController.RollbackUserConfig)
+		api.Post("/config/presets/:slug/apply", configController.ApplyConfigPreset)
+		api.Get("/company/config", middleware.AuthCompany, configController.GetCompanyConfigPreset)
+		api.Put("/company/config", middleware.AuthCompany, configController.UpdateCompanyConfigPreset)
+		api.Delete("/company/config", middleware.AuthCompany, configController.DeleteCompanyConfigPreset)
+	}
+
+	api = router.Group("/users")
+	{
+		api.Use(middleware.AuthAdmin)
+		api.Get("/:id/config", configController.GetUserConfig)
+		api.Put("/:id/config", configController.UpdateUserConfig)
+		api.Patch("/:id/config", configController.PatchUserConfig)
+		api.Post("/:id/config/reset", configController.ResetUserConfig)
+		api.Get("/:id/config/versions", configController.ListUserConfigVersions)
+		api.Post("/:id/config/versions/:version/rollback", configController.RollbackUserConfig)
+		api.Post("/:id/config/presets/:slug/apply", configController.ApplyConfigPreset)
 	}
 
 	api = router.Group("/disabilities")
 	{
+		api.Get("/", disabilityController.ListDisabilities)
+		api.Get("/categories", disabilityController.ListDisabilityCategories)
+		api.Get("/:id", disabilityController.GetDisability)
+
+		api.Use(middleware.AuthAdmin)
 		api.Post("/", disabilityController.CreateDisability)
+		api.Post("/categories", disabilityController.CreateDisabilityCategory)
+		api.Put("/:id", disabilityController.UpdateDisability)
+		api.Delete("/:id", disabilityController.DeleteDisability)
 	}
 
 	api = router.Group("/activities")
@@ -122,11 +202,15 @@ func NewRouter(router *fiber.App, db *gorm.DB) *fiber.App {
 
 		api.Use(middleware.AuthAdmin)
 		api.Post("/", activityController.CreateActivity)
+		api.Get("/export", activityController.ExportActivities)
+		api.Get("/retentions", activityController.ListActivityRetentions)
+		api.Put("/retentions/:type", activityController.SaveActivityRetention)
+		api.Delete("/retentions/:type", activityController.DeleteActivityRetention)
 	}
 
 	api = router.Group("/vacancies")
 	{
-		api.Get("/", vacancyController.ListVacancies)
+		api.Get("/", middleware.AuthOptional, vacancyController.ListVacancies)
 		api.Get("/:id", vacancyController.GetVacancyById)
 		api.Post("/apply", vacancyController.CandidateApply)
 
@@ -139,11 +223,36 @@ func NewRouter(router *fiber.App, db *gorm.DB) *fiber.App {
 		api.Patch("/apply/:id", vacancyController.UpdateVacancyApplyStatus)
 	}
 
+	api = router.Group("/audit-events")
+	{
+		api.Use(middleware.AuthAdmin)
+		api.Get("/", auditController.ListAuditEvents)
+		api.Get("/:id", auditController.GetAuditEvent)
+	}
+
 	api = router.Group("/reports")
 	{
-		api.Get("/disabilities", reportsController.GetDisabilityTotals)
-		api.Get("/disabilities/:neighborhood", reportsController.GetDisabilityTotalsByNeighborhood)
-		api.Get("/activities/:type/:period", reportsController.CountActivitiesByPeriod)
+		reportsRoutes(api, reportsController)
+	}
+
+	// the same reports with the exact counts
+	api = router.Group("/reports/exact")
+	{
+		api.Use(middleware.AuthAdmin)
+		reportsRoutes(api, exactReportsController)
+	}
+
+	api = router.Group("/open-data")
+	{
+		api.Get("/", openDataController.ListDatasets)
+		api.Get("/:id", openDataController.GetDataset)
+		api.Get("/:id/dictionary", openDataController.GetDatasetDictionary)
+	}
+
+	api = router.Group("/reports/snapshots")
+	{
+		api.Use(middleware.AuthAdmin)
+		api.Post("/", exactReportsController.TakeSnapshots)
 	}
 
 	basePath := getBasePath()
@@ -160,6 +269,22 @@ func NewRouter(router *fiber.App, db *gorm.DB) *fiber.App {
 	return router
 }
 
+func reportsRoutes(api fiber.Router, reportsController *controller.ReportsController) {
+	api.Get("/disabilities", reportsController.GetDisabilityTotals)
+	api.Get("/disabilities/:neighborhood", reportsController.GetDisabilityTotalsByNeighborhood)
+	api.Get("/crosstab", reportsController.GetDisabilityCrossTab)
+	api.Get("/activities", reportsController.GetActivityReport)
+	api.Get("/activities/:type/:period", reportsController.CountActivitiesByPeriod)
+	api.Get("/employment/vacancies", reportsController.CountVacanciesByCategory)
+	api.Get("/employment/applies", reportsController.CountAppliesByVacancy)
+	api.Get("/employment/acceptance-rates", reportsController.GetAcceptanceRates)
+	api.Get("/employment/time-to-hire", reportsController.GetTimeToHire)
+	api.Get("/employment/funnel", reportsController.GetApplyFunnel)
+	api.Get("/trends/disabilities", reportsController.GetDisabilityTrend)
+	api.Get("/trends/activities", reportsController.GetActivityTrend)
+	api.Get("/trends/employment", reportsController.GetEmploymentTrend)
+}
+
 func getBasePath() string {
 	return "http://localhost:3040"
 }
@@ -0,0 +1,29 @@
+package router_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHealthCheck(t *testing.T) {
+	h := newHarness(t)
+
+	var body map[string]string
+	h.request(http.MethodGet, "/health", nil, "").expect(http.StatusOK).decode(&body)
+
+	if body["data"] != "Server is up and running" {
+		t.Fatalf("unexpected health body: %v", body)
+	}
+}
+
+func TestSwagger(t *testing.T) {
+	h := newHarness(t)
+
+	h.request(http.MethodGet, "/swagger/doc.json", nil, "").expect(http.StatusOK)
+}
+
+func TestUnknownRoute(t *testing.T) {
+	h := newHarness(t)
+
+	h.request(http.MethodGet, "/unknown", nil, "").expect(http.StatusNotFound)
+}
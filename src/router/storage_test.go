@@ -0,0 +1,84 @@
+package router_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeStorage keeps the uploaded files in memory and serves them back over
+// HTTP, so uploads and config downloads never leave the test process.
+type fakeStorage struct {
+	server *httptest.Server
+	mutex  sync.Mutex
+	files  map[string][]byte
+}
+
+func newFakeStorage() *fakeStorage {
+	storage := &fakeStorage{
+		files: map[string][]byte{},
+	}
+
+	storage.server = httptest.NewServer(http.HandlerFunc(storage.handle))
+
+	return storage
+}
+
+func (s *fakeStorage) handle(w http.ResponseWriter, r *http.Request) {
+	content, ok := s.file(strings.TrimPrefix(r.URL.Path, "/files/"))
+	if r.Method != http.MethodGet || !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Write(content)
+}
+
+func (s *fakeStorage) Put(key string, content io.Reader) (string, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	s.files[key] = data
+	s.mutex.Unlock()
+
+	return s.server.URL + "/files/" + key, nil
+}
+
+func (s *fakeStorage) Get(key string) (io.ReadCloser, error) {
+	content, ok := s.file(key)
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (s *fakeStorage) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.files, key)
+
+	return nil
+}
+
+func (s *fakeStorage) SignedURL(key string, expiresIn time.Duration) (string, error) {
+	return s.server.URL + "/files/" + key, nil
+}
+
+func (s *fakeStorage) file(key string) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	content, ok := s.files[key]
+
+	return content, ok
+}
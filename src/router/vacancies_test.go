@@ -0,0 +1,387 @@
+package router_test
+
+import (
+	"cij_api/src/enum"
+	vacancy "cij_api/src/model/vacancy"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestListVacancies(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	other := h.createCompany()
+	person := h.createPerson()
+
+	applied := h.createVacancy(company)
+	h.createVacancy(company)
+	h.createVacancy(other)
+	h.createApply(applied.Id, person)
+
+	tests := []struct {
+		name  string
+		query string
+		total int
+	}{
+		{"all", "", 3},
+		{"per page", "?per_page=2", 2},
+		{"company", fmt.Sprintf("?company_id=%d", company.Id), 2},
+		{"disability", fmt.Sprintf("?disability_id=%d", h.disability().Id), 3},
+		{"unknown disability", "?disability_id=9999", 0},
+		{"candidate", fmt.Sprintf("?candidate_id=%d", person.Id), 1},
+		{"area", "?area=ti", 3},
+		{"contract type", "?contract_type=pj", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h.t = t
+
+			var body struct {
+				Data []vacancy.VacancySimpleResponse `json:"data"`
+			}
+
+			h.request(http.MethodGet, "/vacancies"+test.query, nil, "").expect(http.StatusOK).decode(&body)
+
+			if len(body.Data) != test.total {
+				t.Fatalf("expected %d vacancies, got %d", test.total, len(body.Data))
+			}
+		})
+	}
+}
+
+func TestListVacanciesByDistance(t *testing.T) {
+	h := newHarness(t)
+	person := h.createPerson()
+	other := h.createPerson()
+	token := h.personToken(person)
+
+	near := h.createCompany()
+	nearby := h.createCompany()
+	far := h.createCompany()
+	unlocated := h.createCompany()
+	antimeridian := h.createCompany()
+
+	h.locate(*near.AddressId, -26.4856, -49.0700)
+	h.locate(*nearby.AddressId, -26.4731, -49.0021)
+	h.locate(*far.AddressId, -26.3045, -48.8487)
+	h.locate(*antimeridian.AddressId, -16.5000, 179.9900)
+	h.locate(*person.AddressId, -26.4856, -49.0700)
+
+	farVacancy := h.createVacancy(far)
+	nearbyVacancy := h.createVacancy(nearby)
+	nearVacancy := h.createVacancy(near)
+	h.createVacancy(unlocated)
+	antimeridianVacancy := h.createVacancy(antimeridian)
+
+	tests := []struct {
+		name      string
+		query     string
+		token     string
+		vacancies []int
+	}{
+		{"point", "?lat=-26.4856&lng=-49.0700", "", []int{nearVacancy.Id, nearbyVacancy.Id}},
+		{"radius", "?lat=-26.4856&lng=-49.0700&radius_km=50", "", []int{nearVacancy.Id, nearbyVacancy.Id, farVacancy.Id}},
+		{"small radius", "?lat=-26.4856&lng=-49.0700&radius_km=5", "", []int{nearVacancy.Id}},
+		{"per page", "?lat=-26.4856&lng=-49.0700&radius_km=50&per_page=2", "", []int{nearVacancy.Id, nearbyVacancy.Id}},
+		{"near the person", fmt.Sprintf("?near_person_id=%d", person.Id), token, []int{nearVacancy.Id, nearbyVacancy.Id}},
+		{"near a person as admin", fmt.Sprintf("?near_person_id=%d&radius_km=5", person.Id), h.adminToken(), []int{nearVacancy.Id}},
+		{"across the antimeridian", "?lat=-16.5000&lng=-179.9900&radius_km=5", "", []int{antimeridianVacancy.Id}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h.t = t
+
+			var body struct {
+				Data []vacancy.VacancySimpleResponse `json:"data"`
+			}
+
+			h.request(http.MethodGet, "/vacancies"+test.query, nil, test.token).expect(http.StatusOK).decode(&body)
+
+			if len(body.Data) != len(test.vacancies) {
+				t.Fatalf("expected %d vacancies, got %d", len(test.vacancies), len(body.Data))
+			}
+
+			for i, vacancy := range body.Data {
+				if vacancy.Id != test.vacancies[i] || vacancy.DistanceKm == nil {
+					t.Fatalf("expected the vacancy %d with its distance at %d, got %+v", test.vacancies[i], i, vacancy)
+				}
+			}
+		})
+	}
+
+	h.t = t
+
+	var body struct {
+		Data []vacancy.VacancySimpleResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, "/vacancies?lat=-26.4856&lng=-49.0700&radius_km=10", nil, "").expect(http.StatusOK).decode(&body)
+
+	if distance := *body.Data[1].DistanceKm; distance < 6 || distance > 8 {
+		t.Fatalf("expected the nearby vacancy about 7 km away, got %v", distance)
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/vacancies?near_person_id=%d", person.Id), nil, "").
+		expect(http.StatusBadRequest).expectMessage("role don't have permission")
+	h.request(http.MethodGet, fmt.Sprintf("/vacancies?near_person_id=%d", other.Id), nil, token).
+		expect(http.StatusBadRequest).expectCode("11007")
+	h.request(http.MethodGet, fmt.Sprintf("/vacancies?near_person_id=%d", other.Id), nil, h.personToken(other)).
+		expect(http.StatusBadRequest).expectCode("11008")
+
+	errors := []struct {
+		query string
+		code  string
+	}{
+		{"?lat=abc&lng=-49.07", "41001"},
+		{"?near_person_id=abc", "41002"},
+		{"?lat=-26.48&lng=-49.07&radius_km=0", "11001"},
+		{fmt.Sprintf("?near_person_id=%d&lat=-26.48&lng=-49.07", person.Id), "11002"},
+		{"?lat=-26.48", "11003"},
+		{"?lat=-126.48&lng=-49.07", "11004"},
+		{"?radius_km=10", "11005"},
+	}
+
+	for _, test := range errors {
+		h.request(http.MethodGet, "/vacancies"+test.query, nil, h.adminToken()).expect(http.StatusBadRequest).expectCode(test.code)
+	}
+}
+
+func TestGetVacancy(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	person := h.createPerson()
+	created := h.createVacancy(company)
+	h.createApply(created.Id, person)
+
+	var body struct {
+		Data vacancy.VacancyResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/vacancies/%d?candidate_id=%d", created.Id, person.Id), nil, "").
+		expect(http.StatusOK).
+		decode(&body)
+
+	if body.Data.Code != created.Code || body.Data.Company != company.Name {
+		t.Fatalf("unexpected vacancy: %+v", body.Data)
+	}
+
+	if len(body.Data.Skills) != 1 || len(body.Data.Requirements) != 1 || len(body.Data.Responsabilities) != 1 || len(body.Data.Disabilities) != 1 {
+		t.Fatalf("expected the vacancy details: %+v", body.Data)
+	}
+
+	if !body.Data.CandidateAlreadyApplied {
+		t.Fatal("expected the candidate to have already applied")
+	}
+
+	h.request(http.MethodGet, "/vacancies/9999", nil, "").expect(http.StatusNotFound).expectMessage("failed to get the vacancy")
+}
+
+func TestCreateVacancy(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	request := h.vacancyRequest(company)
+
+	h.request(http.MethodPost, "/vacancies", request, h.companyToken(company)).
+		expect(http.StatusCreated).
+		expectMessage("vacancy created successfully")
+
+	var created vacancy.Vacancy
+	if err := h.db.Where("code = ?", request.Code).First(&created).Error; err != nil {
+		t.Fatalf("vacancy not created: %v", err)
+	}
+
+	var skills int64
+	h.db.Model(&vacancy.VacancySkill{}).Where("vacancy_id = ?", created.Id).Count(&skills)
+
+	if skills != 1 {
+		t.Fatalf("expected the vacancy skills, got %d", skills)
+	}
+}
+
+func TestCreateVacancyErrors(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	token := h.companyToken(company)
+
+	invalid := func(change func(request *vacancy.VacancyRequest)) vacancy.VacancyRequest {
+		request := h.vacancyRequest(company)
+		change(&request)
+
+		return request
+	}
+
+	tests := []struct {
+		name    string
+		request vacancy.VacancyRequest
+		message string
+	}{
+		{"missing code", invalid(func(r *vacancy.VacancyRequest) { r.Code = "" }), "code is required"},
+		{"missing title", invalid(func(r *vacancy.VacancyRequest) { r.Title = "" }), "title is required"},
+		{"missing area", invalid(func(r *vacancy.VacancyRequest) { r.Area = "" }), "area is required"},
+		{"missing disabilities", invalid(func(r *vacancy.VacancyRequest) { r.Disabilities = nil }), "at least one disability is required"},
+		{"missing skills", invalid(func(r *vacancy.VacancyRequest) { r.Skills = nil }), "at least one skill is required"},
+		{"invalid requirement", invalid(func(r *vacancy.VacancyRequest) { r.Requirements[0].Type = "optional" }), "invalid requirement type. valid values are: 'desirable', 'obligatory'"},
+		{"invalid contract", invalid(func(r *vacancy.VacancyRequest) { r.ContractType = "freelance" }), "invalid contract type. valid values are: 'clt', 'pj', 'trainee'"},
+		{"missing company", invalid(func(r *vacancy.VacancyRequest) { r.CompanyId = 0 }), "company ID is required"},
+		{"unknown company", invalid(func(r *vacancy.VacancyRequest) { r.CompanyId = 9999 }), "company not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h.t = t
+
+			h.request(http.MethodPost, "/vacancies", test.request, token).
+				expect(http.StatusBadRequest).
+				expectMessage(test.message)
+		})
+	}
+
+	h.t = t
+	h.request(http.MethodPost, "/vacancies", []byte("{"), token).
+		expect(http.StatusBadRequest).
+		expectMessage("failed to parse the request body")
+}
+
+func TestUpdateVacancy(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	token := h.companyToken(company)
+	created := h.createVacancy(company)
+
+	request := h.vacancyRequest(company)
+	request.Title = "Cientista de dados"
+
+	h.request(http.MethodPut, fmt.Sprintf("/vacancies/%d", created.Id), request, token).expect(http.StatusOK)
+
+	var updated vacancy.Vacancy
+	h.db.First(&updated, created.Id)
+
+	if updated.Title != "Cientista de dados" || updated.ContractType != enum.PJ {
+		t.Fatalf("unexpected vacancy: %+v", updated)
+	}
+
+	var skills []vacancy.VacancySkill
+	h.db.Where("vacancy_id = ?", created.Id).Find(&skills)
+
+	if len(skills) != 1 || skills[0].Skill != "SQL" {
+		t.Fatalf("expected the skills to be replaced: %+v", skills)
+	}
+
+	h.request(http.MethodPut, "/vacancies/9999", request, token).expect(http.StatusBadRequest).expectCode("21007")
+
+	request.Title = ""
+	h.request(http.MethodPut, fmt.Sprintf("/vacancies/%d", created.Id), request, token).
+		expect(http.StatusBadRequest).
+		expectMessage("title is required")
+}
+
+func TestDeleteVacancy(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	token := h.companyToken(company)
+	created := h.createVacancy(company)
+	h.createApply(created.Id, h.createPerson())
+
+	h.request(http.MethodDelete, fmt.Sprintf("/vacancies/%d", created.Id), nil, token).expect(http.StatusOK)
+
+	var applies int64
+	h.db.Model(&vacancy.VacancyApply{}).Where("vacancy_id = ?", created.Id).Count(&applies)
+
+	if applies != 0 {
+		t.Fatalf("expected the applies to be deleted, got %d", applies)
+	}
+
+	h.request(http.MethodDelete, fmt.Sprintf("/vacancies/%d", created.Id), nil, token).
+		expect(http.StatusBadRequest).
+		expectCode("21007")
+}
+
+func TestCandidateApply(t *testing.T) {
+	h := newHarness(t)
+	created := h.createVacancy(h.createCompany())
+	person := h.createPerson()
+
+	request := vacancy.VacancyApplyRequest{VacancyId: created.Id, CandidateId: person.Id}
+
+	h.request(http.MethodPost, "/vacancies/apply", request, "").
+		expect(http.StatusOK).
+		expectMessage("candidate applied to the vacancy successfully")
+
+	h.request(http.MethodPost, "/vacancies/apply", request, "").expect(http.StatusBadRequest).expectCode("21013")
+
+	request.VacancyId = 9999
+	h.request(http.MethodPost, "/vacancies/apply", request, "").expect(http.StatusBadRequest).expectCode("21010")
+
+	h.request(http.MethodPost, "/vacancies/apply", []byte("{"), "").expect(http.StatusBadRequest)
+}
+
+func TestListVacancyApplies(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	created := h.createVacancy(company)
+	person := h.createPerson()
+	apply := h.createApply(created.Id, person)
+
+	var body struct {
+		Data []vacancy.VacancyApplyResponse `json:"data"`
+	}
+
+	h.request(http.MethodGet, fmt.Sprintf("/vacancies/apply/%d", created.Id), nil, h.companyToken(company)).
+		expect(http.StatusOK).
+		decode(&body)
+
+	if len(body.Data) != 1 || body.Data[0].Id != apply.Id || body.Data[0].Candidate.Cpf != person.Cpf {
+		t.Fatalf("unexpected applies: %+v", body.Data)
+	}
+
+	if len(body.Data[0].Candidate.Disabilities) != 1 {
+		t.Fatalf("expected the candidate disabilities: %+v", body.Data[0].Candidate)
+	}
+}
+
+func TestUpdateVacancyApplyStatus(t *testing.T) {
+	h := newHarness(t)
+	company := h.createCompany()
+	token := h.companyToken(company)
+	apply := h.createApply(h.createVacancy(company).Id, h.createPerson())
+
+	h.request(http.MethodPatch, fmt.Sprintf("/vacancies/apply/%d?status=accepted", apply.Id), nil, token).
+		expect(http.StatusOK).
+		expectMessage("vacancy apply status updated successfully")
+
+	var updated vacancy.VacancyApply
+	h.db.First(&updated, apply.Id)
+
+	if updated.Status != enum.VacancyApplyAccepted || updated.CreatedAt == nil || updated.DecidedAt == nil {
+		t.Fatalf("expected the apply to be accepted with its dates, got %+v", updated)
+	}
+
+	h.request(http.MethodPatch, fmt.Sprintf("/vacancies/apply/%d?status=hired", apply.Id), nil, token).
+		expect(http.StatusBadRequest)
+}
+
+func TestVacanciesAuthorization(t *testing.T) {
+	h := newHarness(t)
+	personToken := h.personToken(h.createPerson())
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/vacancies"},
+		{http.MethodPut, "/vacancies/1"},
+		{http.MethodDelete, "/vacancies/1"},
+		{http.MethodGet, "/vacancies/apply/1"},
+		{http.MethodPatch, "/vacancies/apply/1"},
+	}
+
+	for _, route := range routes {
+		h.request(route.method, route.path, nil, "").expect(http.StatusBadRequest).expectMessage("token not found")
+		h.request(route.method, route.path, nil, personToken).expect(http.StatusBadRequest).expectMessage("role don't have permission")
+	}
+
+	h.request(http.MethodPost, "/vacancies", nil, h.adminToken()).expect(http.StatusBadRequest).expectMessage("failed to parse the request body")
+}
@@ -1,35 +1,72 @@
 package service
 
 import (
+	"bytes"
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/repo"
+	"cij_api/src/storage"
 	"cij_api/src/utils"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
 )
 
+// activityArchiveBatch is the maximum number of activities in an archive file.
+const activityArchiveBatch = 5000
+
+var activityTypePattern = regexp.MustCompile("^[a-z0-9]+([_-][a-z0-9]+)*$")
+
 type ActivityService interface {
-	CreateActivity(activity *model.Activity) utils.Error
+	CreateActivity(activity *model.Activity, actor model.AuditActor) utils.Error
 	GetActivitiesByTypeAndPeriod(activityType string, startDate int64, endDate int64) ([]model.ActivityResponse, utils.Error)
+	ExportActivities(filter model.ActivityExportFilter, each func(activity model.ActivityResponse) error) utils.Error
+	ListActivityRetentions() ([]model.ActivityRetention, utils.Error)
+	SaveActivityRetention(activityType string, retentionRequest model.ActivityRetentionRequest, actor model.AuditActor) (model.ActivityRetention, utils.Error)
+	DeleteActivityRetention(activityType string, actor model.AuditActor) (model.ActivityRetention, utils.Error)
+	ArchiveExpiredActivities(actor model.AuditActor) ([]model.ActivityArchive, utils.Error)
 }
 
 type activityService struct {
 	activityRepo repo.ActivityRepo
+	fileStorage  storage.FileStorage
+	auditService AuditService
 }
 
-func NewActivityService(activityRepo repo.ActivityRepo) ActivityService {
+func NewActivityService(activityRepo repo.ActivityRepo, fileStorage storage.FileStorage, auditService AuditService) ActivityService {
 	return &activityService{
 		activityRepo: activityRepo,
+		fileStorage:  fileStorage,
+		auditService: auditService,
 	}
 }
 
-func (a *activityService) CreateActivity(activity *model.Activity) utils.Error {
-	return a.activityRepo.CreateActivity(activity)
+func activityServiceError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ServiceErrorCode, utils.ActivityErrorType, code)
+
+	return utils.NewError(message, errorCode)
 }
 
-func (a *activityService) GetActivitiesByTypeAndPeriod(activityType string, startDate int64, endDate int64) ([]model.ActivityResponse, utils.Error) {
-	startDateStr := utils.GetFormattedDate(startDate)
-	endDateStr := utils.GetFormattedDate(endDate)
+func activityValidationError(message string, code string, fields []model.Field) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.ActivityErrorType, code)
+
+	return utils.NewErrorWithFields(message, errorCode, fields)
+}
+
+func (a *activityService) CreateActivity(activity *model.Activity, actor model.AuditActor) utils.Error {
+	if err := a.activityRepo.CreateActivity(activity); err.Code != "" {
+		return err
+	}
+
+	a.auditService.Record(actor, enum.AuditCreate, enum.AuditActivity, int(activity.ID), nil, activity.ToResponse())
 
-	activities, err := a.activityRepo.GetActivitiesByTypeAndPeriod(activityType, startDateStr, endDateStr)
+	return utils.Error{}
+}
+
+func (a *activityService) GetActivitiesByTypeAndPeriod(activityType string, startDate int64, endDate int64) ([]model.ActivityResponse, utils.Error) {
+	activities, err := a.activityRepo.GetActivitiesByTypeAndPeriod(activityType, time.Unix(startDate, 0), time.Unix(endDate, 0))
 	if err.Code != "" {
 		return nil, err
 	}
@@ -42,3 +79,158 @@ func (a *activityService) GetActivitiesByTypeAndPeriod(activityType string, star
 
 	return activitiesResponse, utils.Error{}
 }
+
+// ExportActivities calls each for the activities of the period, oldest first.
+func (a *activityService) ExportActivities(filter model.ActivityExportFilter, each func(activity model.ActivityResponse) error) utils.Error {
+	return a.activityRepo.StreamActivities(filter, func(activity model.Activity) error {
+		return each(*activity.ToResponse())
+	})
+}
+
+func (a *activityService) ListActivityRetentions() ([]model.ActivityRetention, utils.Error) {
+	retentions, err := a.activityRepo.ListActivityRetentions()
+	if err.Code != "" {
+		return []model.ActivityRetention{}, err
+	}
+
+	return retentions, utils.Error{}
+}
+
+// SaveActivityRetention creates or replaces the retention of the type.
+func (a *activityService) SaveActivityRetention(activityType string, retentionRequest model.ActivityRetentionRequest, actor model.AuditActor) (model.ActivityRetention, utils.Error) {
+	if !activityTypePattern.MatchString(activityType) || len(activityType) > 100 {
+		return model.ActivityRetention{}, activityValidationError("invalid activity type", "01", []model.Field{
+			{Name: "type", Value: activityType},
+		})
+	}
+
+	if retentionRequest.Days < 1 {
+		return model.ActivityRetention{}, activityValidationError("the retention must be of at least one day", "02", []model.Field{
+			{Name: "days", Value: fmt.Sprint(retentionRequest.Days)},
+		})
+	}
+
+	retention, err := a.activityRepo.GetActivityRetention(activityType)
+	if err.Code != "" {
+		return model.ActivityRetention{}, err
+	}
+
+	before := retention
+	action := enum.AuditUpdate
+
+	if retention.Id == 0 {
+		action = enum.AuditCreate
+		retention.Type = activityType
+	}
+
+	retention.Days = retentionRequest.Days
+
+	if err := a.activityRepo.SaveActivityRetention(&retention); err.Code != "" {
+		return model.ActivityRetention{}, err
+	}
+
+	if action == enum.AuditCreate {
+		a.auditService.Record(actor, action, enum.AuditActivityRetention, retention.Id, nil, retention)
+	} else {
+		a.auditService.Record(actor, action, enum.AuditActivityRetention, retention.Id, before, retention)
+	}
+
+	return retention, utils.Error{}
+}
+
+// DeleteActivityRetention keeps the activities of the type forever again. It
+// returns an empty retention when the type had none.
+func (a *activityService) DeleteActivityRetention(activityType string, actor model.AuditActor) (model.ActivityRetention, utils.Error) {
+	retention, err := a.activityRepo.GetActivityRetention(activityType)
+	if err.Code != "" || retention.Id == 0 {
+		return model.ActivityRetention{}, err
+	}
+
+	if err := a.activityRepo.DeleteActivityRetention(activityType); err.Code != "" {
+		return model.ActivityRetention{}, err
+	}
+
+	a.auditService.Record(actor, enum.AuditDelete, enum.AuditActivityRetention, retention.Id, retention, nil)
+
+	return retention, utils.Error{}
+}
+
+// ArchiveExpiredActivities moves the activities older than the retention of
+// their type to gzipped JSON Lines files in the private storage, and deletes
+// them once the file is stored. Each file holds up to activityArchiveBatch
+// activities.
+func (a *activityService) ArchiveExpiredActivities(actor model.AuditActor) ([]model.ActivityArchive, utils.Error) {
+	archives := []model.ActivityArchive{}
+
+	retentions, err := a.activityRepo.ListActivityRetentions()
+	if err.Code != "" {
+		return archives, err
+	}
+
+	now := time.Now()
+
+	for _, retention := range retentions {
+		before := now.AddDate(0, 0, -retention.Days)
+
+		for {
+			activities, err := a.activityRepo.ListActivitiesBefore(retention.Type, before, activityArchiveBatch)
+			if err.Code != "" {
+				return archives, err
+			}
+
+			if len(activities) == 0 {
+				break
+			}
+
+			archive, err := a.archiveActivities(retention.Type, before, activities)
+			if err.Code != "" {
+				return archives, err
+			}
+
+			archives = append(archives, archive)
+
+			a.auditService.Record(actor, enum.AuditCreate, enum.AuditActivityArchive, 0, nil, archive)
+
+			if len(activities) < activityArchiveBatch {
+				break
+			}
+		}
+	}
+
+	return archives, utils.Error{}
+}
+
+func (a *activityService) archiveActivities(activityType string, before time.Time, activities []model.Activity) (model.ActivityArchive, utils.Error) {
+	var content bytes.Buffer
+
+	writer := gzip.NewWriter(&content)
+	encoder := json.NewEncoder(writer)
+
+	for _, activity := range activities {
+		if err := encoder.Encode(activity.ToResponse()); err != nil {
+			return model.ActivityArchive{}, activityServiceError("failed to compress the activities", "01")
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return model.ActivityArchive{}, activityServiceError("failed to compress the activities", "01")
+	}
+
+	first, last := activities[0].ID, activities[len(activities)-1].ID
+	key := fmt.Sprintf("%sarchive/activities/%s/%d-%d.jsonl.gz", storage.PrivatePrefix, activityType, first, last)
+
+	if _, err := a.fileStorage.Put(key, &content); err != nil {
+		return model.ActivityArchive{}, activityServiceError("failed to store the activities archive", "02")
+	}
+
+	if err := a.activityRepo.DeleteActivitiesBefore(activityType, before, last); err.Code != "" {
+		return model.ActivityArchive{}, err
+	}
+
+	return model.ActivityArchive{
+		Type:   activityType,
+		Key:    key,
+		Count:  len(activities),
+		Before: before,
+	}, utils.Error{}
+}
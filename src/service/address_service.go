@@ -1,22 +1,30 @@
 package service
 
 import (
+	"cij_api/src/geocoding"
 	"cij_api/src/model"
 	"cij_api/src/repo"
 	"cij_api/src/utils"
+	"fmt"
 )
 
+const geocodeBatchSize = 100
+
 type AddressService interface {
 	GetAddressById(id int) (model.Address, utils.Error)
+	LocateAddress(address *model.Address)
+	GeocodeMissingAddresses() (model.GeocodeResult, utils.Error)
 }
 
 type addressService struct {
 	addressRepo repo.AddressRepo
+	geocoder    geocoding.Geocoder
 }
 
-func NewAddressService(addressRepo repo.AddressRepo) AddressService {
+func NewAddressService(addressRepo repo.AddressRepo, geocoder geocoding.Geocoder) AddressService {
 	return &addressService{
 		addressRepo: addressRepo,
+		geocoder:    geocoder,
 	}
 }
 
@@ -28,3 +36,58 @@ func (n *addressService) GetAddressById(id int) (model.Address, utils.Error) {
 
 	return address, utils.Error{}
 }
+
+// LocateAddress sets the coordinates of the address from the geocoder, unless
+// valid ones were given. The addresses it can't locate are saved without them,
+// so a geocoder down never blocks a sign up.
+func (n *addressService) LocateAddress(address *model.Address) {
+	if address.Latitude != nil && address.Longitude != nil && geocoding.ValidCoordinates(*address.Latitude, *address.Longitude) {
+		return
+	}
+
+	address.Latitude, address.Longitude = nil, nil
+
+	point, err := n.geocoder.Geocode(*address)
+	if err != nil {
+		fmt.Println("Error: failed to geocode the address:", err)
+		return
+	}
+
+	if point != nil {
+		address.Latitude, address.Longitude = &point.Latitude, &point.Longitude
+	}
+}
+
+// GeocodeMissingAddresses locates the addresses saved without coordinates,
+// like the ones saved before the geocoding or while the geocoder was down.
+func (n *addressService) GeocodeMissingAddresses() (model.GeocodeResult, utils.Error) {
+	result := model.GeocodeResult{}
+	lastId := 0
+
+	for {
+		addresses, err := n.addressRepo.ListAddressesWithoutCoordinates(lastId, geocodeBatchSize)
+		if err.Code != "" {
+			return result, err
+		}
+
+		for _, address := range addresses {
+			lastId = address.Id
+
+			n.LocateAddress(&address)
+			if address.Latitude == nil {
+				result.NotFound++
+				continue
+			}
+
+			if err := n.addressRepo.UpdateAddressCoordinates(address.Id, *address.Latitude, *address.Longitude); err.Code != "" {
+				return result, err
+			}
+
+			result.Geocoded++
+		}
+
+		if len(addresses) < geocodeBatchSize {
+			return result, utils.Error{}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/repo"
+	"cij_api/src/utils"
+	"fmt"
+)
+
+type AuditService interface {
+	Record(actor model.AuditActor, action enum.AuditAction, entityType enum.AuditEntity, entityId int, before interface{}, after interface{})
+	ListAuditEvents(filter model.AuditFilter) ([]model.AuditEventResponse, int64, utils.Error)
+	GetAuditEventById(eventId int) (model.AuditEventResponse, utils.Error)
+}
+
+type auditService struct {
+	auditRepo repo.AuditRepo
+	userRepo  repo.UserRepo
+}
+
+func NewAuditService(auditRepo repo.AuditRepo, userRepo repo.UserRepo) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// Record stores what the actor changed in the entity, given its state before
+// and after the change. Creations have no before and deletions no after. The
+// change is already saved when it's recorded, so a failure to record it is
+// logged instead of failing the request.
+func (s *auditService) Record(actor model.AuditActor, action enum.AuditAction, entityType enum.AuditEntity, entityId int, before interface{}, after interface{}) {
+	if actor.UserId == 0 && actor.Email != "" {
+		user, err := s.userRepo.GetUserByEmail(actor.Email)
+		if err.Code != "" {
+			fmt.Println("Error: failed to record the audit event:", err.Message)
+			return
+		}
+
+		actor.UserId = user.Id
+	}
+
+	event := model.AuditEvent{
+		ActorEmail: actor.Email,
+		ActorRole:  actor.Role,
+		Action:     action,
+		EntityType: entityType,
+		EntityId:   entityId,
+		Changes:    model.NewAuditChanges(before, after),
+		Ip:         actor.Ip,
+		UserAgent:  truncate(actor.UserAgent, 255),
+		RequestId:  truncate(actor.RequestId, 64),
+	}
+
+	if actor.UserId != 0 {
+		event.ActorId = &actor.UserId
+	}
+
+	if err := s.auditRepo.CreateAuditEvent(&event, nil); err.Code != "" {
+		fmt.Println("Error: failed to record the audit event:", err.Message)
+	}
+}
+
+func (s *auditService) ListAuditEvents(filter model.AuditFilter) ([]model.AuditEventResponse, int64, utils.Error) {
+	eventsResponse := []model.AuditEventResponse{}
+
+	events, total, err := s.auditRepo.ListAuditEvents(filter)
+	if err.Code != "" {
+		return eventsResponse, 0, err
+	}
+
+	for _, event := range events {
+		eventsResponse = append(eventsResponse, event.ToResponse())
+	}
+
+	return eventsResponse, total, utils.Error{}
+}
+
+// GetAuditEventById returns an empty event when it doesn't exist.
+func (s *auditService) GetAuditEventById(eventId int) (model.AuditEventResponse, utils.Error) {
+	event, err := s.auditRepo.GetAuditEventById(eventId)
+	if err.Code != "" || event.Id == 0 {
+		return model.AuditEventResponse{}, err
+	}
+
+	return event.ToResponse(), utils.Error{}
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+
+	return string(runes[:length])
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/repo"
 	"cij_api/src/utils"
@@ -10,21 +11,25 @@ import (
 )
 
 type CompanyService interface {
-	CreateCompany(createCompany model.CompanyRequest) utils.Error
+	CreateCompany(createCompany model.CompanyRequest, actor model.AuditActor) utils.Error
 	ListCompanies() ([]model.CompanyResponse, utils.Error)
 	GetCompanyByUserId(userId int) (model.Company, utils.Error)
 	GetCompanyByCnpj(cnpj string) (model.Company, utils.Error)
 	GetCompanyById(companyId int) (model.Company, utils.Error)
 	GetUserByEmail(email string) (model.User, utils.Error)
-	UpdateCompany(company model.CompanyRequest, companyId int) utils.Error
-	DeleteCompany(companyId int) utils.Error
+	GetConfigPresetBySlug(slug string) (model.ConfigPresetResponse, utils.Error)
+	UpdateCompany(company model.CompanyRequest, companyId int, actor model.AuditActor) utils.Error
+	DeleteCompany(companyId int, actor model.AuditActor) utils.Error
 }
 
 type companyService struct {
-	companyRepo  repo.CompanyRepo
-	userRepo     repo.UserRepo
-	addressRepo  repo.AddressRepo
-	activityRepo repo.ActivityRepo
+	companyRepo    repo.CompanyRepo
+	userRepo       repo.UserRepo
+	addressRepo    repo.AddressRepo
+	activityRepo   repo.ActivityRepo
+	addressService AddressService
+	configService  ConfigService
+	auditService   AuditService
 }
 
 func NewCompanyService(
@@ -32,12 +37,18 @@ func NewCompanyService(
 	userRepo repo.UserRepo,
 	addressRepo repo.AddressRepo,
 	activityRepo repo.ActivityRepo,
+	addressService AddressService,
+	configService ConfigService,
+	auditService AuditService,
 ) CompanyService {
 	return &companyService{
-		companyRepo:  companyRepo,
-		userRepo:     userRepo,
-		addressRepo:  addressRepo,
-		activityRepo: activityRepo,
+		companyRepo:    companyRepo,
+		userRepo:       userRepo,
+		addressRepo:    addressRepo,
+		activityRepo:   activityRepo,
+		addressService: addressService,
+		configService:  configService,
+		auditService:   auditService,
 	}
 }
 
@@ -56,42 +67,59 @@ func (s *companyService) ListCompanies() ([]model.CompanyResponse, utils.Error)
 	}
 
 	for _, company := range companies {
-		user, err := s.userRepo.GetUserById(company.User.Id)
+		companyResponse, err := s.companyToResponse(company)
 		if err.Code != "" {
 			return companiesResponse, err
 		}
 
-		companyResponse := company.ToResponse(user)
+		companiesResponse = append(companiesResponse, companyResponse)
+	}
 
-		address, err := s.addressRepo.GetAddressById(*company.AddressId)
-		if err.Code != "" {
-			return companiesResponse, err
-		}
+	return companiesResponse, utils.Error{}
+}
 
-		if address.Id != 0 {
-			addressResponse := address.ToResponse()
-			companyResponse.Address = addressResponse
-		}
+func (s *companyService) companyToResponse(company model.Company) (model.CompanyResponse, utils.Error) {
+	user, err := s.userRepo.GetUserById(company.UserId)
+	if err.Code != "" {
+		return model.CompanyResponse{}, err
+	}
 
-		var userConfig interface{}
-		userConfig = model.DefaultConfig
+	companyResponse := company.ToResponse(user)
 
-		if user.ConfigUrl != "" {
-			configService := NewConfigService(s.userRepo)
-			userConfig, err = configService.GetUserConfig(user.ConfigUrl)
-			if err.Code != "" {
-				fmt.Println("Error:", err)
-			}
-		}
+	address, err := s.addressRepo.GetAddressById(*company.AddressId)
+	if err.Code != "" {
+		return companyResponse, err
+	}
 
-		companyResponse.User.Config = userConfig
-		companiesResponse = append(companiesResponse, companyResponse)
+	if address.Id != 0 {
+		companyResponse.Address = address.ToResponse()
 	}
 
-	return companiesResponse, utils.Error{}
+	userConfig, err := s.configService.GetUserConfig(user.Id)
+	if err.Code != "" {
+		return companyResponse, err
+	}
+
+	companyResponse.User.Config = userConfig
+
+	return companyResponse, utils.Error{}
+}
+
+// recordCompany audits the change of the company, given its state before it.
+func (s *companyService) recordCompany(actor model.AuditActor, action enum.AuditAction, company model.Company, before interface{}) utils.Error {
+	after, err := s.companyToResponse(company)
+	if err.Code != "" {
+		return err
+	}
+
+	s.auditService.Record(actor, action, enum.AuditCompany, company.Id, before, after)
+
+	return utils.Error{}
 }
 
-func (n *companyService) CreateCompany(createCompany model.CompanyRequest) utils.Error {
+// CreateCompany registers the company and its user. Anonymous registrations
+// are audited as made by the new user.
+func (n *companyService) CreateCompany(createCompany model.CompanyRequest, actor model.AuditActor) utils.Error {
 	userInfo := createCompany.ToUser()
 
 	hashedPassword, err := utils.EncryptPassword(userInfo.Password)
@@ -102,6 +130,9 @@ func (n *companyService) CreateCompany(createCompany model.CompanyRequest) utils
 	userInfo.Password = hashedPassword
 	userInfo.RoleId = model.CompanyRole
 
+	addressInfo := createCompany.ToAddress()
+	n.addressService.LocateAddress(&addressInfo)
+
 	errTx := n.userRepo.BeginTransaction(func(tx *gorm.DB) error {
 		userId, userError := n.userRepo.CreateUser(userInfo, tx)
 		if userError.Code != "" {
@@ -109,7 +140,7 @@ func (n *companyService) CreateCompany(createCompany model.CompanyRequest) utils
 			return userError
 		}
 
-		addressInfo := createCompany.ToAddress()
+		userInfo.Id = userId
 
 		addressId, addresError := n.addressRepo.UpsertAddress(addressInfo, tx)
 		if addresError.Code != "" {
@@ -134,19 +165,30 @@ func (n *companyService) CreateCompany(createCompany model.CompanyRequest) utils
 		return companyServiceError("failed to create the company", "02")
 	}
 
-	activityService := NewActivityService(n.activityRepo)
+	actor = actor.OrRegistering(userInfo.Id, userInfo.Email, userInfo.RoleId.Name())
+
+	company, companyError := n.companyRepo.GetCompanyByUserId(userInfo.Id)
+	if companyError.Code != "" {
+		return companyError
+	}
+
+	if err := n.recordCompany(actor, enum.AuditCreate, company, nil); err.Code != "" {
+		return err
+	}
+
+	if createCompany.ConfigPreset != "" {
+		if _, err := n.configService.ApplyConfigPreset(userInfo.Id, createCompany.ConfigPreset, actor); err.Code != "" {
+			return err
+		}
+	}
+
 	activity := model.Activity{
 		Type:        "register_company",
 		Description: "Company " + userInfo.Email + " registered",
 		Actor:       userInfo.Email,
 	}
 
-	activityError := activityService.CreateActivity(&activity)
-	if activityError.Code != "" {
-		return activityError
-	}
-
-	return utils.Error{}
+	return n.activityRepo.CreateActivity(&activity)
 }
 
 func (n *companyService) GetCompanyByUserId(userId int) (model.Company, utils.Error) {
@@ -176,10 +218,20 @@ func (n *companyService) GetCompanyById(companyId int) (model.Company, utils.Err
 	return company, utils.Error{}
 }
 
-func (n *companyService) UpdateCompany(updateCompany model.CompanyRequest, companyId int) utils.Error {
+func (n *companyService) UpdateCompany(updateCompany model.CompanyRequest, companyId int, actor model.AuditActor) utils.Error {
 	userInfo := updateCompany.ToUser()
 
-	if userInfo.Password == "" {
+	company, companyError := n.companyRepo.GetCompanyById(companyId)
+	if companyError.Code != "" {
+		return companyError
+	}
+
+	before, companyError := n.companyToResponse(company)
+	if companyError.Code != "" {
+		return companyError
+	}
+
+	if userInfo.Password != "" {
 		hashedPassword, err := utils.EncryptPassword(userInfo.Password)
 		if err != nil {
 			return companyServiceError("failed to encrypt the password", "02")
@@ -187,20 +239,15 @@ func (n *companyService) UpdateCompany(updateCompany model.CompanyRequest, compa
 
 		userInfo.Password = hashedPassword
 
-		userError := n.userRepo.UpdateUser(userInfo, companyId)
+		userError := n.userRepo.UpdateUser(userInfo, company.UserId)
 		if userError.Code != "" {
 			return userError
 		}
 	}
 
 	addressInfo := updateCompany.ToAddress()
-
-	company, companyError := n.companyRepo.GetCompanyById(companyId)
-	if companyError.Code != "" {
-		return companyError
-	}
-
 	addressInfo.Id = *company.AddressId
+	n.addressService.LocateAddress(&addressInfo)
 
 	addressId, addresError := n.addressRepo.UpsertAddress(addressInfo, nil)
 	if addresError.Code != "" {
@@ -215,15 +262,29 @@ func (n *companyService) UpdateCompany(updateCompany model.CompanyRequest, compa
 		return companyError
 	}
 
-	return utils.Error{}
+	company, companyError = n.companyRepo.GetCompanyById(companyId)
+	if companyError.Code != "" {
+		return companyError
+	}
+
+	return n.recordCompany(actor, enum.AuditUpdate, company, before)
 }
 
-func (n *companyService) DeleteCompany(companyId int) utils.Error {
+func (n *companyService) DeleteCompany(companyId int, actor model.AuditActor) utils.Error {
 	company, err := n.companyRepo.GetCompanyById(companyId)
 	if err.Code != "" {
 		return err
 	}
 
+	before, err := n.companyToResponse(company)
+	if err.Code != "" {
+		return err
+	}
+
+	if _, err := n.configService.DeleteCompanyConfigPreset(company.UserId, actor); err.Code != "" {
+		return err
+	}
+
 	err = n.companyRepo.DeleteCompany(companyId)
 	if err.Code != "" {
 		return err
@@ -239,6 +300,8 @@ func (n *companyService) DeleteCompany(companyId int) utils.Error {
 		return err
 	}
 
+	n.auditService.Record(actor, enum.AuditDelete, enum.AuditCompany, companyId, before, nil)
+
 	return utils.Error{}
 }
 
@@ -250,3 +313,7 @@ func (n *companyService) GetUserByEmail(email string) (model.User, utils.Error)
 
 	return user, utils.Error{}
 }
+
+func (n *companyService) GetConfigPresetBySlug(slug string) (model.ConfigPresetResponse, utils.Error) {
+	return n.configService.GetConfigPresetBySlug(slug)
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/utils"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// WCAG 2.x minimum contrast ratios. Text of at least 24px (18pt) is large
+// text, and non-text elements like buttons and charts need 3:1.
+const (
+	contrastAA            = 4.5
+	contrastAAA           = 7.0
+	contrastLargeAA       = 3.0
+	contrastLargeAAA      = 4.5
+	contrastNonText       = 3.0
+	largeTextFontSize     = 24
+	minChartColorDistance = 12.0
+)
+
+// chartCategories are the categories drawn in the charts, in the order the
+// palette colors are assigned.
+var chartCategories = []enum.DisabilityCategoryEnum{
+	enum.Visual, enum.Hearing, enum.Motor, enum.Intellectual, enum.Psychosocial,
+}
+
+// chartCandidates is the Okabe-Ito palette, designed to be told apart with
+// every kind of color blindness.
+var chartCandidates = []string{
+	"#0072B2", "#E69F00", "#009E73", "#D55E00", "#CC79A7", "#56B4E9", "#F0E442", "#000000",
+}
+
+type contrastPair struct {
+	foreground string
+	background string
+	// required pairs are rejected below AA. The secondary font is used for
+	// placeholders and disabled text, which WCAG exempts, so it only warns.
+	required bool
+}
+
+var contrastPairs = []contrastPair{
+	{foreground: "font_color", background: "background_color", required: true},
+	{foreground: "font_color", background: "input_color", required: true},
+	{foreground: "secondary_font_color", background: "background_color"},
+	{foreground: "secondary_font_color", background: "input_color"},
+}
+
+func contrastThresholds(fontSize int) (float64, float64) {
+	if fontSize >= largeTextFontSize {
+		return contrastLargeAA, contrastLargeAAA
+	}
+
+	return contrastAA, contrastAAA
+}
+
+func primaryColorsByName(colors model.SystemPrimaryColors) map[string]string {
+	return map[string]string{
+		"primary_color":        colors.PrimaryColor,
+		"secondary_color":      colors.SecondaryColor,
+		"font_color":           colors.FontColor,
+		"secondary_font_color": colors.SecondaryFontColor,
+		"input_color":          colors.InputColor,
+		"background_color":     colors.BackgroundColor,
+	}
+}
+
+// checkConfigContrast returns the contrast errors, below AA on the required
+// pairs, and the warnings, below AAA or below AA on the optional pairs.
+func checkConfigContrast(config model.Config) ([]model.Field, []model.Field) {
+	errorFields := []model.Field{}
+	warnings := []model.Field{}
+
+	colors := primaryColorsByName(config.SystemColors.PrimaryColors)
+	minAA, minAAA := contrastThresholds(config.FontSize)
+
+	for _, pair := range contrastPairs {
+		foreground, errForeground := utils.ParseHexColor(colors[pair.foreground])
+		background, errBackground := utils.ParseHexColor(colors[pair.background])
+		if errForeground != nil || errBackground != nil {
+			continue
+		}
+
+		ratio := utils.ContrastRatio(foreground, background)
+
+		switch {
+		case ratio < minAA && pair.required:
+			errorFields = append(errorFields, contrastField(pair, ratio, enum.ContrastAA, minAA))
+		case ratio < minAA:
+			warnings = append(warnings, contrastField(pair, ratio, enum.ContrastAA, minAA))
+		case ratio < minAAA:
+			warnings = append(warnings, contrastField(pair, ratio, enum.ContrastAAA, minAAA))
+		}
+	}
+
+	primary, errPrimary := utils.ParseHexColor(colors["primary_color"])
+	background, errBackground := utils.ParseHexColor(colors["background_color"])
+
+	if errPrimary == nil && errBackground == nil {
+		if ratio := utils.ContrastRatio(primary, background); ratio < contrastNonText {
+			warnings = append(warnings, contrastField(contrastPair{foreground: "primary_color", background: "background_color"}, ratio, enum.ContrastAA, contrastNonText))
+		}
+	}
+
+	return errorFields, warnings
+}
+
+func contrastField(pair contrastPair, ratio float64, level enum.ContrastLevelEnum, minRatio float64) model.Field {
+	return model.Field{
+		Name:  pair.foreground,
+		Value: fmt.Sprintf("contrast of %.2f:1 with %s is below the WCAG %s minimum of %.1f:1", ratio, pair.background, level, minRatio),
+	}
+}
+
+// checkChartColors simulates the color blindness of the config on the chart
+// colors and warns about the ones that become hard to tell apart.
+func checkChartColors(config model.Config) []model.Field {
+	warnings := []model.Field{}
+
+	if config.ColorBlindness == enum.Normal || !config.ColorBlindness.IsValid() {
+		return warnings
+	}
+
+	categories := []string{}
+	simulated := map[string]utils.Color{}
+
+	for category, hex := range config.SystemColors.ChartColors {
+		color, err := utils.ParseHexColor(hex)
+		if err != nil {
+			continue
+		}
+
+		categories = append(categories, string(category))
+		simulated[string(category)] = utils.SimulateColorBlindness(color, config.ColorBlindness)
+	}
+
+	sort.Strings(categories)
+
+	for i, first := range categories {
+		for _, second := range categories[i+1:] {
+			if utils.ColorDistance(simulated[first], simulated[second]) < minChartColorDistance {
+				warnings = append(warnings, model.Field{
+					Name:  "chart_colors",
+					Value: fmt.Sprintf("%s and %s are hard to tell apart with %s", first, second, config.ColorBlindness),
+				})
+			}
+		}
+	}
+
+	return warnings
+}
+
+// CheckUserConfig returns the accessibility warnings of a valid config.
+func (s *configService) CheckUserConfig(config model.Config) []model.Field {
+	_, warnings := checkConfigContrast(config)
+
+	return append(warnings, checkChartColors(config)...)
+}
+
+// GeneratePalette builds system colors around the primary color where the
+// text reaches the contrast level and the chart colors can be told apart with
+// the color blindness.
+func (s *configService) GeneratePalette(primaryColor string, theme enum.ThemeEnum, colorBlindness enum.ColorBlindnessEnum, level enum.ContrastLevelEnum) (model.PaletteResponse, utils.Error) {
+	errorFields := []model.Field{}
+
+	if !strings.HasPrefix(primaryColor, "#") {
+		primaryColor = "#" + primaryColor
+	}
+
+	primary, err := utils.ParseHexColor(primaryColor)
+	if err != nil {
+		errorFields = append(errorFields, model.Field{Name: "primary_color", Value: primaryColor + " must be a valid hex color"})
+	}
+
+	if !theme.IsValid() {
+		errorFields = append(errorFields, model.Field{Name: "theme", Value: "theme must be 'light', 'dark' or 'system'"})
+	}
+
+	if !colorBlindness.IsValid() {
+		errorFields = append(errorFields, model.Field{Name: "color_blindness", Value: "color_blindness must be 'normal', 'protanopia', 'deuteranopia' or 'tritanopia'"})
+	}
+
+	if !level.IsValid() {
+		errorFields = append(errorFields, model.Field{Name: "level", Value: "level must be 'AA' or 'AAA'"})
+	}
+
+	if len(errorFields) > 0 {
+		err := configValidationError("invalid palette request", "03")
+		err.Fields = errorFields
+
+		return model.PaletteResponse{}, err
+	}
+
+	minText := contrastAA
+	if level == enum.ContrastAAA {
+		minText = contrastAAA
+	}
+
+	background, _ := utils.ParseHexColor("#FFFFFF")
+	input, _ := utils.ParseHexColor("#F2F2F2")
+	font, _ := utils.ParseHexColor("#1A1A1A")
+	secondaryFont, _ := utils.ParseHexColor("#808080")
+	secondaryShift := -0.12
+
+	if theme == enum.Dark {
+		background, _ = utils.ParseHexColor("#121212")
+		input, _ = utils.ParseHexColor("#2A2A2A")
+		font, _ = utils.ParseHexColor("#F5F5F5")
+		secondaryShift = 0.12
+	}
+
+	font = utils.EnsureContrast(utils.EnsureContrast(font, input, minText), background, minText)
+	secondaryFont = utils.EnsureContrast(utils.EnsureContrast(secondaryFont, input, contrastAA), background, contrastAA)
+
+	primary = utils.EnsureContrast(primary, background, contrastNonText)
+
+	_, _, lightness := primary.HSL()
+	secondary := utils.EnsureContrast(primary.WithLightness(lightness+secondaryShift), background, contrastNonText)
+
+	primaryColors := model.SystemPrimaryColors{
+		PrimaryColor:       primary.Hex(),
+		SecondaryColor:     secondary.Hex(),
+		FontColor:          font.Hex(),
+		SecondaryFontColor: secondaryFont.Hex(),
+		InputColor:         input.Hex(),
+		BackgroundColor:    background.Hex(),
+	}
+
+	palette := model.PaletteResponse{
+		SystemColors: model.SystemColors{
+			PrimaryColors: primaryColors,
+			ChartColors:   generateChartColors(primary, background, colorBlindness),
+		},
+		Contrast: []model.ContrastCheck{},
+	}
+
+	colors := primaryColorsByName(primaryColors)
+
+	for _, pair := range contrastPairs {
+		foreground, _ := utils.ParseHexColor(colors[pair.foreground])
+		behind, _ := utils.ParseHexColor(colors[pair.background])
+		ratio := utils.ContrastRatio(foreground, behind)
+
+		palette.Contrast = append(palette.Contrast, model.ContrastCheck{
+			Foreground: pair.foreground,
+			Background: pair.background,
+			Ratio:      math.Round(ratio*100) / 100,
+			AA:         ratio >= contrastAA,
+			AAA:        ratio >= contrastAAA,
+		})
+	}
+
+	return palette, utils.Error{}
+}
+
+// generateChartColors starts from the primary color and picks, one at a
+// time, the candidate that is the most different from the colors already
+// picked, as seen with the color blindness.
+func generateChartColors(primary utils.Color, background utils.Color, colorBlindness enum.ColorBlindnessEnum) model.SystemChartColors {
+	candidates := []utils.Color{}
+
+	for _, hex := range chartCandidates {
+		candidate, _ := utils.ParseHexColor(hex)
+		candidates = append(candidates, utils.EnsureContrast(candidate, background, contrastNonText))
+	}
+
+	picked := []utils.Color{primary}
+
+	for len(picked) < len(chartCategories) && len(candidates) > 0 {
+		best, bestDistance := 0, -1.0
+
+		for i, candidate := range candidates {
+			distance := math.MaxFloat64
+
+			for _, color := range picked {
+				distance = math.Min(distance, utils.ColorDistance(
+					utils.SimulateColorBlindness(candidate, colorBlindness),
+					utils.SimulateColorBlindness(color, colorBlindness),
+				))
+			}
+
+			if distance > bestDistance {
+				best, bestDistance = i, distance
+			}
+		}
+
+		picked = append(picked, candidates[best])
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+
+	chartColors := model.SystemChartColors{}
+	for i, category := range chartCategories {
+		chartColors[category] = picked[i].Hex()
+	}
+
+	return chartColors
+}
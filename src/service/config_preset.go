@@ -0,0 +1,277 @@
+package service
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/utils"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var presetSlugPattern = regexp.MustCompile("^[a-z0-9]+(-[a-z0-9]+)*$")
+
+// companyPresetPrefix is reserved for the slugs of the company presets.
+const companyPresetPrefix = "company-"
+
+func (s *configService) ListConfigPresets() ([]model.ConfigPresetResponse, utils.Error) {
+	presetsResponse := []model.ConfigPresetResponse{}
+
+	presets, err := s.configPresetRepo.ListConfigPresets()
+	if err.Code != "" {
+		return presetsResponse, err
+	}
+
+	for _, preset := range presets {
+		presetsResponse = append(presetsResponse, preset.ToResponse())
+	}
+
+	return presetsResponse, utils.Error{}
+}
+
+// GetConfigPreset returns an empty preset when it doesn't exist.
+func (s *configService) GetConfigPreset(presetId int) (model.ConfigPresetResponse, utils.Error) {
+	preset, err := s.configPresetRepo.GetConfigPresetById(presetId)
+	if err.Code != "" || preset.Id == 0 {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	return preset.ToResponse(), utils.Error{}
+}
+
+// GetConfigPresetBySlug returns the public preset with the slug, or an empty
+// preset. The branded defaults of the companies are never returned.
+func (s *configService) GetConfigPresetBySlug(slug string) (model.ConfigPresetResponse, utils.Error) {
+	preset, err := s.configPresetRepo.GetConfigPresetBySlug(slug)
+	if err.Code != "" || preset.Id == 0 || preset.CompanyId != nil {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	return preset.ToResponse(), utils.Error{}
+}
+
+func (s *configService) CreateConfigPreset(presetRequest model.ConfigPresetRequest, actor model.AuditActor) (model.ConfigPresetResponse, utils.Error) {
+	if err := s.validateConfigPreset(presetRequest, 0); err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	preset := presetRequest.ToModel()
+
+	presetId, err := s.configPresetRepo.CreateConfigPreset(preset)
+	if err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	preset.Id = presetId
+	response := preset.ToResponse()
+
+	s.auditService.Record(actor, enum.AuditCreate, enum.AuditConfigPreset, presetId, nil, response)
+
+	return response, utils.Error{}
+}
+
+// UpdateConfigPreset returns an empty preset when it doesn't exist. The users
+// that already applied the preset keep their config.
+func (s *configService) UpdateConfigPreset(presetRequest model.ConfigPresetRequest, presetId int, actor model.AuditActor) (model.ConfigPresetResponse, utils.Error) {
+	preset, err := s.configPresetRepo.GetConfigPresetById(presetId)
+	if err.Code != "" || preset.Id == 0 {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	if err := s.validateConfigPreset(presetRequest, presetId); err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	updated := presetRequest.ToModel()
+
+	if err := s.configPresetRepo.UpdateConfigPreset(updated, presetId); err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	updated.Id = presetId
+	updated.CompanyId = preset.CompanyId
+	response := updated.ToResponse()
+
+	s.auditService.Record(actor, enum.AuditUpdate, enum.AuditConfigPreset, presetId, preset.ToResponse(), response)
+
+	return response, utils.Error{}
+}
+
+func (s *configService) DeleteConfigPreset(presetId int, actor model.AuditActor) utils.Error {
+	preset, err := s.configPresetRepo.GetConfigPresetById(presetId)
+	if err.Code != "" {
+		return err
+	}
+
+	if err := s.configPresetRepo.DeleteConfigPreset(presetId); err.Code != "" {
+		return err
+	}
+
+	if preset.CompanyId != nil {
+		s.clearCache()
+	}
+
+	s.auditService.Record(actor, enum.AuditDelete, enum.AuditConfigPreset, presetId, preset.ToResponse(), nil)
+
+	return utils.Error{}
+}
+
+// ApplyConfigPreset saves a copy of the preset config as a new version of the
+// user config. It returns an empty preset when the slug doesn't exist.
+func (s *configService) ApplyConfigPreset(userId int, slug string, actor model.AuditActor) (model.ConfigPresetResponse, utils.Error) {
+	preset, err := s.GetConfigPresetBySlug(slug)
+	if err.Code != "" || preset.Id == 0 {
+		return preset, err
+	}
+
+	if err := s.createVersion(userId, preset.Config, actor); err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	return preset, utils.Error{}
+}
+
+// GetCompanyConfigPreset returns the branded default config of the company of
+// the user, or an empty preset when the company didn't define one.
+func (s *configService) GetCompanyConfigPreset(userId int) (model.ConfigPresetResponse, utils.Error) {
+	company, err := s.companyRepo.GetCompanyByUserId(userId)
+	if err.Code != "" || company.Id == 0 {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	preset, err := s.configPresetRepo.GetCompanyConfigPreset(company.Id)
+	if err.Code != "" || preset.Id == 0 {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	return preset.ToResponse(), utils.Error{}
+}
+
+// SaveCompanyConfigPreset sets the config that the recruiters of the company
+// of the user get until they change their own.
+func (s *configService) SaveCompanyConfigPreset(userId int, config model.Config, actor model.AuditActor) (model.ConfigPresetResponse, utils.Error) {
+	company, err := s.companyRepo.GetCompanyByUserId(userId)
+	if err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	if company.Id == 0 {
+		return model.ConfigPresetResponse{}, configValidationError("the user doesn't belong to a company", "06")
+	}
+
+	if err := validateUserConfig(config); err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	preset, err := s.configPresetRepo.GetCompanyConfigPreset(company.Id)
+	if err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	var before interface{}
+	action := enum.AuditCreate
+
+	if preset.Id != 0 {
+		before = preset.ToResponse()
+		action = enum.AuditUpdate
+	}
+
+	preset.Slug = fmt.Sprintf("%s%d", companyPresetPrefix, company.Id)
+	preset.Name = company.Name
+	preset.CompanyId = &company.Id
+	preset.Config = config
+
+	if preset.Id == 0 {
+		preset.Id, err = s.configPresetRepo.CreateConfigPreset(preset)
+	} else {
+		err = s.configPresetRepo.UpdateConfigPreset(preset, preset.Id)
+	}
+
+	if err.Code != "" {
+		return model.ConfigPresetResponse{}, err
+	}
+
+	s.clearCache()
+
+	response := preset.ToResponse()
+
+	s.auditService.Record(actor, action, enum.AuditConfigPreset, preset.Id, before, response)
+
+	return response, utils.Error{}
+}
+
+// DeleteCompanyConfigPreset returns false when the company of the user didn't
+// define a branded default config.
+func (s *configService) DeleteCompanyConfigPreset(userId int, actor model.AuditActor) (bool, utils.Error) {
+	preset, err := s.GetCompanyConfigPreset(userId)
+	if err.Code != "" || preset.Id == 0 {
+		return false, err
+	}
+
+	if err := s.DeleteConfigPreset(preset.Id, actor); err.Code != "" {
+		return false, err
+	}
+
+	return true, utils.Error{}
+}
+
+// defaultUserConfig is the config of the users that never changed theirs: the
+// branded default of their company, if any, or the system default.
+func (s *configService) defaultUserConfig(userId int) (model.Config, utils.Error) {
+	preset, err := s.GetCompanyConfigPreset(userId)
+	if err.Code != "" {
+		return model.Config{}, err
+	}
+
+	if preset.Id != 0 {
+		return preset.Config, utils.Error{}
+	}
+
+	return model.DefaultConfig, utils.Error{}
+}
+
+// validateConfigPreset checks the preset with the same rules as the user
+// configs. The presetId is the preset being updated, which may keep its slug.
+func (s *configService) validateConfigPreset(presetRequest model.ConfigPresetRequest, presetId int) utils.Error {
+	errorFields := []model.Field{}
+
+	if !presetSlugPattern.MatchString(presetRequest.Slug) {
+		errorFields = append(errorFields, model.Field{Name: "slug", Value: "slug must have only lowercase letters, numbers and hyphens"})
+	}
+
+	if presetId == 0 && strings.HasPrefix(presetRequest.Slug, companyPresetPrefix) {
+		errorFields = append(errorFields, model.Field{Name: "slug", Value: "slug is reserved for the company presets"})
+	}
+
+	if presetRequest.Name == "" {
+		errorFields = append(errorFields, model.Field{Name: "name"})
+	}
+
+	if err := validateUserConfig(presetRequest.Config); err.Code != "" {
+		errorFields = append(errorFields, err.Fields...)
+	}
+
+	if len(errorFields) > 0 {
+		err := configValidationError("invalid config preset", "04")
+		err.Fields = errorFields
+
+		return err
+	}
+
+	existing, err := s.configPresetRepo.GetConfigPresetBySlug(presetRequest.Slug)
+	if err.Code != "" {
+		return err
+	}
+
+	if existing.Id != 0 && existing.Id != presetId {
+		return configValidationError("slug already registered", "05")
+	}
+
+	return utils.Error{}
+}
+
+func (s *configService) clearCache() {
+	s.cacheMutex.Lock()
+	s.cache = map[int]model.Config{}
+	s.cacheMutex.Unlock()
+}
@@ -1,27 +1,66 @@
 package service
 
 import (
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/repo"
 	"cij_api/src/utils"
 	"encoding/json"
-	"fmt"
-	"net/http"
-	"os"
+	"sync"
+
+	"gorm.io/gorm"
 )
 
 type ConfigService interface {
-	UploadUserConfig(email string, config *interface{}) utils.Error
-	GetUserConfig(email string) (interface{}, utils.Error)
+	GetUserConfig(userId int) (model.Config, utils.Error)
+	SaveUserConfig(userId int, config model.Config, actor model.AuditActor) utils.Error
+	PatchUserConfig(userId int, patch []byte, actor model.AuditActor) (model.Config, utils.Error)
+	ResetUserConfig(userId int, actor model.AuditActor) utils.Error
+	ListUserConfigVersions(userId int) ([]model.UserConfigVersionResponse, utils.Error)
+	RollbackUserConfig(userId int, version int, actor model.AuditActor) (model.UserConfigVersionResponse, utils.Error)
+	GetUserByEmail(email string) (model.User, utils.Error)
+	GetUserById(userId int) (model.User, utils.Error)
+	ValidateUserConfig(config model.Config) utils.Error
+	CheckUserConfig(config model.Config) []model.Field
+	GeneratePalette(primaryColor string, theme enum.ThemeEnum, colorBlindness enum.ColorBlindnessEnum, level enum.ContrastLevelEnum) (model.PaletteResponse, utils.Error)
+	ListConfigPresets() ([]model.ConfigPresetResponse, utils.Error)
+	GetConfigPreset(presetId int) (model.ConfigPresetResponse, utils.Error)
+	GetConfigPresetBySlug(slug string) (model.ConfigPresetResponse, utils.Error)
+	CreateConfigPreset(presetRequest model.ConfigPresetRequest, actor model.AuditActor) (model.ConfigPresetResponse, utils.Error)
+	UpdateConfigPreset(presetRequest model.ConfigPresetRequest, presetId int, actor model.AuditActor) (model.ConfigPresetResponse, utils.Error)
+	DeleteConfigPreset(presetId int, actor model.AuditActor) utils.Error
+	ApplyConfigPreset(userId int, slug string, actor model.AuditActor) (model.ConfigPresetResponse, utils.Error)
+	GetCompanyConfigPreset(userId int) (model.ConfigPresetResponse, utils.Error)
+	SaveCompanyConfigPreset(userId int, config model.Config, actor model.AuditActor) (model.ConfigPresetResponse, utils.Error)
+	DeleteCompanyConfigPreset(userId int, actor model.AuditActor) (bool, utils.Error)
 }
 
 type configService struct {
-	userRepo repo.UserRepo
+	userRepo         repo.UserRepo
+	userConfigRepo   repo.UserConfigRepo
+	configPresetRepo repo.ConfigPresetRepo
+	companyRepo      repo.CompanyRepo
+	auditService     AuditService
+	// cache keeps the current config of the users, so rendering a list of
+	// people doesn't query every config.
+	cache      map[int]model.Config
+	cacheMutex sync.RWMutex
 }
 
-func NewConfigService(userRepo repo.UserRepo) ConfigService {
+func NewConfigService(
+	userRepo repo.UserRepo,
+	userConfigRepo repo.UserConfigRepo,
+	configPresetRepo repo.ConfigPresetRepo,
+	companyRepo repo.CompanyRepo,
+	auditService AuditService,
+) ConfigService {
 	return &configService{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		userConfigRepo:   userConfigRepo,
+		configPresetRepo: configPresetRepo,
+		companyRepo:      companyRepo,
+		auditService:     auditService,
+		cache:            map[int]model.Config{},
 	}
 }
 
@@ -31,64 +70,222 @@ func configServiceError(message string, code string) utils.Error {
 	return utils.NewError(message, errorCode)
 }
 
-func (s *configService) UploadUserConfig(email string, config *interface{}) utils.Error {
-	userConfig, err := json.Marshal(model.DefaultConfig)
-	if err != nil {
-		return configServiceError("failed to marshall user config", "01")
+func configValidationError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.ConfigErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+// GetUserConfig returns the current config of the user, or the default config
+// when the user never changed it.
+func (s *configService) GetUserConfig(userId int) (model.Config, utils.Error) {
+	s.cacheMutex.RLock()
+	config, ok := s.cache[userId]
+	s.cacheMutex.RUnlock()
+
+	if ok {
+		return config.Clone(), utils.Error{}
+	}
+
+	userConfig, err := s.userConfigRepo.GetLatestUserConfig(userId, nil)
+	if err.Code != "" {
+		return model.Config{}, err
 	}
 
-	if config != nil {
-		userConfig, err = json.Marshal(*config)
-		if err != nil {
-			return configServiceError("failed to marshall user config", "02")
+	config = userConfig.Config
+	if userConfig.Id == 0 {
+		config, err = s.defaultUserConfig(userId)
+		if err.Code != "" {
+			return model.Config{}, err
 		}
 	}
 
-	err = os.WriteFile(email+".json", userConfig, 0644)
-	if err != nil {
-		fmt.Print("Error: ", err)
-		return configServiceError("failed to write user config", "03")
+	s.cacheMutex.Lock()
+	s.cache[userId] = config
+	s.cacheMutex.Unlock()
+
+	return config.Clone(), utils.Error{}
+}
+
+// SaveUserConfig validates the config and stores it as a new version.
+func (s *configService) SaveUserConfig(userId int, config model.Config, actor model.AuditActor) utils.Error {
+	if err := validateUserConfig(config); err.Code != "" {
+		return err
+	}
+
+	return s.createVersion(userId, config, actor)
+}
+
+// PatchUserConfig applies a JSON Merge Patch to the current config and saves
+// the result as a new version.
+func (s *configService) PatchUserConfig(userId int, patch []byte, actor model.AuditActor) (model.Config, utils.Error) {
+	current, err := s.GetUserConfig(userId)
+	if err.Code != "" {
+		return model.Config{}, err
 	}
 
-	userFile, err := os.Open(email + ".json")
-	if err != nil {
-		return configServiceError("failed to open user config", "04")
+	currentJson, marshalErr := json.Marshal(current)
+	if marshalErr != nil {
+		return model.Config{}, configServiceError("failed to marshall user config", "01")
 	}
 
-	filesService := NewFilesService()
-	fileUrl, err := filesService.UploadFile(userFile, "cij/user_config/"+email)
-	if err != nil {
-		return configServiceError("failed to upload user config", "05")
+	patchedJson, patchErr := utils.MergePatch(currentJson, patch)
+	if patchErr != nil {
+		return model.Config{}, configValidationError("invalid merge patch", "02")
 	}
 
-	userFile.Close()
-	err = os.Remove(email + ".json")
-	if err != nil {
-		return configServiceError("failed to remove user config", "06")
+	var patched model.Config
+
+	if unmarshalErr := json.Unmarshal(patchedJson, &patched); unmarshalErr != nil {
+		return model.Config{}, configValidationError("invalid merge patch", "02")
 	}
 
-	updateUserErr := s.userRepo.UpdateUserConfig(fileUrl, email)
-	if updateUserErr.Code != "" {
-		return updateUserErr
+	if err := s.SaveUserConfig(userId, patched, actor); err.Code != "" {
+		return model.Config{}, err
 	}
 
+	return patched, utils.Error{}
+}
+
+// ResetUserConfig saves the default config as a new version.
+func (s *configService) ResetUserConfig(userId int, actor model.AuditActor) utils.Error {
+	return s.createVersion(userId, model.DefaultConfig.Clone(), actor)
+}
+
+func (s *configService) ListUserConfigVersions(userId int) ([]model.UserConfigVersionResponse, utils.Error) {
+	versionsResponse := []model.UserConfigVersionResponse{}
+
+	userConfigs, err := s.userConfigRepo.ListUserConfigVersions(userId)
+	if err.Code != "" {
+		return versionsResponse, err
+	}
+
+	for _, userConfig := range userConfigs {
+		versionsResponse = append(versionsResponse, userConfig.ToVersionResponse())
+	}
+
+	return versionsResponse, utils.Error{}
+}
+
+// RollbackUserConfig restores a previous version by saving it again as the
+// newest one, so the history is never rewritten. The returned version is empty
+// when the requested one doesn't exist.
+func (s *configService) RollbackUserConfig(userId int, version int, actor model.AuditActor) (model.UserConfigVersionResponse, utils.Error) {
+	userConfig, err := s.userConfigRepo.GetUserConfigVersion(userId, version)
+	if err.Code != "" {
+		return model.UserConfigVersionResponse{}, err
+	}
+
+	if userConfig.Id == 0 {
+		return model.UserConfigVersionResponse{}, utils.Error{}
+	}
+
+	if err := s.createVersion(userId, userConfig.Config, actor); err.Code != "" {
+		return model.UserConfigVersionResponse{}, err
+	}
+
+	return userConfig.ToVersionResponse(), utils.Error{}
+}
+
+func (s *configService) GetUserByEmail(email string) (model.User, utils.Error) {
+	return s.userRepo.GetUserByEmail(email)
+}
+
+func (s *configService) GetUserById(userId int) (model.User, utils.Error) {
+	return s.userRepo.GetUserById(userId)
+}
+
+func (s *configService) ValidateUserConfig(config model.Config) utils.Error {
+	return validateUserConfig(config)
+}
+
+// createVersion saves the config as the newest version and audits it as an
+// update of the config of the user, whose id identifies it in the audit log.
+func (s *configService) createVersion(userId int, config model.Config, actor model.AuditActor) utils.Error {
+	before, err := s.GetUserConfig(userId)
+	if err.Code != "" {
+		return err
+	}
+
+	errTx := s.userConfigRepo.BeginTransaction(func(tx *gorm.DB) error {
+		latest, err := s.userConfigRepo.GetLatestUserConfig(userId, tx)
+		if err.Code != "" {
+			return err
+		}
+
+		err = s.userConfigRepo.CreateUserConfig(model.UserConfig{
+			UserId:  userId,
+			Version: latest.Version + 1,
+			Config:  config,
+		}, tx)
+		if err.Code != "" {
+			return err
+		}
+
+		return nil
+	})
+
+	if errTx != nil {
+		return configServiceError("failed to save the user config", "03")
+	}
+
+	s.cacheMutex.Lock()
+	s.cache[userId] = config.Clone()
+	s.cacheMutex.Unlock()
+
+	s.auditService.Record(actor, enum.AuditUpdate, enum.AuditUserConfig, userId, before, config)
+
 	return utils.Error{}
 }
 
-func (s *configService) GetUserConfig(url string) (interface{}, utils.Error) {
-	userConfig, err := http.Get(url)
-	if err != nil {
-		return nil, configServiceError("failed to get user config", "07")
+func validateUserConfig(config model.Config) utils.Error {
+	errorFields := []model.Field{}
+
+	const MAX_FONT_SIZE = 30
+	const MIN_FONT_SIZE = 14
+
+	if !config.Theme.IsValid() {
+		errorFields = append(errorFields, model.Field{Name: "theme", Value: "theme must be 'light', 'dark' or 'system'"})
 	}
 
-	defer userConfig.Body.Close()
+	if config.FontSize < MIN_FONT_SIZE || config.FontSize > MAX_FONT_SIZE {
+		errorFields = append(errorFields, model.Field{Name: "font_size", Value: "font_size must be between 14 and 30"})
+	}
+
+	if !config.ColorBlindness.IsValid() {
+		errorFields = append(errorFields, model.Field{Name: "color_blindness", Value: "color_blindness must be 'normal', 'protanopia', 'deuteranopia' or 'tritanopia'"})
+	}
 
-	var config interface{}
+	errorFields = append(errorFields, validateConfigColors(config)...)
+
+	contrastErrors, _ := checkConfigContrast(config)
+	errorFields = append(errorFields, contrastErrors...)
+
+	if len(errorFields) > 0 {
+		err := configValidationError("invalid user config", "01")
+		err.Fields = errorFields
+
+		return err
+	}
 
-	err = json.NewDecoder(userConfig.Body).Decode(&config)
-	if err != nil {
-		return nil, configServiceError("failed to decode user config", "08")
+	return utils.Error{}
+}
+
+func validateConfigColors(config model.Config) []model.Field {
+	errorColors := []model.Field{}
+	configColors := config.SystemColors
+
+	for colorCategory, colorValue := range primaryColorsByName(configColors.PrimaryColors) {
+		if !utils.IsHexColor(colorValue) {
+			errorColors = append(errorColors, model.Field{Name: colorCategory, Value: colorValue + " must be a valid hex color"})
+		}
+	}
+
+	for chartCategory, chartColor := range configColors.ChartColors {
+		if !utils.IsHexColor(chartColor) {
+			errorColors = append(errorColors, model.Field{Name: string(chartCategory), Value: chartColor + " must be a valid hex color"})
+		}
 	}
 
-	return config, utils.Error{}
+	return errorColors
 }
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/repo"
+	repoVacancy "cij_api/src/repo/vacancy"
+	"cij_api/src/storage"
+	"cij_api/src/utils"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CurriculumUrlExpiration is how long a curriculum download URL is valid.
+const CurriculumUrlExpiration = 5 * time.Minute
+
+const curriculumKeyPrefix = storage.PrivatePrefix + "curriculum/"
+
+type CurriculumService interface {
+	UploadCurriculum(curriculum multipart.FileHeader, personId int, actor model.AuditActor) utils.Error
+	CanUploadCurriculum(person model.PersonResponse, requester model.User) bool
+	CanDownloadCurriculum(person model.PersonResponse, requester model.User) (bool, utils.Error)
+	GetCurriculumUrl(person model.PersonResponse, requester model.User) (model.CurriculumUrlResponse, utils.Error)
+	MigrateLegacyCurricula(actor model.AuditActor) (int, utils.Error)
+}
+
+type curriculumService struct {
+	personRepo       repo.PersonRepo
+	companyRepo      repo.CompanyRepo
+	vacancyApplyRepo repoVacancy.VacancyApplyRepo
+	activityRepo     repo.ActivityRepo
+	fileStorage      storage.FileStorage
+	auditService     AuditService
+}
+
+func NewCurriculumService(
+	personRepo repo.PersonRepo,
+	companyRepo repo.CompanyRepo,
+	vacancyApplyRepo repoVacancy.VacancyApplyRepo,
+	activityRepo repo.ActivityRepo,
+	fileStorage storage.FileStorage,
+	auditService AuditService,
+) CurriculumService {
+	return &curriculumService{
+		personRepo:       personRepo,
+		companyRepo:      companyRepo,
+		vacancyApplyRepo: vacancyApplyRepo,
+		activityRepo:     activityRepo,
+		fileStorage:      fileStorage,
+		auditService:     auditService,
+	}
+}
+
+func curriculumServiceError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ServiceErrorCode, utils.CurriculumErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+// recordCurriculum audits the change of the curriculum key of the person.
+func (s *curriculumService) recordCurriculum(actor model.AuditActor, personId int, before string, after string) {
+	s.auditService.Record(
+		actor, enum.AuditUpdate, enum.AuditPerson, personId,
+		map[string]string{"curriculum": before}, map[string]string{"curriculum": after},
+	)
+}
+
+// UploadCurriculum stores the curriculum as a private file under an opaque
+// key and removes the previous one.
+func (s *curriculumService) UploadCurriculum(curriculum multipart.FileHeader, personId int, actor model.AuditActor) utils.Error {
+	person, err := s.personRepo.GetPersonById(personId, nil)
+	if err.Code != "" {
+		return err
+	}
+
+	openCurriculum, fileError := curriculum.Open()
+	if fileError != nil {
+		return curriculumServiceError("failed to open the file", "01")
+	}
+
+	defer openCurriculum.Close()
+
+	key := curriculumKeyPrefix + uuid.NewString() + strings.ToLower(path.Ext(curriculum.Filename))
+
+	if _, uploadError := s.fileStorage.Put(key, openCurriculum); uploadError != nil {
+		return curriculumServiceError("failed to upload the file", "02")
+	}
+
+	err = s.personRepo.UploadCurriculum(personId, key)
+	if err.Code != "" {
+		return err
+	}
+
+	if strings.HasPrefix(person.Curriculum, curriculumKeyPrefix) {
+		if deleteError := s.fileStorage.Delete(person.Curriculum); deleteError != nil {
+			fmt.Println("Error: failed to delete the previous curriculum:", deleteError)
+		}
+	}
+
+	s.recordCurriculum(actor, personId, person.Curriculum, key)
+
+	return utils.Error{}
+}
+
+// CanUploadCurriculum allows the person and the admins.
+func (s *curriculumService) CanUploadCurriculum(person model.PersonResponse, requester model.User) bool {
+	return requester.RoleId == model.AdminRole || (requester.RoleId == model.PersonRole && person.User.Id == requester.Id)
+}
+
+// CanDownloadCurriculum allows the person, the admins and the companies the
+// person applied to.
+func (s *curriculumService) CanDownloadCurriculum(person model.PersonResponse, requester model.User) (bool, utils.Error) {
+	switch requester.RoleId {
+	case model.AdminRole:
+		return true, utils.Error{}
+	case model.PersonRole:
+		return person.User.Id == requester.Id, utils.Error{}
+	case model.CompanyRole:
+		company, err := s.companyRepo.GetCompanyByUserId(requester.Id)
+		if err.Code != "" {
+			return false, err
+		}
+
+		if company.Id == 0 {
+			return false, utils.Error{}
+		}
+
+		return s.vacancyApplyRepo.HasCompanyApply(company.Id, person.Id)
+	}
+
+	return false, utils.Error{}
+}
+
+// GetCurriculumUrl returns a short-lived URL to download the curriculum and
+// records the download.
+func (s *curriculumService) GetCurriculumUrl(person model.PersonResponse, requester model.User) (model.CurriculumUrlResponse, utils.Error) {
+	storedPerson, err := s.personRepo.GetPersonById(person.Id, nil)
+	if err.Code != "" {
+		return model.CurriculumUrlResponse{}, err
+	}
+
+	if storedPerson.Curriculum == "" {
+		return model.CurriculumUrlResponse{}, utils.Error{}
+	}
+
+	expiresAt := time.Now().Add(CurriculumUrlExpiration)
+
+	url, signError := s.fileStorage.SignedURL(storedPerson.Curriculum, CurriculumUrlExpiration)
+	if signError != nil {
+		return model.CurriculumUrlResponse{}, curriculumServiceError("failed to sign the curriculum url", "03")
+	}
+
+	activity := model.Activity{
+		Type:        "download_curriculum",
+		Description: fmt.Sprintf("Curriculum of person %d downloaded", person.Id),
+		Actor:       requester.Email,
+	}
+
+	err = s.activityRepo.CreateActivity(&activity)
+	if err.Code != "" {
+		return model.CurriculumUrlResponse{}, err
+	}
+
+	return model.CurriculumUrlResponse{
+		Url:       url,
+		ExpiresAt: expiresAt.Unix(),
+	}, utils.Error{}
+}
+
+// MigrateLegacyCurricula moves the curricula uploaded as public files, which
+// are stored as URLs, to private files under opaque keys.
+func (s *curriculumService) MigrateLegacyCurricula(actor model.AuditActor) (int, utils.Error) {
+	people, err := s.personRepo.ListPeople()
+	if err.Code != "" {
+		return 0, err
+	}
+
+	migrated := 0
+
+	for _, person := range people {
+		if !strings.HasPrefix(person.Curriculum, "http") {
+			continue
+		}
+
+		response, downloadError := http.Get(person.Curriculum)
+		if downloadError != nil {
+			return migrated, curriculumServiceError("failed to download the legacy curriculum", "04")
+		}
+
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			return migrated, curriculumServiceError("failed to download the legacy curriculum", "04")
+		}
+
+		key := curriculumKeyPrefix + uuid.NewString() + strings.ToLower(path.Ext(person.Curriculum))
+
+		_, uploadError := s.fileStorage.Put(key, response.Body)
+		response.Body.Close()
+
+		if uploadError != nil {
+			return migrated, curriculumServiceError("failed to upload the file", "02")
+		}
+
+		err = s.personRepo.UploadCurriculum(person.Id, key)
+		if err.Code != "" {
+			return migrated, err
+		}
+
+		if deleteError := s.fileStorage.Delete("cij/curriculum/" + person.Cpf); deleteError != nil {
+			fmt.Println("Error: failed to delete the legacy curriculum:", deleteError)
+		}
+
+		s.recordCurriculum(actor, person.Id, person.Curriculum, key)
+
+		migrated++
+	}
+
+	return migrated, utils.Error{}
+}
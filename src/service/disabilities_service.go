@@ -1,31 +1,81 @@
 package service
 
 import (
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/repo"
 	"cij_api/src/utils"
 	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// cid10Pattern matches CID-10 codes like "H54" or "H54.0", and icd11Pattern
+// matches ICD-11 stem codes like "9D90" or "6A00.0", which skip the letters I
+// and O.
+var (
+	cid10Pattern = regexp.MustCompile(`^[A-Z][0-9]{2}(\.[0-9]{1,2})?$`)
+	icd11Pattern = regexp.MustCompile(`^[1-9A-HJ-NP-Z][A-HJ-NP-Z][0-9][0-9A-HJ-NP-Z](\.[0-9A-HJ-NP-Z]{1,2})?$`)
 )
 
 type DisabilityService interface {
-	CreateDisability(disability []model.DisabilityRequest) utils.Error
+	CreateDisability(disability []model.DisabilityRequest, actor model.AuditActor) utils.Error
+	ListDisabilities(category string, language enum.LanguageEnum) ([]model.DisabilityResponse, utils.Error)
+	GetDisabilityById(disabilityId int, language enum.LanguageEnum) (model.DisabilityResponse, utils.Error)
+	UpdateDisability(disability model.DisabilityRequest, disabilityId int, actor model.AuditActor) (model.DisabilityResponse, utils.Error)
+	DeleteDisability(disabilityId int, replacementId int, actor model.AuditActor) (model.DisabilityUsage, utils.Error)
+	ListDisabilityCategories(language enum.LanguageEnum) ([]model.DisabilityCategoryResponse, utils.Error)
+	CreateDisabilityCategory(category model.DisabilityCategoryRequest, actor model.AuditActor) (model.DisabilityCategoryResponse, utils.Error)
 }
 
 type disabilityService struct {
 	disabilityRepo repo.DisabilityRepo
+	auditService   AuditService
 }
 
-func NewDisabilityService(disabilityRepo repo.DisabilityRepo) DisabilityService {
+func NewDisabilityService(disabilityRepo repo.DisabilityRepo, auditService AuditService) DisabilityService {
 	return &disabilityService{
 		disabilityRepo: disabilityRepo,
+		auditService:   auditService,
 	}
 }
 
-func (s *disabilityService) CreateDisability(disabilities []model.DisabilityRequest) utils.Error {
+func disabilityServiceError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ServiceErrorCode, utils.DisabilityErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+func disabilityValidationError(message string, code string, fields []model.Field) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.DisabilityErrorType, code)
+
+	return utils.NewErrorWithFields(message, errorCode, fields)
+}
+
+func (s *disabilityService) CreateDisability(disabilities []model.DisabilityRequest, actor model.AuditActor) utils.Error {
 	disabilitiesToInsert := []*model.Disability{}
+	requested := map[string]bool{}
 
 	for _, disability := range disabilities {
-		disabilityModel := disability.ToModel()
+		disability = normalizeDisabilityRequest(disability)
+
+		category, err := s.validateDisability(disability, 0)
+		if err.Code != "" {
+			return err
+		}
+
+		key := strings.ToLower(string(category.Slug) + "\n" + disability.Description)
+		if requested[key] {
+			return disabilityValidationError("disability already registered", "02", []model.Field{
+				{Name: "description", Value: disability.Description},
+			})
+		}
+
+		requested[key] = true
+
+		disabilityModel := disability.ToModel(category)
 
 		disabilitiesToInsert = append(disabilitiesToInsert, &disabilityModel)
 	}
@@ -36,5 +86,260 @@ func (s *disabilityService) CreateDisability(disabilities []model.DisabilityRequ
 		return err
 	}
 
+	for _, disability := range disabilitiesToInsert {
+		s.auditService.Record(actor, enum.AuditCreate, enum.AuditDisability, disability.Id, nil, disability.ToResponse())
+	}
+
 	return utils.Error{}
 }
+
+// ListDisabilities lists the disabilities with the descriptions in the
+// language. An empty language keeps the Brazilian Portuguese ones.
+func (s *disabilityService) ListDisabilities(category string, language enum.LanguageEnum) ([]model.DisabilityResponse, utils.Error) {
+	disabilitiesResponse := []model.DisabilityResponse{}
+
+	disabilities, err := s.disabilityRepo.ListDisabilities(utils.DisabilityCategorySlug(category))
+	if err.Code != "" {
+		return disabilitiesResponse, err
+	}
+
+	for _, disability := range disabilities {
+		disabilitiesResponse = append(disabilitiesResponse, disability.ToLocalizedResponse(language))
+	}
+
+	return disabilitiesResponse, utils.Error{}
+}
+
+// GetDisabilityById returns an empty disability when it doesn't exist.
+func (s *disabilityService) GetDisabilityById(disabilityId int, language enum.LanguageEnum) (model.DisabilityResponse, utils.Error) {
+	disability, err := s.disabilityRepo.GetDisabilityById(disabilityId)
+	if err.Code != "" || disability.Id == 0 {
+		return model.DisabilityResponse{}, err
+	}
+
+	return disability.ToLocalizedResponse(language), utils.Error{}
+}
+
+// UpdateDisability returns an empty disability when it doesn't exist.
+func (s *disabilityService) UpdateDisability(disabilityRequest model.DisabilityRequest, disabilityId int, actor model.AuditActor) (model.DisabilityResponse, utils.Error) {
+	disability, err := s.disabilityRepo.GetDisabilityById(disabilityId)
+	if err.Code != "" || disability.Id == 0 {
+		return model.DisabilityResponse{}, err
+	}
+
+	disabilityRequest = normalizeDisabilityRequest(disabilityRequest)
+
+	category, err := s.validateDisability(disabilityRequest, disabilityId)
+	if err.Code != "" {
+		return model.DisabilityResponse{}, err
+	}
+
+	updated := disabilityRequest.ToModel(category)
+
+	if err := s.disabilityRepo.UpdateDisability(updated, disabilityId); err.Code != "" {
+		return model.DisabilityResponse{}, err
+	}
+
+	updated.Id = disabilityId
+	response := updated.ToResponse()
+
+	s.auditService.Record(actor, enum.AuditUpdate, enum.AuditDisability, disabilityId, disability.ToResponse(), response)
+
+	return response, utils.Error{}
+}
+
+// DeleteDisability deletes a disability that no person or vacancy references.
+// When a replacement is given, the references are moved to it first.
+// Otherwise the deletion is blocked and the returned usage is not empty.
+func (s *disabilityService) DeleteDisability(disabilityId int, replacementId int, actor model.AuditActor) (model.DisabilityUsage, utils.Error) {
+	disability, err := s.disabilityRepo.GetDisabilityById(disabilityId)
+	if err.Code != "" {
+		return model.DisabilityUsage{}, err
+	}
+
+	if replacementId != 0 {
+		replacement, err := s.disabilityRepo.GetDisabilityById(replacementId)
+		if err.Code != "" {
+			return model.DisabilityUsage{}, err
+		}
+
+		if replacement.Id == 0 || replacementId == disabilityId {
+			return model.DisabilityUsage{}, disabilityValidationError("invalid replacement disability", "03", []model.Field{
+				{Name: "replace_with", Value: "replace_with must be another existing disability"},
+			})
+		}
+	}
+
+	var usage model.DisabilityUsage
+
+	// the usage is counted in the transaction, so a person or vacancy given
+	// the disability meanwhile is remapped or blocks the deletion
+	errTx := s.disabilityRepo.BeginTransaction(func(tx *gorm.DB) error {
+		var err utils.Error
+
+		usage, err = s.disabilityRepo.GetDisabilityUsage(disabilityId, tx)
+		if err.Code != "" {
+			return err
+		}
+
+		if usage.InUse() && replacementId == 0 {
+			return nil
+		}
+
+		if replacementId != 0 {
+			if err := s.disabilityRepo.RemapDisability(disabilityId, replacementId, tx); err.Code != "" {
+				return err
+			}
+		}
+
+		if err := s.disabilityRepo.DeleteDisability(disabilityId, tx); err.Code != "" {
+			return err
+		}
+
+		return nil
+	})
+
+	if errTx != nil {
+		return model.DisabilityUsage{}, disabilityServiceError("failed to delete the disability", "01")
+	}
+
+	if usage.InUse() && replacementId == 0 {
+		return usage, utils.Error{}
+	}
+
+	s.auditService.Record(actor, enum.AuditDelete, enum.AuditDisability, disabilityId, disability.ToResponse(), nil)
+
+	return model.DisabilityUsage{}, utils.Error{}
+}
+
+func (s *disabilityService) ListDisabilityCategories(language enum.LanguageEnum) ([]model.DisabilityCategoryResponse, utils.Error) {
+	categoriesResponse := []model.DisabilityCategoryResponse{}
+
+	categories, err := s.disabilityRepo.ListDisabilityCategories()
+	if err.Code != "" {
+		return categoriesResponse, err
+	}
+
+	for _, category := range categories {
+		categoriesResponse = append(categoriesResponse, category.ToLocalizedResponse(language))
+	}
+
+	return categoriesResponse, utils.Error{}
+}
+
+// CreateDisabilityCategory adds a category, which is listed in the reports from
+// then on. The slug is derived from the name when not given.
+func (s *disabilityService) CreateDisabilityCategory(categoryRequest model.DisabilityCategoryRequest, actor model.AuditActor) (model.DisabilityCategoryResponse, utils.Error) {
+	categoryRequest.Name = strings.TrimSpace(categoryRequest.Name)
+	categoryRequest.Slug = strings.TrimSpace(categoryRequest.Slug)
+
+	if categoryRequest.Slug == "" {
+		categoryRequest.Slug = utils.Slugify(categoryRequest.Name)
+	}
+
+	errorFields := []model.Field{}
+
+	if categoryRequest.Name == "" {
+		errorFields = append(errorFields, model.Field{Name: "name"})
+	}
+
+	if !presetSlugPattern.MatchString(categoryRequest.Slug) {
+		errorFields = append(errorFields, model.Field{Name: "slug", Value: "slug must have only lowercase letters, numbers and hyphens"})
+	}
+
+	errorFields = append(errorFields, categoryRequest.Translations.InvalidLanguages("translations")...)
+
+	if len(errorFields) > 0 {
+		return model.DisabilityCategoryResponse{}, disabilityValidationError("invalid disability category", "04", errorFields)
+	}
+
+	slug := enum.DisabilityCategoryEnum(categoryRequest.Slug)
+
+	existing, err := s.disabilityRepo.GetDisabilityCategoryBySlug(slug)
+	if err.Code != "" {
+		return model.DisabilityCategoryResponse{}, err
+	}
+
+	if existing.Id != 0 {
+		return model.DisabilityCategoryResponse{}, disabilityValidationError("disability category already registered", "05", []model.Field{
+			{Name: "slug", Value: categoryRequest.Slug},
+		})
+	}
+
+	category := model.DisabilityCategory{Slug: slug, Name: categoryRequest.Name, Translations: categoryRequest.Translations}
+
+	category.Id, err = s.disabilityRepo.CreateDisabilityCategory(category)
+	if err.Code != "" {
+		return model.DisabilityCategoryResponse{}, err
+	}
+
+	response := category.ToResponse()
+
+	s.auditService.Record(actor, enum.AuditCreate, enum.AuditDisabilityCategory, category.Id, nil, response)
+
+	return response, utils.Error{}
+}
+
+func normalizeDisabilityRequest(disability model.DisabilityRequest) model.DisabilityRequest {
+	disability.Category = strings.TrimSpace(disability.Category)
+	disability.Description = strings.TrimSpace(disability.Description)
+	disability.Cid10 = strings.ToUpper(strings.TrimSpace(disability.Cid10))
+	disability.Icd11 = strings.ToUpper(strings.TrimSpace(disability.Icd11))
+
+	return disability
+}
+
+// validateDisability checks the fields and that no other disability has the
+// same category and description, returning the category of the disability. The
+// disabilityId is the one being updated.
+func (s *disabilityService) validateDisability(disability model.DisabilityRequest, disabilityId int) (model.DisabilityCategory, utils.Error) {
+	errorFields := []model.Field{}
+
+	category, err := s.disabilityRepo.GetDisabilityCategoryBySlug(utils.DisabilityCategorySlug(disability.Category))
+	if err.Code != "" {
+		return category, err
+	}
+
+	if category.Id == 0 {
+		errorFields = append(errorFields, model.Field{Name: "category", Value: "category must be one of the disability categories"})
+	}
+
+	if disability.Description == "" {
+		errorFields = append(errorFields, model.Field{Name: "description"})
+	}
+
+	if disability.Rate < 0 || disability.Rate > 100 {
+		errorFields = append(errorFields, model.Field{Name: "rate", Value: "rate must be between 0 and 100"})
+	}
+
+	if disability.Cid10 != "" && !cid10Pattern.MatchString(disability.Cid10) {
+		errorFields = append(errorFields, model.Field{Name: "cid10", Value: disability.Cid10 + " is not a valid CID-10 code"})
+	}
+
+	if disability.Icd11 != "" && !icd11Pattern.MatchString(disability.Icd11) {
+		errorFields = append(errorFields, model.Field{Name: "icd11", Value: disability.Icd11 + " is not a valid ICD-11 code"})
+	}
+
+	if disability.LegalClassification != "" && !disability.LegalClassification.IsValid() {
+		errorFields = append(errorFields, model.Field{Name: "legal_classification", Value: "legal_classification must be 'physical', 'hearing', 'visual', 'intellectual', 'psychosocial', 'autism' or 'multiple'"})
+	}
+
+	errorFields = append(errorFields, disability.Translations.InvalidLanguages("translations")...)
+
+	if len(errorFields) > 0 {
+		return category, disabilityValidationError("invalid disability", "01", errorFields)
+	}
+
+	duplicated, err := s.disabilityRepo.FindDuplicatedDisability(category.Id, disability.Description, disabilityId)
+	if err.Code != "" {
+		return category, err
+	}
+
+	if duplicated.Id != 0 {
+		return category, disabilityValidationError("disability already registered", "02", []model.Field{
+			{Name: "id", Value: fmt.Sprint(duplicated.Id)},
+		})
+	}
+
+	return category, utils.Error{}
+}
@@ -1,26 +1,80 @@
 package service
 
 import (
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/repo"
+	"cij_api/src/storage"
 	"cij_api/src/utils"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
 
 	"mime/multipart"
 	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxNewsSlugLength leaves room in the slug column for the suffix that tells
+// apart news with the same title.
+const maxNewsSlugLength = 200
+
+const (
+	maxNewsTags      = 10
+	maxTagLength     = 50
+	maxAltTextLength = 250
+	newsFeedCapacity = 20
 )
 
+type newsMediaType struct {
+	contentTypes []string
+	message      string
+}
+
+// newsMediaTypes are the content types accepted for the sign language video
+// and the audio narration, as sniffed from the file, and the message sent
+// when the file is of another type.
+var newsMediaTypes = map[string]newsMediaType{
+	"libras_video": {
+		contentTypes: []string{"video/mp4", "video/webm"},
+		message:      "unsupported file type, send a mp4 or webm video",
+	},
+	"audio_narration": {
+		contentTypes: []string{"audio/mpeg", "audio/wave", "application/ogg"},
+		message:      "unsupported file type, send a mp3, wav or ogg audio",
+	},
+}
+
 type NewsService interface {
-	ListNews() ([]model.NewsResponse, utils.Error)
-	CreateNews(model.NewsRequest, map[string]multipart.FileHeader) utils.Error
+	ListNews(filter model.NewsFilter) ([]model.NewsResponse, int64, utils.Error)
+	GetNewsById(newsId int) (model.NewsResponse, utils.Error)
+	GetNewsBySlug(slug string) (model.NewsResponse, utils.Error)
+	CreateNews(news model.NewsRequest, images map[string]multipart.FileHeader, actor model.AuditActor) (model.NewsResponse, utils.Error)
+	UpdateNews(news model.NewsRequest, newsId int, images map[string]multipart.FileHeader, actor model.AuditActor) (model.NewsResponse, utils.Error)
+	DeleteNews(newsId int, actor model.AuditActor) utils.Error
+	PublishScheduledNews(actor model.AuditActor) (int64, utils.Error)
+	ListNewsCategories() ([]model.NewsCategoryResponse, utils.Error)
+	CreateNewsCategory(category model.NewsCategoryRequest, actor model.AuditActor) (model.NewsCategoryResponse, utils.Error)
+	ListTags() ([]model.TagResponse, utils.Error)
+	GetNewsFeed(categorySlug string) (model.NewsFeed, utils.Error)
 }
 
 type newsService struct {
-	newsRepo repo.NewsRepo
+	newsRepo     repo.NewsRepo
+	userRepo     repo.UserRepo
+	fileStorage  storage.FileStorage
+	auditService AuditService
 }
 
-func NewNewsService(newsRepo repo.NewsRepo) NewsService {
+func NewNewsService(newsRepo repo.NewsRepo, userRepo repo.UserRepo, fileStorage storage.FileStorage, auditService AuditService) NewsService {
 	return &newsService{
-		newsRepo: newsRepo,
+		newsRepo:     newsRepo,
+		userRepo:     userRepo,
+		fileStorage:  fileStorage,
+		auditService: auditService,
 	}
 }
 
@@ -30,59 +84,612 @@ func newsServiceError(message string, code string) utils.Error {
 	return utils.NewError(message, errorCode)
 }
 
-func (n *newsService) ListNews() ([]model.NewsResponse, utils.Error) {
+func newsValidationError(message string, code string, fields []model.Field) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.NewsErrorType, code)
+
+	return utils.NewErrorWithFields(message, errorCode, fields)
+}
+
+func (n *newsService) ListNews(filter model.NewsFilter) ([]model.NewsResponse, int64, utils.Error) {
 	newsResponse := []model.NewsResponse{}
 
-	news, err := n.newsRepo.ListNews()
+	news, total, err := n.newsRepo.ListNews(filter)
 	if err.Code != "" {
-		return newsResponse, err
+		return newsResponse, 0, err
 	}
 
 	for _, news := range news {
 		newsResponse = append(newsResponse, news.ToResponse())
 	}
 
-	return newsResponse, utils.Error{}
+	return newsResponse, total, utils.Error{}
+}
+
+// GetNewsById returns an empty news when it doesn't exist.
+func (n *newsService) GetNewsById(newsId int) (model.NewsResponse, utils.Error) {
+	news, err := n.newsRepo.GetNewsById(newsId)
+	if err.Code != "" || news.Id == 0 {
+		return model.NewsResponse{}, err
+	}
+
+	return news.ToResponse(), utils.Error{}
+}
+
+// GetNewsBySlug returns an empty news when it doesn't exist.
+func (n *newsService) GetNewsBySlug(slug string) (model.NewsResponse, utils.Error) {
+	news, err := n.newsRepo.GetNewsBySlug(slug)
+	if err.Code != "" || news.Id == 0 {
+		return model.NewsResponse{}, err
+	}
+
+	return news.ToResponse(), utils.Error{}
+}
+
+// CreateNews saves the news written by the actor. The slug comes from the
+// title and is kept when the title changes, so links don't break.
+func (n *newsService) CreateNews(newsRequest model.NewsRequest, images map[string]multipart.FileHeader, actor model.AuditActor) (model.NewsResponse, utils.Error) {
+	news := model.News{}
+
+	if err := n.applyNewsRequest(&news, newsRequest, images, time.Now()); err.Code != "" {
+		return model.NewsResponse{}, err
+	}
+
+	author, err := n.userRepo.GetUserByEmail(actor.Email)
+	if err.Code != "" {
+		return model.NewsResponse{}, err
+	}
+
+	if author.Id != 0 {
+		news.AuthorId = &author.Id
+	}
+
+	news.Slug, err = n.newNewsSlug(news.Title)
+	if err.Code != "" {
+		return model.NewsResponse{}, err
+	}
+
+	keys, err := n.uploadNewsMedia(&news, images)
+	if err.Code != "" {
+		return model.NewsResponse{}, err
+	}
+
+	news.Id, err = n.newsRepo.CreateNews(news)
+	if err.Code != "" {
+		n.deleteNewsMedia(keys)
+		return model.NewsResponse{}, err
+	}
+
+	created, err := n.GetNewsById(news.Id)
+	if err.Code != "" {
+		return created, err
+	}
+
+	n.auditService.Record(actor, enum.AuditCreate, enum.AuditNews, created.Id, nil, created)
+
+	return created, utils.Error{}
+}
+
+// UpdateNews replaces the news, keeping the files that are not sent again.
+// It returns an empty news when it doesn't exist.
+func (n *newsService) UpdateNews(newsRequest model.NewsRequest, newsId int, images map[string]multipart.FileHeader, actor model.AuditActor) (model.NewsResponse, utils.Error) {
+	news, err := n.newsRepo.GetNewsById(newsId)
+	if err.Code != "" || news.Id == 0 {
+		return model.NewsResponse{}, err
+	}
+
+	before := news.ToResponse()
+
+	if err := n.applyNewsRequest(&news, newsRequest, images, time.Now()); err.Code != "" {
+		return model.NewsResponse{}, err
+	}
+
+	keys, err := n.uploadNewsMedia(&news, images)
+	if err.Code != "" {
+		return model.NewsResponse{}, err
+	}
+
+	if err := n.newsRepo.UpdateNews(news, newsId); err.Code != "" {
+		n.deleteNewsMedia(keys)
+		return model.NewsResponse{}, err
+	}
+
+	updated, err := n.GetNewsById(newsId)
+	if err.Code != "" {
+		return updated, err
+	}
+
+	n.auditService.Record(actor, enum.AuditUpdate, enum.AuditNews, newsId, before, updated)
+
+	return updated, utils.Error{}
+}
+
+func (n *newsService) DeleteNews(newsId int, actor model.AuditActor) utils.Error {
+	news, err := n.GetNewsById(newsId)
+	if err.Code != "" {
+		return err
+	}
+
+	if err := n.newsRepo.DeleteNews(newsId); err.Code != "" {
+		return err
+	}
+
+	n.auditService.Record(actor, enum.AuditDelete, enum.AuditNews, newsId, news, nil)
+
+	return utils.Error{}
+}
+
+// PublishScheduledNews publishes the drafts whose publication date arrived. It
+// is run periodically by the server.
+func (n *newsService) PublishScheduledNews(actor model.AuditActor) (int64, utils.Error) {
+	newsIds, err := n.newsRepo.PublishScheduledNews(time.Now())
+	if err.Code != "" {
+		return 0, err
+	}
+
+	for _, newsId := range newsIds {
+		published, err := n.GetNewsById(newsId)
+		if err.Code != "" {
+			return 0, err
+		}
+
+		draft := published
+		draft.Status = enum.NewsDraft
+		draft.Scheduled = true
+		draft.Date = nil
+
+		n.auditService.Record(actor, enum.AuditUpdate, enum.AuditNews, newsId, draft, published)
+	}
+
+	return int64(len(newsIds)), utils.Error{}
+}
+
+func (n *newsService) ListNewsCategories() ([]model.NewsCategoryResponse, utils.Error) {
+	categoriesResponse := []model.NewsCategoryResponse{}
+
+	categories, err := n.newsRepo.ListNewsCategories()
+	if err.Code != "" {
+		return categoriesResponse, err
+	}
+
+	for _, category := range categories {
+		categoriesResponse = append(categoriesResponse, category.ToResponse())
+	}
+
+	return categoriesResponse, utils.Error{}
+}
+
+// CreateNewsCategory adds a category, with its own feeds. The slug is derived
+// from the name when not given.
+func (n *newsService) CreateNewsCategory(categoryRequest model.NewsCategoryRequest, actor model.AuditActor) (model.NewsCategoryResponse, utils.Error) {
+	categoryRequest.Name = strings.TrimSpace(categoryRequest.Name)
+	categoryRequest.Slug = strings.TrimSpace(categoryRequest.Slug)
+
+	if categoryRequest.Slug == "" {
+		categoryRequest.Slug = utils.Slugify(categoryRequest.Name)
+	}
+
+	errorFields := []model.Field{}
+
+	if categoryRequest.Name == "" {
+		errorFields = append(errorFields, model.Field{Name: "name"})
+	}
+
+	if !presetSlugPattern.MatchString(categoryRequest.Slug) {
+		errorFields = append(errorFields, model.Field{Name: "slug", Value: "slug must have only lowercase letters, numbers and hyphens"})
+	}
+
+	if len(errorFields) > 0 {
+		return model.NewsCategoryResponse{}, newsValidationError("invalid news category", "02", errorFields)
+	}
+
+	existing, err := n.newsRepo.GetNewsCategoryBySlug(categoryRequest.Slug)
+	if err.Code != "" {
+		return model.NewsCategoryResponse{}, err
+	}
+
+	if existing.Id != 0 {
+		return model.NewsCategoryResponse{}, newsValidationError("news category already registered", "03", []model.Field{
+			{Name: "slug", Value: categoryRequest.Slug},
+		})
+	}
+
+	category := model.NewsCategory{Slug: categoryRequest.Slug, Name: categoryRequest.Name}
+
+	category.Id, err = n.newsRepo.CreateNewsCategory(category)
+	if err.Code != "" {
+		return model.NewsCategoryResponse{}, err
+	}
+
+	response := category.ToResponse()
+
+	n.auditService.Record(actor, enum.AuditCreate, enum.AuditNewsCategory, category.Id, nil, response)
+
+	return response, utils.Error{}
+}
+
+func (n *newsService) ListTags() ([]model.TagResponse, utils.Error) {
+	tagsResponse := []model.TagResponse{}
+
+	tags, err := n.newsRepo.ListTags()
+	if err.Code != "" {
+		return tagsResponse, err
+	}
+
+	for _, tag := range tags {
+		tagsResponse = append(tagsResponse, tag.ToResponse())
+	}
+
+	return tagsResponse, utils.Error{}
 }
 
-func (n *newsService) CreateNews(createNews model.NewsRequest, images map[string]multipart.FileHeader) utils.Error {
-	filesService := NewFilesService()
-	news := createNews.ToModel()
+// GetNewsFeed returns the latest published news of the category, or of every
+// category when the slug is empty. The feed has no category when the slug
+// doesn't exist.
+func (n *newsService) GetNewsFeed(categorySlug string) (model.NewsFeed, utils.Error) {
+	feed := model.NewsFeed{}
+
+	if categorySlug != "" {
+		category, err := n.newsRepo.GetNewsCategoryBySlug(categorySlug)
+		if err.Code != "" || category.Id == 0 {
+			return feed, err
+		}
+
+		response := category.ToResponse()
+		feed.Category = &response
+	}
+
+	news, _, err := n.ListNews(model.NewsFilter{
+		Status:   enum.NewsPublished,
+		Category: categorySlug,
+		Page:     1,
+		PerPage:  newsFeedCapacity,
+	})
+	if err.Code != "" {
+		return feed, err
+	}
+
+	feed.News = news
+
+	return feed, utils.Error{}
+}
 
-	for fileName, image := range images {
-		openedFile, err := image.Open()
+// applyNewsRequest validates the request and sets it on the news. Without a
+// status the news is published, or scheduled when publish_at is in the future.
+// A draft with a future publish_at is scheduled, and can't have a past one, as
+// the scheduler would publish it without the validation of the published news.
+// Published news are dated when first published. Drafts may leave the
+// alternative text of the images for later, but it is required to publish.
+func (n *newsService) applyNewsRequest(news *model.News, newsRequest model.NewsRequest, files map[string]multipart.FileHeader, now time.Time) utils.Error {
+	errorFields := []model.Field{}
+
+	newsRequest.Title = strings.TrimSpace(newsRequest.Title)
+	newsRequest.Description = strings.TrimSpace(newsRequest.Description)
+	newsRequest.BannerAlt = strings.TrimSpace(newsRequest.BannerAlt)
+	newsRequest.BannerCaption = strings.TrimSpace(newsRequest.BannerCaption)
+	newsRequest.BannerLongDescription = strings.TrimSpace(newsRequest.BannerLongDescription)
+	newsRequest.AuthorImageAlt = strings.TrimSpace(newsRequest.AuthorImageAlt)
+
+	if newsRequest.Title == "" {
+		errorFields = append(errorFields, model.Field{Name: "title"})
+	}
+
+	if newsRequest.Description == "" {
+		errorFields = append(errorFields, model.Field{Name: "description"})
+	}
+
+	var publishAt *time.Time
+
+	if newsRequest.PublishAt != "" {
+		parsed, err := parseNewsDate(newsRequest.PublishAt)
 		if err != nil {
-			return newsServiceError("failed to open file", "01")
+			errorFields = append(errorFields, model.Field{Name: "publish_at", Value: "publish_at must be a date like 2024-05-10 or 2024-05-10T09:00:00-03:00"})
+		} else {
+			publishAt = &parsed
+		}
+	}
+
+	status := newsRequest.Status
+	scheduled := publishAt != nil && publishAt.After(now)
+
+	if status == "" {
+		status = enum.NewsPublished
+
+		if scheduled {
+			status = enum.NewsDraft
+		}
+	}
+
+	if !status.IsValid() {
+		errorFields = append(errorFields, model.Field{Name: "status", Value: "status must be 'draft', 'published' or 'archived'"})
+	}
+
+	if status == enum.NewsPublished && scheduled {
+		errorFields = append(errorFields, model.Field{Name: "publish_at", Value: "a published news can't have a future publish_at, save it as a draft to schedule it"})
+	}
+
+	if status == enum.NewsDraft && publishAt != nil && !scheduled {
+		errorFields = append(errorFields, model.Field{Name: "publish_at", Value: "a draft can't have a past publish_at, give a future one to schedule it or leave it empty"})
+	}
+
+	_, hasBanner := files["banner"]
+	_, hasAuthorImage := files["author_image"]
+
+	errorFields = append(errorFields, altTextFields(
+		"banner_alt", newsRequest.BannerAlt, hasBanner || news.Banner != "", status == enum.NewsPublished || scheduled,
+	)...)
+	errorFields = append(errorFields, altTextFields(
+		"author_image_alt", newsRequest.AuthorImageAlt, hasAuthorImage || news.AuthorImage != "", status == enum.NewsPublished || scheduled,
+	)...)
+
+	category, tags, fields, err := n.newsTaxonomy(newsRequest)
+	if err.Code != "" {
+		return err
+	}
+
+	errorFields = append(errorFields, fields...)
+
+	if len(errorFields) > 0 {
+		return newsValidationError("invalid news", "01", errorFields)
+	}
+
+	tags, err = n.newsRepo.FindOrCreateTags(tags)
+	if err.Code != "" {
+		return err
+	}
+
+	news.CategoryId = nil
+	news.Category = nil
+	news.Tags = tags
+
+	if category.Id != 0 {
+		news.CategoryId = &category.Id
+		news.Category = &category
+	}
+
+	news.Title = newsRequest.Title
+	news.Description = newsRequest.Description
+	news.BannerAlt = newsRequest.BannerAlt
+	news.BannerCaption = newsRequest.BannerCaption
+	news.BannerLongDescription = newsRequest.BannerLongDescription
+	news.AuthorImageAlt = newsRequest.AuthorImageAlt
+	news.Status = status
+	news.PublishAt = publishAt
+	news.Scheduled = status == enum.NewsDraft && scheduled
+
+	if status == enum.NewsPublished {
+		if publishAt != nil {
+			news.Date = publishAt
+		} else if news.Date == nil {
+			news.Date = &now
+		}
+	}
+
+	if status == enum.NewsDraft {
+		news.Date = nil
+	}
+
+	return utils.Error{}
+}
+
+// altTextFields validates the alternative text of an image. It is required
+// when the image is published, and must not be its file name.
+func altTextFields(name string, alt string, hasImage bool, publishing bool) []model.Field {
+	if alt == "" {
+		if hasImage && publishing {
+			return []model.Field{{Name: name, Value: "images must have an alternative text to be published"}}
+		}
+
+		return nil
+	}
+
+	if len([]rune(alt)) > maxAltTextLength {
+		return []model.Field{{Name: name, Value: fmt.Sprintf("the alternative text must have up to %d characters", maxAltTextLength)}}
+	}
+
+	if path.Ext(alt) != "" && !strings.Contains(alt, " ") {
+		return []model.Field{{Name: name, Value: "the alternative text must describe the image, not name its file"}}
+	}
+
+	return nil
+}
+
+// newsTaxonomy returns the category and the tags of the request, with the
+// fields that are invalid. The category must exist, while new tags are created
+// when the news is saved.
+func (n *newsService) newsTaxonomy(newsRequest model.NewsRequest) (model.NewsCategory, []model.Tag, []model.Field, utils.Error) {
+	var category model.NewsCategory
+	errorFields := []model.Field{}
+
+	if slug := strings.TrimSpace(newsRequest.Category); slug != "" {
+		var err utils.Error
+
+		category, err = n.newsRepo.GetNewsCategoryBySlug(slug)
+		if err.Code != "" {
+			return category, nil, errorFields, err
+		}
+
+		if category.Id == 0 {
+			errorFields = append(errorFields, model.Field{Name: "category", Value: "category not found"})
+		}
+	}
+
+	tags := []model.Tag{}
+	seen := map[string]bool{}
+
+	for _, name := range newsRequest.Tags {
+		name = strings.Join(strings.Fields(name), " ")
+		slug := utils.Slugify(name)
+
+		if slug == "" || seen[slug] {
+			continue
+		}
+
+		if len([]rune(name)) > maxTagLength {
+			errorFields = append(errorFields, model.Field{Name: "tags", Value: fmt.Sprintf("tags must have up to %d characters", maxTagLength)})
+			break
+		}
+
+		seen[slug] = true
+		tags = append(tags, model.Tag{Slug: slug, Name: name})
+	}
+
+	if len(tags) > maxNewsTags {
+		errorFields = append(errorFields, model.Field{Name: "tags", Value: fmt.Sprintf("a news can have up to %d tags", maxNewsTags)})
+	}
+
+	return category, tags, errorFields, utils.Error{}
+}
+
+func parseNewsDate(value string) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// newNewsSlug returns the slug of the title, with a number when another news
+// already has it.
+func (n *newsService) newNewsSlug(title string) (string, utils.Error) {
+	base := utils.Slugify(title)
+	if len(base) > maxNewsSlugLength {
+		base = strings.Trim(base[:maxNewsSlugLength], "-")
+	}
+
+	if base == "" {
+		base = "news"
+	}
+
+	slug := base
+
+	for suffix := 2; ; suffix++ {
+		exists, err := n.newsRepo.SlugExists(slug)
+		if err.Code != "" {
+			return "", err
+		}
+
+		if !exists {
+			return slug, utils.Error{}
+		}
+
+		slug = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
+
+// uploadNewsMedia stores the images, the sign language video and the audio
+// narration sent with the news. The video and the audio are checked by their
+// content, since their names and headers are up to the client. Each upload
+// gets its own opaque key, so news never replace each other's files. The keys
+// are returned to delete the files when the news can't be saved, and the
+// files already stored are deleted when one of them fails.
+func (n *newsService) uploadNewsMedia(news *model.News, files map[string]multipart.FileHeader) ([]string, utils.Error) {
+	keys := []string{}
+
+	fail := func(err utils.Error) ([]string, utils.Error) {
+		n.deleteNewsMedia(keys)
+		return nil, err
+	}
+
+	for fileName, mediaType := range newsMediaTypes {
+		file, ok := files[fileName]
+		if !ok {
+			continue
+		}
+
+		valid, err := isNewsMediaType(file, mediaType.contentTypes)
+		if err != nil {
+			return fail(newsServiceError("failed to open file", "01"))
+		}
+
+		if !valid {
+			return fail(newsValidationError("invalid media file", "04", []model.Field{
+				{Name: fileName, Value: mediaType.message},
+			}))
+		}
+	}
+
+	for fileName, file := range files {
+		openedFile, err := file.Open()
+		if err != nil {
+			return fail(newsServiceError("failed to open file", "01"))
 		}
 
 		defer openedFile.Close()
 
+		key := "cij/news/" + fileName + "/" + uuid.NewString() + strings.ToLower(path.Ext(file.Filename))
+
 		switch fileName {
 		case "banner":
-			fileUrl, err := filesService.UploadFile(openedFile, "cij/news/banner/"+strings.Split(image.Filename, ".")[0])
+			fileUrl, err := n.fileStorage.Put(key, openedFile)
 			if err != nil {
-				return newsServiceError("failed to upload file", "02")
+				return fail(newsServiceError("failed to upload file", "02"))
 			}
 
 			news.Banner = fileUrl
 
 		case "author_image":
-			fileUrl, err := filesService.UploadFile(openedFile, "cij/news/author_image/"+strings.Split(image.Filename, ".")[0])
+			fileUrl, err := n.fileStorage.Put(key, openedFile)
 			if err != nil {
-				return newsServiceError("failed to upload file", "03")
+				return fail(newsServiceError("failed to upload file", "03"))
 			}
 
 			news.AuthorImage = fileUrl
 
+		case "libras_video", "audio_narration":
+			fileUrl, err := n.fileStorage.Put(key, openedFile)
+			if err != nil {
+				return fail(newsServiceError("failed to upload file", "05"))
+			}
+
+			if fileName == "libras_video" {
+				news.LibrasVideo = fileUrl
+			} else {
+				news.AudioNarration = fileUrl
+			}
+
 		default:
-			return newsServiceError("invalid file name", "04")
+			return fail(newsServiceError("invalid file name", "04"))
 		}
+
+		keys = append(keys, key)
 	}
 
-	err := n.newsRepo.CreateNews(news)
-	if err.Code != "" {
-		return err
+	return keys, utils.Error{}
+}
+
+// deleteNewsMedia removes the files uploaded for a news that wasn't saved.
+func (n *newsService) deleteNewsMedia(keys []string) {
+	for _, key := range keys {
+		if err := n.fileStorage.Delete(key); err != nil {
+			fmt.Println("Error: failed to delete the news media:", err)
+		}
+	}
+}
+
+// isNewsMediaType tells whether the content type sniffed from the file is one
+// of the accepted ones.
+func isNewsMediaType(file multipart.FileHeader, contentTypes []string) (bool, error) {
+	openedFile, err := file.Open()
+	if err != nil {
+		return false, err
 	}
 
-	return utils.Error{}
+	defer openedFile.Close()
+
+	header := make([]byte, 512)
+
+	read, err := io.ReadFull(openedFile, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+
+	contentType := http.DetectContentType(header[:read])
+
+	for _, accepted := range contentTypes {
+		if contentType == accepted {
+			return true, nil
+		}
+	}
+
+	return false, nil
 }
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OpenDataLicense is the license the datasets are published under.
+var OpenDataLicense = model.OpenDataLicense{
+	Id:   "CC-BY-4.0",
+	Name: "Creative Commons Attribution 4.0 International",
+	URL:  "https://creativecommons.org/licenses/by/4.0/",
+}
+
+const openDataTrendMonths = 12
+
+// OpenDataService publishes the reports as open datasets, protected by the
+// privacy of the published reports. Each dataset is computed once a day of
+// the snapshots timezone and served as is until the next, so the noise of
+// its counts can't be averaged away by downloading it again.
+type OpenDataService interface {
+	ListDatasets() ([]model.OpenDataset, utils.Error)
+	GetDataset(id string) (model.OpenData, utils.Error)
+}
+
+type openDataset struct {
+	id          string
+	title       string
+	description string
+	version     string
+	fields      []model.OpenDataField
+	rows        func(s *openDataService) ([][]interface{}, utils.Error)
+}
+
+type openDataService struct {
+	reportsService        ReportsService
+	reportSnapshotService ReportSnapshotService
+	privacy               ReportsPrivacy
+
+	mutex     sync.Mutex
+	published map[string]model.OpenData
+}
+
+func NewOpenDataService(reportsService ReportsService, reportSnapshotService ReportSnapshotService, privacy ReportsPrivacy) OpenDataService {
+	return &openDataService{
+		reportsService:        reportsService,
+		reportSnapshotService: reportSnapshotService,
+		privacy:               privacy,
+		published:             map[string]model.OpenData{},
+	}
+}
+
+func categoryField() model.OpenDataField {
+	return model.OpenDataField{Name: "category", Type: "string", Description: "Disability category"}
+}
+
+func countField(name string, description string) model.OpenDataField {
+	return model.OpenDataField{Name: name, Type: "integer", Description: description + ". Empty when suppressed", Nullable: true}
+}
+
+func rateField(description string) model.OpenDataField {
+	return model.OpenDataField{Name: "rate", Type: "number", Description: description + ", from 0 to 1. Empty when a count it is computed from is suppressed", Nullable: true}
+}
+
+var openDatasets = []openDataset{
+	{
+		id:          "disability-totals",
+		title:       "People with disabilities per category",
+		description: "The people registered with disabilities of each category. A person is counted once in each category of their disabilities.",
+		version:     "1.0",
+		fields:      []model.OpenDataField{categoryField(), countField("people", "People with disabilities of the category")},
+		rows:        (*openDataService).disabilityTotalsRows,
+	},
+	{
+		id:          "disabilities-by-gender",
+		title:       "People with disabilities per category and gender",
+		description: "The people registered with disabilities of each category per gender.",
+		version:     "1.0",
+		fields: []model.OpenDataField{
+			categoryField(),
+			{Name: "gender", Type: "string", Description: "Gender of the people"},
+			countField("people", "People with disabilities of the category and the gender"),
+		},
+		rows: func(s *openDataService) ([][]interface{}, utils.Error) {
+			return s.crossTabRows(enum.DimensionGender)
+		},
+	},
+	{
+		id:          "disabilities-by-age-band",
+		title:       "People with disabilities per category and age band",
+		description: "The people registered with disabilities of each category per age band, from their birth dates on the update day.",
+		version:     "1.0",
+		fields: []model.OpenDataField{
+			categoryField(),
+			{Name: "age_band", Type: "string", Description: "Age band of the people, in years"},
+			countField("people", "People with disabilities of the category in the age band"),
+		},
+		rows: func(s *openDataService) ([][]interface{}, utils.Error) {
+			return s.crossTabRows(enum.DimensionAgeBand)
+		},
+	},
+	{
+		id:          "vacancies-by-category",
+		title:       "Vacancies per disability category",
+		description: "The vacancies published for the people with disabilities of each category. The vacancies are public, so their counts are never suppressed.",
+		version:     "1.0",
+		fields:      []model.OpenDataField{categoryField(), {Name: "vacancies", Type: "integer", Description: "Vacancies for the category"}},
+		rows:        (*openDataService).vacanciesRows,
+	},
+	{
+		id:          "acceptance-rates-by-category",
+		title:       "Acceptance of the applies per disability category",
+		description: "The applies of the candidates with disabilities of each category, with how many were accepted and rejected. An apply of a candidate with disabilities of many categories is counted in each of them.",
+		version:     "1.0",
+		fields: []model.OpenDataField{
+			categoryField(),
+			countField("applications", "Applies made"),
+			countField("accepted", "Applies accepted"),
+			countField("rejected", "Applies rejected"),
+			rateField("Share of the applies accepted"),
+		},
+		rows: (*openDataService).acceptanceRatesRows,
+	},
+	{
+		id:          "apply-funnel",
+		title:       "Applies per stage",
+		description: "The applies made, the ones already reviewed by the companies and the ones accepted.",
+		version:     "1.0",
+		fields: []model.OpenDataField{
+			{Name: "stage", Type: "string", Description: "Stage of the applies: applied, reviewed or accepted"},
+			countField("count", "Applies that reached the stage"),
+			rateField("Share of the applies that reached the stage"),
+		},
+		rows: (*openDataService).applyFunnelRows,
+	},
+	{
+		id:          "disability-trend",
+		title:       "Monthly people with disabilities per category",
+		description: "The people with disabilities of each category at the end of each of the last 12 months, from the nightly snapshots.",
+		version:     "1.0",
+		fields: []model.OpenDataField{
+			{Name: "month", Type: "string", Description: "Month, as YYYY-MM"},
+			categoryField(),
+			countField("people", "People with disabilities of the category at the end of the month"),
+		},
+		rows: func(s *openDataService) ([][]interface{}, utils.Error) {
+			return s.trendRows(s.reportSnapshotService.GetDisabilityTrend)
+		},
+	},
+	{
+		id:          "employment-trend",
+		title:       "Monthly vacancies and applies",
+		description: "The vacancies open at the end of each of the last 12 months and the applies made, accepted and rejected in it, from the nightly snapshots.",
+		version:     "1.0",
+		fields: []model.OpenDataField{
+			{Name: "month", Type: "string", Description: "Month, as YYYY-MM"},
+			{Name: "metric", Type: "string", Description: "open_vacancies, applies, accepted or rejected"},
+			countField("count", "Value of the metric in the month, the open vacancies never suppressed"),
+		},
+		rows: func(s *openDataService) ([][]interface{}, utils.Error) {
+			return s.trendRows(s.reportSnapshotService.GetEmploymentTrend, trendOpenVacancies)
+		},
+	},
+}
+
+// ListDatasets lists the datasets, updating the ones not published today.
+func (s *openDataService) ListDatasets() ([]model.OpenDataset, utils.Error) {
+	datasets := []model.OpenDataset{}
+
+	for _, dataset := range openDatasets {
+		data, err := s.publish(dataset)
+		if err.Code != "" {
+			return nil, err
+		}
+
+		datasets = append(datasets, data.Dataset)
+	}
+
+	return datasets, utils.Error{}
+}
+
+// GetDataset returns the dataset published today, with an empty id when
+// there is no dataset of the id.
+func (s *openDataService) GetDataset(id string) (model.OpenData, utils.Error) {
+	for _, dataset := range openDatasets {
+		if dataset.id == id {
+			return s.publish(dataset)
+		}
+	}
+
+	return model.OpenData{}, utils.Error{}
+}
+
+func (s *openDataService) publish(dataset openDataset) (model.OpenData, utils.Error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now().In(SnapshotsLocation())
+
+	if data, ok := s.published[dataset.id]; ok && sameDay(data.Dataset.UpdatedAt.In(SnapshotsLocation()), now) {
+		return data, utils.Error{}
+	}
+
+	rows, err := dataset.rows(s)
+	if err.Code != "" {
+		return model.OpenData{}, err
+	}
+
+	data := model.OpenData{
+		Dataset: model.OpenDataset{
+			Id:          dataset.id,
+			Title:       dataset.title,
+			Description: dataset.description,
+			Version:     dataset.version,
+			UpdatedAt:   now.Truncate(time.Second),
+			License:     OpenDataLicense,
+			Fields:      dataset.fields,
+			Dictionary:  "/open-data/" + dataset.id + "/dictionary",
+			Downloads: []model.OpenDataDownload{
+				{Format: "csv", URL: "/open-data/" + dataset.id + "?format=csv"},
+				{Format: "json", URL: "/open-data/" + dataset.id + "?format=json"},
+			},
+		},
+		Rows: rows,
+	}
+
+	s.published[dataset.id] = data
+
+	return data, utils.Error{}
+}
+
+func sameDay(a time.Time, b time.Time) bool {
+	return a.Format(time.DateOnly) == b.Format(time.DateOnly)
+}
+
+// openDataCell is the value of a count in a dataset, nil when suppressed.
+func openDataCell(count model.Count) interface{} {
+	if count.Suppressed() {
+		return nil
+	}
+
+	return int(count)
+}
+
+// openDataRate computes the rate from the counts as published, so it
+// doesn't reveal them without their noise.
+func openDataRate(part model.Count, total model.Count) interface{} {
+	if part.Suppressed() || total.Suppressed() {
+		return nil
+	}
+
+	return *ratio(part, total)
+}
+
+func (s *openDataService) disabilityTotalsRows() ([][]interface{}, utils.Error) {
+	totals, err := s.reportsService.GetDisabilityTotals()
+	if err.Code != "" {
+		return nil, err
+	}
+
+	totals = s.privacy.DisabilityTotals(totals)
+
+	categories := []string{}
+	for category := range totals {
+		categories = append(categories, string(category))
+	}
+
+	sort.Strings(categories)
+
+	rows := [][]interface{}{}
+	for _, category := range categories {
+		rows = append(rows, []interface{}{category, openDataCell(totals[enum.DisabilityCategoryEnum(category)])})
+	}
+
+	return rows, utils.Error{}
+}
+
+// crossTabRows has a row per category and value of the dimension.
+func (s *openDataService) crossTabRows(columns enum.ReportDimension) ([][]interface{}, utils.Error) {
+	crossTab, err := s.reportsService.GetDisabilityCrossTab(model.CrossTabFilter{Rows: enum.DimensionCategory, Columns: columns})
+	if err.Code != "" {
+		return nil, err
+	}
+
+	crossTab = s.privacy.CrossTab(crossTab)
+
+	rows := [][]interface{}{}
+	for i, category := range crossTab.Rows.Labels {
+		for j, value := range crossTab.Columns.Labels {
+			rows = append(rows, []interface{}{category, value, openDataCell(crossTab.Values[i][j])})
+		}
+	}
+
+	return rows, utils.Error{}
+}
+
+func (s *openDataService) vacanciesRows() ([][]interface{}, utils.Error) {
+	totals, err := s.reportsService.CountVacanciesByCategory(model.EmploymentReportFilter{})
+	if err.Code != "" {
+		return nil, err
+	}
+
+	rows := [][]interface{}{}
+	for _, total := range totals {
+		rows = append(rows, []interface{}{string(total.Category), total.Vacancies})
+	}
+
+	return rows, utils.Error{}
+}
+
+func (s *openDataService) acceptanceRatesRows() ([][]interface{}, utils.Error) {
+	rates, err := s.reportsService.GetAcceptanceRates(model.EmploymentReportFilter{}, enum.ByDisabilityCategory)
+	if err.Code != "" {
+		return nil, err
+	}
+
+	rates = s.privacy.AcceptanceRates(rates)
+
+	rows := [][]interface{}{}
+	for _, rate := range rates {
+		rows = append(rows, []interface{}{
+			rate.Group, openDataCell(rate.Applications), openDataCell(rate.Accepted), openDataCell(rate.Rejected),
+			openDataRate(rate.Accepted, rate.Applications),
+		})
+	}
+
+	return rows, utils.Error{}
+}
+
+func (s *openDataService) applyFunnelRows() ([][]interface{}, utils.Error) {
+	stages, err := s.reportsService.GetApplyFunnel(model.EmploymentReportFilter{})
+	if err.Code != "" {
+		return nil, err
+	}
+
+	stages = s.privacy.ApplyFunnel(stages)
+
+	rows := [][]interface{}{}
+	for _, stage := range stages {
+		rows = append(rows, []interface{}{stage.Stage, openDataCell(stage.Count), openDataRate(stage.Count, stages[0].Count)})
+	}
+
+	return rows, utils.Error{}
+}
+
+// trendRows has a row per month of the last ones and series of the trend.
+func (s *openDataService) trendRows(getTrend func(filter model.TrendFilter) (model.Trend, utils.Error), public ...string) ([][]interface{}, utils.Error) {
+	year, month, _ := time.Now().In(SnapshotsLocation()).Date()
+	end := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+
+	trend, err := getTrend(model.TrendFilter{
+		From:        end.AddDate(0, -openDataTrendMonths, 0).Format(time.DateOnly),
+		To:          end.AddDate(0, 0, -1).Format(time.DateOnly),
+		Granularity: enum.Month,
+	})
+	if err.Code != "" {
+		return nil, err
+	}
+
+	trend = s.privacy.Trend(trend, public...)
+
+	rows := [][]interface{}{}
+	for _, series := range trend.Series {
+		for _, point := range series.Points {
+			rows = append(rows, []interface{}{point.Label, series.Name, openDataCell(point.Count)})
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i][0].(string) < rows[j][0].(string)
+	})
+
+	return rows, utils.Error{}
+}
@@ -1,29 +1,28 @@
 package service
 
 import (
+	"cij_api/src/enum"
 	"cij_api/src/model"
 	"cij_api/src/repo"
 	"cij_api/src/utils"
 	"fmt"
-	"mime/multipart"
 
 	"gorm.io/gorm"
 )
 
 type PersonService interface {
-	CreatePerson(createPerson model.PersonRequest) utils.Error
+	CreatePerson(createPerson model.PersonRequest, actor model.AuditActor) utils.Error
 	ListPeople() ([]model.PersonResponse, utils.Error)
 	GetPersonByUserId(userId int) (model.Person, utils.Error)
 	GetPersonById(personId int) (model.PersonResponse, utils.Error)
 	GetPersonByCpf(cpf string) (model.Person, utils.Error)
 	GetUserByEmail(email string) (model.User, utils.Error)
 	GetDisabilityById(disabilityId int) (model.Disability, utils.Error)
-	UpdatePerson(person model.PersonRequest, personId int) utils.Error
-	UpdatePersonAddress(address model.AddressRequest, personId int, tx *gorm.DB) utils.Error
-	UpdatePersonDisabilities(disabilities []model.PersonDisabilityRequest, personId int, tx *gorm.DB) utils.Error
-	DeletePerson(personId int) utils.Error
-
-	UploadCurriculum(curriculum multipart.FileHeader, personId int) utils.Error
+	GetConfigPresetBySlug(slug string) (model.ConfigPresetResponse, utils.Error)
+	UpdatePerson(person model.PersonRequest, personId int, actor model.AuditActor) utils.Error
+	UpdatePersonAddress(address model.AddressRequest, personId int, actor model.AuditActor) utils.Error
+	UpdatePersonDisabilities(disabilities []model.PersonDisabilityRequest, personId int, actor model.AuditActor) utils.Error
+	DeletePerson(personId int, actor model.AuditActor) utils.Error
 }
 
 type personService struct {
@@ -32,6 +31,9 @@ type personService struct {
 	addressRepo          repo.AddressRepo
 	personDisabilityRepo repo.PersonDisabilityRepo
 	activityRepo         repo.ActivityRepo
+	addressService       AddressService
+	configService        ConfigService
+	auditService         AuditService
 }
 
 func NewPersonService(
@@ -40,6 +42,9 @@ func NewPersonService(
 	addressRepo repo.AddressRepo,
 	personDisabilityRepo repo.PersonDisabilityRepo,
 	activityRepo repo.ActivityRepo,
+	addressService AddressService,
+	configService ConfigService,
+	auditService AuditService,
 ) PersonService {
 	return &personService{
 		personRepo:           personRepo,
@@ -47,6 +52,9 @@ func NewPersonService(
 		addressRepo:          addressRepo,
 		personDisabilityRepo: personDisabilityRepo,
 		activityRepo:         activityRepo,
+		addressService:       addressService,
+		configService:        configService,
+		auditService:         auditService,
 	}
 }
 
@@ -75,7 +83,9 @@ func (s *personService) ListPeople() ([]model.PersonResponse, utils.Error) {
 	return peopleResponse, utils.Error{}
 }
 
-func (n *personService) CreatePerson(createPerson model.PersonRequest) utils.Error {
+// CreatePerson registers the person and its user. Anonymous registrations are
+// audited as made by the new user.
+func (n *personService) CreatePerson(createPerson model.PersonRequest, actor model.AuditActor) utils.Error {
 	userInfo := createPerson.ToUser()
 
 	hashedPassword, err := utils.EncryptPassword(userInfo.Password)
@@ -86,6 +96,11 @@ func (n *personService) CreatePerson(createPerson model.PersonRequest) utils.Err
 	userInfo.Password = hashedPassword
 	userInfo.RoleId = model.PersonRole
 
+	addressInfo := createPerson.Address.ToModel()
+	n.addressService.LocateAddress(&addressInfo)
+
+	var personId int
+
 	errTx := n.userRepo.BeginTransaction(func(tx *gorm.DB) error {
 		userId, userError := n.userRepo.CreateUser(userInfo, tx)
 		if userError.Code != "" {
@@ -98,19 +113,21 @@ func (n *personService) CreatePerson(createPerson model.PersonRequest) utils.Err
 		personInfo := createPerson.ToModel(userInfo)
 		personInfo.UserId = userId
 
-		personId, personError := n.personRepo.CreatePerson(personInfo, tx)
+		var personError utils.Error
+
+		personId, personError = n.personRepo.CreatePerson(personInfo, tx)
 		if personError.Code != "" {
 			fmt.Print("Error: ", personError)
 			return personError
 		}
 
-		addressError := n.UpdatePersonAddress(createPerson.Address, personId, tx)
+		addressError := n.updatePersonAddress(addressInfo, personId, tx)
 		if addressError.Code != "" {
 			fmt.Println("Error: ", addressError)
 			return addressError
 		}
 
-		disabilityError := n.UpdatePersonDisabilities(createPerson.Disabilities, personId, tx)
+		disabilityError := n.updatePersonDisabilities(createPerson.Disabilities, personId, tx)
 		if disabilityError.Code != "" {
 			fmt.Print("Error: ", disabilityError)
 			return disabilityError
@@ -123,27 +140,28 @@ func (n *personService) CreatePerson(createPerson model.PersonRequest) utils.Err
 		return personServiceError("failed to create the person", "02")
 	}
 
-	activityService := NewActivityService(n.activityRepo)
-	activity := model.Activity{
-		Type:        "register_person",
-		Description: "Person " + userInfo.Email + " registered",
-		Actor:       userInfo.Email,
-	}
+	actor = actor.OrRegistering(userInfo.Id, userInfo.Email, userInfo.RoleId.Name())
 
-	activityError := activityService.CreateActivity(&activity)
-	if activityError.Code != "" {
-		return activityError
+	person, personError := n.GetPersonById(personId)
+	if personError.Code != "" {
+		return personError
 	}
 
-	configService := NewConfigService(n.userRepo)
+	n.auditService.Record(actor, enum.AuditCreate, enum.AuditPerson, personId, nil, person)
 
-	uploadErr := configService.UploadUserConfig(userInfo.Email, nil)
-	if uploadErr.Code != "" {
-		fmt.Print("Error: ", uploadErr)
-		return uploadErr
+	if createPerson.ConfigPreset != "" {
+		if _, err := n.configService.ApplyConfigPreset(userInfo.Id, createPerson.ConfigPreset, actor); err.Code != "" {
+			return err
+		}
 	}
 
-	return utils.Error{}
+	activity := model.Activity{
+		Type:        "register_person",
+		Description: "Person " + userInfo.Email + " registered",
+		Actor:       userInfo.Email,
+	}
+
+	return n.activityRepo.CreateActivity(&activity)
 }
 
 func (n *personService) GetPersonByUserId(userId int) (model.Person, utils.Error) {
@@ -164,7 +182,7 @@ func (s *personService) GetPersonById(personId int) (model.PersonResponse, utils
 	}
 
 	if person.Id == 0 {
-		return personResponse, personServiceError("person not found", "01")
+		return personResponse, utils.Error{}
 	}
 
 	s.personToResponse(&personResponse, person)
@@ -190,7 +208,12 @@ func (n *personService) GetUserByEmail(email string) (model.User, utils.Error) {
 	return user, utils.Error{}
 }
 
-func (n *personService) UpdatePerson(updatePerson model.PersonRequest, personId int) utils.Error {
+func (n *personService) UpdatePerson(updatePerson model.PersonRequest, personId int, actor model.AuditActor) utils.Error {
+	before, err := n.GetPersonById(personId)
+	if err.Code != "" {
+		return err
+	}
+
 	userInfo := updatePerson.ToUser()
 
 	if userInfo.Password != "" {
@@ -201,7 +224,12 @@ func (n *personService) UpdatePerson(updatePerson model.PersonRequest, personId
 
 		userInfo.Password = hashedPassword
 
-		userError := n.userRepo.UpdateUser(userInfo, personId)
+		person, personError := n.personRepo.GetPersonById(personId, nil)
+		if personError.Code != "" {
+			return personError
+		}
+
+		userError := n.userRepo.UpdateUser(userInfo, person.UserId)
 		if userError.Code != "" {
 			return userError
 		}
@@ -214,12 +242,26 @@ func (n *personService) UpdatePerson(updatePerson model.PersonRequest, personId
 		return personError
 	}
 
-	return utils.Error{}
+	return n.recordPersonUpdate(actor, personId, before)
 }
 
-func (n *personService) UpdatePersonAddress(updateAddress model.AddressRequest, personId int, tx *gorm.DB) utils.Error {
+func (n *personService) UpdatePersonAddress(updateAddress model.AddressRequest, personId int, actor model.AuditActor) utils.Error {
+	before, err := n.GetPersonById(personId)
+	if err.Code != "" {
+		return err
+	}
+
 	addressInfo := updateAddress.ToModel()
+	n.addressService.LocateAddress(&addressInfo)
 
+	if err := n.updatePersonAddress(addressInfo, personId, nil); err.Code != "" {
+		return err
+	}
+
+	return n.recordPersonUpdate(actor, personId, before)
+}
+
+func (n *personService) updatePersonAddress(addressInfo model.Address, personId int, tx *gorm.DB) utils.Error {
 	person, err := n.personRepo.GetPersonById(personId, tx)
 	if err.Code != "" {
 		return err
@@ -244,6 +286,10 @@ func (n *personService) UpdatePersonAddress(updateAddress model.AddressRequest,
 	return utils.Error{}
 }
 
+func (n *personService) GetConfigPresetBySlug(slug string) (model.ConfigPresetResponse, utils.Error) {
+	return n.configService.GetConfigPresetBySlug(slug)
+}
+
 func (n *personService) GetDisabilityById(id int) (model.Disability, utils.Error) {
 	disability, err := n.personDisabilityRepo.GetDisabilityById(id)
 	if err.Code != "" {
@@ -253,7 +299,20 @@ func (n *personService) GetDisabilityById(id int) (model.Disability, utils.Error
 	return disability, utils.Error{}
 }
 
-func (n *personService) UpdatePersonDisabilities(disabilities []model.PersonDisabilityRequest, personId int, tx *gorm.DB) utils.Error {
+func (n *personService) UpdatePersonDisabilities(disabilities []model.PersonDisabilityRequest, personId int, actor model.AuditActor) utils.Error {
+	before, err := n.GetPersonById(personId)
+	if err.Code != "" {
+		return err
+	}
+
+	if err := n.updatePersonDisabilities(disabilities, personId, nil); err.Code != "" {
+		return err
+	}
+
+	return n.recordPersonUpdate(actor, personId, before)
+}
+
+func (n *personService) updatePersonDisabilities(disabilities []model.PersonDisabilityRequest, personId int, tx *gorm.DB) utils.Error {
 	person, err := n.personRepo.GetPersonById(personId, tx)
 	if err.Code != "" {
 		return err
@@ -285,7 +344,12 @@ func (n *personService) UpdatePersonDisabilities(disabilities []model.PersonDisa
 	return utils.Error{}
 }
 
-func (n *personService) DeletePerson(personId int) utils.Error {
+func (n *personService) DeletePerson(personId int, actor model.AuditActor) utils.Error {
+	before, err := n.GetPersonById(personId)
+	if err.Code != "" {
+		return err
+	}
+
 	person, err := n.personRepo.GetPersonById(personId, nil)
 	if err.Code != "" {
 		return err
@@ -311,32 +375,19 @@ func (n *personService) DeletePerson(personId int) utils.Error {
 		return err
 	}
 
+	n.auditService.Record(actor, enum.AuditDelete, enum.AuditPerson, personId, before, nil)
+
 	return utils.Error{}
 }
 
-func (n *personService) UploadCurriculum(curriculum multipart.FileHeader, personId int) utils.Error {
-	person, err := n.personRepo.GetPersonById(personId, nil)
+// recordPersonUpdate audits the changes to the person since the before state.
+func (n *personService) recordPersonUpdate(actor model.AuditActor, personId int, before model.PersonResponse) utils.Error {
+	after, err := n.GetPersonById(personId)
 	if err.Code != "" {
 		return err
 	}
 
-	openCurriculum, fileError := curriculum.Open()
-	if fileError != nil {
-		return personServiceError("failed to open the file", "03")
-	}
-
-	defer openCurriculum.Close()
-
-	filesService := NewFilesService()
-	url, uploadError := filesService.UploadFile(openCurriculum, "cij/curriculum/"+person.Cpf)
-	if uploadError != nil {
-		return personServiceError("failed to upload the file", "04")
-	}
-
-	err = n.personRepo.UploadCurriculum(personId, url)
-	if err.Code != "" {
-		return err
-	}
+	n.auditService.Record(actor, enum.AuditUpdate, enum.AuditPerson, personId, before, after)
 
 	return utils.Error{}
 }
@@ -377,15 +428,9 @@ func (n *personService) personToResponse(personResponse *model.PersonResponse, p
 		personResponse.Disabilities = &disabilitiesResponse
 	}
 
-	var userConfig interface{}
-	userConfig = model.DefaultConfig
-
-	if user.ConfigUrl != "" {
-		configService := NewConfigService(n.userRepo)
-		userConfig, err = configService.GetUserConfig(user.ConfigUrl)
-		if err.Code != "" {
-			return *personResponse, err
-		}
+	userConfig, err := n.configService.GetUserConfig(user.Id)
+	if err.Code != "" {
+		return *personResponse, err
 	}
 
 	personResponse.User.Config = userConfig
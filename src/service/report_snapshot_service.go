@@ -0,0 +1,440 @@
+package service
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/repo"
+	"cij_api/src/utils"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	_ "time/tzdata"
+)
+
+const (
+	// SnapshotsTimezone is the timezone of the snapshot days.
+	SnapshotsTimezone = "America/Sao_Paulo"
+
+	maxSnapshotDays = 366
+	// maxMissingSnapshotDays is how far back the missing snapshots are taken
+	// when the scheduler was stopped for a while.
+	maxMissingSnapshotDays = 31
+)
+
+// the series of the employment trend, the open vacancies counted at the end
+// of the buckets and the others summed over them.
+const (
+	trendOpenVacancies = "open_vacancies"
+	trendApplies       = "applies"
+	trendAccepted      = "accepted"
+	trendRejected      = "rejected"
+)
+
+// ReportSnapshotService stores the daily counts of the reports, so their
+// history survives the activities archived and the people deleted, and reads
+// the trends from them.
+type ReportSnapshotService interface {
+	TakeSnapshots(from string, to string, actor model.AuditActor) (model.SnapshotResult, utils.Error)
+	TakeMissingSnapshots(actor model.AuditActor) (model.SnapshotResult, utils.Error)
+	GetDisabilityTrend(filter model.TrendFilter) (model.Trend, utils.Error)
+	GetActivityTrend(filter model.TrendFilter) (model.Trend, utils.Error)
+	GetEmploymentTrend(filter model.TrendFilter) (model.Trend, utils.Error)
+}
+
+type reportSnapshotService struct {
+	reportSnapshotRepo   repo.ReportSnapshotRepo
+	personDisabilityRepo repo.PersonDisabilityRepo
+	activityRepo         repo.ActivityRepo
+	employmentReportRepo repo.EmploymentReportRepo
+	auditService         AuditService
+}
+
+func NewReportSnapshotService(
+	reportSnapshotRepo repo.ReportSnapshotRepo,
+	personDisabilityRepo repo.PersonDisabilityRepo,
+	activityRepo repo.ActivityRepo,
+	employmentReportRepo repo.EmploymentReportRepo,
+	auditService AuditService,
+) ReportSnapshotService {
+	return &reportSnapshotService{
+		reportSnapshotRepo:   reportSnapshotRepo,
+		personDisabilityRepo: personDisabilityRepo,
+		activityRepo:         activityRepo,
+		employmentReportRepo: employmentReportRepo,
+		auditService:         auditService,
+	}
+}
+
+// SnapshotsLocation is the location of SnapshotsTimezone.
+func SnapshotsLocation() *time.Location {
+	location, err := time.LoadLocation(SnapshotsTimezone)
+	if err != nil {
+		return time.Local
+	}
+
+	return location
+}
+
+// today is the midnight starting the current day of the snapshots.
+func today() time.Time {
+	year, month, day := time.Now().In(SnapshotsLocation()).Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, SnapshotsLocation())
+}
+
+// TakeSnapshots recomputes the snapshots of the days from from to to, both
+// included, which must be over and newer than the shortest activity
+// retention, as the older activities may be archived.
+func (s *reportSnapshotService) TakeSnapshots(from string, to string, actor model.AuditActor) (model.SnapshotResult, utils.Error) {
+	start, end, err := parseSnapshotPeriod(from, to)
+	if err.Code != "" {
+		return model.SnapshotResult{}, err
+	}
+
+	if days := int(math.Round(end.Sub(start).Hours()/24)) + 1; days > maxSnapshotDays {
+		return model.SnapshotResult{}, reportsValidationError("at most 366 days can be snapshot at once", "10", []model.Field{
+			{Name: "from", Value: from},
+			{Name: "to", Value: to},
+		})
+	}
+
+	if !end.Before(today()) {
+		return model.SnapshotResult{}, reportsValidationError("only the days already over can be snapshot", "11", []model.Field{
+			{Name: "to", Value: to},
+		})
+	}
+
+	oldest, err := s.oldestSnapshotDay()
+	if err.Code != "" {
+		return model.SnapshotResult{}, err
+	}
+
+	if start.Before(oldest) {
+		return model.SnapshotResult{}, reportsValidationError("the days older than the shortest activity retention can't be snapshot", "12", []model.Field{
+			{Name: "from", Value: from},
+		})
+	}
+
+	return s.takeSnapshots(start, end, actor)
+}
+
+// TakeMissingSnapshots takes the snapshots of the days over since the last
+// one, up to a month back and to the shortest activity retention, or of
+// yesterday when there is none.
+func (s *reportSnapshotService) TakeMissingSnapshots(actor model.AuditActor) (model.SnapshotResult, utils.Error) {
+	end := today().AddDate(0, 0, -1)
+	start := end
+
+	last, err := s.reportSnapshotRepo.GetLastSnapshotDate()
+	if err.Code != "" {
+		return model.SnapshotResult{}, err
+	}
+
+	if last != "" {
+		lastDay, parseErr := time.ParseInLocation(time.DateOnly, last, SnapshotsLocation())
+		if parseErr == nil {
+			start = lastDay.AddDate(0, 0, 1)
+		}
+	}
+
+	if oldest := end.AddDate(0, 0, 1-maxMissingSnapshotDays); start.Before(oldest) {
+		start = oldest
+	}
+
+	oldest, err := s.oldestSnapshotDay()
+	if err.Code != "" {
+		return model.SnapshotResult{}, err
+	}
+
+	if start.Before(oldest) {
+		start = oldest
+	}
+
+	if start.After(end) {
+		return model.SnapshotResult{}, utils.Error{}
+	}
+
+	return s.takeSnapshots(start, end, actor)
+}
+
+// oldestSnapshotDay is the oldest day whose activities can't have been
+// archived yet, or the zero time when no activity type has a retention.
+func (s *reportSnapshotService) oldestSnapshotDay() (time.Time, utils.Error) {
+	retentions, err := s.activityRepo.ListActivityRetentions()
+	if err.Code != "" {
+		return time.Time{}, err
+	}
+
+	oldest := time.Time{}
+	for _, retention := range retentions {
+		// the activities are archived once older than the retention, so the
+		// day starting at today's midnight minus the retention may be too
+		if day := today().AddDate(0, 0, 1-retention.Days); day.After(oldest) {
+			oldest = day
+		}
+	}
+
+	return oldest, utils.Error{}
+}
+
+func (s *reportSnapshotService) takeSnapshots(start time.Time, end time.Time, actor model.AuditActor) (model.SnapshotResult, utils.Error) {
+	result := model.SnapshotResult{
+		From: start.Format(time.DateOnly),
+		To:   end.Format(time.DateOnly),
+	}
+
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if err := s.takeSnapshot(day); err.Code != "" {
+			return result, err
+		}
+
+		result.Days++
+	}
+
+	s.auditService.Record(actor, enum.AuditCreate, enum.AuditReportSnapshot, 0, nil, result)
+
+	return result, utils.Error{}
+}
+
+// takeSnapshot counts the day starting at the midnight. The people deleted
+// are gone for good, so the people of a day already snapshot are kept
+// instead of counted again without them.
+func (s *reportSnapshotService) takeSnapshot(day time.Time) utils.Error {
+	date := day.Format(time.DateOnly)
+	end := day.AddDate(0, 0, 1)
+
+	disabilitySnapshots, err := s.reportSnapshotRepo.ListDisabilitySnapshots(date, date)
+	if err.Code != "" {
+		return err
+	}
+
+	if len(disabilitySnapshots) == 0 {
+		totals, err := s.personDisabilityRepo.CountDisabilityAt(end)
+		if err.Code != "" {
+			return err
+		}
+
+		for category, people := range totals {
+			disabilitySnapshots = append(disabilitySnapshots, model.DisabilitySnapshot{Date: date, Category: category, People: int(people)})
+		}
+	}
+
+	activities, err := s.activityRepo.CountActivitiesByType(day, end)
+	if err.Code != "" {
+		return err
+	}
+
+	employment, err := s.employmentReportRepo.CountEmploymentDay(day)
+	if err.Code != "" {
+		return err
+	}
+
+	activitySnapshots := []model.ActivitySnapshot{}
+	for _, activity := range activities {
+		activitySnapshots = append(activitySnapshots, model.ActivitySnapshot{Date: date, Type: activity.Type, Count: activity.Count})
+	}
+
+	return s.reportSnapshotRepo.SaveSnapshot(
+		model.ReportSnapshot{Date: date, TakenAt: time.Now()},
+		disabilitySnapshots,
+		activitySnapshots,
+		model.EmploymentSnapshot{
+			Date:          date,
+			OpenVacancies: employment.OpenVacancies,
+			Applies:       employment.Applies,
+			Accepted:      employment.Accepted,
+			Rejected:      employment.Rejected,
+		},
+	)
+}
+
+// GetDisabilityTrend has a series per disability category with the people
+// at the end of each bucket.
+func (s *reportSnapshotService) GetDisabilityTrend(filter model.TrendFilter) (model.Trend, utils.Error) {
+	dates, err := s.trendDates(filter)
+	if err.Code != "" {
+		return model.Trend{}, err
+	}
+
+	snapshots, err := s.reportSnapshotRepo.ListDisabilitySnapshots(filter.From, filter.To)
+	if err.Code != "" {
+		return model.Trend{}, err
+	}
+
+	values := trendValues{}
+	for _, snapshot := range snapshots {
+		values.set(string(snapshot.Category), snapshot.Date, snapshot.People)
+	}
+
+	return buildTrend(filter, "category", dates, values, values.names(), func(string) bool { return true }), utils.Error{}
+}
+
+// GetActivityTrend has a series per activity type with the activities made
+// in each bucket, of the types of the filter or of all of them.
+func (s *reportSnapshotService) GetActivityTrend(filter model.TrendFilter) (model.Trend, utils.Error) {
+	if len(filter.Types) > maxReportActivityTypes {
+		return model.Trend{}, reportsValidationError("at most 10 activity types can be informed", "01", []model.Field{
+			{Name: "types", Value: fmt.Sprint(len(filter.Types))},
+		})
+	}
+
+	dates, err := s.trendDates(filter)
+	if err.Code != "" {
+		return model.Trend{}, err
+	}
+
+	snapshots, err := s.reportSnapshotRepo.ListActivitySnapshots(filter.Types, filter.From, filter.To)
+	if err.Code != "" {
+		return model.Trend{}, err
+	}
+
+	values := trendValues{}
+	for _, snapshot := range snapshots {
+		values.set(snapshot.Type, snapshot.Date, snapshot.Count)
+	}
+
+	names := filter.Types
+	if len(names) == 0 {
+		names = values.names()
+	}
+
+	return buildTrend(filter, "activity_type", dates, values, names, func(string) bool { return false }), utils.Error{}
+}
+
+// GetEmploymentTrend has the vacancies open at the end of each bucket and
+// the applies made, accepted and rejected in it.
+func (s *reportSnapshotService) GetEmploymentTrend(filter model.TrendFilter) (model.Trend, utils.Error) {
+	dates, err := s.trendDates(filter)
+	if err.Code != "" {
+		return model.Trend{}, err
+	}
+
+	snapshots, err := s.reportSnapshotRepo.ListEmploymentSnapshots(filter.From, filter.To)
+	if err.Code != "" {
+		return model.Trend{}, err
+	}
+
+	values := trendValues{}
+	for _, snapshot := range snapshots {
+		values.set(trendOpenVacancies, snapshot.Date, snapshot.OpenVacancies)
+		values.set(trendApplies, snapshot.Date, snapshot.Applies)
+		values.set(trendAccepted, snapshot.Date, snapshot.Accepted)
+		values.set(trendRejected, snapshot.Date, snapshot.Rejected)
+	}
+
+	names := []string{trendOpenVacancies, trendApplies, trendAccepted, trendRejected}
+
+	return buildTrend(filter, "metric", dates, values, names, func(name string) bool { return name == trendOpenVacancies }), utils.Error{}
+}
+
+// trendDates validates the filter and lists the days snapshot in it.
+func (s *reportSnapshotService) trendDates(filter model.TrendFilter) ([]string, utils.Error) {
+	if !filter.Granularity.IsValid() {
+		return nil, reportsValidationError("granularity must be 'day', 'week', 'month', 'quarter' or 'year'", "02", []model.Field{
+			{Name: "granularity", Value: string(filter.Granularity)},
+		})
+	}
+
+	if _, _, err := parseSnapshotPeriod(filter.From, filter.To); err.Code != "" {
+		return nil, err
+	}
+
+	return s.reportSnapshotRepo.ListSnapshotDates(filter.From, filter.To)
+}
+
+// parseSnapshotPeriod parses the days from and to, from not after to.
+func parseSnapshotPeriod(from string, to string) (time.Time, time.Time, utils.Error) {
+	start, startErr := time.ParseInLocation(time.DateOnly, from, SnapshotsLocation())
+	end, endErr := time.ParseInLocation(time.DateOnly, to, SnapshotsLocation())
+
+	if startErr != nil || endErr != nil || start.After(end) {
+		return time.Time{}, time.Time{}, reportsValidationError("from and to must be days, from not after to", "09", []model.Field{
+			{Name: "from", Value: from},
+			{Name: "to", Value: to},
+		})
+	}
+
+	return start, end, utils.Error{}
+}
+
+// trendValues are the snapshot values per series and day.
+type trendValues map[string]map[string]int
+
+func (v trendValues) set(name string, date string, value int) {
+	if v[name] == nil {
+		v[name] = map[string]int{}
+	}
+
+	v[name][date] = value
+}
+
+func (v trendValues) names() []string {
+	names := []string{}
+	for name := range v {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// buildTrend adds the values of the snapshot days per bucket, or keeps the
+// last day of each bucket for the series counted at the end of the days.
+// The days without a value of a series count as zero.
+func buildTrend(filter model.TrendFilter, dimension string, dates []string, values trendValues, names []string, atEndOfDay func(name string) bool) model.Trend {
+	trend := model.Trend{
+		From:        filter.From,
+		To:          filter.To,
+		Granularity: filter.Granularity,
+		Dimension:   dimension,
+		Series:      []model.TrendSeries{},
+	}
+
+	buckets := []time.Time{}
+	bucketDates := map[string][]string{}
+
+	for _, date := range dates {
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			continue
+		}
+
+		start := truncateToBucket(day, filter.Granularity)
+		key := start.Format(time.DateOnly)
+
+		if _, ok := bucketDates[key]; !ok {
+			buckets = append(buckets, start)
+		}
+
+		bucketDates[key] = append(bucketDates[key], date)
+	}
+
+	for _, name := range names {
+		series := model.TrendSeries{Name: name, Points: []model.TrendPoint{}}
+
+		for _, start := range buckets {
+			days := bucketDates[start.Format(time.DateOnly)]
+			count := model.Count(0)
+
+			if atEndOfDay(name) {
+				count = model.Count(values[name][days[len(days)-1]])
+			} else {
+				for _, date := range days {
+					count += model.Count(values[name][date])
+				}
+			}
+
+			series.Points = append(series.Points, model.TrendPoint{
+				Label: bucketLabel(start, filter.Granularity),
+				Start: start.Format(time.DateOnly),
+				Count: count,
+			})
+		}
+
+		trend.Series = append(trend.Series, series)
+	}
+
+	return trend
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"cij_api/src/config"
+	"cij_api/src/model"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// defaultMinCellSize is the smallest count the public reports show when
+// REPORTS_MIN_CELL_SIZE isn't set.
+const defaultMinCellSize = 5
+
+// ReportsPrivacy hides the report counts small enough to identify someone.
+// The counts from 1 to MinCellSize-1 are suppressed, and so are as many
+// others as needed for them not to be recovered from the totals. Published
+// data may also get Laplace noise of NoiseScale added to the counts shown.
+// The zero value leaves the counts exact.
+type ReportsPrivacy struct {
+	MinCellSize int
+	NoiseScale  float64
+}
+
+// ExactReports leaves the counts as they are, for the admins.
+var ExactReports = ReportsPrivacy{}
+
+// PublicReportsPrivacy suppresses the small counts of the public endpoints.
+func PublicReportsPrivacy(config *config.Config) ReportsPrivacy {
+	if config.ReportsMinCellSize <= 0 {
+		return ReportsPrivacy{MinCellSize: defaultMinCellSize}
+	}
+
+	return ReportsPrivacy{MinCellSize: config.ReportsMinCellSize}
+}
+
+// PublishedReportsPrivacy also adds the noise of OPEN_DATA_NOISE_SCALE to the
+// counts, for the reports published as open data.
+func PublishedReportsPrivacy(config *config.Config) ReportsPrivacy {
+	privacy := PublicReportsPrivacy(config)
+
+	if config.OpenDataNoiseScale > 0 {
+		privacy.NoiseScale = config.OpenDataNoiseScale
+	}
+
+	return privacy
+}
+
+func (p ReportsPrivacy) small(count model.Count) bool {
+	return count > 0 && int(count) < p.MinCellSize
+}
+
+// suppress hides the small counts of a line and its total. When one count
+// alone is hidden, it would be the total minus the others, so the next
+// smallest one is hidden too, or the total when there is none. It returns
+// whether any count was hidden.
+func (p ReportsPrivacy) suppress(total *model.Count, line []*model.Count) bool {
+	changed := false
+	hidden := 0
+
+	if p.small(*total) {
+		*total = model.SuppressedCount
+		changed = true
+	}
+
+	for _, count := range line {
+		if p.small(*count) {
+			*count = model.SuppressedCount
+			changed = true
+		}
+
+		if count.Suppressed() {
+			hidden++
+		}
+	}
+
+	if hidden != 1 || total.Suppressed() {
+		return changed
+	}
+
+	var smallest *model.Count
+	for _, count := range line {
+		if *count > 0 && (smallest == nil || *count < *smallest) {
+			smallest = count
+		}
+	}
+
+	if smallest == nil {
+		smallest = total
+	}
+
+	*smallest = model.SuppressedCount
+
+	return true
+}
+
+// noise adds the noise to the counts shown, never below zero.
+func (p ReportsPrivacy) noise(counts ...*model.Count) {
+	if p.NoiseScale <= 0 {
+		return
+	}
+
+	for _, count := range counts {
+		if count.Suppressed() {
+			continue
+		}
+
+		u := rand.Float64() - 0.5
+		noise := -p.NoiseScale * math.Copysign(math.Log(1-2*math.Abs(u)), u)
+
+		*count = model.Count(math.Max(0, math.Round(float64(*count)+noise)))
+	}
+}
+
+func (p ReportsPrivacy) DisabilityTotals(totals model.DisabilityTotals) model.DisabilityTotals {
+	protected := model.DisabilityTotals{}
+
+	for category, count := range totals {
+		if p.small(count) {
+			count = model.SuppressedCount
+		}
+
+		p.noise(&count)
+		protected[category] = count
+	}
+
+	return protected
+}
+
+func (p ReportsPrivacy) ActivitiesByPeriod(activities model.CountActivitiesByPeriod) model.CountActivitiesByPeriod {
+	for month, count := range activities.MonthsCount {
+		if p.small(count) {
+			count = model.SuppressedCount
+		}
+
+		p.noise(&count)
+		activities.MonthsCount[month] = count
+	}
+
+	return activities
+}
+
+// ActivityReport protects the buckets of each series, and of its previous
+// period, against their totals.
+func (p ReportsPrivacy) ActivityReport(report model.ActivityReport) model.ActivityReport {
+	for i := range report.Series {
+		series := &report.Series[i]
+		counts, previousCounts := []*model.Count{}, []*model.Count{}
+
+		for j := range series.Buckets {
+			counts = append(counts, &series.Buckets[j].Count)
+
+			if series.Buckets[j].PreviousCount != nil {
+				previousCounts = append(previousCounts, series.Buckets[j].PreviousCount)
+			}
+		}
+
+		p.suppress(&series.Total, counts)
+		p.noise(append(counts, &series.Total)...)
+
+		if series.Previous == nil {
+			continue
+		}
+
+		p.suppress(&series.Previous.Total, previousCounts)
+		p.noise(append(previousCounts, &series.Previous.Total)...)
+
+		if series.Total.Suppressed() || series.Previous.Total.Suppressed() {
+			series.Previous.ChangePercent = nil
+		}
+	}
+
+	return report
+}
+
+// AppliesByVacancy protects the accepted and rejected applies of each
+// vacancy against the applications.
+func (p ReportsPrivacy) AppliesByVacancy(totals []model.VacancyApplyTotals) []model.VacancyApplyTotals {
+	for i := range totals {
+		p.applies(&totals[i].Applications, &totals[i].Accepted, &totals[i].Rejected)
+	}
+
+	return totals
+}
+
+func (p ReportsPrivacy) AcceptanceRates(rates []model.AcceptanceRate) []model.AcceptanceRate {
+	for i := range rates {
+		rate := &rates[i]
+		p.applies(&rate.Applications, &rate.Accepted, &rate.Rejected)
+
+		if rate.Applications.Suppressed() || rate.Accepted.Suppressed() {
+			rate.Rate = nil
+		}
+	}
+
+	return rates
+}
+
+func (p ReportsPrivacy) applies(applications *model.Count, accepted *model.Count, rejected *model.Count) {
+	if *applications > *accepted+*rejected {
+		// the pending applies aren't shown, so the others can't be told
+		// from the applications
+		for _, count := range []*model.Count{applications, accepted, rejected} {
+			p.suppress(count, nil)
+		}
+	} else {
+		p.suppress(applications, []*model.Count{accepted, rejected})
+	}
+
+	p.noise(applications, accepted, rejected)
+}
+
+func (p ReportsPrivacy) TimeToHire(timeToHire model.TimeToHire) model.TimeToHire {
+	p.suppress(&timeToHire.Hires, nil)
+	p.noise(&timeToHire.Hires)
+
+	if timeToHire.Hires.Suppressed() {
+		timeToHire.MedianDays = nil
+	}
+
+	return timeToHire
+}
+
+// ApplyFunnel protects each stage on its own, as they are nested and none
+// is the difference of the others.
+func (p ReportsPrivacy) ApplyFunnel(stages []model.ApplyFunnelStage) []model.ApplyFunnelStage {
+	for i := range stages {
+		p.suppress(&stages[i].Count, nil)
+		p.noise(&stages[i].Count)
+
+		if stages[i].Count.Suppressed() {
+			stages[i].Rate = nil
+		}
+	}
+
+	return stages
+}
+
+// Trend protects each point on its own, as the trends have no totals, but
+// the points of the series counting what is public, like the open vacancies.
+func (p ReportsPrivacy) Trend(trend model.Trend, public ...string) model.Trend {
+	for i := range trend.Series {
+		if slices.Contains(public, trend.Series[i].Name) {
+			continue
+		}
+
+		for j := range trend.Series[i].Points {
+			p.suppress(&trend.Series[i].Points[j].Count, nil)
+			p.noise(&trend.Series[i].Points[j].Count)
+		}
+	}
+
+	return trend
+}
+
+// CrossTab protects the rows, the columns and their totals until no hidden
+// count can be recovered from the others.
+func (p ReportsPrivacy) CrossTab(crossTab model.CrossTab) model.CrossTab {
+	cells := []*model.Count{&crossTab.Total}
+	rowTotals, columnTotals := []*model.Count{}, []*model.Count{}
+
+	for i := range crossTab.RowTotals {
+		rowTotals = append(rowTotals, &crossTab.RowTotals[i])
+	}
+
+	for j := range crossTab.ColumnTotals {
+		columnTotals = append(columnTotals, &crossTab.ColumnTotals[j])
+	}
+
+	cells = append(append(cells, rowTotals...), columnTotals...)
+
+	for changed := true; changed; {
+		changed = false
+
+		for i := range crossTab.Values {
+			row := []*model.Count{}
+			for j := range crossTab.Values[i] {
+				row = append(row, &crossTab.Values[i][j])
+			}
+
+			changed = p.suppress(rowTotals[i], row) || changed
+		}
+
+		for j := range columnTotals {
+			column := []*model.Count{}
+			for i := range crossTab.Values {
+				column = append(column, &crossTab.Values[i][j])
+			}
+
+			changed = p.suppress(columnTotals[j], column) || changed
+		}
+
+		changed = p.suppress(&crossTab.Total, rowTotals) || changed
+		changed = p.suppress(&crossTab.Total, columnTotals) || changed
+	}
+
+	for i := range crossTab.Values {
+		for j := range crossTab.Values[i] {
+			cells = append(cells, &crossTab.Values[i][j])
+		}
+	}
+
+	p.noise(cells...)
+
+	return crossTab
+}
@@ -5,27 +5,64 @@ import (
 	"cij_api/src/model"
 	"cij_api/src/repo"
 	"cij_api/src/utils"
+	"fmt"
+	"math"
+	"sort"
 	"time"
 )
 
+const (
+	maxReportActivityTypes = 10
+	maxReportBuckets       = 1000
+	unknownLabel           = "unknown"
+)
+
+// ageBands are the upper age of each band of the cross-tabs, but the last.
+var ageBands = []struct {
+	label  string
+	maxAge int
+}{
+	{"0-17", 17},
+	{"18-24", 24},
+	{"25-34", 34},
+	{"35-44", 44},
+	{"45-59", 59},
+	{"60+", math.MaxInt},
+}
+
 type ReportsService interface {
 	GetDisabilityTotals() (model.DisabilityTotals, utils.Error)
 	GetDisabilityTotalsByNeighborhood(neighborhood string) (model.DisabilityTotalsByNeighborhood, utils.Error)
 	CountActivitiesByPeriod(activityType string, period enum.PeriodFilterEnum) (model.CountActivitiesByPeriod, utils.Error)
+	GetActivityReport(filter model.ActivityReportFilter) (model.ActivityReport, utils.Error)
+	CountVacanciesByCategory(filter model.EmploymentReportFilter) ([]model.VacancyCategoryTotal, utils.Error)
+	CountAppliesByVacancy(filter model.EmploymentReportFilter) ([]model.VacancyApplyTotals, utils.Error)
+	GetAcceptanceRates(filter model.EmploymentReportFilter, group enum.AcceptanceRateGroup) ([]model.AcceptanceRate, utils.Error)
+	GetTimeToHire(filter model.EmploymentReportFilter) (model.TimeToHire, utils.Error)
+	GetApplyFunnel(filter model.EmploymentReportFilter) ([]model.ApplyFunnelStage, utils.Error)
+	GetDisabilityCrossTab(filter model.CrossTabFilter) (model.CrossTab, utils.Error)
 }
 
 type reportsService struct {
 	personDisabilityRepo repo.PersonDisabilityRepo
 	activityRepo         repo.ActivityRepo
+	employmentReportRepo repo.EmploymentReportRepo
 }
 
-func NewReportsService(personDisabilityRepo repo.PersonDisabilityRepo, activityRepo repo.ActivityRepo) ReportsService {
+func NewReportsService(personDisabilityRepo repo.PersonDisabilityRepo, activityRepo repo.ActivityRepo, employmentReportRepo repo.EmploymentReportRepo) ReportsService {
 	return &reportsService{
 		personDisabilityRepo: personDisabilityRepo,
 		activityRepo:         activityRepo,
+		employmentReportRepo: employmentReportRepo,
 	}
 }
 
+func reportsValidationError(message string, code string, fields []model.Field) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.ReportsErrorType, code)
+
+	return utils.NewErrorWithFields(message, errorCode, fields)
+}
+
 func (s *reportsService) GetDisabilityTotals() (model.DisabilityTotals, utils.Error) {
 	disabilityTotals, err := s.personDisabilityRepo.CountDisability()
 	if err.Code != "" {
@@ -44,41 +81,545 @@ func (s *reportsService) GetDisabilityTotalsByNeighborhood(neighborhood string)
 	return disabilityTotals, utils.Error{}
 }
 
+// CountActivitiesByPeriod counts the activities of the type per month of the
+// server timezone, from the start of the period until now.
 func (s *reportsService) CountActivitiesByPeriod(activityType string, period enum.PeriodFilterEnum) (model.CountActivitiesByPeriod, utils.Error) {
-	var countActivitiesByPeriod model.CountActivitiesByPeriod
-
-	startDate := time.Now().AddDate(0, 0, -utils.PeriodToDays(period))
 	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -utils.PeriodToDays(period))
 
-	activities, err := s.activityRepo.GetActivitiesByTypeAndPeriod(activityType, utils.GetFormattedDate(startDate.Unix()), utils.GetFormattedDate(endDate.Unix()))
+	report, err := s.GetActivityReport(model.ActivityReportFilter{
+		Types:       []string{activityType},
+		From:        startDate,
+		To:          endDate,
+		Granularity: enum.Month,
+	})
 	if err.Code != "" {
 		return model.CountActivitiesByPeriod{}, err
 	}
 
-	activitiesByMonth := activitiesByMonthInitial(startDate, endDate)
+	activitiesByMonth := make(map[string]model.Count)
 
-	for _, activity := range activities {
-		month := activity.CreatedAt.Format("2006-01")
-		activitiesByMonth[month]++
+	for _, bucket := range report.Series[0].Buckets {
+		activitiesByMonth[bucket.Label] = bucket.Count
 	}
 
-	countActivitiesByPeriod = model.CountActivitiesByPeriod{
+	return model.CountActivitiesByPeriod{
 		ActivityType: activityType,
 		MonthsCount:  activitiesByMonth,
+	}, utils.Error{}
+}
+
+// GetActivityReport counts the activities of each type per bucket of the
+// granularity, aligned to the timezone of the filter dates. The database
+// counts them per hour, which are then added to the bucket they fall in.
+func (s *reportsService) GetActivityReport(filter model.ActivityReportFilter) (model.ActivityReport, utils.Error) {
+	if err := validateActivityReportFilter(filter); err.Code != "" {
+		return model.ActivityReport{}, err
+	}
+
+	location := filter.From.Location()
+	filter.To = filter.To.In(location)
+
+	buckets := reportBuckets(filter.From, filter.To, filter.Granularity)
+	if len(buckets) > maxReportBuckets {
+		return model.ActivityReport{}, reportsValidationError("the period has too many buckets for the granularity", "04", []model.Field{
+			{Name: "granularity", Value: string(filter.Granularity)},
+		})
+	}
+
+	_, offset := filter.From.Zone()
+	shift := offset % 3600
+
+	counts, err := s.countActivitiesByBucket(filter.Types, buckets, filter.From, filter.To, shift)
+	if err.Code != "" {
+		return model.ActivityReport{}, err
+	}
+
+	previousFrom := shiftBuckets(filter.From, filter.Granularity, -len(buckets))
+	previousBuckets := reportBuckets(previousFrom, filter.From, filter.Granularity)
+	previousCounts := map[string][]model.Count{}
+
+	if filter.Compare {
+		previousCounts, err = s.countActivitiesByBucket(filter.Types, previousBuckets, previousFrom, filter.From, shift)
+		if err.Code != "" {
+			return model.ActivityReport{}, err
+		}
+	}
+
+	report := model.ActivityReport{
+		From:        filter.From,
+		To:          filter.To,
+		Granularity: filter.Granularity,
+		Timezone:    location.String(),
+		Series:      []model.ActivitySeries{},
+	}
+
+	for _, activityType := range filter.Types {
+		series := model.ActivitySeries{
+			ActivityType: activityType,
+			Buckets:      []model.ActivityBucket{},
+		}
+
+		for i, bucketStart := range buckets {
+			bucket := model.ActivityBucket{
+				Label: bucketLabel(bucketStart, filter.Granularity),
+				Start: bucketStart,
+				Count: counts[activityType][i],
+			}
+
+			if filter.Compare && i < len(previousBuckets) {
+				previousCount := previousCounts[activityType][i]
+				bucket.PreviousCount = &previousCount
+			}
+
+			series.Total += bucket.Count
+			series.Buckets = append(series.Buckets, bucket)
+		}
+
+		if filter.Compare {
+			series.Previous = compareActivities(previousFrom, filter.From, series.Total, previousCounts[activityType])
+		}
+
+		report.Series = append(report.Series, series)
+	}
+
+	return report, utils.Error{}
+}
+
+func (s *reportsService) CountVacanciesByCategory(filter model.EmploymentReportFilter) ([]model.VacancyCategoryTotal, utils.Error) {
+	if err := validateEmploymentReportFilter(filter); err.Code != "" {
+		return nil, err
+	}
+
+	return s.employmentReportRepo.CountVacanciesByCategory(filter)
+}
+
+func (s *reportsService) CountAppliesByVacancy(filter model.EmploymentReportFilter) ([]model.VacancyApplyTotals, utils.Error) {
+	if err := validateEmploymentReportFilter(filter); err.Code != "" {
+		return nil, err
+	}
+
+	return s.employmentReportRepo.CountAppliesByVacancy(filter)
+}
+
+func (s *reportsService) GetAcceptanceRates(filter model.EmploymentReportFilter, group enum.AcceptanceRateGroup) ([]model.AcceptanceRate, utils.Error) {
+	if err := validateEmploymentReportFilter(filter); err.Code != "" {
+		return nil, err
+	}
+
+	if !group.IsValid() {
+		return nil, reportsValidationError("group_by must be 'category', 'company' or 'area'", "06", []model.Field{
+			{Name: "group_by", Value: string(group)},
+		})
+	}
+
+	rates, err := s.employmentReportRepo.CountAppliesByGroup(filter, group)
+	if err.Code != "" {
+		return nil, err
+	}
+
+	for i := range rates {
+		rates[i].Rate = ratio(rates[i].Accepted, rates[i].Applications)
+	}
+
+	return rates, utils.Error{}
+}
+
+// GetTimeToHire computes the median in Go, as the databases don't agree on a
+// median function. Only the accepted applies are loaded.
+func (s *reportsService) GetTimeToHire(filter model.EmploymentReportFilter) (model.TimeToHire, utils.Error) {
+	if err := validateEmploymentReportFilter(filter); err.Code != "" {
+		return model.TimeToHire{}, err
+	}
+
+	hires, err := s.employmentReportRepo.ListHires(filter)
+	if err.Code != "" {
+		return model.TimeToHire{}, err
+	}
+
+	timeToHire := model.TimeToHire{Hires: model.Count(len(hires))}
+	if len(hires) == 0 {
+		return timeToHire, utils.Error{}
+	}
+
+	days := make([]float64, 0, len(hires))
+	for _, hire := range hires {
+		days = append(days, hire.DecidedAt.Sub(hire.CreatedAt).Hours()/24)
+	}
+
+	sort.Float64s(days)
+
+	median := days[len(days)/2]
+	if len(days)%2 == 0 {
+		median = (days[len(days)/2-1] + median) / 2
+	}
+
+	median = math.Round(median*10) / 10
+	timeToHire.MedianDays = &median
+
+	return timeToHire, utils.Error{}
+}
+
+// GetApplyFunnel counts the applies that were made, reviewed by the company
+// (accepted or rejected) and accepted.
+func (s *reportsService) GetApplyFunnel(filter model.EmploymentReportFilter) ([]model.ApplyFunnelStage, utils.Error) {
+	if err := validateEmploymentReportFilter(filter); err.Code != "" {
+		return nil, err
+	}
+
+	funnel, err := s.employmentReportRepo.CountApplyFunnel(filter)
+	if err.Code != "" {
+		return nil, err
+	}
+
+	return []model.ApplyFunnelStage{
+		{Stage: string(enum.VacancyApplyApplied), Count: funnel.Applied, Rate: ratio(funnel.Applied, funnel.Applied)},
+		{Stage: "reviewed", Count: funnel.Reviewed, Rate: ratio(funnel.Reviewed, funnel.Applied)},
+		{Stage: string(enum.VacancyApplyAccepted), Count: funnel.Accepted, Rate: ratio(funnel.Accepted, funnel.Applied)},
+	}, utils.Error{}
+}
+
+// GetDisabilityCrossTab counts the people with disabilities per pair of
+// values of two dimensions. The neighborhoods and cities are merged ignoring
+// case and accents, and the ages are computed from the birth dates today.
+func (s *reportsService) GetDisabilityCrossTab(filter model.CrossTabFilter) (model.CrossTab, utils.Error) {
+	for name, dimension := range map[string]enum.ReportDimension{"rows": filter.Rows, "columns": filter.Columns} {
+		if !dimension.IsValid() {
+			return model.CrossTab{}, reportsValidationError("the dimensions must be 'category', 'gender', 'neighborhood', 'city' or 'age_band'", "07", []model.Field{
+				{Name: name, Value: string(dimension)},
+			})
+		}
+	}
+
+	if filter.Rows == filter.Columns {
+		return model.CrossTab{}, reportsValidationError("the rows and columns must be of different dimensions", "08", []model.Field{
+			{Name: "columns", Value: string(filter.Columns)},
+		})
+	}
+
+	counts, err := s.personDisabilityRepo.CountPeopleByDimensions(filter)
+	if err.Code != "" {
+		return model.CrossTab{}, err
+	}
+
+	now := time.Now()
+	rows := newCrossTabAxis(filter.Rows)
+	columns := newCrossTabAxis(filter.Columns)
+
+	type cell struct{ row, column string }
+	cells := map[cell]model.Count{}
+
+	for _, count := range counts {
+		row := rows.add(count.Row, now)
+		column := columns.add(count.Column, now)
+		cells[cell{row, column}] += model.Count(count.Total)
+	}
+
+	rowLabels, columnLabels := rows.labels(), columns.labels()
+
+	crossTab := model.CrossTab{
+		Rows:         model.CrossTabAxis{Dimension: filter.Rows, Labels: rowLabels},
+		Columns:      model.CrossTabAxis{Dimension: filter.Columns, Labels: columnLabels},
+		Values:       [][]model.Count{},
+		RowTotals:    []model.Count{},
+		ColumnTotals: make([]model.Count, len(columns.keys)),
+	}
+
+	for _, row := range rows.keys {
+		values := make([]model.Count, len(columns.keys))
+		rowTotal := model.Count(0)
+
+		for j, column := range columns.keys {
+			values[j] = cells[cell{row, column}]
+			rowTotal += values[j]
+			crossTab.ColumnTotals[j] += values[j]
+		}
+
+		crossTab.Values = append(crossTab.Values, values)
+		crossTab.RowTotals = append(crossTab.RowTotals, rowTotal)
+		crossTab.Total += rowTotal
+	}
+
+	return crossTab, utils.Error{}
+}
+
+// crossTabAxis collects the labels of a dimension. The genders and age bands
+// are always listed in their order, the other values as they are found,
+// sorted, with the unknown ones last.
+type crossTabAxis struct {
+	dimension enum.ReportDimension
+	keys      []string
+	names     map[string]string
+}
+
+func newCrossTabAxis(dimension enum.ReportDimension) *crossTabAxis {
+	axis := &crossTabAxis{dimension: dimension, keys: []string{}, names: map[string]string{}}
+
+	switch dimension {
+	case enum.DimensionGender:
+		for _, gender := range []enum.GenderEnum{enum.Male, enum.Female, enum.Other} {
+			axis.names[string(gender)] = string(gender)
+		}
+	case enum.DimensionAgeBand:
+		for _, band := range ageBands {
+			axis.names[band.label] = band.label
+		}
+
+		axis.names[unknownLabel] = unknownLabel
+	}
+
+	return axis
+}
+
+// add returns the key of the value in the axis.
+func (a *crossTabAxis) add(value string, now time.Time) string {
+	key, name := value, value
+
+	switch a.dimension {
+	case enum.DimensionNeighborhood, enum.DimensionCity:
+		key = utils.NormalizeText(value)
+		if key == "" {
+			name = unknownLabel
+		}
+	case enum.DimensionAgeBand:
+		key = ageBand(value, now)
+		name = key
+	}
+
+	// keeps the same spelling whatever the order the values come in
+	if current, ok := a.names[key]; !ok || name < current {
+		a.names[key] = name
+	}
+
+	return key
+}
+
+// labels orders the keys of the axis and returns their labels.
+func (a *crossTabAxis) labels() []string {
+	a.keys = []string{}
+
+	switch a.dimension {
+	case enum.DimensionGender:
+		a.keys = append(a.keys, string(enum.Male), string(enum.Female), string(enum.Other))
+	case enum.DimensionAgeBand:
+		for _, band := range ageBands {
+			a.keys = append(a.keys, band.label)
+		}
+
+		a.keys = append(a.keys, unknownLabel)
+	default:
+		for key := range a.names {
+			a.keys = append(a.keys, key)
+		}
+
+		sort.Slice(a.keys, func(i, j int) bool {
+			if (a.keys[i] == "") != (a.keys[j] == "") {
+				return a.keys[j] == ""
+			}
+
+			return a.keys[i] < a.keys[j]
+		})
+	}
+
+	labels := []string{}
+	for _, key := range a.keys {
+		labels = append(labels, a.names[key])
+	}
+
+	return labels
+}
+
+// ageBand returns the band of the age at the date of someone born in the
+// birth date, which the databases may return with a time after the date.
+func ageBand(birthDate string, now time.Time) string {
+	if len(birthDate) < len(time.DateOnly) {
+		return unknownLabel
+	}
+
+	born, err := time.Parse(time.DateOnly, birthDate[:len(time.DateOnly)])
+	if err != nil {
+		return unknownLabel
+	}
+
+	age := now.Year() - born.Year()
+	if now.Month() < born.Month() || now.Month() == born.Month() && now.Day() < born.Day() {
+		age--
+	}
+
+	for _, band := range ageBands {
+		if age <= band.maxAge {
+			return band.label
+		}
+	}
+
+	return unknownLabel
+}
+
+func validateEmploymentReportFilter(filter model.EmploymentReportFilter) utils.Error {
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return reportsValidationError("the start of the period must be before its end", "05", []model.Field{
+			{Name: "from", Value: filter.From.Format(time.RFC3339)},
+			{Name: "to", Value: filter.To.Format(time.RFC3339)},
+		})
+	}
+
+	return utils.Error{}
+}
+
+// ratio returns part/total rounded to four decimals, or zero without a total.
+func ratio(part model.Count, total model.Count) *float64 {
+	rate := 0.0
+
+	if total > 0 {
+		rate = math.Round(float64(part)/float64(total)*10000) / 10000
 	}
 
-	return countActivitiesByPeriod, utils.Error{}
+	return &rate
 }
 
-func activitiesByMonthInitial(startDate time.Time, endDate time.Time) map[string]int {
-	activitiesByMonth := make(map[string]int)
+// countActivitiesByBucket adds the hourly counts of the database to the
+// buckets they fall in. The hours are shifted by shift seconds, see
+// ActivityRepo.CountActivitiesByHour.
+func (s *reportsService) countActivitiesByBucket(activityTypes []string, buckets []time.Time, from time.Time, to time.Time, shift int) (map[string][]model.Count, utils.Error) {
+	counts := map[string][]model.Count{}
 
-	for startDate.Before(endDate) {
-		month := startDate.Format("2006-01")
-		activitiesByMonth[month] = 0
+	for _, activityType := range activityTypes {
+		counts[activityType] = make([]model.Count, len(buckets))
+	}
+
+	hours, err := s.activityRepo.CountActivitiesByHour(activityTypes, from, to, shift)
+	if err.Code != "" {
+		return nil, err
+	}
+
+	for _, hour := range hours {
+		shifted, parseErr := time.Parse("2006-01-02 15", hour.Hour)
+		if parseErr != nil || counts[hour.Type] == nil {
+			continue
+		}
+
+		start := shifted.Add(-time.Duration(shift) * time.Second)
+		counts[hour.Type][bucketIndex(buckets, start)] += model.Count(hour.Count)
+	}
+
+	return counts, utils.Error{}
+}
+
+func validateActivityReportFilter(filter model.ActivityReportFilter) utils.Error {
+	if len(filter.Types) == 0 || len(filter.Types) > maxReportActivityTypes {
+		return reportsValidationError("between 1 and 10 activity types must be informed", "01", []model.Field{
+			{Name: "types", Value: fmt.Sprint(len(filter.Types))},
+		})
+	}
+
+	if !filter.Granularity.IsValid() {
+		return reportsValidationError("granularity must be 'day', 'week', 'month', 'quarter' or 'year'", "02", []model.Field{
+			{Name: "granularity", Value: string(filter.Granularity)},
+		})
+	}
+
+	if !filter.From.Before(filter.To) {
+		return reportsValidationError("the start of the period must be before its end", "03", []model.Field{
+			{Name: "from", Value: filter.From.Format(time.RFC3339)},
+			{Name: "to", Value: filter.To.Format(time.RFC3339)},
+		})
+	}
+
+	return utils.Error{}
+}
+
+func compareActivities(from time.Time, to time.Time, total model.Count, previousCounts []model.Count) *model.ActivityComparison {
+	comparison := &model.ActivityComparison{From: from, To: to}
+
+	for _, count := range previousCounts {
+		comparison.Total += count
+	}
+
+	if comparison.Total > 0 {
+		change := float64(total-comparison.Total) * 100 / float64(comparison.Total)
+		comparison.ChangePercent = &change
+	}
+
+	return comparison
+}
+
+// reportBuckets returns the start of the buckets that cover the period, the
+// first one starting at or before from.
+func reportBuckets(from time.Time, to time.Time, granularity enum.ReportGranularity) []time.Time {
+	buckets := []time.Time{}
+
+	for start := truncateToBucket(from, granularity); start.Before(to); start = shiftBuckets(start, granularity, 1) {
+		buckets = append(buckets, start)
+
+		if len(buckets) > maxReportBuckets {
+			break
+		}
+	}
+
+	return buckets
+}
+
+// bucketIndex returns the index of the bucket the date falls in.
+func bucketIndex(buckets []time.Time, date time.Time) int {
+	index := sort.Search(len(buckets), func(i int) bool {
+		return buckets[i].After(date)
+	}) - 1
+
+	if index < 0 {
+		return 0
+	}
+
+	return index
+}
+
+// truncateToBucket returns the start of the bucket of the date in its own
+// timezone. Weeks start on monday.
+func truncateToBucket(date time.Time, granularity enum.ReportGranularity) time.Time {
+	year, month, day := date.Date()
+
+	switch granularity {
+	case enum.Week:
+		weekday := (int(date.Weekday()) + 6) % 7
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, date.Location())
+	case enum.Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+	case enum.Quarter:
+		return time.Date(year, (month-1)/3*3+1, 1, 0, 0, 0, 0, date.Location())
+	case enum.Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, date.Location())
+	}
+
+	return time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+}
+
+// shiftBuckets moves the date by a number of buckets, keeping its wall clock.
+func shiftBuckets(date time.Time, granularity enum.ReportGranularity, buckets int) time.Time {
+	switch granularity {
+	case enum.Week:
+		return date.AddDate(0, 0, 7*buckets)
+	case enum.Month:
+		return date.AddDate(0, buckets, 0)
+	case enum.Quarter:
+		return date.AddDate(0, 3*buckets, 0)
+	case enum.Year:
+		return date.AddDate(buckets, 0, 0)
+	}
+
+	return date.AddDate(0, 0, buckets)
+}
 
-		startDate = startDate.AddDate(0, 1, 0)
+func bucketLabel(start time.Time, granularity enum.ReportGranularity) string {
+	switch granularity {
+	case enum.Week:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case enum.Month:
+		return start.Format("2006-01")
+	case enum.Quarter:
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case enum.Year:
+		return start.Format("2006")
 	}
 
-	return activitiesByMonth
+	return start.Format(time.DateOnly)
 }
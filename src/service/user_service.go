@@ -0,0 +1,111 @@
+package service
+
+import (
+	"cij_api/src/enum"
+	"cij_api/src/model"
+	"cij_api/src/repo"
+	"cij_api/src/utils"
+)
+
+type UserService interface {
+	CreateAdmin(createAdmin model.UserRequest, actor model.AuditActor) utils.Error
+	ResetPassword(email string, password string, actor model.AuditActor) utils.Error
+	ListUsers() ([]model.User, utils.Error)
+}
+
+type userService struct {
+	userRepo     repo.UserRepo
+	activityRepo repo.ActivityRepo
+	auditService AuditService
+}
+
+func NewUserService(userRepo repo.UserRepo, activityRepo repo.ActivityRepo, auditService AuditService) UserService {
+	return &userService{
+		userRepo:     userRepo,
+		activityRepo: activityRepo,
+		auditService: auditService,
+	}
+}
+
+func userServiceError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ServiceErrorCode, utils.UserErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+func (s *userService) CreateAdmin(createAdmin model.UserRequest, actor model.AuditActor) utils.Error {
+	if err := utils.ValidateUser(createAdmin); err.Code != "" {
+		return err
+	}
+
+	existingUser, err := s.userRepo.GetUserByEmail(createAdmin.Email)
+	if err.Code != "" {
+		return err
+	}
+
+	if existingUser.Id != 0 {
+		return userServiceError("email already registered", "01")
+	}
+
+	hashedPassword, hashError := utils.EncryptPassword(createAdmin.Password)
+	if hashError != nil {
+		return userServiceError("failed to encrypt the password", "02")
+	}
+
+	userInfo := model.User{
+		Email:    createAdmin.Email,
+		Password: hashedPassword,
+		RoleId:   model.AdminRole,
+	}
+
+	userInfo.Id, err = s.userRepo.CreateUser(userInfo, nil)
+	if err.Code != "" {
+		return err
+	}
+
+	s.auditService.Record(actor, enum.AuditCreate, enum.AuditUser, userInfo.Id, nil, userInfo.ToResponse())
+
+	activity := model.Activity{
+		Type:        "register_admin",
+		Description: "Admin " + userInfo.Email + " registered",
+		Actor:       userInfo.Email,
+	}
+
+	return s.activityRepo.CreateActivity(&activity)
+}
+
+func (s *userService) ResetPassword(email string, password string, actor model.AuditActor) utils.Error {
+	if err := utils.ValidateUser(model.UserRequest{Email: email, Password: password}); err.Code != "" {
+		return err
+	}
+
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err.Code != "" {
+		return err
+	}
+
+	if user.Id == 0 {
+		return userServiceError("user with this email not found", "03")
+	}
+
+	hashedPassword, hashError := utils.EncryptPassword(password)
+	if hashError != nil {
+		return userServiceError("failed to encrypt the password", "04")
+	}
+
+	err = s.userRepo.UpdateUser(model.User{Password: hashedPassword}, user.Id)
+	if err.Code != "" {
+		return err
+	}
+
+	s.auditService.Record(
+		actor, enum.AuditUpdate, enum.AuditUser, user.Id,
+		map[string]string{"password": user.Password}, map[string]string{"password": hashedPassword},
+	)
+
+	return utils.Error{}
+}
+
+func (s *userService) ListUsers() ([]model.User, utils.Error) {
+	return s.userRepo.ListUsers()
+}
@@ -2,16 +2,26 @@ package service
 
 import (
 	"cij_api/src/enum"
+	"cij_api/src/geocoding"
 	"cij_api/src/model"
 	modelVacancy "cij_api/src/model/vacancy"
 	"cij_api/src/repo"
 	repoVacancy "cij_api/src/repo/vacancy"
 	"cij_api/src/utils"
+	"fmt"
+	"math"
 	"slices"
+	"sort"
 
 	"gorm.io/gorm"
 )
 
+// defaultVacancyRadiusKm is the radius of the distance searches that don't
+// give one, about the size of Jaraguá do Sul and the cities around it.
+const defaultVacancyRadiusKm = 25.0
+
+const maxVacancyRadiusKm = 500.0
+
 type vacancyService struct {
 	vacancyRepo             repoVacancy.VacancyRepo
 	skillsRepo              repoVacancy.SkillsRepo
@@ -21,18 +31,19 @@ type vacancyService struct {
 	vacancyAppliesRepo      repoVacancy.VacancyApplyRepo
 	personRepo              repo.PersonRepo
 	personDisabilitiesRepo  repo.PersonDisabilityRepo
+	auditService            AuditService
 }
 
 type VacancyService interface {
-	CreateVacancy(vacancy modelVacancy.VacancyRequest) utils.Error
-	ListVacancies(perPage int, companyId int, disabilityId int, candidateId int, area string, contractType enum.VacancyContractType, searchText string) ([]modelVacancy.VacancySimpleResponse, utils.Error)
+	CreateVacancy(vacancy modelVacancy.VacancyRequest, actor model.AuditActor) utils.Error
+	ListVacancies(perPage int, companyId int, disabilityId int, candidateId int, area string, contractType enum.VacancyContractType, searchText string, distance modelVacancy.VacancyDistanceFilter) ([]modelVacancy.VacancySimpleResponse, utils.Error)
 	GetVacancyById(id int, candidateId int) (modelVacancy.VacancyResponse, utils.Error)
-	UpdateVacancy(vacancy modelVacancy.VacancyRequest, id int) utils.Error
-	DeleteVacancy(id int) utils.Error
+	UpdateVacancy(vacancy modelVacancy.VacancyRequest, id int, actor model.AuditActor) utils.Error
+	DeleteVacancy(id int, actor model.AuditActor) utils.Error
 
-	CandidateApplyVacancy(candidateId int, vacancyId int) utils.Error
+	CandidateApplyVacancy(candidateId int, vacancyId int, actor model.AuditActor) utils.Error
 	GetVacancyAppliesByVacancyId(vacancyId int) ([]modelVacancy.VacancyApplyResponse, utils.Error)
-	UpdateVacancyApplyStatus(vacancyApplyId int, status enum.VacancyApplyStatus) utils.Error
+	UpdateVacancyApplyStatus(vacancyApplyId int, status enum.VacancyApplyStatus, actor model.AuditActor) utils.Error
 }
 
 func NewVacancyService(
@@ -44,6 +55,7 @@ func NewVacancyService(
 	vacancyAppliesRepo repoVacancy.VacancyApplyRepo,
 	personRepo repo.PersonRepo,
 	personDisabilitiesRepo repo.PersonDisabilityRepo,
+	auditService AuditService,
 ) VacancyService {
 	return &vacancyService{
 		vacancyRepo:             vacancyRepo,
@@ -54,6 +66,7 @@ func NewVacancyService(
 		vacancyAppliesRepo:      vacancyAppliesRepo,
 		personRepo:              personRepo,
 		personDisabilitiesRepo:  personDisabilitiesRepo,
+		auditService:            auditService,
 	}
 }
 
@@ -63,11 +76,21 @@ func vacancyServiceError(message string, code string) utils.Error {
 	return utils.NewError(message, errorCode)
 }
 
-func (v *vacancyService) CreateVacancy(vacancy modelVacancy.VacancyRequest) utils.Error {
+func vacancyValidationError(message string, code string) utils.Error {
+	errorCode := utils.NewErrorCode(utils.ValidationErrorCode, utils.VacancyErrorType, code)
+
+	return utils.NewError(message, errorCode)
+}
+
+func (v *vacancyService) CreateVacancy(vacancy modelVacancy.VacancyRequest, actor model.AuditActor) utils.Error {
 	vacancyModel := vacancy.ToModel()
 
+	var vacancyId int
+
 	errTx := v.vacancyRepo.BeginTransaction(func(tx *gorm.DB) error {
-		vacancyId, err := v.vacancyRepo.UpsertVacancy(*vacancyModel, tx)
+		var err utils.Error
+
+		vacancyId, err = v.vacancyRepo.UpsertVacancy(*vacancyModel, tx)
 		if err.Code != "" {
 			return err
 		}
@@ -121,13 +144,31 @@ func (v *vacancyService) CreateVacancy(vacancy modelVacancy.VacancyRequest) util
 		return vacancyServiceError("failed to create the vacancy", "01")
 	}
 
+	created, err := v.GetVacancyById(vacancyId, 0)
+	if err.Code != "" {
+		return err
+	}
+
+	v.auditService.Record(actor, enum.AuditCreate, enum.AuditVacancy, vacancyId, nil, created)
+
 	return utils.Error{}
 }
 
-func (v *vacancyService) ListVacancies(perPage int, companyId int, disabilityId int, candidateId int, area string, contractType enum.VacancyContractType, searchText string) ([]modelVacancy.VacancySimpleResponse, utils.Error) {
+func (v *vacancyService) ListVacancies(perPage int, companyId int, disabilityId int, candidateId int, area string, contractType enum.VacancyContractType, searchText string, distance modelVacancy.VacancyDistanceFilter) ([]modelVacancy.VacancySimpleResponse, utils.Error) {
 	var vacanciesResponse []modelVacancy.VacancySimpleResponse
 
-	vacancies, err := v.vacancyRepo.ListVacancies(companyId, area, contractType, searchText)
+	origin, radiusKm, err := v.distanceOrigin(distance)
+	if err.Code != "" {
+		return []modelVacancy.VacancySimpleResponse{}, err
+	}
+
+	var companiesWithin *geocoding.BoundingBox
+	if origin != nil {
+		box := geocoding.BoundingBoxAround(*origin, radiusKm)
+		companiesWithin = &box
+	}
+
+	vacancies, err := v.vacancyRepo.ListVacancies(companyId, area, contractType, searchText, companiesWithin)
 	if err.Code != "" {
 		return []modelVacancy.VacancySimpleResponse{}, vacancyServiceError("failed to list the vacancies", "02")
 	}
@@ -135,6 +176,22 @@ func (v *vacancyService) ListVacancies(perPage int, companyId int, disabilityId
 DisabilityLoop:
 	for _, vacancy := range vacancies {
 		var disabilities []model.DisabilityResponse
+		var distanceKm *float64
+
+		if origin != nil {
+			companyPoint := geocoding.AddressPoint(vacancy.Company.Address)
+			if companyPoint == nil {
+				continue DisabilityLoop
+			}
+
+			kilometers := geocoding.Distance(*origin, *companyPoint)
+			if kilometers > radiusKm {
+				continue DisabilityLoop
+			}
+
+			kilometers = math.Round(kilometers*10) / 10
+			distanceKm = &kilometers
+		}
 
 		vacancyDisabilities, err := v.vacancyDisabilitiesRepo.GetVacancyDisabilities(vacancy.Id)
 		if err.Code != "" {
@@ -169,16 +226,90 @@ DisabilityLoop:
 			}
 		}
 
-		if len(vacanciesResponse) >= perPage {
+		// the nearest vacancies are only known once all of them are measured
+		if origin == nil && len(vacanciesResponse) >= perPage {
 			break
 		}
 
-		vacanciesResponse = append(vacanciesResponse, vacancy.ToSimpleResponse(disabilities))
+		vacancyResponse := vacancy.ToSimpleResponse(disabilities)
+		vacancyResponse.DistanceKm = distanceKm
+
+		vacanciesResponse = append(vacanciesResponse, vacancyResponse)
+	}
+
+	if origin != nil {
+		sort.SliceStable(vacanciesResponse, func(i, j int) bool {
+			return *vacanciesResponse[i].DistanceKm < *vacanciesResponse[j].DistanceKm
+		})
+
+		if len(vacanciesResponse) > perPage {
+			vacanciesResponse = vacanciesResponse[:perPage]
+		}
 	}
 
 	return vacanciesResponse, utils.Error{}
 }
 
+// distanceOrigin validates the distance filter and returns the point the
+// distances are measured from, nil when the vacancies are not searched by
+// distance.
+func (v *vacancyService) distanceOrigin(distance modelVacancy.VacancyDistanceFilter) (*geocoding.Point, float64, utils.Error) {
+	if !distance.IsSet() {
+		return nil, 0, utils.Error{}
+	}
+
+	radiusKm := defaultVacancyRadiusKm
+	if distance.RadiusKm != nil {
+		radiusKm = *distance.RadiusKm
+	}
+
+	if radiusKm <= 0 || radiusKm > maxVacancyRadiusKm {
+		return nil, 0, vacancyValidationError(fmt.Sprintf("radius_km must be greater than 0 and up to %g", maxVacancyRadiusKm), "01")
+	}
+
+	hasPoint := distance.Latitude != nil || distance.Longitude != nil
+
+	if distance.NearPersonId != 0 && hasPoint {
+		return nil, 0, vacancyValidationError("near_person_id and lat/lng can't be used together", "02")
+	}
+
+	if hasPoint {
+		if distance.Latitude == nil || distance.Longitude == nil {
+			return nil, 0, vacancyValidationError("lat and lng must be given together", "03")
+		}
+
+		if !geocoding.ValidCoordinates(*distance.Latitude, *distance.Longitude) {
+			return nil, 0, vacancyValidationError("lat must be between -90 and 90 and lng between -180 and 180", "04")
+		}
+
+		return &geocoding.Point{Latitude: *distance.Latitude, Longitude: *distance.Longitude}, radiusKm, utils.Error{}
+	}
+
+	if distance.NearPersonId == 0 {
+		return nil, 0, vacancyValidationError("radius_km needs near_person_id or lat/lng", "05")
+	}
+
+	person, err := v.personRepo.GetPersonById(distance.NearPersonId, nil)
+	if err.Code != "" {
+		return nil, 0, vacancyServiceError("failed to get the person", "16")
+	}
+
+	if person.Id == 0 {
+		return nil, 0, vacancyValidationError("person not found", "06")
+	}
+
+	if distance.RequesterEmail != "" && (person.User == nil || person.User.Email != distance.RequesterEmail) {
+		return nil, 0, vacancyValidationError("only the person can search the vacancies near their address", "07")
+	}
+
+	origin := geocoding.AddressPoint(person.Address)
+	if origin == nil {
+		return nil, 0, vacancyValidationError("the person address has no coordinates", "08")
+	}
+
+	return origin, radiusKm, utils.Error{}
+}
+
 func (v *vacancyService) GetVacancyById(id int, candidateId int) (modelVacancy.VacancyResponse, utils.Error) {
 	vacancy, err := v.vacancyRepo.GetVacancyById(id)
 	if err.Code != "" {
@@ -229,10 +360,10 @@ func (v *vacancyService) GetVacancyById(id int, candidateId int) (modelVacancy.V
 	return vacancyResponse, utils.Error{}
 }
 
-func (v *vacancyService) UpdateVacancy(vacancy modelVacancy.VacancyRequest, id int) utils.Error {
+func (v *vacancyService) UpdateVacancy(vacancy modelVacancy.VacancyRequest, id int, actor model.AuditActor) utils.Error {
 	vacancyModel := vacancy.ToModel()
 
-	_, err := v.vacancyRepo.GetVacancyById(id)
+	before, err := v.GetVacancyById(id, 0)
 	if err.Code != "" {
 		return vacancyServiceError("failed to get the vacancy", "07")
 	}
@@ -314,11 +445,18 @@ func (v *vacancyService) UpdateVacancy(vacancy modelVacancy.VacancyRequest, id i
 		return vacancyServiceError("failed to update the vacancy", "08")
 	}
 
+	after, err := v.GetVacancyById(id, 0)
+	if err.Code != "" {
+		return err
+	}
+
+	v.auditService.Record(actor, enum.AuditUpdate, enum.AuditVacancy, id, before, after)
+
 	return utils.Error{}
 }
 
-func (v *vacancyService) DeleteVacancy(id int) utils.Error {
-	_, err := v.vacancyRepo.GetVacancyById(id)
+func (v *vacancyService) DeleteVacancy(id int, actor model.AuditActor) utils.Error {
+	before, err := v.GetVacancyById(id, 0)
 	if err.Code != "" {
 		return vacancyServiceError("failed to get the vacancy", "07")
 	}
@@ -349,7 +487,7 @@ func (v *vacancyService) DeleteVacancy(id int) utils.Error {
 			return err
 		}
 
-		err = v.vacancyRepo.DeleteVacancy(id)
+		err = v.vacancyRepo.DeleteVacancy(id, tx)
 		if err.Code != "" {
 			return err
 		}
@@ -361,10 +499,12 @@ func (v *vacancyService) DeleteVacancy(id int) utils.Error {
 		return vacancyServiceError("failed to delete the vacancy", "09")
 	}
 
+	v.auditService.Record(actor, enum.AuditDelete, enum.AuditVacancy, id, before, nil)
+
 	return utils.Error{}
 }
 
-func (v *vacancyService) CandidateApplyVacancy(candidateId int, vacancyId int) utils.Error {
+func (v *vacancyService) CandidateApplyVacancy(candidateId int, vacancyId int, actor model.AuditActor) utils.Error {
 	_, err := v.vacancyRepo.GetVacancyById(vacancyId)
 	if err.Code != "" {
 		return vacancyServiceError("failed to get the vacancy", "10")
@@ -386,11 +526,13 @@ func (v *vacancyService) CandidateApplyVacancy(candidateId int, vacancyId int) u
 		Status:      enum.VacancyApplyApplied,
 	}
 
-	_, err = v.vacancyAppliesRepo.CreateVacancyApply(vacancyApply)
+	vacancyApply.Id, err = v.vacancyAppliesRepo.CreateVacancyApply(vacancyApply)
 	if err.Code != "" {
 		return vacancyServiceError("failed to apply the vacancy", "12")
 	}
 
+	v.auditService.Record(actor, enum.AuditCreate, enum.AuditVacancyApply, vacancyApply.Id, nil, vacancyApply)
+
 	return utils.Error{}
 }
 
@@ -429,11 +571,21 @@ func (v *vacancyService) GetVacancyAppliesByVacancyId(vacancyId int) ([]modelVac
 	return vacancyAppliesResponse, utils.Error{}
 }
 
-func (v *vacancyService) UpdateVacancyApplyStatus(vacancyApplyId int, status enum.VacancyApplyStatus) utils.Error {
-	err := v.vacancyAppliesRepo.UpdateVacancyApplyStatus(vacancyApplyId, status)
+func (v *vacancyService) UpdateVacancyApplyStatus(vacancyApplyId int, status enum.VacancyApplyStatus, actor model.AuditActor) utils.Error {
+	vacancyApply, err := v.vacancyAppliesRepo.GetVacancyApplyById(vacancyApplyId)
+	if err.Code != "" {
+		return err
+	}
+
+	err = v.vacancyAppliesRepo.UpdateVacancyApplyStatus(vacancyApplyId, status)
 	if err.Code != "" {
 		return vacancyServiceError("failed to update the vacancy apply status", "14")
 	}
 
+	updated := vacancyApply
+	updated.Status = status
+
+	v.auditService.Record(actor, enum.AuditUpdate, enum.AuditVacancyApply, vacancyApplyId, vacancyApply, updated)
+
 	return utils.Error{}
 }
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"cij_api/src/integration"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// CloudinaryStorage keeps the files in Cloudinary, using the key as the
+// public id of the asset. Private files are uploaded with the private
+// delivery type.
+type CloudinaryStorage struct {
+	cloudinary *cloudinary.Cloudinary
+}
+
+func NewCloudinaryStorage(url string) (*CloudinaryStorage, error) {
+	cld, err := integration.CloudinaryConnect(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CloudinaryStorage{
+		cloudinary: cld,
+	}, nil
+}
+
+func (s *CloudinaryStorage) Put(key string, content io.Reader) (string, error) {
+	overwrite := true
+
+	uploadResult, err := s.cloudinary.Upload.Upload(context.Background(), content, uploader.UploadParams{
+		PublicID:  key,
+		Overwrite: &overwrite,
+		Type:      deliveryType(key),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if uploadResult.Error.Message != "" {
+		return "", errors.New(uploadResult.Error.Message)
+	}
+
+	return uploadResult.SecureURL, nil
+}
+
+func (s *CloudinaryStorage) Get(key string) (io.ReadCloser, error) {
+	asset, err := s.asset(key)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.Get(asset.SecureURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", key, response.Status)
+	}
+
+	return response.Body, nil
+}
+
+func (s *CloudinaryStorage) Delete(key string) error {
+	asset, err := s.asset(key)
+	if err != nil {
+		return err
+	}
+
+	destroyResult, err := s.cloudinary.Upload.Destroy(context.Background(), uploader.DestroyParams{
+		PublicID:     key,
+		Type:         asset.Type,
+		ResourceType: asset.ResourceType,
+	})
+	if err != nil {
+		return err
+	}
+
+	if destroyResult.Error.Message != "" {
+		return errors.New(destroyResult.Error.Message)
+	}
+
+	return nil
+}
+
+func (s *CloudinaryStorage) SignedURL(key string, expiresIn time.Duration) (string, error) {
+	asset, err := s.asset(key)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(expiresIn)
+
+	return s.cloudinary.Upload.PrivateDownloadURL(uploader.PrivateDownloadURLParams{
+		PublicID:     key,
+		Format:       asset.Format,
+		DeliveryType: asset.Type,
+		ExpiresAt:    &expiresAt,
+		ResourceType: api.AssetType(asset.ResourceType),
+	})
+}
+
+// asset finds the uploaded asset. The files are uploaded with automatic type
+// detection, so every asset type is tried.
+func (s *CloudinaryStorage) asset(key string) (*admin.AssetResult, error) {
+	for _, assetType := range []api.AssetType{api.Image, api.File, api.Video} {
+		asset, err := s.cloudinary.Admin.Asset(context.Background(), admin.AssetParams{
+			AssetType:    assetType,
+			DeliveryType: deliveryType(key),
+			PublicID:     key,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if asset.Error.Message == "" {
+			return asset, nil
+		}
+	}
+
+	return nil, fmt.Errorf("file %s not found", key)
+}
+
+func deliveryType(key string) api.DeliveryType {
+	if IsPrivate(key) {
+		return api.Private
+	}
+
+	return api.Upload
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// LocalStorage keeps the files in a directory of the server. The files are
+// served by the API itself under baseUrl, and the private ones only with a
+// valid signature.
+type LocalStorage struct {
+	root    string
+	baseUrl string
+	secret  []byte
+}
+
+func NewLocalStorage(root string, baseUrl string, secret string) (*LocalStorage, error) {
+	if root == "" {
+		return nil, errors.New("STORAGE_PATH is required by the local storage")
+	}
+
+	if baseUrl == "" {
+		return nil, errors.New("STORAGE_URL is required by the local storage")
+	}
+
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		root:    root,
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		secret:  []byte(secret),
+	}, nil
+}
+
+func (s *LocalStorage) Put(key string, content io.Reader) (string, error) {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	if _, err := io.Copy(file, content); err != nil {
+		return "", err
+	}
+
+	return s.url(key), nil
+}
+
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filePath)
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(filePath)
+}
+
+func (s *LocalStorage) SignedURL(key string, expiresIn time.Duration) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiresIn).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(cleaned, expires))
+
+	return s.url(cleaned) + "?" + query.Encode(), nil
+}
+
+// Verify checks a signature generated by SignedURL.
+func (s *LocalStorage) Verify(key string, expires string, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) sign(key string, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s", key, expires)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *LocalStorage) url(key string) string {
+	return s.baseUrl + "/" + key
+}
+
+func (s *LocalStorage) filePath(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Options struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSsl    bool
+	// PublicUrl is the address the objects are served from, like a CDN. The
+	// bucket URL on the endpoint is used when it is empty.
+	PublicUrl string
+}
+
+// S3Storage keeps the files in a bucket of any S3 compatible service, like
+// AWS S3, MinIO or Cloudflare R2. The objects are private by default, so the
+// bucket policy must not grant public reads on the PrivatePrefix.
+type S3Storage struct {
+	client    *minio.Client
+	bucket    string
+	publicUrl string
+}
+
+func NewS3Storage(options S3Options) (*S3Storage, error) {
+	if options.Endpoint == "" || options.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required by the s3 storage")
+	}
+
+	client, err := minio.New(options.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(options.AccessKey, options.SecretKey, ""),
+		Secure: options.UseSsl,
+		Region: options.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publicUrl := options.PublicUrl
+	if publicUrl == "" {
+		publicUrl = client.EndpointURL().String() + "/" + options.Bucket
+	}
+
+	return &S3Storage{
+		client:    client,
+		bucket:    options.Bucket,
+		publicUrl: strings.TrimSuffix(publicUrl, "/"),
+	}, nil
+}
+
+func (s *S3Storage) Put(key string, content io.Reader) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.client.PutObject(context.Background(), s.bucket, cleaned, content, -1, minio.PutObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return s.publicUrl + "/" + cleaned, nil
+}
+
+func (s *S3Storage) Get(key string) (io.ReadCloser, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := s.client.GetObject(context.Background(), s.bucket, cleaned, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy, so the object is checked before being returned.
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func (s *S3Storage) Delete(key string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	return s.client.RemoveObject(context.Background(), s.bucket, cleaned, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) SignedURL(key string, expiresIn time.Duration) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	signedUrl, err := s.client.PresignedGetObject(context.Background(), s.bucket, cleaned, expiresIn, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return signedUrl.String(), nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"cij_api/src/config"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	CloudinaryDriver = "cloudinary"
+	LocalDriver      = "local"
+	S3Driver         = "s3"
+)
+
+// PrivatePrefix marks the keys of the private files. They are never served
+// publicly and can only be downloaded through a signed URL.
+const PrivatePrefix = "private/"
+
+var ErrInvalidKey = errors.New("invalid file key")
+
+// FileStorage stores the files uploaded to the API. Files are addressed by a
+// slash separated key, like "cij/news/banner/banner.png".
+type FileStorage interface {
+	// Put stores the content under the key, replacing any previous file, and
+	// returns the URL where it can be downloaded.
+	Put(key string, content io.Reader) (string, error)
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// SignedURL returns a URL that allows downloading the file until it expires.
+	SignedURL(key string, expiresIn time.Duration) (string, error)
+}
+
+// NewFileStorage creates the storage selected by the STORAGE_DRIVER config.
+// Cloudinary is used when no driver is set.
+func NewFileStorage(config *config.Config) (FileStorage, error) {
+	switch config.StorageDriver {
+	case "", CloudinaryDriver:
+		return NewCloudinaryStorage(config.CloudinaryUrl)
+	case LocalDriver:
+		return NewLocalStorage(config.StoragePath, config.StorageUrl, config.SecretKey)
+	case S3Driver:
+		return NewS3Storage(S3Options{
+			Endpoint:  config.S3Endpoint,
+			Region:    config.S3Region,
+			Bucket:    config.S3Bucket,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			UseSsl:    config.S3UseSsl,
+			PublicUrl: config.StorageUrl,
+		})
+	}
+
+	return nil, fmt.Errorf("unsupported storage driver %q", config.StorageDriver)
+}
+
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(key, "/"), PrivatePrefix)
+}
+
+// cleanKey normalizes the key and rejects the ones that could escape the
+// storage root.
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+
+	return cleaned, nil
+}
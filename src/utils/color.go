@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"cij_api/src/enum"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Color is a sRGB color with channels between 0 and 1.
+type Color struct {
+	R float64
+	G float64
+	B float64
+}
+
+var hexColorPattern = regexp.MustCompile("^#([a-fA-F0-9]{6}|[a-fA-F0-9]{3})$")
+
+func IsHexColor(s string) bool {
+	return hexColorPattern.MatchString(s)
+}
+
+// ParseHexColor parses colors like "#004AAD" or "#FFF".
+func ParseHexColor(s string) (Color, error) {
+	if !IsHexColor(s) {
+		return Color{}, fmt.Errorf("%s is not a valid hex color", s)
+	}
+
+	hex := s[1:]
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return Color{}, err
+	}
+
+	return Color{
+		R: float64(value>>16&0xFF) / 255,
+		G: float64(value>>8&0xFF) / 255,
+		B: float64(value&0xFF) / 255,
+	}, nil
+}
+
+func (c Color) Hex() string {
+	channel := func(v float64) int {
+		return int(math.Round(clamp(v) * 255))
+	}
+
+	return strings.ToUpper(fmt.Sprintf("#%02x%02x%02x", channel(c.R), channel(c.G), channel(c.B)))
+}
+
+// RelativeLuminance follows the WCAG 2.x definition.
+func (c Color) RelativeLuminance() float64 {
+	r, g, b := c.linear()
+
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// ContrastRatio returns the WCAG 2.x contrast ratio between two colors, from
+// 1 (no contrast) to 21 (black on white).
+func ContrastRatio(a Color, b Color) float64 {
+	lighter, darker := a.RelativeLuminance(), b.RelativeLuminance()
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+
+	return (lighter + 0.05) / (darker + 0.05)
+}
+
+// colorBlindnessMatrices are the Machado et al. (2009) simulation matrices
+// for full severity dichromacy, applied to linear RGB.
+var colorBlindnessMatrices = map[enum.ColorBlindnessEnum][3][3]float64{
+	enum.Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	enum.Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	enum.Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// SimulateColorBlindness returns the color as seen with the given color
+// blindness. Normal vision returns the color unchanged.
+func SimulateColorBlindness(c Color, colorBlindness enum.ColorBlindnessEnum) Color {
+	matrix, ok := colorBlindnessMatrices[colorBlindness]
+	if !ok {
+		return c
+	}
+
+	r, g, b := c.linear()
+
+	return fromLinear(
+		matrix[0][0]*r+matrix[0][1]*g+matrix[0][2]*b,
+		matrix[1][0]*r+matrix[1][1]*g+matrix[1][2]*b,
+		matrix[2][0]*r+matrix[2][1]*g+matrix[2][2]*b,
+	)
+}
+
+// ColorDistance is the CIE76 difference between two colors in the CIELAB
+// space. Values below 10 are hard to tell apart at a glance.
+func ColorDistance(a Color, b Color) float64 {
+	l1, a1, b1 := a.lab()
+	l2, a2, b2 := b.lab()
+
+	return math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+}
+
+// HSL returns the hue in degrees and the saturation and lightness between 0
+// and 1.
+func (c Color) HSL() (float64, float64, float64) {
+	max := math.Max(c.R, math.Max(c.G, c.B))
+	min := math.Min(c.R, math.Min(c.G, c.B))
+	lightness := (max + min) / 2
+
+	if max == min {
+		return 0, 0, lightness
+	}
+
+	delta := max - min
+
+	saturation := delta / (1 - math.Abs(2*lightness-1))
+
+	var hue float64
+	switch max {
+	case c.R:
+		hue = math.Mod((c.G-c.B)/delta, 6)
+	case c.G:
+		hue = (c.B-c.R)/delta + 2
+	default:
+		hue = (c.R-c.G)/delta + 4
+	}
+
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+
+	return hue, saturation, lightness
+}
+
+func ColorFromHSL(hue float64, saturation float64, lightness float64) Color {
+	chroma := (1 - math.Abs(2*lightness-1)) * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := lightness - chroma/2
+
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g, b = chroma, x, 0
+	case hue < 120:
+		r, g, b = x, chroma, 0
+	case hue < 180:
+		r, g, b = 0, chroma, x
+	case hue < 240:
+		r, g, b = 0, x, chroma
+	case hue < 300:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	return Color{R: clamp(r + m), G: clamp(g + m), B: clamp(b + m)}
+}
+
+// WithLightness returns the color with the same hue and saturation and the
+// given lightness.
+func (c Color) WithLightness(lightness float64) Color {
+	hue, saturation, _ := c.HSL()
+
+	return ColorFromHSL(hue, saturation, clamp(lightness))
+}
+
+// EnsureContrast darkens or lightens the color, keeping its hue, until its
+// contrast with the background reaches the minimum ratio. The color moves
+// away from the background luminance and stops at black or white.
+func EnsureContrast(c Color, background Color, minRatio float64) Color {
+	if ContrastRatio(c, background) >= minRatio {
+		return c
+	}
+
+	_, _, lightness := c.HSL()
+
+	step := -0.01
+	if background.RelativeLuminance() < 0.18 {
+		step = 0.01
+	}
+
+	for (step > 0 && lightness < 1) || (step < 0 && lightness > 0) {
+		lightness += step
+		c = c.WithLightness(lightness)
+
+		if ContrastRatio(c, background) >= minRatio {
+			break
+		}
+	}
+
+	return c
+}
+
+func (c Color) linear() (float64, float64, float64) {
+	return toLinear(c.R), toLinear(c.G), toLinear(c.B)
+}
+
+func (c Color) lab() (float64, float64, float64) {
+	r, g, b := c.linear()
+
+	x := (0.4124*r + 0.3576*g + 0.1805*b) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*b
+	z := (0.0193*r + 0.1192*g + 0.9505*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 0.008856 {
+			return math.Cbrt(t)
+		}
+
+		return 7.787*t + 16.0/116
+	}
+
+	return 116*f(y) - 16, 500 * (f(x) - f(y)), 200 * (f(y) - f(z))
+}
+
+func toLinear(v float64) float64 {
+	if v <= 0.03928 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func fromLinear(r float64, g float64, b float64) Color {
+	channel := func(v float64) float64 {
+		v = clamp(v)
+		if v <= 0.0031308 {
+			return v * 12.92
+		}
+
+		return 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+
+	return Color{R: channel(r), G: channel(g), B: channel(b)}
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"cij_api/src/model"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 type Error struct {
@@ -27,6 +29,11 @@ func (e Error) GetFields() []model.Field {
 	return e.Fields
 }
 
+// IsValidation reports whether the error was caused by invalid input.
+func (e Error) IsValidation() bool {
+	return strings.HasPrefix(e.Code, strconv.Itoa(int(ValidationErrorCode)))
+}
+
 func NewErrorCode(errorType ErrorType, errorEntity ErrorEntity, identifier string) string {
 	return fmt.Sprintf("%d%d%s", errorType, errorEntity, identifier)
 }
@@ -69,4 +76,7 @@ const (
 	ActivityErrorType   ErrorEntity = 8
 	ReportsErrorType    ErrorEntity = 9
 	VacancyErrorType    ErrorEntity = 10
+	AuditErrorType      ErrorEntity = 11
+	OpenDataErrorType   ErrorEntity = 12
+	CurriculumErrorType ErrorEntity = 13
 )
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"cij_api/src/enum"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeText lowercases the text and removes its accents and spaces, so
+// values typed by the users can be compared with the stored ones.
+func NormalizeText(text string) string {
+	removeAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	normalized, _, err := transform.String(removeAccents, text)
+	if err != nil {
+		normalized = text
+	}
+
+	return strings.ReplaceAll(strings.ToLower(normalized), " ", "")
+}
+
+var nonSlugCharacters = regexp.MustCompile("[^a-z0-9]+")
+
+// Slugify lowercases the text, removes its accents and joins its words with
+// hyphens, like "Física Motora" to "fisica-motora".
+func Slugify(text string) string {
+	removeAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	slug, _, err := transform.String(removeAccents, strings.ToLower(text))
+	if err != nil {
+		slug = strings.ToLower(text)
+	}
+
+	return strings.Trim(nonSlugCharacters.ReplaceAllString(slug, "-"), "-")
+}
+
+// disabilityCategoryAliases maps the names the categories had before the
+// categories table, in english and portuguese, to their slugs.
+var disabilityCategoryAliases = map[string]enum.DisabilityCategoryEnum{
+	"visual":        enum.Visual,
+	"visao":         enum.Visual,
+	"hearing":       enum.Hearing,
+	"auditiva":      enum.Hearing,
+	"physical":      enum.Motor,
+	"fisica":        enum.Motor,
+	"motora":        enum.Motor,
+	"fisica-motora": enum.Motor,
+	"intelectual":   enum.Intellectual,
+	"mental":        enum.Intellectual,
+	"psicossocial":  enum.Psychosocial,
+	"other":         enum.Others,
+	"outras":        enum.Others,
+	"outros":        enum.Others,
+}
+
+// DisabilityCategorySlug returns the slug of a category name, resolving the
+// legacy names like "Physical" to the current slugs.
+func DisabilityCategorySlug(name string) enum.DisabilityCategoryEnum {
+	slug := Slugify(name)
+
+	if alias, ok := disabilityCategoryAliases[slug]; ok {
+		return alias
+	}
+
+	return enum.DisabilityCategoryEnum(slug)
+}
@@ -41,6 +41,18 @@ func ValidateAddress(addressRequest model.AddressRequest) Error {
 		fieldsWithErrors = append(fieldsWithErrors, model.Field{Name: "country"})
 	}
 
+	if (addressRequest.Latitude == nil) != (addressRequest.Longitude == nil) {
+		fieldsWithErrors = append(fieldsWithErrors, model.Field{Name: "coordinates", Value: "latitude and longitude must be given together"})
+	}
+
+	if addressRequest.Latitude != nil && (*addressRequest.Latitude < -90 || *addressRequest.Latitude > 90) {
+		fieldsWithErrors = append(fieldsWithErrors, model.Field{Name: "latitude", Value: "latitude must be between -90 and 90"})
+	}
+
+	if addressRequest.Longitude != nil && (*addressRequest.Longitude < -180 || *addressRequest.Longitude > 180) {
+		fieldsWithErrors = append(fieldsWithErrors, model.Field{Name: "longitude", Value: "longitude must be between -180 and 180"})
+	}
+
 	if len(fieldsWithErrors) > 0 {
 		errorCode := NewErrorCode(ValidationErrorCode, AddressErrorType, "01")
 